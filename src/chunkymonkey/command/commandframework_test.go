@@ -6,6 +6,7 @@ import (
     "code.google.com/p/gomock/gomock"
 
     "chunkymonkey/gamerules"
+    . "chunkymonkey/types"
     "testmatcher"
 )
 
@@ -27,7 +28,7 @@ func TestCommandFramework(t *testing.T) {
     mockGame.EXPECT().PlayerByName("thePlayer").Return(mockPlayer)
     mockGame.EXPECT().ItemTypeById(1).Return(itemType1, true)
     mockPlayer.EXPECT().EchoMessage("Giving 64 of '1' to thePlayer")
-    mockPlayer.EXPECT().GiveItem(gamerules.Slot{1, 64, 0})
+    mockPlayer.EXPECT().GiveItem(gamerules.Slot{1, 64, 0, nil})
     cf.Process(mockPlayer, "/give thePlayer 1 64", mockGame)
 
     mockGame.EXPECT().PlayerByName("otherPlayer")
@@ -44,6 +45,27 @@ func TestCommandFramework(t *testing.T) {
     mockPlayer.EXPECT().EchoMessage("Cannot give more than 512 items at once")
     cf.Process(mockPlayer, "/give otherPlayer 1 513", mockGame)
 
+    mockGame.EXPECT().PlayerByName("otherPlayer").Return(mockOther)
+    mockOther.EXPECT().SetGameType(GameTypeCreative)
+    mockPlayer.EXPECT().EchoMessage("Set game mode of otherPlayer to creative")
+    cf.Process(mockPlayer, "/gamemode otherPlayer Creative", mockGame)
+
+    mockGame.EXPECT().PlayerByName("otherPlayer").Return(mockOther)
+    mockOther.EXPECT().SetGameType(GameTypeAdventure)
+    mockPlayer.EXPECT().EchoMessage("Set game mode of otherPlayer to 2")
+    cf.Process(mockPlayer, "/gamemode otherPlayer 2", mockGame)
+
+    mockGame.EXPECT().PlayerByName("otherPlayer").Return(mockOther)
+    mockPlayer.EXPECT().EchoMessage(gamemodeUsage)
+    cf.Process(mockPlayer, "/gamemode otherPlayer hardcore", mockGame)
+
+    mockGame.EXPECT().PlayerByName("otherPlayer")
+    mockPlayer.EXPECT().EchoMessage("'otherPlayer' is not logged in")
+    cf.Process(mockPlayer, "/gamemode otherPlayer creative", mockGame)
+
+    mockPlayer.EXPECT().EchoMessage(gamemodeUsage)
+    cf.Process(mockPlayer, "/gamemode creative", mockGame)
+
     mockPlayer.EXPECT().EchoMessage(&testmatcher.StringPrefix{"Commands:"})
     cf.Process(mockPlayer, "/help", mockGame)
 
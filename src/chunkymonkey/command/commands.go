@@ -17,6 +17,7 @@ func getCommands() map[string]*Command {
     cmds[killCmd] = NewCommand(killCmd, killDesc, killUsage, cmdKill)
     cmds[tellCmd] = NewCommand(tellCmd, tellDesc, tellUsage, cmdTell)
     cmds[giveCmd] = NewCommand(giveCmd, giveDesc, giveUsage, cmdGive)
+    cmds[gamemodeCmd] = NewCommand(gamemodeCmd, gamemodeDesc, gamemodeUsage, cmdGamemode)
     return cmds
 }
 
@@ -222,3 +223,41 @@ func cmdGive(player gamerules.IPlayerClient, message string, cmdHandler gamerule
         target.EchoMessage(msg)
     }
 }
+
+const gamemodeCmd = "gamemode"
+const gamemodeUsage = "gamemode <player> <survival|creative|adventure|0|1|2>"
+const gamemodeDesc = "Changes the game mode of a player."
+
+var gameTypeNames = map[string]GameType{
+    "survival":  GameTypeSurvival,
+    "creative":  GameTypeCreative,
+    "adventure": GameTypeAdventure,
+    "0":         GameTypeSurvival,
+    "1":         GameTypeCreative,
+    "2":         GameTypeAdventure,
+}
+
+func cmdGamemode(player gamerules.IPlayerClient, message string, cmdHandler gamerules.IGame) {
+    args := strings.Split(message, " ")
+    if len(args) != 3 {
+        player.EchoMessage(gamemodeUsage)
+        return
+    }
+    args = args[1:]
+
+    target := cmdHandler.PlayerByName(args[0])
+    if target == nil {
+        msg := fmt.Sprintf("'%s' is not logged in", args[0])
+        player.EchoMessage(msg)
+        return
+    }
+
+    gameType, ok := gameTypeNames[strings.ToLower(args[1])]
+    if !ok {
+        player.EchoMessage(gamemodeUsage)
+        return
+    }
+
+    target.SetGameType(gameType)
+    player.EchoMessage(fmt.Sprintf("Set game mode of %s to %s", args[0], strings.ToLower(args[1])))
+}
@@ -22,8 +22,16 @@ import (
 // That is: characters that might be abused in filename components, etc.
 var validPlayerUsername = regexp.MustCompile(`^[\-a-zA-Z0-9_]+$`)
 
+// The number of ticks that all players must have been asleep for before the
+// night is skipped.
+const sleepTicksBeforeMorning = Ticks(100)
+
+// The dimensions that the server runs.
+var dimensions = []DimensionId{DimensionNormal, DimensionNether, DimensionEnd}
+
 type Game struct {
-    shardManager  *shardserver.LocalShardManager
+    // Shard managers for each dimension. Not modified after NewGame.
+    shardManagers map[DimensionId]*shardserver.LocalShardManager
     entityManager EntityManager
     worldStore    *worldstore.WorldStore
     connHandler   *ConnHandler
@@ -33,6 +41,9 @@ type Game struct {
     players     map[EntityId]*player.Player
     playerNames map[string]*player.Player
 
+    // Players currently in bed, mapped to the time that they went to sleep.
+    sleepingPlayers map[EntityId]Ticks
+
     // Channels for events/actions
     workQueue        chan func(*Game)
     playerConnect    chan *player.Player
@@ -53,6 +64,7 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
     game = &Game{
         players:          make(map[EntityId]*player.Player),
         playerNames:      make(map[string]*player.Player),
+        sleepingPlayers:  make(map[EntityId]Ticks),
         workQueue:        make(chan func(*Game), 256),
         playerConnect:    make(chan *player.Player),
         playerDisconnect: make(chan EntityId),
@@ -63,7 +75,16 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
 
     game.entityManager.Init()
 
-    game.shardManager = shardserver.NewLocalShardManager(worldStore.ChunkStore, &game.entityManager)
+    game.shardManagers = make(map[DimensionId]*shardserver.LocalShardManager)
+    for _, dimension := range dimensions {
+        chunkStore := worldStore.ChunkStore
+        if dimension != DimensionNormal {
+            if chunkStore, err = worldStore.ChunkStoreForDimension(dimension); err != nil {
+                return nil, err
+            }
+        }
+        game.shardManagers[dimension] = shardserver.NewLocalShardManager(dimension, chunkStore, &game.entityManager)
+    }
 
     // TODO: Load the prefix from a config file
     gamerules.CommandFramework = command.NewCommandFramework("/")
@@ -81,7 +102,7 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
         maxPlayerCount:  game.maxPlayerCount,
         serverDesc:      serverDesc,
         maintenanceMsg:  maintenanceMsg,
-        shardManager:    game.shardManager,
+        shardManager:    game.shardManagers[DimensionNormal],
         entityManager:   &game.entityManager,
         worldStore:      game.worldStore,
         authserver:      authserver,
@@ -125,6 +146,7 @@ func (game *Game) onPlayerDisconnect(entityId EntityId) {
     oldPlayer := game.players[entityId]
     delete(game.players, entityId)
     delete(game.playerNames, oldPlayer.Name())
+    delete(game.sleepingPlayers, entityId)
     game.entityManager.RemoveEntityById(entityId)
 
     playerData := nbt.NewCompound()
@@ -140,11 +162,36 @@ func (game *Game) onPlayerDisconnect(entityId EntityId) {
 
 func (game *Game) onTick() {
     game.time++
+    game.checkSleepingPlayers()
     if game.time%TicksPerSecond == 0 {
         game.sendTimeUpdate()
     }
 }
 
+// checkSleepingPlayers moves the time on to the next morning if all players
+// have been asleep for long enough, and wakes them all up.
+func (game *Game) checkSleepingPlayers() {
+    if len(game.sleepingPlayers) == 0 || len(game.sleepingPlayers) < len(game.players) {
+        return
+    }
+
+    for _, sleptAt := range game.sleepingPlayers {
+        if game.time-sleptAt < sleepTicksBeforeMorning {
+            return
+        }
+    }
+
+    game.time += TicksPerDay - game.time%TicksPerDay
+    game.sendTimeUpdate()
+
+    for entityId := range game.sleepingPlayers {
+        if player, ok := game.players[entityId]; ok {
+            player.WakeUp()
+        }
+        delete(game.sleepingPlayers, entityId)
+    }
+}
+
 // Utility functions
 
 // Send a time/keepalive packet
@@ -187,6 +234,39 @@ func (game *Game) ItemTypeById(id int) (gamerules.ItemType, bool) {
     return *itemType, ok
 }
 
+func (game *Game) TimeOfDay() Ticks {
+    result := make(chan Ticks)
+    game.enqueue(func(_ *Game) {
+        result <- game.time % TicksPerDay
+    })
+    return <-result
+}
+
+func (game *Game) SetPlayerSleeping(id EntityId, sleeping bool) {
+    game.enqueue(func(_ *Game) {
+        if _, ok := game.players[id]; !ok {
+            return
+        }
+        if sleeping {
+            game.sleepingPlayers[id] = game.time
+        } else {
+            delete(game.sleepingPlayers, id)
+        }
+    })
+}
+
+// ShardConnecter returns the shard connecter for the given dimension.
+// ok=false if the dimension does not exist.
+func (game *Game) ShardConnecter(dimension DimensionId) (connecter gamerules.IShardConnecter, ok bool) {
+    // shardManagers is not modified after NewGame, so it is safe to read from
+    // any goroutine.
+    shardManager, ok := game.shardManagers[dimension]
+    if !ok {
+        return nil, false
+    }
+    return shardManager, true
+}
+
 func (game *Game) PlayerCount() int {
     result := make(chan int)
     game.enqueue(func(_ *Game) {
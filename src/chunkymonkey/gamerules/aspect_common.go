@@ -9,14 +9,20 @@ import (
 // spawnItemInBlock creates an item in a block. It must be run within
 // instance.Chunk's goroutine.
 func spawnItemInBlock(chunk IChunkBlock, blockLoc BlockXyz, itemTypeId ItemTypeId, count ItemCount, data ItemData) {
+    spawnSlotInBlock(chunk, blockLoc, Slot{ItemTypeId: itemTypeId, Count: count, Data: data})
+}
+
+// spawnSlotInBlock is like spawnItemInBlock, but keeps any NBT data on the
+// items in slot.
+func spawnSlotInBlock(chunk IChunkBlock, blockLoc BlockXyz, slot Slot) {
     rand := chunk.Rand()
     position := blockLoc.ToAbsXyz()
     position.X += AbsCoord(blockItemSpawnFromEdge + rand.Float64()*(1-2*blockItemSpawnFromEdge))
     position.Y += AbsCoord(blockItemSpawnFromEdge)
     position.Z += AbsCoord(blockItemSpawnFromEdge + rand.Float64()*(1-2*blockItemSpawnFromEdge))
     chunk.AddEntity(
-        NewItem(
-            itemTypeId, count, data,
+        NewItemFromSlot(
+            slot,
             *position,
             AbsVelocity{},
             0,
@@ -53,3 +59,26 @@ func (bdi *blockDropItem) check() error {
 
     return nil
 }
+
+// lookFace returns the face of a block that points most nearly in the
+// direction that the player is looking.
+func lookFace(look *LookDegrees) Face {
+    dx, dy, dz := look.Direction()
+    ax, ay, az := dx*dx, dy*dy, dz*dz
+    switch {
+    case ay >= ax && ay >= az:
+        if dy < 0 {
+            return FaceBottom
+        }
+        return FaceTop
+    case ax >= az:
+        if dx < 0 {
+            return FaceNorth
+        }
+        return FaceSouth
+    }
+    if dz < 0 {
+        return FaceEast
+    }
+    return FaceWest
+}
@@ -0,0 +1,73 @@
+package gamerules
+
+import (
+    "math"
+
+    . "chunkymonkey/types"
+)
+
+const (
+    // The lower bits of an anvil's block data are the direction it faces, and
+    // the upper bits how damaged it is.
+    anvilFacingMask  = 0x3
+    anvilDamageShift = 2
+    anvilMaxDamage   = 2
+
+    // Percentage chance of an anvil being damaged each time that it is used.
+    anvilDamageChance = 12
+)
+
+func makeAnvilAspect() (aspect IBlockAspect) {
+    return &AnvilAspect{}
+}
+
+// AnvilAspect is the behaviour of anvils. The anvil itself holds nothing -
+// each player using it gets their own window. Anvils become more damaged with
+// use, until they break.
+type AnvilAspect struct {
+    StandardAspect
+}
+
+func (aspect *AnvilAspect) Name() string {
+    return "Anvil"
+}
+
+// Place puts an anvil into the world, side on to the player that placed it,
+// and as damaged as the item it was placed from.
+func (aspect *AnvilAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    damage := instance.Data
+    if damage > anvilMaxDamage {
+        damage = 0
+    }
+    facing := byte(int(math.Floor(float64(look.Yaw)*4/360+0.5)+1) & anvilFacingMask)
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, facing|damage<<anvilDamageShift)
+    return true
+}
+
+func (aspect *AnvilAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+    player.OpenAnvil(instance.BlockLoc)
+}
+
+// Use wears the anvil after a player has taken an item from its window. It
+// returns true if the anvil broke.
+func (aspect *AnvilAspect) Use(instance *BlockInstance) (broken bool) {
+    if instance.Chunk.Rand().Intn(100) >= anvilDamageChance {
+        return false
+    }
+
+    damage := instance.Data>>anvilDamageShift + 1
+    if damage > anvilMaxDamage {
+        instance.Chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+        return true
+    }
+
+    facing := instance.Data & anvilFacingMask
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, facing|damage<<anvilDamageShift)
+    return false
+}
+
+func (aspect *AnvilAspect) Destroy(instance *BlockInstance) {
+    // The dropped anvil keeps its damage, but not its facing.
+    instance.Data >>= anvilDamageShift
+    aspect.StandardAspect.Destroy(instance)
+}
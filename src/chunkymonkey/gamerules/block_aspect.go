@@ -3,6 +3,7 @@ package gamerules
 import (
     "math/rand"
 
+    "chunkymonkey/physics"
     . "chunkymonkey/types"
 )
 
@@ -12,10 +13,36 @@ const blockItemSpawnFromEdge = 4.0 / PixelsPerBlock
 
 // The interface required of a chunk by block behaviour.
 type IChunkBlock interface {
+    physics.IBlockQuerier
+
+    // Dimension returns the dimension that the chunk is in.
+    Dimension() DimensionId
+
     Rand() *rand.Rand
     ItemType(itemTypeId ItemTypeId) (itemType *ItemType, ok bool)
     AddEntity(s INonPlayerEntity)
+    RemoveEntity(s INonPlayerEntity)
     SetBlockByIndex(blockIndex BlockIndex, blockId BlockId, blockData byte)
+
+    // BlockAt returns the type and data of the block at the given location,
+    // which may be in a neighbouring loaded chunk within the same shard.
+    // ok=false if the block is not known.
+    BlockAt(blockLoc *BlockXyz) (blockType *BlockType, blockData byte, ok bool)
+
+    // SetBlockAt sets the block at the given location, which may be in a
+    // neighbouring loaded chunk within the same shard. ok=false if the block
+    // could not be set.
+    SetBlockAt(blockLoc *BlockXyz, blockId BlockId, blockData byte) (ok bool)
+
+    // BlockInstanceAt returns the instance of the block at the given location,
+    // which may be in a neighbouring loaded chunk within the same shard.
+    // ok=false if the block is not known.
+    BlockInstanceAt(blockLoc *BlockXyz) (instance *BlockInstance, ok bool)
+
+    // ItemsInBlock returns the item entities within the chunk that are inside
+    // the given block.
+    ItemsInBlock(blockLoc *BlockXyz) []*Item
+
     TileEntity(blockIndex BlockIndex) ITileEntity
     SetTileEntity(blockIndex BlockIndex, extra ITileEntity)
     AddOnUnsubscribe(entityId EntityId, observer IUnsubscribed)
@@ -26,6 +53,10 @@ type IChunkBlock interface {
 
     // AddActiveBlockIndex flags a block in the chunk itself as active by index.
     AddActiveBlockIndex(blockIndex BlockIndex)
+
+    // SubscribedPlayer returns the player with the given EntityId if they are
+    // subscribed to the chunk.
+    SubscribedPlayer(entityId EntityId) (player IPlayerClient, ok bool)
 }
 
 // IUnsubscribed is the interface by which blocks (and potentially other
@@ -47,6 +78,14 @@ type BlockInstance struct {
     BlockType *BlockType
     // Note that only the lower nibble of data is stored.
     Data byte
+    // NoDrops is set when the block is being destroyed in a way that should
+    // not drop its items (e.g dug without an adequate tool).
+    NoDrops bool
+    // Placer is the player placing the block, and Face is the face of the
+    // block that it is being placed against. They are only set when the block
+    // is being placed by IBlockPlacer.Place.
+    Placer EntityId
+    Face   Face
 }
 
 // Defines the behaviour of a block.
@@ -75,6 +114,14 @@ type IBlockAspect interface {
     // inventory for the block (assuming it still has one).
     InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient)
 
+    // DigTicks returns how long the block takes to dig while holding the given
+    // item. ok=false if the block cannot be dug.
+    DigTicks(held *Slot) (ticks Ticks, ok bool)
+
+    // CanHarvest returns true if the block drops its items when dug while
+    // holding the given item.
+    CanHarvest(held *Slot) bool
+
     // Destroy is called when the block is destroyed by a player hitting it.
     // TODO And in other situations, maybe?
     Destroy(instance *BlockInstance)
@@ -83,3 +130,38 @@ type IBlockAspect interface {
     // if the block should not tick again.
     Tick(instance *BlockInstance) bool
 }
+
+// IBlockPlacer is optionally implemented by block aspects that need to control
+// how their block is placed into the world, e.g blocks that occupy more than
+// one location, or whose orientation depends on the player placing them.
+type IBlockPlacer interface {
+    // Place puts the block described by instance into the world, returning
+    // false if it could not be placed. look is the direction that the placing
+    // player was facing.
+    Place(instance *BlockInstance, look LookDegrees) bool
+}
+
+// IBlockItemReceiver is optionally implemented by block aspects that accept
+// non-block items being placed directly onto them, e.g minecarts onto rails.
+type IBlockItemReceiver interface {
+    // AcceptsItem returns true if the item can be placed onto the block.
+    AcceptsItem(instance *BlockInstance, item *Slot) bool
+
+    // PlaceItem places the item onto the block, decrementing the slot by the
+    // number of items used.
+    PlaceItem(instance *BlockInstance, item *Slot, look LookDegrees)
+}
+
+// IBlockActivator is optionally implemented by block aspects that do something
+// when a neighbouring block signals them, e.g droppers next to a comparator.
+type IBlockActivator interface {
+    // Activate is called when the block receives a signal.
+    Activate(instance *BlockInstance)
+}
+
+// IBlockEnterable is optionally implemented by block aspects that react to a
+// player being inside the block, e.g portals.
+type IBlockEnterable interface {
+    // PlayerInside is called when a player moves while inside the block.
+    PlayerInside(instance *BlockInstance, player IPlayerClient)
+}
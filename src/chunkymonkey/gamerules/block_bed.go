@@ -0,0 +1,103 @@
+package gamerules
+
+import (
+    "math"
+
+    . "chunkymonkey/types"
+)
+
+const (
+    // The lower two bits of bed block data give the direction from the foot
+    // of the bed to its head.
+    bedDirectionMask = 0x3
+    // Set on the block data of the head half of the bed.
+    bedHeadFlag = 0x8
+)
+
+func makeBedAspect() (aspect IBlockAspect) {
+    return &BedAspect{}
+}
+
+// BedAspect is the behaviour for the two halves of a bed. A bed is placed as a
+// "foot" block at the target location and a "head" block in the direction
+// that the player was facing. Players can sleep in a bed at night, which also
+// sets their spawn point.
+type BedAspect struct {
+    StandardAspect
+}
+
+func (aspect *BedAspect) Name() string {
+    return "Bed"
+}
+
+// bedHeadOffset returns the offset from the foot of a bed to its head, given
+// the block data of either half.
+func bedHeadOffset(data byte) (dx BlockCoord, dz BlockCoord) {
+    switch data & bedDirectionMask {
+    case 0:
+        dz = 1
+    case 1:
+        dx = -1
+    case 2:
+        dz = -1
+    case 3:
+        dx = 1
+    }
+    return
+}
+
+// otherHalf returns the location of the other half of the bed.
+func (aspect *BedAspect) otherHalf(instance *BlockInstance) *BlockXyz {
+    dx, dz := bedHeadOffset(instance.Data)
+    if instance.Data&bedHeadFlag != 0 {
+        dx, dz = -dx, -dz
+    }
+    return instance.BlockLoc.AddXyz(dx, 0, dz)
+}
+
+func (aspect *BedAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    direction := byte(int(math.Floor(float64(look.Yaw)*4/360+0.5)) & bedDirectionMask)
+    dx, dz := bedHeadOffset(direction)
+
+    headLoc := instance.BlockLoc.AddXyz(dx, 0, dz)
+    if headLoc == nil {
+        return false
+    }
+
+    headBlockType, _, ok := instance.Chunk.BlockAt(headLoc)
+    if !ok || !headBlockType.Replaceable {
+        return false
+    }
+
+    if !instance.Chunk.SetBlockAt(headLoc, instance.BlockType.id, direction|bedHeadFlag) {
+        return false
+    }
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, direction)
+
+    return true
+}
+
+func (aspect *BedAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+    headLoc := instance.BlockLoc
+    if instance.Data&bedHeadFlag == 0 {
+        otherLoc := aspect.otherHalf(instance)
+        if otherLoc == nil {
+            return
+        }
+        headLoc = *otherLoc
+    }
+
+    player.SleepInBed(headLoc)
+}
+
+func (aspect *BedAspect) Destroy(instance *BlockInstance) {
+    aspect.StandardAspect.Destroy(instance)
+
+    // Remove the other half of the bed, if it is still there.
+    if otherLoc := aspect.otherHalf(instance); otherLoc != nil {
+        otherType, _, ok := instance.Chunk.BlockAt(otherLoc)
+        if ok && otherType.id == instance.BlockType.id {
+            instance.Chunk.SetBlockAt(otherLoc, BlockIdAir, 0)
+        }
+    }
+}
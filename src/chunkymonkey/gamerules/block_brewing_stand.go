@@ -0,0 +1,89 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+func makeBrewingStandAspect() IBlockAspect {
+    return &BrewingStandAspect{
+        InventoryAspect{
+            name:                 "BrewingStand",
+            createBlockInventory: createBrewingInventory,
+        },
+    }
+}
+
+// BrewingStandAspect is the behaviour of brewing stands. The block data shows
+// which of the bottle slots hold potions.
+type BrewingStandAspect struct {
+    InventoryAspect
+}
+
+// Creates a new tile entity for a brewing stand. UnmarshalNbt and SetChunk
+// must be called before any other methods.
+func NewBrewingStandTileEntity() ITileEntity {
+    return createBrewingInventory(nil)
+}
+
+func createBrewingInventory(instance *BlockInstance) *blockInventory {
+    return newBlockInventory(
+        instance,
+        NewBrewingInventory(),
+        false,
+        InvTypeIdBrewing,
+    )
+}
+
+func (aspect *BrewingStandAspect) InventoryClick(instance *BlockInstance, player IPlayerClient, click *Click) {
+
+    aspect.InventoryAspect.InventoryClick(instance, player, click)
+
+    blockInv, brewingInv := aspect.brewingInventory(instance)
+    if brewingInv == nil {
+        // Invalid or missing inventory.
+        return
+    }
+
+    aspect.updateBlock(instance, blockInv, brewingInv)
+
+    if brewingInv.IsBrewing() {
+        instance.Chunk.AddActiveBlockIndex(instance.Index)
+    }
+}
+
+func (aspect *BrewingStandAspect) Tick(instance *BlockInstance) bool {
+    blockInv, brewingInv := aspect.brewingInventory(instance)
+    if brewingInv == nil {
+        // Invalid or missing inventory.
+        return false
+    }
+
+    brewingInv.Tick()
+
+    aspect.updateBlock(instance, blockInv, brewingInv)
+
+    return brewingInv.IsBrewing()
+}
+
+func (aspect *BrewingStandAspect) brewingInventory(instance *BlockInstance) (blockInv *blockInventory, brewingInv *BrewingInventory) {
+
+    blockInv = aspect.InventoryAspect.blockInv(instance, false)
+    if blockInv == nil {
+        return nil, nil
+    }
+
+    brewingInv, ok := blockInv.inv.(*BrewingInventory)
+    if !ok {
+        return nil, nil
+    }
+
+    return
+}
+
+// updateBlock shows the bottles in the brewing stand, if they have changed.
+func (aspect *BrewingStandAspect) updateBlock(instance *BlockInstance, blockInv *blockInventory, brewingInv *BrewingInventory) {
+    if data := brewingInv.BottleBits(); data != instance.Data {
+        instance.Chunk.SetBlockByIndex(instance.Index, aspect.blockAttrs.id, data)
+        instance.Chunk.SetTileEntity(instance.Index, blockInv)
+    }
+}
@@ -4,11 +4,233 @@ import (
     . "chunkymonkey/types"
 )
 
+// Block data values for the direction that a chest faces.
+const (
+    chestFacingNorth = 2
+    chestFacingSouth = 3
+    chestFacingWest  = 4
+    chestFacingEast  = 5
+)
+
 func makeChestAspect() (aspect IBlockAspect) {
-    return &InventoryAspect{
-        name:                 "Chest",
-        createBlockInventory: createChestInventory,
+    return &ChestAspect{
+        InventoryAspect{
+            name:                 "Chest",
+            createBlockInventory: createChestInventory,
+        },
+    }
+}
+
+// ChestAspect is the behaviour of chests. Two chests placed side by side form
+// a large chest, whose window shows the inventories of both halves. Each half
+// keeps its own tile entity, so that breaking one leaves the other as a single
+// chest.
+//
+// Chests in neighbouring shards are not seen, so a chest cannot be placed
+// where it would need to be checked against blocks in another shard.
+type ChestAspect struct {
+    InventoryAspect
+}
+
+// Place puts a chest into the world, facing the player that placed it. A chest
+// may be placed next to one lone chest to form a large chest, but not next to
+// a large chest or between two chests. It is not placed if any of the blocks
+// that would need checking for chests are not known.
+func (aspect *ChestAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    neighbours, known := chestNeighbours(instance)
+    if !known || len(neighbours) > 1 {
+        return false
+    }
+
+    dx, _, dz := look.Direction()
+
+    if len(neighbours) == 0 {
+        instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, chestFacing(dx*dx < dz*dz, dx, dz))
+        return true
+    }
+
+    other := neighbours[0]
+    if otherNeighbours, known := chestNeighbours(other); !known || len(otherNeighbours) > 0 {
+        return false
+    }
+
+    // Both halves face the same way, to the front of the large chest. Setting
+    // the other half's block removes its tile entity, so it is put back.
+    facing := chestFacing(other.BlockLoc.X != instance.BlockLoc.X, dx, dz)
+    otherInv := other.Chunk.TileEntity(other.Index)
+    other.Chunk.SetBlockByIndex(other.Index, other.BlockType.id, facing)
+    if otherInv != nil {
+        other.Chunk.SetTileEntity(other.Index, otherInv)
+    }
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, facing)
+
+    return true
+}
+
+func (aspect *ChestAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+    first, second := largeChest(instance)
+    if second == nil {
+        aspect.InventoryAspect.Interact(instance, player)
+        return
+    }
+
+    firstInv := aspect.blockInv(first, true)
+    secondInv := aspect.blockInv(second, true)
+    firstInv.addViewer(player, instance.BlockLoc, 0)
+    secondInv.addViewer(player, instance.BlockLoc, firstInv.inv.NumSlots())
+
+    slots := append(firstInv.inv.MakeProtoSlots(), secondInv.inv.MakeProtoSlots()...)
+    player.InventorySubscribed(instance.BlockLoc, InvTypeIdChest, slots)
+}
+
+func (aspect *ChestAspect) InventoryClick(instance *BlockInstance, player IPlayerClient, click *Click) {
+    firstInv, secondInv, ok := aspect.viewedHalves(instance, player.GetEntityId())
+    if !ok {
+        aspect.InventoryAspect.InventoryClick(instance, player, click)
+        return
+    }
+
+    txState := largeChestClick(firstInv.inv, secondInv.inv, click)
+
+    replyClick(player, instance.BlockLoc, click, txState)
+}
+
+func (aspect *ChestAspect) InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient) {
+    // The player may also be viewing the other half through this chest's
+    // window.
+    neighbours, _ := chestNeighbours(instance)
+    for _, neighbour := range neighbours {
+        if blkInv := aspect.blockInv(neighbour, false); blkInv != nil {
+            blkInv.removeViewer(player.GetEntityId(), &instance.BlockLoc)
+        }
+    }
+
+    aspect.InventoryAspect.InventoryUnsubscribed(instance, player)
+}
+
+func (aspect *ChestAspect) Destroy(instance *BlockInstance) {
+    // Players viewing the other half through this chest's window have it
+    // closed, and cannot unsubscribe from it through this chest afterwards.
+    neighbours, _ := chestNeighbours(instance)
+    for _, neighbour := range neighbours {
+        if blkInv := aspect.blockInv(neighbour, false); blkInv != nil {
+            for entityId := range blkInv.subscribers {
+                blkInv.removeViewer(entityId, &instance.BlockLoc)
+            }
+        }
+    }
+
+    aspect.InventoryAspect.Destroy(instance)
+}
+
+// transferInvs returns the inventories of both halves of a large chest, in
+// the order that they appear in its window.
+func (aspect *ChestAspect) transferInvs(instance *BlockInstance) (invs []ITransferInventory) {
+    first, second := largeChest(instance)
+    invs = aspect.InventoryAspect.transferInvs(first)
+    if second != nil {
+        invs = append(invs, aspect.InventoryAspect.transferInvs(second)...)
+    }
+    return
+}
+
+// viewedHalves returns the inventories of the large chest that the player is
+// viewing through the chest's window. ok=false if the player is viewing a
+// single chest, e.g if the window was opened before the chest was joined.
+func (aspect *ChestAspect) viewedHalves(instance *BlockInstance, entityId EntityId) (firstInv, secondInv *blockInventory, ok bool) {
+    first, second := largeChest(instance)
+    if second == nil {
+        return nil, nil, false
+    }
+
+    firstInv = aspect.blockInv(first, false)
+    secondInv = aspect.blockInv(second, false)
+    if firstInv == nil || secondInv == nil {
+        return nil, nil, false
+    }
+
+    ok = firstInv.isViewing(entityId, &instance.BlockLoc, 0) &&
+        secondInv.isViewing(entityId, &instance.BlockLoc, firstInv.inv.NumSlots())
+
+    return
+}
+
+// chestFacing returns the block data for a chest facing the player, who is
+// looking along (dx, dz). The chest faces along the Z axis if alongX is true
+// (i.e the chest's front runs along the X axis), otherwise along the X axis.
+func chestFacing(alongX bool, dx, dz AbsCoord) byte {
+    if alongX {
+        if dz >= 0 {
+            return chestFacingNorth
+        }
+        return chestFacingSouth
+    }
+    if dx >= 0 {
+        return chestFacingWest
     }
+    return chestFacingEast
+}
+
+// chestNeighbours returns the chests horizontally adjacent to the given chest.
+// known=false if any of the adjacent blocks could not be checked, e.g because
+// they are in another shard.
+func chestNeighbours(instance *BlockInstance) (neighbours []*BlockInstance, known bool) {
+    known = true
+    offsets := [...][2]BlockCoord{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+    for _, offset := range offsets {
+        loc := instance.BlockLoc.AddXyz(offset[0], 0, offset[1])
+        if loc == nil {
+            continue
+        }
+        neighbour, ok := instance.Chunk.BlockInstanceAt(loc)
+        if !ok {
+            known = false
+            continue
+        }
+        if neighbour.BlockType.id == instance.BlockType.id {
+            neighbours = append(neighbours, neighbour)
+        }
+    }
+    return
+}
+
+// largeChest returns the halves of the large chest that the chest is part of,
+// in the order that they appear in its window. The half nearer to negative X
+// or Z comes first. second is nil if the chest is alone.
+func largeChest(instance *BlockInstance) (first, second *BlockInstance) {
+    neighbours, _ := chestNeighbours(instance)
+    if len(neighbours) != 1 {
+        return instance, nil
+    }
+
+    other := neighbours[0]
+    if other.BlockLoc.X < instance.BlockLoc.X || other.BlockLoc.Z < instance.BlockLoc.Z {
+        return other, instance
+    }
+    return instance, other
+}
+
+// largeChestClick applies a click on the window of a large chest to the
+// inventories of its two halves.
+func largeChestClick(first, second IInventory, click *Click) TxState {
+    if click.isPut() || click.Mode == ClickModeDoubleClick {
+        // Items are put into, or collected from, each half in turn.
+        if txState := first.Click(click); txState != TxStateAccepted {
+            return txState
+        }
+        return second.Click(click)
+    }
+
+    numFirst := first.NumSlots()
+    if click.SlotId < numFirst {
+        return first.Click(click)
+    }
+
+    click.SlotId -= numFirst
+    txState := second.Click(click)
+    click.SlotId += numFirst
+
+    return txState
 }
 
 // Creates a new tile entity for a chest. UnmarshalNbt and SetChunk must be
@@ -0,0 +1,65 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func TestLargeChestClick(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+    apple := ItemTypeId(1)
+    makeItemType(apple)
+
+    first, second := NewChestInventory(), NewChestInventory()
+    second.slots[0] = Slot{apple, 5, 0, nil}
+
+    // Slots in the second half follow on from those in the first.
+    click := Click{SlotId: ChestNumSlots, ExpectedSlot: second.slots[0]}
+    checkTx(t, TxStateAccepted, largeChestClick(first, second, &click))
+    checkSlot(t, Slot{apple, 5, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{}, second.slots[0])
+    if click.SlotId != ChestNumSlots {
+        t.Errorf("expected click slot to be restored, got %d", click.SlotId)
+    }
+
+    click = Click{SlotId: 2, Cursor: click.Cursor}
+    checkTx(t, TxStateAccepted, largeChestClick(first, second, &click))
+    checkSlot(t, Slot{}, click.Cursor)
+    checkSlot(t, Slot{apple, 5, 0, nil}, first.slots[2])
+
+    // Items put into the chest fill the first half before the second.
+    for i := range first.slots {
+        first.slots[i] = Slot{apple, 63, 0, nil}
+    }
+    click = Click{Cursor: Slot{apple, 40, 0, nil}, Mode: ClickModeShift}
+    checkTx(t, TxStateAccepted, largeChestClick(first, second, &click))
+    checkSlot(t, Slot{}, click.Cursor)
+    checkSlot(t, Slot{apple, 64, 0, nil}, first.slots[ChestNumSlots-1])
+    checkSlot(t, Slot{apple, 13, 0, nil}, second.slots[0])
+}
+
+func TestChestFacing(t *testing.T) {
+    type Test struct {
+        desc   string
+        alongX bool
+        dx, dz AbsCoord
+        expect byte
+    }
+
+    tests := []Test{
+        {"looking south", true, 0, 1, chestFacingNorth},
+        {"looking north", true, 0, -1, chestFacingSouth},
+        {"looking east", false, 1, 0, chestFacingWest},
+        {"looking west", false, -1, 0, chestFacingEast},
+        {"joined along X, looking mostly east", true, 0.9, -0.1, chestFacingSouth},
+    }
+
+    for _, test := range tests {
+        result := chestFacing(test.alongX, test.dx, test.dz)
+        if result != test.expect {
+            t.Errorf("%s: expected facing %d, got %d", test.desc, test.expect, result)
+        }
+    }
+}
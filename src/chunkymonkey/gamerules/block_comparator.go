@@ -0,0 +1,150 @@
+package gamerules
+
+import (
+    "math"
+
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    // The bits of a comparator's block data that hold its direction.
+    comparatorDirectionMask = 0x3
+
+    // The strongest signal that a comparator puts out.
+    comparatorMaxSignal = 15
+)
+
+// Offsets to the block behind a comparator (which it reads from) for each of
+// its directions. The block in front of it is in the opposite direction.
+var comparatorInputDx = [4]BlockCoord{0, -1, 0, 1}
+var comparatorInputDz = [4]BlockCoord{1, 0, -1, 0}
+
+func makeComparatorAspect() (aspect IBlockAspect) {
+    return &ComparatorAspect{}
+}
+
+type comparatorTileEntity struct {
+    tileEntity
+    outputSignal int32
+}
+
+func NewComparatorTileEntity() ITileEntity {
+    return &comparatorTileEntity{}
+}
+
+func (comparator *comparatorTileEntity) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = comparator.tileEntity.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    if outputTag, ok := tag.Lookup("OutputSignal").(*nbt.Int); ok {
+        comparator.outputSignal = outputTag.Value
+    }
+
+    return nil
+}
+
+func (comparator *comparatorTileEntity) MarshalNbt(tag nbt.Compound) (err error) {
+    if err = comparator.tileEntity.MarshalNbt(tag); err != nil {
+        return
+    }
+
+    tag.Set("id", &nbt.String{"Comparator"})
+    tag.Set("OutputSignal", &nbt.Int{comparator.outputSignal})
+
+    return nil
+}
+
+// ComparatorAspect is the behaviour of comparators. A comparator measures how
+// full the container behind it is. There is no redstone to carry its signal,
+// but when the signal turns on the comparator activates the block in front of
+// it, e.g a dropper.
+type ComparatorAspect struct {
+    StandardAspect
+    Unpowered BlockId
+    Powered   BlockId
+}
+
+func (aspect *ComparatorAspect) Name() string {
+    return "Comparator"
+}
+
+// Place puts a comparator into the world, pointing away from the player that
+// placed it.
+func (aspect *ComparatorAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    direction := byte((int(math.Floor(float64(look.Yaw)*4/360+0.5))&3+2)%4)
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, direction)
+    return true
+}
+
+func (aspect *ComparatorAspect) Tick(instance *BlockInstance) bool {
+    direction := instance.Data & comparatorDirectionMask
+    dx, dz := comparatorInputDx[direction], comparatorInputDz[direction]
+
+    var signal int32
+    if inputLoc := instance.BlockLoc.AddXyz(dx, 0, dz); inputLoc != nil {
+        _, invs := containerAt(instance.Chunk, inputLoc)
+        signal = comparatorSignal(invs)
+    }
+
+    comparator := aspect.comparator(instance)
+    if signal == comparator.outputSignal {
+        // Comparators are always active, watching their container.
+        return true
+    }
+
+    rising := comparator.outputSignal == 0
+    comparator.outputSignal = signal
+
+    newBlockId := aspect.Unpowered
+    if signal > 0 {
+        newBlockId = aspect.Powered
+    }
+    if newBlockId != aspect.blockAttrs.id {
+        instance.Chunk.SetBlockByIndex(instance.Index, newBlockId, instance.Data)
+        instance.Chunk.SetTileEntity(instance.Index, comparator)
+    }
+
+    if rising {
+        if outputLoc := instance.BlockLoc.AddXyz(-dx, 0, -dz); outputLoc != nil {
+            if output, ok := instance.Chunk.BlockInstanceAt(outputLoc); ok {
+                if activator, ok := output.BlockType.Aspect.(IBlockActivator); ok {
+                    activator.Activate(output)
+                }
+            }
+        }
+    }
+
+    return true
+}
+
+func (aspect *ComparatorAspect) comparator(instance *BlockInstance) *comparatorTileEntity {
+    comparator, ok := instance.Chunk.TileEntity(instance.Index).(*comparatorTileEntity)
+    if !ok {
+        comparator = &comparatorTileEntity{}
+        comparator.chunk = instance.Chunk
+        comparator.blockLoc = instance.BlockLoc
+        instance.Chunk.SetTileEntity(instance.Index, comparator)
+    }
+
+    return comparator
+}
+
+// comparatorSignal returns the strength of the signal put out by a comparator
+// reading the given inventories. Any item at all gives a signal of at least 1,
+// and full inventories give the strongest signal.
+func comparatorSignal(invs []ITransferInventory) int32 {
+    var fullness float64
+    var numSlots SlotId
+    for _, inv := range invs {
+        fullness += inv.Fullness()
+        numSlots += inv.NumSlots()
+    }
+
+    if fullness <= 0 || numSlots == 0 {
+        return 0
+    }
+
+    return int32(math.Floor(fullness/float64(numSlots)*(comparatorMaxSignal-1))) + 1
+}
@@ -0,0 +1,35 @@
+package gamerules
+
+import (
+    "testing"
+)
+
+func TestComparatorSignal(t *testing.T) {
+    type Test struct {
+        desc   string
+        slots  []Slot
+        expect int32
+    }
+
+    full := Slot{5, 64, 0, nil}
+    tests := []Test{
+        {"empty", nil, 0},
+        {"one item", []Slot{{5, 1, 0, nil}}, 1},
+        {"half full", []Slot{full, full, {5, 32, 0, nil}}, 8},
+        {"nearly full", []Slot{full, full, full, full, {5, 63, 0, nil}}, 14},
+        {"full", []Slot{full, full, full, full, full}, 15},
+    }
+
+    for _, test := range tests {
+        hopper := NewHopperInventory()
+        copy(hopper.slots, test.slots)
+        result := comparatorSignal([]ITransferInventory{hopper})
+        if result != test.expect {
+            t.Errorf("%s: expected signal %d, got %d", test.desc, test.expect, result)
+        }
+    }
+
+    if result := comparatorSignal(nil); result != 0 {
+        t.Errorf("no container: expected signal 0, got %d", result)
+    }
+}
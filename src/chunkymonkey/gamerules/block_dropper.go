@@ -0,0 +1,98 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+const (
+    // The bits of a dropper's block data that hold the face that it points
+    // out of.
+    dropperFacingMask = 0x7
+
+    // Speed in blocks per tick of items thrown out of a dropper.
+    dropperThrowSpeed = 0.2
+)
+
+func makeDropperAspect() (aspect IBlockAspect) {
+    return &DropperAspect{
+        InventoryAspect{
+            name:                 "Dropper",
+            createBlockInventory: createDropperInventory,
+        },
+    }
+}
+
+// DropperAspect is the behaviour of droppers. When activated, a dropper puts
+// an item from a random slot into the container in front of it, or throws it
+// out if there is no container there.
+type DropperAspect struct {
+    InventoryAspect
+}
+
+// Place puts a dropper into the world, facing the player that placed it.
+func (aspect *DropperAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    facing := lookFace(&look).Opposite()
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, byte(facing))
+    return true
+}
+
+func (aspect *DropperAspect) Activate(instance *BlockInstance) {
+    blkInv := aspect.blockInv(instance, false)
+    if blkInv == nil {
+        // Nothing to drop.
+        return
+    }
+    dropperInv, ok := blkInv.inv.(*DropperInventory)
+    if !ok {
+        return
+    }
+
+    slotId, ok := dropperInv.ChooseSlot(instance.Chunk.Rand())
+    if !ok {
+        return
+    }
+
+    facing := Face(instance.Data & dropperFacingMask)
+    frontLoc := instance.BlockLoc.AddXyz(facing.Dxyz())
+    if frontLoc == nil {
+        return
+    }
+
+    if target, invs := containerAt(instance.Chunk, frontLoc); len(invs) > 0 {
+        if dropperInv.InsertFrom(slotId, invs, facing.Opposite()) {
+            target.Chunk.AddActiveBlockIndex(target.Index)
+        }
+        return
+    }
+
+    var item Slot
+    dropperInv.TakeOneItem(slotId, &item)
+
+    dx, dy, dz := facing.Dxyz()
+    position := instance.BlockLoc.MidPointToAbsXyz()
+    position.X += AbsCoord(dx) * 0.7
+    position.Y += AbsCoord(dy) * 0.7
+    position.Z += AbsCoord(dz) * 0.7
+    velocity := AbsVelocity{
+        AbsVelocityCoord(dx) * dropperThrowSpeed,
+        AbsVelocityCoord(dy) * dropperThrowSpeed,
+        AbsVelocityCoord(dz) * dropperThrowSpeed,
+    }
+
+    instance.Chunk.AddEntity(NewItemFromSlot(item, position, velocity, 0))
+}
+
+// Creates a new tile entity for a dropper. UnmarshalNbt and SetChunk must be
+// called before any other methods.
+func NewDropperTileEntity() ITileEntity {
+    return createDropperInventory(nil)
+}
+
+func createDropperInventory(instance *BlockInstance) *blockInventory {
+    return newBlockInventory(
+        instance,
+        NewDropperInventory(),
+        false,
+        InvTypeIdDropper,
+    )
+}
@@ -0,0 +1,67 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+func makeEnchantingTableAspect() (aspect IBlockAspect) {
+    return &EnchantingTableAspect{}
+}
+
+// EnchantingTableAspect is the behaviour of enchanting tables. The table
+// itself holds nothing - each player using it gets their own window, offering
+// enchantments that are stronger the more bookshelves there are around the
+// table.
+type EnchantingTableAspect struct {
+    StandardAspect
+    Bookshelf BlockId
+}
+
+func (aspect *EnchantingTableAspect) Name() string {
+    return "EnchantingTable"
+}
+
+func (aspect *EnchantingTableAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+    player.OpenEnchantingTable(instance.BlockLoc, aspect.bookshelves(instance))
+}
+
+// bookshelves counts the bookshelves around the table. They must be one block
+// away from the table, level with it or one block higher, with air between
+// them and the table.
+func (aspect *EnchantingTableAspect) bookshelves(instance *BlockInstance) (count int) {
+    loc := &instance.BlockLoc
+    for dz := BlockCoord(-1); dz <= 1; dz++ {
+        for dx := BlockCoord(-1); dx <= 1; dx++ {
+            if dx == 0 && dz == 0 {
+                continue
+            }
+            if !aspect.isBlock(instance, loc.AddXyz(dx, 0, dz), BlockIdAir) ||
+                !aspect.isBlock(instance, loc.AddXyz(dx, 1, dz), BlockIdAir) {
+                continue
+            }
+
+            // The shelves directly out from the gap, and for the corners the
+            // ones either side of them.
+            shelves := [][2]BlockCoord{{dx * 2, dz * 2}}
+            if dx != 0 && dz != 0 {
+                shelves = append(shelves, [2]BlockCoord{dx * 2, dz}, [2]BlockCoord{dx, dz * 2})
+            }
+            for _, shelf := range shelves {
+                for dy := BlockYCoord(0); dy <= 1; dy++ {
+                    if aspect.isBlock(instance, loc.AddXyz(shelf[0], dy, shelf[1]), aspect.Bookshelf) {
+                        count++
+                    }
+                }
+            }
+        }
+    }
+    return
+}
+
+func (aspect *EnchantingTableAspect) isBlock(instance *BlockInstance, blockLoc *BlockXyz, blockId BlockId) bool {
+    if blockLoc == nil {
+        return false
+    }
+    blockType, _, ok := instance.Chunk.BlockAt(blockLoc)
+    return ok && blockType.id == blockId
+}
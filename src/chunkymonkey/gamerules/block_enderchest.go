@@ -0,0 +1,31 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+func makeEnderChestAspect() (aspect IBlockAspect) {
+    return &EnderChestAspect{}
+}
+
+// EnderChestAspect is the behaviour of ender chests. The inventory seen
+// through an ender chest belongs to the player viewing it, not to the block,
+// so a player finds the same items in every ender chest.
+type EnderChestAspect struct {
+    StandardAspect
+}
+
+func (aspect *EnderChestAspect) Name() string {
+    return "EnderChest"
+}
+
+// Place puts an ender chest into the world, facing the player that placed it.
+func (aspect *EnderChestAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    dx, _, dz := look.Direction()
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, chestFacing(dx*dx < dz*dz, dx, dz))
+    return true
+}
+
+func (aspect *EnderChestAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+    player.OpenEnderChest(instance.BlockLoc)
+}
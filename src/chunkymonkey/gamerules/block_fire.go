@@ -0,0 +1,31 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+func makeFireAspect() (aspect IBlockAspect) {
+    return &FireAspect{}
+}
+
+// FireAspect is the behaviour of fire blocks. Fire lit inside an empty
+// obsidian frame turns into a nether portal.
+type FireAspect struct {
+    StandardAspect
+}
+
+func (aspect *FireAspect) Name() string {
+    return "Fire"
+}
+
+func (aspect *FireAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    if instance.Chunk.Dimension() != DimensionEnd {
+        if corner, axis, ok := findPortalFrame(instance.Chunk, &instance.BlockLoc); ok {
+            fillPortal(instance.Chunk, corner, axis)
+            return true
+        }
+    }
+
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, instance.Data)
+    return true
+}
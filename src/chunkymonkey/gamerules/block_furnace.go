@@ -44,6 +44,10 @@ func (aspect *FurnaceAspect) InventoryClick(instance *BlockInstance, player IPla
         return
     }
 
+    if amount := furnaceInv.TakeExperience(); amount > 0 {
+        spawnExperienceInBlock(instance.Chunk, instance.BlockLoc, amount)
+    }
+
     aspect.updateBlock(instance, blockInv, furnaceInv.IsLit())
 }
 
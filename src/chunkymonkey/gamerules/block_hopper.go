@@ -0,0 +1,123 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+// The bits of a hopper's block data that hold the face that it points out of.
+const hopperFacingMask = 0x7
+
+func makeHopperAspect() (aspect IBlockAspect) {
+    return &HopperAspect{
+        InventoryAspect{
+            name:                 "Hopper",
+            createBlockInventory: createHopperInventory,
+        },
+    }
+}
+
+// HopperAspect is the behaviour of hoppers. Every few ticks a hopper pushes an
+// item into the container that it points into, and pulls an item from the
+// container above it, or picks up items lying on top of it.
+type HopperAspect struct {
+    InventoryAspect
+}
+
+// Place puts a hopper into the world, pointing in the direction that the
+// player is looking. Hoppers point down or to the side, never up.
+func (aspect *HopperAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    facing := lookFace(&look)
+    if facing == FaceTop {
+        facing = FaceBottom
+    }
+    instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, byte(facing))
+    return true
+}
+
+func (aspect *HopperAspect) Tick(instance *BlockInstance) bool {
+    hopperInv := aspect.hopperInventory(instance)
+    if hopperInv == nil {
+        // Invalid inventory.
+        return false
+    }
+
+    if hopperInv.CoolingDown() {
+        return true
+    }
+
+    pushed := aspect.push(instance, hopperInv)
+    pulled := aspect.pull(instance, hopperInv)
+    if pushed || pulled {
+        hopperInv.Transferred()
+    }
+
+    // Hoppers are always active, waiting for items to arrive.
+    return true
+}
+
+// push moves an item into the container that the hopper points into.
+func (aspect *HopperAspect) push(instance *BlockInstance, hopperInv *HopperInventory) bool {
+    facing := Face(instance.Data & hopperFacingMask)
+    target, invs := containerAt(instance.Chunk, instance.BlockLoc.AddXyz(facing.Dxyz()))
+    if !hopperInv.PushInto(invs, facing.Opposite()) {
+        return false
+    }
+
+    // Let the container react to the item, e.g a furnace starting to smelt.
+    target.Chunk.AddActiveBlockIndex(target.Index)
+    return true
+}
+
+// pull moves an item from the container above the hopper, or takes items
+// lying in the block above if there is no container there.
+func (aspect *HopperAspect) pull(instance *BlockInstance, hopperInv *HopperInventory) bool {
+    aboveLoc := instance.BlockLoc.AddXyz(0, 1, 0)
+    if aboveLoc == nil {
+        return false
+    }
+
+    if source, invs := containerAt(instance.Chunk, aboveLoc); len(invs) > 0 {
+        if !hopperInv.PullFrom(invs) {
+            return false
+        }
+        source.Chunk.AddActiveBlockIndex(source.Index)
+        return true
+    }
+
+    absorbed := false
+    for _, item := range instance.Chunk.ItemsInBlock(aboveLoc) {
+        if hopperInv.Absorb(&item.Slot) {
+            absorbed = true
+            if item.Slot.IsEmpty() {
+                instance.Chunk.RemoveEntity(item)
+            }
+        }
+    }
+
+    return absorbed
+}
+
+func (aspect *HopperAspect) hopperInventory(instance *BlockInstance) *HopperInventory {
+    blkInv := aspect.blockInv(instance, true)
+    if blkInv == nil {
+        return nil
+    }
+
+    hopperInv, _ := blkInv.inv.(*HopperInventory)
+    return hopperInv
+}
+
+// Creates a new tile entity for a hopper. UnmarshalNbt and SetChunk must be
+// called before any other methods.
+func NewHopperTileEntity() ITileEntity {
+    return createHopperInventory(nil)
+}
+
+func createHopperInventory(instance *BlockInstance) *blockInventory {
+    return newBlockInventory(
+        instance,
+        NewHopperInventory(),
+        false,
+        InvTypeIdHopper,
+    )
+}
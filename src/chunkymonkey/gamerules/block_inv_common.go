@@ -1,5 +1,9 @@
 package gamerules
 
+import (
+    . "chunkymonkey/types"
+)
+
 // InventoryAspect is the common behaviour for blocks that have inventory.
 type InventoryAspect struct {
     StandardAspect
@@ -24,8 +28,7 @@ func (aspect *InventoryAspect) InventoryClick(instance *BlockInstance, player IP
         blkInv.Click(player, click)
     } else {
         // No inventory to act on (shouldn't happen, normally).
-        player.InventoryTxState(blkInv.blockLoc, click.TxId, false)
-        player.InventoryCursorUpdate(instance.BlockLoc, click.Cursor)
+        replyClick(player, instance.BlockLoc, click, TxStateRejected)
         return
     }
 }
@@ -56,3 +59,48 @@ func (aspect *InventoryAspect) blockInv(instance *BlockInstance, create bool) *b
 
     return blkInv
 }
+
+// transferInvs returns the inventory of the block for hoppers to move items
+// into and out of, creating it if need be. It returns nil for blocks whose
+// items do not stay in them, e.g workbenches.
+func (aspect *InventoryAspect) transferInvs(instance *BlockInstance) []ITransferInventory {
+    blkInv := aspect.blockInv(instance, false)
+    if blkInv == nil {
+        blkInv = aspect.createBlockInventory(instance)
+        if blkInv.ejectOnUnsubscribe {
+            return nil
+        }
+        instance.Chunk.SetTileEntity(instance.Index, blkInv)
+    }
+
+    if inv, ok := blkInv.inv.(ITransferInventory); ok && !blkInv.ejectOnUnsubscribe {
+        return []ITransferInventory{inv}
+    }
+
+    return nil
+}
+
+// iTransferAspect is implemented by the aspects of containers.
+type iTransferAspect interface {
+    transferInvs(instance *BlockInstance) []ITransferInventory
+}
+
+// containerAt returns the container block at the given location, and its
+// inventories (two for a large chest). invs is empty if there is no container
+// there.
+func containerAt(chunk IChunkBlock, blockLoc *BlockXyz) (container *BlockInstance, invs []ITransferInventory) {
+    if blockLoc == nil {
+        return nil, nil
+    }
+
+    container, ok := chunk.BlockInstanceAt(blockLoc)
+    if !ok {
+        return nil, nil
+    }
+
+    if aspect, ok := container.BlockType.Aspect.(iTransferAspect); ok {
+        invs = aspect.transferInvs(container)
+    }
+
+    return
+}
@@ -11,18 +11,29 @@ import (
 type blockInventory struct {
     tileEntity
     inv                IInventory
-    subscribers        map[EntityId]IPlayerClient
+    subscribers        map[EntityId]invViewer
     ejectOnUnsubscribe bool
     invTypeId          InvTypeId
+    entityId           EntityId // The owning entity, or EntityIdNull for blocks.
+}
+
+// invViewer is a player subscribed to a blockInventory. The player's window
+// may be for another block, when the inventory is one half of a large chest,
+// in which case the inventory's slots start at slotOffset within the window.
+type invViewer struct {
+    player     IPlayerClient
+    blockLoc   BlockXyz
+    slotOffset SlotId
 }
 
 // newBlockInventory creates a new blockInventory.
 func newBlockInventory(instance *BlockInstance, inv IInventory, ejectOnUnsubscribe bool, invTypeId InvTypeId) *blockInventory {
     blkInv := &blockInventory{
         inv:                inv,
-        subscribers:        make(map[EntityId]IPlayerClient),
+        subscribers:        make(map[EntityId]invViewer),
         ejectOnUnsubscribe: ejectOnUnsubscribe,
         invTypeId:          invTypeId,
+        entityId:           EntityIdNull,
     }
 
     if instance != nil {
@@ -62,49 +73,93 @@ func (blkInv *blockInventory) MarshalNbt(tag nbt.Compound) (err error) {
 func (blkInv *blockInventory) Click(player IPlayerClient, click *Click) {
     txState := blkInv.inv.Click(click)
 
-    player.InventoryCursorUpdate(blkInv.blockLoc, click.Cursor)
+    replyClick(player, blkInv.blockLoc, click, txState)
+}
+
+// replyClick sends the outcome of a click on a remote inventory back to the
+// player.
+func replyClick(player IPlayerClient, blockLoc BlockXyz, click *Click, txState TxState) {
+    if click.IsTransfer() {
+        player.InventoryTransferred(blockLoc, *click)
+    } else {
+        player.InventoryCursorUpdate(blockLoc, click.Cursor)
+    }
 
     // Inform client of operation status.
-    player.InventoryTxState(blkInv.blockLoc, click.TxId, txState == TxStateAccepted)
+    player.InventoryTxState(blockLoc, click.TxId, txState == TxStateAccepted)
 }
 
 func (blkInv *blockInventory) SlotUpdate(slot *Slot, slotId SlotId) {
-    for _, subscriber := range blkInv.subscribers {
-        subscriber.InventorySlotUpdate(blkInv.blockLoc, *slot, slotId)
+    for _, viewer := range blkInv.subscribers {
+        viewer.player.InventorySlotUpdate(viewer.blockLoc, *slot, viewer.slotOffset+slotId)
     }
 }
 
 func (blkInv *blockInventory) ProgressUpdate(prgBarId PrgBarId, value PrgBarValue) {
-    for _, subscriber := range blkInv.subscribers {
-        subscriber.InventoryProgressUpdate(blkInv.blockLoc, prgBarId, value)
+    for _, viewer := range blkInv.subscribers {
+        viewer.player.InventoryProgressUpdate(viewer.blockLoc, prgBarId, value)
     }
 }
 
 func (blkInv *blockInventory) AddSubscriber(player IPlayerClient) {
+    blkInv.addViewer(player, blkInv.blockLoc, 0)
+
+    slots := blkInv.inv.MakeProtoSlots()
+
+    if blkInv.entityId != EntityIdNull {
+        player.EntityInventorySubscribed(blkInv.entityId, blkInv.blockLoc, blkInv.invTypeId, slots)
+    } else {
+        player.InventorySubscribed(blkInv.blockLoc, blkInv.invTypeId, slots)
+    }
+}
+
+// addViewer subscribes the player to the inventory, as seen through the window
+// for the given block, without telling them about it.
+func (blkInv *blockInventory) addViewer(player IPlayerClient, blockLoc BlockXyz, slotOffset SlotId) {
     entityId := player.GetEntityId()
-    blkInv.subscribers[entityId] = player
+    blkInv.subscribers[entityId] = invViewer{player, blockLoc, slotOffset}
 
     // Register self for automatic removal when IPlayerClient unsubscribes
     // from the chunk.
     blkInv.chunk.AddOnUnsubscribe(entityId, blkInv)
+}
 
-    slots := blkInv.inv.MakeProtoSlots()
+// removeViewer unsubscribes the player if they are viewing the inventory
+// through the window for the given block.
+func (blkInv *blockInventory) removeViewer(entityId EntityId, blockLoc *BlockXyz) {
+    if viewer, ok := blkInv.subscribers[entityId]; ok && viewer.blockLoc == *blockLoc {
+        delete(blkInv.subscribers, entityId)
+        blkInv.chunk.RemoveOnUnsubscribe(entityId, blkInv)
+    }
+}
 
-    player.InventorySubscribed(blkInv.blockLoc, blkInv.invTypeId, slots)
+// isViewing returns true if the player is viewing the inventory through the
+// window for the given block, with its slots at the given offset.
+func (blkInv *blockInventory) isViewing(entityId EntityId, blockLoc *BlockXyz, slotOffset SlotId) bool {
+    viewer, ok := blkInv.subscribers[entityId]
+    return ok && viewer.blockLoc == *blockLoc && viewer.slotOffset == slotOffset
 }
 
 func (blkInv *blockInventory) RemoveSubscriber(entityId EntityId) {
+    viewer, ok := blkInv.subscribers[entityId]
     delete(blkInv.subscribers, entityId)
     blkInv.chunk.RemoveOnUnsubscribe(entityId, blkInv)
     if blkInv.ejectOnUnsubscribe && len(blkInv.subscribers) == 0 {
-        blkInv.EjectItems()
+        if ok {
+            // The last player to close the window gets the items back.
+            for _, slot := range blkInv.inv.TakeAllItems() {
+                viewer.player.GiveItem(slot)
+            }
+        } else {
+            blkInv.EjectItems()
+        }
     }
 }
 
 func (blkInv *blockInventory) Destroyed() {
-    for _, subscriber := range blkInv.subscribers {
-        subscriber.InventoryUnsubscribed(blkInv.blockLoc)
-        blkInv.chunk.RemoveOnUnsubscribe(subscriber.GetEntityId(), blkInv)
+    for entityId, viewer := range blkInv.subscribers {
+        viewer.player.InventoryUnsubscribed(viewer.blockLoc)
+        blkInv.chunk.RemoveOnUnsubscribe(entityId, blkInv)
     }
     blkInv.subscribers = nil
 }
@@ -121,6 +176,6 @@ func (blkInv *blockInventory) EjectItems() {
     items := blkInv.inv.TakeAllItems()
 
     for _, slot := range items {
-        spawnItemInBlock(blkInv.chunk, blkInv.blockLoc, slot.ItemTypeId, slot.Count, slot.Data)
+        spawnSlotInBlock(blkInv.chunk, blkInv.blockLoc, slot)
     }
 }
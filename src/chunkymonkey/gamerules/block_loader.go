@@ -194,17 +194,29 @@ func LoadBlocksFromFile(filename string) (blockTypes BlockTypeList, err error) {
 
 func init() {
     aspectMakers = map[string]aspectMakerFn{
-        "Chest":        makeChestAspect,
-        "Dispenser":    makeDispenserAspect,
-        "Furnace":      makeFurnaceAspect,
-        "MobSpawner":   makeMobSpawnerAspect,
-        "Music":        makeMusicAspect,
-        "RecordPlayer": makeRecordPlayerAspect,
-        "Sapling":      makeSaplingAspect,
-        "Sign":         makeSignAspect,
-        "Standard":     makeStandardAspect,
-        "Todo":         makeTodoAspect,
-        "Void":         makeVoidAspect,
-        "Workbench":    makeWorkbenchAspect,
+        "Anvil":           makeAnvilAspect,
+        "Bed":             makeBedAspect,
+        "BrewingStand":    makeBrewingStandAspect,
+        "Chest":           makeChestAspect,
+        "Comparator":      makeComparatorAspect,
+        "Dispenser":       makeDispenserAspect,
+        "Dropper":         makeDropperAspect,
+        "EnchantingTable": makeEnchantingTableAspect,
+        "EnderChest":      makeEnderChestAspect,
+        "Fire":            makeFireAspect,
+        "Furnace":         makeFurnaceAspect,
+        "Hopper":          makeHopperAspect,
+        "MobSpawner":      makeMobSpawnerAspect,
+        "Music":           makeMusicAspect,
+        "Portal":          makePortalAspect,
+        "Rail":            makeRailAspect,
+        "RecordPlayer":    makeRecordPlayerAspect,
+        "Sapling":         makeSaplingAspect,
+        "Sign":            makeSignAspect,
+        "Standard":        makeStandardAspect,
+        "Todo":            makeTodoAspect,
+        "Void":            makeVoidAspect,
+        "Water":           makeWaterAspect,
+        "Workbench":       makeWorkbenchAspect,
     }
 }
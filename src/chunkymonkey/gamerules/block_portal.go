@@ -0,0 +1,431 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+// BlockIdPortal is the block that portals are filled with.
+const BlockIdPortal = BlockId(90)
+
+const (
+    blockIdObsidian = BlockId(49)
+
+    // Size of the inside of a portal frame.
+    portalWidth  = 2
+    portalHeight = 3
+
+    // Distance around the arrival point that is searched for an existing
+    // portal before building a new one.
+    portalSearchRadius = 16
+
+    // Coordinates in the nether are scaled down by this factor relative to the
+    // overworld.
+    netherScale = 8
+)
+
+// The obsidian platform that players arrive on in the end.
+var endPlatformCentre = BlockXyz{100, 48, 0}
+
+const endPlatformRadius = 2
+
+// portalAxis is the horizontal direction along the width of a portal.
+type portalAxis struct {
+    dx, dz BlockCoord
+}
+
+var portalAxes = [...]portalAxis{{1, 0}, {0, 1}}
+
+func (axis portalAxis) offset(corner *BlockXyz, w int, h int) *BlockXyz {
+    return corner.AddXyz(axis.dx*BlockCoord(w), BlockYCoord(h), axis.dz*BlockCoord(w))
+}
+
+func makePortalAspect() (aspect IBlockAspect) {
+    return &PortalAspect{}
+}
+
+// PortalAspect is the behaviour of portal blocks, which take players that
+// stand in them to another dimension.
+type PortalAspect struct {
+    StandardAspect
+    // ToDimension is the dimension that the portal leads to from the
+    // overworld. Portals in any other dimension lead back to the overworld.
+    ToDimension DimensionId
+}
+
+func (aspect *PortalAspect) Name() string {
+    return "Portal"
+}
+
+// Destination returns the dimension that the portal leads to from the given
+// dimension.
+func (aspect *PortalAspect) Destination(from DimensionId) DimensionId {
+    if from == DimensionNormal {
+        return aspect.ToDimension
+    }
+    return DimensionNormal
+}
+
+func (aspect *PortalAspect) PlayerInside(instance *BlockInstance, player IPlayerClient) {
+    player.EnterPortal(aspect.Destination(instance.Chunk.Dimension()))
+}
+
+func (aspect *PortalAspect) Destroy(instance *BlockInstance) {
+    aspect.StandardAspect.Destroy(instance)
+
+    // The rest of the portal collapses along with it.
+    pending := []BlockXyz{instance.BlockLoc}
+    for len(pending) > 0 {
+        loc := pending[len(pending)-1]
+        pending = pending[:len(pending)-1]
+
+        for face := Face(FaceMinValid); face <= FaceMaxValid; face++ {
+            dx, dy, dz := face.Dxyz()
+            neighbour := loc.AddXyz(dx, dy, dz)
+            if neighbour == nil {
+                continue
+            }
+            blockType, _, ok := instance.Chunk.BlockAt(neighbour)
+            if ok && blockType.id == instance.BlockType.id {
+                instance.Chunk.SetBlockAt(neighbour, BlockIdAir, 0)
+                pending = append(pending, *neighbour)
+            }
+        }
+    }
+}
+
+// findPortalFrame looks for an empty obsidian frame with loc in the bottom row
+// of its inside. It returns the bottom corner of the inside of the frame.
+func findPortalFrame(chunk IChunkBlock, loc *BlockXyz) (corner *BlockXyz, axis portalAxis, ok bool) {
+    for _, axis = range portalAxes {
+        for w := 0; w < portalWidth; w++ {
+            corner = axis.offset(loc, -w, 0)
+            if corner != nil && isEmptyPortalFrame(chunk, corner, axis) {
+                return corner, axis, true
+            }
+        }
+    }
+    return nil, portalAxis{}, false
+}
+
+func isEmptyPortalFrame(chunk IChunkBlock, corner *BlockXyz, axis portalAxis) bool {
+    for w := -1; w <= portalWidth; w++ {
+        for h := -1; h <= portalHeight; h++ {
+            isSide := w == -1 || w == portalWidth
+            isEnd := h == -1 || h == portalHeight
+            if isSide && isEnd {
+                // The corners of the frame can be anything.
+                continue
+            }
+
+            loc := axis.offset(corner, w, h)
+            if loc == nil {
+                return false
+            }
+            blockType, _, ok := chunk.BlockAt(loc)
+            if !ok {
+                return false
+            }
+
+            if isSide || isEnd {
+                if blockType.id != blockIdObsidian {
+                    return false
+                }
+            } else if _, isFire := blockType.Aspect.(*FireAspect); blockType.id != BlockIdAir && !isFire {
+                return false
+            }
+        }
+    }
+    return true
+}
+
+// fillPortal fills the inside of a portal frame with portal blocks.
+func fillPortal(chunk IChunkBlock, corner *BlockXyz, axis portalAxis) {
+    for w := 0; w < portalWidth; w++ {
+        for h := 0; h < portalHeight; h++ {
+            if loc := axis.offset(corner, w, h); loc != nil {
+                chunk.SetBlockAt(loc, BlockIdPortal, 0)
+            }
+        }
+    }
+}
+
+// buildPortal builds a lit portal with the given inside bottom corner, along
+// with a ledge to stand on either side of it.
+func buildPortal(chunk IChunkBlock, corner *BlockXyz, axis portalAxis) {
+    for w := -1; w <= portalWidth; w++ {
+        for h := -1; h <= portalHeight; h++ {
+            loc := axis.offset(corner, w, h)
+            if loc == nil {
+                continue
+            }
+            if w == -1 || w == portalWidth || h == -1 || h == portalHeight {
+                chunk.SetBlockAt(loc, blockIdObsidian, 0)
+                continue
+            }
+
+            // Clear space in front of and behind the portal.
+            for _, side := range [...]BlockCoord{-1, 1} {
+                sideLoc := loc.AddXyz(axis.dz*side, 0, axis.dx*side)
+                if sideLoc == nil {
+                    continue
+                }
+                chunk.SetBlockAt(sideLoc, BlockIdAir, 0)
+                if h == 0 {
+                    if ledgeLoc := sideLoc.AddXyz(0, -1, 0); ledgeLoc != nil {
+                        chunk.SetBlockAt(ledgeLoc, blockIdObsidian, 0)
+                    }
+                }
+            }
+        }
+    }
+
+    fillPortal(chunk, corner, axis)
+}
+
+// PortalSearch looks outward from where a player arrives in a dimension for
+// the nearest portal. The search area can span several shards, which each
+// search their own chunks in turn before the search returns to the shard with
+// the arrival point.
+type PortalSearch struct {
+    player IPlayerClient
+    look   LookDegrees
+    centre BlockXyz
+
+    // The lowest block of the nearest portal found so far, and the squared
+    // distance to it.
+    found  *BlockXyz
+    distSq int
+
+    // Shards left to search, starting with the one being searched.
+    shards []ShardXz
+}
+
+// NewPortalSearch starts a search for the portal nearest to the given
+// position, which the player arriving there is moved to once it is done.
+func NewPortalSearch(player IPlayerClient, position *AbsXyz, look LookDegrees) (search *PortalSearch, ok bool) {
+    centre := position.ToBlockXyz()
+    if centre == nil {
+        return nil, false
+    }
+
+    search = &PortalSearch{
+        player: player,
+        look:   look,
+        centre: *centre,
+    }
+
+    // The shard with the arrival point is searched first.
+    search.shards = append(search.shards, search.centreChunk().ToShardXz())
+    for _, chunkLoc := range search.chunks() {
+        shardLoc := chunkLoc.ToShardXz()
+        known := false
+        for i := range search.shards {
+            known = known || search.shards[i].Equals(&shardLoc)
+        }
+        if !known {
+            search.shards = append(search.shards, shardLoc)
+        }
+    }
+
+    return search, true
+}
+
+// Centre returns the block that the player arrives at.
+func (search *PortalSearch) Centre() *BlockXyz {
+    return &search.centre
+}
+
+func (search *PortalSearch) centreChunk() *ChunkXz {
+    return search.centre.ToChunkXz()
+}
+
+// chunks returns the chunks that the search covers, nearest first.
+func (search *PortalSearch) chunks() (chunks []ChunkXz) {
+    centre := search.centreChunk()
+    radius := ChunkCoord(portalSearchRadius/ChunkSizeH + 1)
+    for r := ChunkCoord(0); r <= radius; r++ {
+        for dx := -r; dx <= r; dx++ {
+            for dz := -r; dz <= r; dz++ {
+                if dx.Abs() == r || dz.Abs() == r {
+                    chunks = append(chunks, ChunkXz{centre.X + dx, centre.Z + dz})
+                }
+            }
+        }
+    }
+    return
+}
+
+// Shard returns the shard that the search should be passed to next. Once
+// every shard has been searched, this is the one with the arrival point, and
+// done=true.
+func (search *PortalSearch) Shard() (shardLoc ShardXz, done bool) {
+    if len(search.shards) == 0 {
+        return search.centreChunk().ToShardXz(), true
+    }
+    return search.shards[0], false
+}
+
+// ChunksInShard returns the chunks that the search covers within the given
+// shard, nearest first.
+func (search *PortalSearch) ChunksInShard(shardLoc ShardXz) (chunks []ChunkXz) {
+    for _, chunkLoc := range search.chunks() {
+        if inShard := chunkLoc.ToShardXz(); inShard.Equals(&shardLoc) {
+            chunks = append(chunks, chunkLoc)
+        }
+    }
+    return
+}
+
+// ShardSearched moves the search on to the next shard.
+func (search *PortalSearch) ShardSearched() {
+    if len(search.shards) > 0 {
+        search.shards = search.shards[1:]
+    }
+}
+
+// Search looks for portals in the given chunk that are nearer than any found
+// so far. Each column is searched outward from the height of the arrival
+// point, and columns that cannot hold a nearer portal are skipped.
+func (search *PortalSearch) Search(chunk IChunkBlock, chunkLoc *ChunkXz) {
+    for x := SubChunkCoord(0); x < ChunkSizeH; x++ {
+        for z := SubChunkCoord(0); z < ChunkSizeH; z++ {
+            loc := chunkLoc.ToBlockXyz(&SubChunkXyz{x, 0, z})
+            dx, dz := int(loc.X-search.centre.X), int(loc.Z-search.centre.Z)
+            if dx < -portalSearchRadius || dx > portalSearchRadius || dz < -portalSearchRadius || dz > portalSearchRadius {
+                continue
+            }
+            search.searchColumn(chunk, loc.X, loc.Z, dx*dx+dz*dz)
+        }
+    }
+}
+
+func (search *PortalSearch) searchColumn(chunk IChunkBlock, x BlockCoord, z BlockCoord, distSqH int) {
+    for dy := 0; dy < ChunkSizeY; dy++ {
+        distSq := distSqH + dy*dy
+        if search.found != nil && distSq >= search.distSq {
+            return
+        }
+
+        for _, y := range [...]int{int(search.centre.Y) - dy, int(search.centre.Y) + dy} {
+            if y < 0 || y >= ChunkSizeY {
+                continue
+            }
+            loc := &BlockXyz{x, BlockYCoord(y), z}
+            blockType, _, ok := chunk.BlockAt(loc)
+            if !ok {
+                // Chunk not loaded.
+                return
+            }
+            if blockType.id == BlockIdPortal {
+                search.found = lowestPortalBlock(chunk, loc)
+                search.distSq = distSq
+                return
+            }
+        }
+    }
+}
+
+// lowestPortalBlock returns the bottom of the column of portal blocks that
+// loc is in.
+func lowestPortalBlock(chunk IChunkBlock, loc *BlockXyz) *BlockXyz {
+    for {
+        below := loc.AddXyz(0, -1, 0)
+        if below == nil {
+            return loc
+        }
+        blockType, _, ok := chunk.BlockAt(below)
+        if !ok || blockType.id != BlockIdPortal {
+            return loc
+        }
+        loc = below
+    }
+}
+
+// Arrive moves the player to the nearest portal found, or to a portal built at
+// the arrival point if none was found. The chunk must be in the shard with the
+// arrival point.
+func (search *PortalSearch) Arrive(chunk IChunkBlock) {
+    portalLoc := search.found
+    if portalLoc == nil {
+        centre := &search.centre
+        corner := &BlockXyz{centre.X, portalBuildHeight(chunk, centre.X, centre.Z), centre.Z}
+        buildPortal(chunk, corner, portalAxes[0])
+        portalLoc = corner
+    }
+
+    arrival := portalLoc.MidPointToAbsXyz()
+    arrival.Y = AbsCoord(portalLoc.Y)
+    search.player.SetPositionLook(arrival, search.look)
+}
+
+// portalBuildHeight picks the height at which to build a portal at the given
+// column, standing on the highest solid block that has room above it.
+func portalBuildHeight(chunk IChunkBlock, x BlockCoord, z BlockCoord) BlockYCoord {
+    for y := ChunkSizeY - portalHeight - 2; y > 1; y-- {
+        below, _, ok := chunk.BlockAt(&BlockXyz{x, BlockYCoord(y - 1), z})
+        if !ok || !below.Solid {
+            continue
+        }
+        clear := true
+        for h := 0; h < portalHeight && clear; h++ {
+            blockType, _, ok := chunk.BlockAt(&BlockXyz{x, BlockYCoord(y + h), z})
+            clear = ok && !blockType.Solid
+        }
+        if clear {
+            return BlockYCoord(y)
+        }
+    }
+    return ChunkSizeY / 2
+}
+
+// PortalDestination returns the approximate position that a player at the
+// given position travels to when moving between the given dimensions.
+func PortalDestination(from DimensionId, to DimensionId, position AbsXyz) AbsXyz {
+    switch {
+    case to == DimensionEnd:
+        return endPlatformArrival()
+    case from == DimensionNormal && to == DimensionNether:
+        position.X /= netherScale
+        position.Z /= netherScale
+    case from == DimensionNether && to == DimensionNormal:
+        position.X *= netherScale
+        position.Z *= netherScale
+    }
+
+    if position.Y < 2 {
+        position.Y = 2
+    } else if maxY := AbsCoord(ChunkSizeY - portalHeight - 2); position.Y > maxY {
+        position.Y = maxY
+    }
+
+    return position
+}
+
+// BuildEndPlatform builds the obsidian platform that players arrive on in the
+// end, and returns the position to arrive at.
+func BuildEndPlatform(chunk IChunkBlock) AbsXyz {
+    c := &endPlatformCentre
+    for dx := -endPlatformRadius; dx <= endPlatformRadius; dx++ {
+        for dz := -endPlatformRadius; dz <= endPlatformRadius; dz++ {
+            for dy := 0; dy <= portalHeight; dy++ {
+                blockId := BlockIdAir
+                if dy == 0 {
+                    blockId = blockIdObsidian
+                }
+                if loc := c.AddXyz(BlockCoord(dx), BlockYCoord(dy), BlockCoord(dz)); loc != nil {
+                    chunk.SetBlockAt(loc, blockId, 0)
+                }
+            }
+        }
+    }
+
+    return endPlatformArrival()
+}
+
+// endPlatformArrival returns the position that players arrive at in the end,
+// standing on the obsidian platform.
+func endPlatformArrival() AbsXyz {
+    arrival := endPlatformCentre.MidPointToAbsXyz()
+    arrival.Y = AbsCoord(endPlatformCentre.Y + 1)
+    return arrival
+}
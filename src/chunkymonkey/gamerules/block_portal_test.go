@@ -0,0 +1,149 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func TestPortalDestination(t *testing.T) {
+    type Test struct {
+        desc     string
+        from, to DimensionId
+        position AbsXyz
+        expect   AbsXyz
+    }
+
+    tests := []Test{
+        {"into nether", DimensionNormal, DimensionNether, AbsXyz{800, 64, -160}, AbsXyz{100, 64, -20}},
+        {"out of nether", DimensionNether, DimensionNormal, AbsXyz{100, 64, -20}, AbsXyz{800, 64, -160}},
+        {"clamped low", DimensionNormal, DimensionNether, AbsXyz{0, -5, 0}, AbsXyz{0, 2, 0}},
+        {"clamped high", DimensionNether, DimensionNormal, AbsXyz{0, 200, 0}, AbsXyz{0, ChunkSizeY - portalHeight - 2, 0}},
+        {"into end", DimensionNormal, DimensionEnd, AbsXyz{5, 70, 5}, AbsXyz{100.5, 49, 0.5}},
+    }
+
+    for _, test := range tests {
+        result := PortalDestination(test.from, test.to, test.position)
+        if result != test.expect {
+            t.Errorf("%s: expected %v, got %v", test.desc, test.expect, result)
+        }
+    }
+}
+
+// portalChunk holds blocks for portals to be searched for in. Blocks that
+// are not set are air, and block 1 is solid.
+type portalChunk struct {
+    IChunkBlock
+    blocks map[BlockXyz]BlockId
+}
+
+func (chunk *portalChunk) BlockAt(blockLoc *BlockXyz) (blockType *BlockType, blockData byte, ok bool) {
+    id := chunk.blocks[*blockLoc]
+    return &BlockType{BlockAttrs: BlockAttrs{id: id, Solid: id == 1}}, 0, true
+}
+
+func (chunk *portalChunk) SetBlockAt(blockLoc *BlockXyz, blockId BlockId, blockData byte) (ok bool) {
+    chunk.blocks[*blockLoc] = blockId
+    return true
+}
+
+// arrivingPlayer records where it is moved to.
+type arrivingPlayer struct {
+    IPlayerClient
+    position AbsXyz
+}
+
+func (player *arrivingPlayer) SetPositionLook(position AbsXyz, look LookDegrees) {
+    player.position = position
+}
+
+func TestNewPortalSearch(t *testing.T) {
+    tests := []struct {
+        desc     string
+        position AbsXyz
+        shards   []ShardXz
+    }{
+        {"middle of shard", AbsXyz{128, 64, 128}, []ShardXz{{0, 0}}},
+        {"corner of shards", AbsXyz{8, 64, 8}, []ShardXz{{0, 0}, {-1, -1}, {-1, 0}, {0, -1}}},
+    }
+
+    for _, test := range tests {
+        search, ok := NewPortalSearch(nil, &test.position, LookDegrees{})
+        if !ok {
+            t.Fatalf("%s: expected a search", test.desc)
+        }
+        if len(search.shards) != len(test.shards) {
+            t.Fatalf("%s: expected shards %v, got %v", test.desc, test.shards, search.shards)
+        }
+        // The shard with the arrival point is searched first.
+        if !search.shards[0].Equals(&test.shards[0]) {
+            t.Errorf("%s: expected shard %v to be searched first, got %v", test.desc, test.shards[0], search.shards[0])
+        }
+        for _, shardLoc := range test.shards {
+            known := false
+            for i := range search.shards {
+                known = known || search.shards[i].Equals(&shardLoc)
+            }
+            if !known {
+                t.Errorf("%s: expected shard %v to be searched, got %v", test.desc, shardLoc, search.shards)
+            }
+        }
+    }
+}
+
+func TestPortalSearch(t *testing.T) {
+    portal := func(x BlockCoord, y BlockYCoord, z BlockCoord) map[BlockXyz]BlockId {
+        blocks := make(map[BlockXyz]BlockId)
+        for h := BlockYCoord(0); h < portalHeight; h++ {
+            blocks[BlockXyz{x, y + h, z}] = BlockIdPortal
+        }
+        return blocks
+    }
+    merge := func(maps ...map[BlockXyz]BlockId) map[BlockXyz]BlockId {
+        blocks := map[BlockXyz]BlockId{{0, 63, 0}: 1}
+        for _, m := range maps {
+            for loc, id := range m {
+                blocks[loc] = id
+            }
+        }
+        return blocks
+    }
+
+    tests := []struct {
+        desc   string
+        blocks map[BlockXyz]BlockId
+        expect AbsXyz
+        built  bool
+    }{
+        {"nearest portal", merge(portal(5, 70, 0), portal(0, 64, 12)), AbsXyz{5.5, 70, 0.5}, false},
+        {"portal in another shard", merge(portal(-3, 60, -3)), AbsXyz{-2.5, 60, -2.5}, false},
+        {"portal too far away", merge(portal(20, 64, 0)), AbsXyz{0.5, 64, 0.5}, true},
+        {"no portal", merge(), AbsXyz{0.5, 64, 0.5}, true},
+    }
+
+    for _, test := range tests {
+        chunk := &portalChunk{blocks: test.blocks}
+        player := &arrivingPlayer{}
+        search, _ := NewPortalSearch(player, &AbsXyz{0.5, 64, 0.5}, LookDegrees{})
+
+        // Each shard searches its own chunks, as the shard server does.
+        for {
+            shardLoc, done := search.Shard()
+            if done {
+                break
+            }
+            for _, chunkLoc := range search.ChunksInShard(shardLoc) {
+                search.Search(chunk, &chunkLoc)
+            }
+            search.ShardSearched()
+        }
+        search.Arrive(chunk)
+
+        if player.position != test.expect {
+            t.Errorf("%s: expected player to arrive at %v, got %v", test.desc, test.expect, player.position)
+        }
+        if built := chunk.blocks[BlockXyz{0, 64, 0}] == BlockIdPortal; built != test.built {
+            t.Errorf("%s: expected portal built %t", test.desc, test.built)
+        }
+    }
+}
@@ -0,0 +1,300 @@
+package gamerules
+
+import (
+    "math"
+
+    . "chunkymonkey/types"
+)
+
+// Direction in which a rail can connect to a neighbouring rail.
+type railDir byte
+
+const (
+    railDirNorth = railDir(iota) // -Z
+    railDirEast                  // +X
+    railDirSouth                 // +Z
+    railDirWest                  // -X
+)
+
+func (d railDir) dxz() (dx BlockCoord, dz BlockCoord) {
+    switch d {
+    case railDirNorth:
+        dz = -1
+    case railDirEast:
+        dx = 1
+    case railDirSouth:
+        dz = 1
+    case railDirWest:
+        dx = -1
+    }
+    return
+}
+
+func (d railDir) opposite() railDir {
+    return (d + 2) & 3
+}
+
+// Rail shapes, as stored in the rail block data.
+const (
+    railShapeNorthSouth = byte(iota)
+    railShapeEastWest
+    railShapeAscendingEast
+    railShapeAscendingWest
+    railShapeAscendingNorth
+    railShapeAscendingSouth
+    railShapeCurveSouthEast
+    railShapeCurveSouthWest
+    railShapeCurveNorthWest
+    railShapeCurveNorthEast
+
+    // Rails that cannot curve use the lower 3 bits of block data for the
+    // shape, and the upper bit for the powered state.
+    railStraightShapeMask = 0x7
+    railPoweredFlag       = 0x8
+)
+
+// railShapeDirs gives the two directions that each shape connects in. For
+// ascending shapes, the second direction is the raised end.
+var railShapeDirs = [...][2]railDir{
+    railShapeNorthSouth:     {railDirNorth, railDirSouth},
+    railShapeEastWest:       {railDirWest, railDirEast},
+    railShapeAscendingEast:  {railDirWest, railDirEast},
+    railShapeAscendingWest:  {railDirEast, railDirWest},
+    railShapeAscendingNorth: {railDirSouth, railDirNorth},
+    railShapeAscendingSouth: {railDirNorth, railDirSouth},
+    railShapeCurveSouthEast: {railDirSouth, railDirEast},
+    railShapeCurveSouthWest: {railDirSouth, railDirWest},
+    railShapeCurveNorthWest: {railDirNorth, railDirWest},
+    railShapeCurveNorthEast: {railDirNorth, railDirEast},
+}
+
+func railShapeIsAscending(shape byte) bool {
+    return shape >= railShapeAscendingEast && shape <= railShapeAscendingSouth
+}
+
+func railShapeIsCurve(shape byte) bool {
+    return shape >= railShapeCurveSouthEast
+}
+
+func railShapeConnects(shape byte, d railDir) bool {
+    dirs := railShapeDirs[shape]
+    return dirs[0] == d || dirs[1] == d
+}
+
+// straightRailShape returns the shape of a straight rail along the axis of
+// the given direction, optionally ascending towards that direction.
+func straightRailShape(d railDir, ascending bool) byte {
+    if ascending {
+        switch d {
+        case railDirEast:
+            return railShapeAscendingEast
+        case railDirWest:
+            return railShapeAscendingWest
+        case railDirNorth:
+            return railShapeAscendingNorth
+        case railDirSouth:
+            return railShapeAscendingSouth
+        }
+    }
+    if d == railDirNorth || d == railDirSouth {
+        return railShapeNorthSouth
+    }
+    return railShapeEastWest
+}
+
+// connectingRailShape returns the shape that best connects a rail in
+// directions a and b. raised[d] is true if the rail connected in direction d is
+// one block higher.
+func connectingRailShape(a, b railDir, raised *[4]bool, curves bool) byte {
+    if b != a.opposite() {
+        if curves {
+            for shape := railShapeCurveSouthEast; shape <= railShapeCurveNorthEast; shape++ {
+                if railShapeConnects(shape, a) && railShapeConnects(shape, b) {
+                    return shape
+                }
+            }
+        }
+        b = a.opposite()
+    }
+
+    switch {
+    case raised[a]:
+        return straightRailShape(a, true)
+    case raised[b]:
+        return straightRailShape(b, true)
+    }
+    return straightRailShape(a, false)
+}
+
+func makeRailAspect() (aspect IBlockAspect) {
+    return &RailAspect{}
+}
+
+// RailAspect is the behaviour of rail blocks. Rails connect themselves to
+// neighbouring rails when placed, and minecarts can be placed on them.
+type RailAspect struct {
+    StandardAspect
+    // Curves is true if the rail can form curves. Rails that cannot curve
+    // store their powered state in the block data.
+    Curves bool
+    // Boosts is true if the rail speeds up minecarts when powered, and slows
+    // them down when unpowered.
+    Boosts bool
+}
+
+func (aspect *RailAspect) Name() string {
+    return "Rail"
+}
+
+// Shape returns the rail shape for the given block data.
+func (aspect *RailAspect) Shape(data byte) byte {
+    if aspect.Curves {
+        return data
+    }
+    return data & railStraightShapeMask
+}
+
+// Powered returns true if the rail is powered, given its block data.
+func (aspect *RailAspect) Powered(data byte) bool {
+    return !aspect.Curves && data&railPoweredFlag != 0
+}
+
+// withShape returns the block data for the rail with its shape changed.
+func (aspect *RailAspect) withShape(data byte, shape byte) byte {
+    if aspect.Curves {
+        return shape
+    }
+    return (data &^ railStraightShapeMask) | shape
+}
+
+// findRail looks for a rail next to loc in the given direction, at the same
+// level, or one block above or below.
+func findRail(chunk IChunkBlock, loc *BlockXyz, d railDir) (railLoc *BlockXyz, data byte, rail *RailAspect, ok bool) {
+    dx, dz := d.dxz()
+    for _, dy := range [...]BlockYCoord{0, 1, -1} {
+        if railLoc = loc.AddXyz(dx, dy, dz); railLoc == nil {
+            continue
+        }
+        var blockType *BlockType
+        if blockType, data, ok = chunk.BlockAt(railLoc); !ok {
+            continue
+        }
+        if rail, ok = blockType.Aspect.(*RailAspect); ok {
+            return
+        }
+    }
+    return nil, 0, nil, false
+}
+
+// connectedDirs returns the directions in which the rail at loc is connected
+// to another rail that connects back to it.
+func (aspect *RailAspect) connectedDirs(chunk IChunkBlock, loc *BlockXyz, data byte) (dirs []railDir) {
+    for _, d := range railShapeDirs[aspect.Shape(data)] {
+        _, otherData, other, ok := findRail(chunk, loc, d)
+        if ok && railShapeConnects(other.Shape(otherData), d.opposite()) {
+            dirs = append(dirs, d)
+        }
+    }
+    return
+}
+
+// canConnect returns true if the rail at loc is, or can be made, connected
+// towards direction d.
+func (aspect *RailAspect) canConnect(chunk IChunkBlock, loc *BlockXyz, data byte, d railDir) bool {
+    if railShapeConnects(aspect.Shape(data), d) {
+        return true
+    }
+    return len(aspect.connectedDirs(chunk, loc, data)) < 2
+}
+
+// connectTo reshapes the rail at loc to connect towards the rail at
+// otherLoc, in direction d, keeping any one existing connection.
+func (aspect *RailAspect) connectTo(chunk IChunkBlock, loc *BlockXyz, data byte, d railDir, otherLoc *BlockXyz) {
+    if railShapeConnects(aspect.Shape(data), d) {
+        return
+    }
+
+    var raised [4]bool
+    raised[d] = otherLoc.Y > loc.Y
+
+    other := d.opposite()
+    if dirs := aspect.connectedDirs(chunk, loc, data); len(dirs) > 0 {
+        other = dirs[0]
+        if otherRailLoc, _, _, ok := findRail(chunk, loc, other); ok {
+            raised[other] = otherRailLoc.Y > loc.Y
+        }
+    }
+
+    shape := connectingRailShape(d, other, &raised, aspect.Curves)
+    blockType, _, ok := chunk.BlockAt(loc)
+    if ok {
+        chunk.SetBlockAt(loc, blockType.id, aspect.withShape(data, shape))
+    }
+}
+
+func (aspect *RailAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    chunk := instance.Chunk
+    loc := &instance.BlockLoc
+
+    // Find neighbouring rails that are able to connect to this one.
+    var candidates []railDir
+    var raised [4]bool
+    for d := railDirNorth; d <= railDirWest; d++ {
+        railLoc, data, rail, ok := findRail(chunk, loc, d)
+        if !ok || !rail.canConnect(chunk, railLoc, data, d.opposite()) {
+            continue
+        }
+        candidates = append(candidates, d)
+        raised[d] = railLoc.Y > loc.Y
+    }
+
+    var shape byte
+    switch len(candidates) {
+    case 0:
+        // Lay the rail in the direction the player is facing.
+        facing := railDir(int(math.Floor(float64(look.Yaw)*4/360+0.5))+2) & 3
+        shape = straightRailShape(facing, false)
+    case 1:
+        shape = connectingRailShape(candidates[0], candidates[0].opposite(), &raised, aspect.Curves)
+    default:
+        // Prefer straight runs over curves.
+        a, b := candidates[0], candidates[1]
+        for _, d := range candidates {
+            for _, e := range candidates {
+                if e == d.opposite() {
+                    a, b = d, e
+                }
+            }
+        }
+        shape = connectingRailShape(a, b, &raised, aspect.Curves)
+    }
+
+    chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, aspect.withShape(instance.Data, shape))
+
+    // Turn the connected neighbours towards this rail.
+    for _, d := range railShapeDirs[shape] {
+        if railLoc, data, rail, ok := findRail(chunk, loc, d); ok {
+            rail.connectTo(chunk, railLoc, data, d.opposite(), loc)
+        }
+    }
+
+    return true
+}
+
+func (aspect *RailAspect) AcceptsItem(instance *BlockInstance, item *Slot) bool {
+    _, ok := minecartTypeByItem[item.ItemTypeId]
+    return ok
+}
+
+func (aspect *RailAspect) PlaceItem(instance *BlockInstance, item *Slot, look LookDegrees) {
+    objTypeId, ok := minecartTypeByItem[item.ItemTypeId]
+    if !ok {
+        return
+    }
+
+    position := instance.BlockLoc.MidPointToAbsXyz()
+    position.Y = AbsCoord(instance.BlockLoc.Y)
+
+    instance.Chunk.AddEntity(NewMinecartAt(objTypeId, position))
+    item.Decrement()
+}
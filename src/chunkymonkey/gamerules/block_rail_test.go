@@ -0,0 +1,35 @@
+package gamerules
+
+import (
+    "testing"
+)
+
+func TestConnectingRailShape(t *testing.T) {
+    type Test struct {
+        desc   string
+        a, b   railDir
+        raised [4]bool
+        curves bool
+        expect byte
+    }
+
+    tests := []Test{
+        {"straight north-south", railDirNorth, railDirSouth, [4]bool{}, true, railShapeNorthSouth},
+        {"straight east-west", railDirEast, railDirWest, [4]bool{}, true, railShapeEastWest},
+        {"curve south-east", railDirSouth, railDirEast, [4]bool{}, true, railShapeCurveSouthEast},
+        {"curve north-west", railDirWest, railDirNorth, [4]bool{}, true, railShapeCurveNorthWest},
+        {"no curve on straight rail", railDirSouth, railDirEast, [4]bool{}, false, railShapeNorthSouth},
+        {"ascending east", railDirEast, railDirWest, [4]bool{railDirEast: true}, true, railShapeAscendingEast},
+        {"ascending north", railDirSouth, railDirNorth, [4]bool{railDirNorth: true}, true, railShapeAscendingNorth},
+    }
+
+    for _, test := range tests {
+        result := connectingRailShape(test.a, test.b, &test.raised, test.curves)
+        if result != test.expect {
+            t.Errorf("%s: expected shape %d, got %d", test.desc, test.expect, result)
+        }
+        if !railShapeConnects(result, test.a) {
+            t.Errorf("%s: shape %d does not connect towards %d", test.desc, result, test.a)
+        }
+    }
+}
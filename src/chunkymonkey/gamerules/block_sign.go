@@ -2,21 +2,41 @@ package gamerules
 
 import (
     "errors"
+    "math"
+    "unicode/utf8"
 
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
     "nbt"
 )
 
+const (
+    // Number of lines of text on a sign.
+    SignLines = 4
+    // Maximum number of characters on each line of a sign.
+    signMaxLineLength = 15
+    // Sign posts face one of 16 directions.
+    signDirectionMask = 0xf
+
+    // Signs placed against the side of a block are wall signs, whose data is
+    // the face that they are on.
+    blockIdWallSign = BlockId(68)
+)
+
 func makeSignAspect() (aspect IBlockAspect) {
     return &SignAspect{}
 }
 
 type signTileEntity struct {
     tileEntity
-    text [4]string
+    text [SignLines]string
+    // The player that placed the sign, who may set its text once. It is
+    // EntityIdNull when the text can no longer be set.
+    editor EntityId
 }
 
 func NewSignTileEntity() ITileEntity {
-    return &signTileEntity{}
+    return &signTileEntity{editor: EntityIdNull}
 }
 
 func (sign *signTileEntity) UnmarshalNbt(tag nbt.Compound) (err error) {
@@ -61,6 +81,45 @@ func (sign *signTileEntity) MarshalNbt(tag nbt.Compound) (err error) {
     return nil
 }
 
+func (sign *signTileEntity) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
+    return append(pkts, &proto.PacketSignUpdate{
+        X:     int32(sign.blockLoc.X),
+        Y:     int16(sign.blockLoc.Y),
+        Z:     int32(sign.blockLoc.Z),
+        Text1: sign.text[0],
+        Text2: sign.text[1],
+        Text3: sign.text[2],
+        Text4: sign.text[3],
+    })
+}
+
+// setText sets the text of the sign, if it is being set by the player that
+// placed it and has not been set already. ok=false if the text was not set.
+func (sign *signTileEntity) setText(editor EntityId, text *[SignLines]string) (ok bool) {
+    if sign.editor == EntityIdNull || sign.editor != editor {
+        return false
+    }
+    sign.text = *text
+    sign.editor = EntityIdNull
+    return true
+}
+
+// isValidSignText returns true if the text is allowed on a sign. Lines must be
+// short enough to fit, and must not contain control or formatting characters.
+func isValidSignText(text *[SignLines]string) bool {
+    for _, line := range text {
+        if !utf8.ValidString(line) || utf8.RuneCountInString(line) > signMaxLineLength {
+            return false
+        }
+        for _, r := range line {
+            if r < 0x20 || r == 0x7f || r == '\u00a7' {
+                return false
+            }
+        }
+    }
+    return true
+}
+
 type SignAspect struct {
     StandardAspect
 }
@@ -69,4 +128,50 @@ func (aspect SignAspect) Name() string {
     return "Sign"
 }
 
-// TODO behaviours for signs.
+// Place puts a sign into the world, ready for the player that placed it to set
+// its text. Signs placed on top of a block are sign posts facing the player,
+// and those placed against the side of a block are wall signs.
+func (aspect *SignAspect) Place(instance *BlockInstance, look LookDegrees) bool {
+    switch instance.Face {
+    case FaceTop:
+        direction := byte(int(math.Floor(float64(look.Yaw+180)*16/360+0.5)) & signDirectionMask)
+        instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, direction)
+    case FaceEast, FaceWest, FaceNorth, FaceSouth:
+        instance.Chunk.SetBlockByIndex(instance.Index, blockIdWallSign, byte(instance.Face))
+    default:
+        // Signs cannot hang from the bottom of blocks.
+        return false
+    }
+
+    sign := aspect.sign(instance, true)
+    sign.text = [SignLines]string{}
+    sign.editor = instance.Placer
+    return true
+}
+
+// SetText validates and sets the text of the sign. Only the player that
+// placed the sign may set its text, and only once. It returns the sign's tile
+// entity, or ok=false if the text was rejected.
+func (aspect *SignAspect) SetText(instance *BlockInstance, editor EntityId, text [SignLines]string) (sign ITileEntity, ok bool) {
+    if !isValidSignText(&text) {
+        return nil, false
+    }
+
+    signEntity := aspect.sign(instance, false)
+    if signEntity == nil || !signEntity.setText(editor, &text) {
+        return nil, false
+    }
+    return signEntity, true
+}
+
+func (aspect *SignAspect) sign(instance *BlockInstance, create bool) *signTileEntity {
+    sign, ok := instance.Chunk.TileEntity(instance.Index).(*signTileEntity)
+    if !ok && create {
+        sign = &signTileEntity{editor: EntityIdNull}
+        sign.chunk = instance.Chunk
+        sign.blockLoc = instance.BlockLoc
+        instance.Chunk.SetTileEntity(instance.Index, sign)
+    }
+
+    return sign
+}
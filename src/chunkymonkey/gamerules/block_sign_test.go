@@ -0,0 +1,54 @@
+package gamerules
+
+import (
+    "testing"
+)
+
+func Test_isValidSignText(t *testing.T) {
+    type Test struct {
+        desc   string
+        text   [SignLines]string
+        expect bool
+    }
+
+    tests := []Test{
+        {"empty", [SignLines]string{}, true},
+        {"normal", [SignLines]string{"Welcome", "to my", "house", ""}, true},
+        {"full line", [SignLines]string{"123456789012345", "", "", ""}, true},
+        {"long line", [SignLines]string{"", "1234567890123456", "", ""}, false},
+        {"non-ascii", [SignLines]string{"héllo wörld", "", "", ""}, true},
+        {"formatting", [SignLines]string{"§cred", "", "", ""}, false},
+        {"control", [SignLines]string{"", "", "", "a\nb"}, false},
+    }
+
+    for _, test := range tests {
+        if result := isValidSignText(&test.text); result != test.expect {
+            t.Errorf("%s: expected %t, got %t", test.desc, test.expect, result)
+        }
+    }
+}
+
+func Test_signTileEntity_setText(t *testing.T) {
+    sign := NewSignTileEntity().(*signTileEntity)
+    text := [SignLines]string{"Welcome", "to my", "house", ""}
+
+    if sign.setText(1, &text) {
+        t.Errorf("expected text of a sign that nobody placed to be rejected")
+    }
+
+    sign.editor = 1
+    if sign.setText(2, &text) {
+        t.Errorf("expected text from another player to be rejected")
+    }
+    if !sign.setText(1, &text) {
+        t.Fatalf("expected text from the player that placed the sign to be set")
+    }
+    if sign.text != text {
+        t.Errorf("expected text %q, got %q", text, sign.text)
+    }
+
+    // The text can only be set once.
+    if sign.setText(1, &[SignLines]string{"Keep out"}) {
+        t.Errorf("expected the sign to be locked after its text was set")
+    }
+}
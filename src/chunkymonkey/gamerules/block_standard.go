@@ -18,9 +18,16 @@ type StandardAspect struct {
     // Items, up to one of which will potentially spawn when block destroyed.
     DroppedItems []blockDropItem
     BreakOn      DigStatus
-    ToolType     int8
+    // ToolType is the type of tool that digs the block faster.
+    ToolType ToolTypeId
+    // ToolRequired is true if the block only drops items when dug with a tool
+    // of ToolType whose material has at least HarvestLevel.
     ToolRequired bool
+    HarvestLevel int8
     ToolDamage   int8
+    // Range of experience dropped when the block is destroyed (e.g ores).
+    ExperienceMin Experience
+    ExperienceMax Experience
 }
 
 func (aspect *StandardAspect) setAttrs(blockAttrs *BlockAttrs) {
@@ -60,7 +67,7 @@ func (aspect *StandardAspect) InventoryUnsubscribed(instance *BlockInstance, pla
 }
 
 func (aspect *StandardAspect) Destroy(instance *BlockInstance) {
-    if len(aspect.DroppedItems) > 0 {
+    if len(aspect.DroppedItems) > 0 && !instance.NoDrops {
         rand := instance.Chunk.Rand()
         // Possibly drop item(s)
         r := byte(rand.Intn(100))
@@ -72,6 +79,14 @@ func (aspect *StandardAspect) Destroy(instance *BlockInstance) {
             r -= dropItem.Probability
         }
     }
+
+    if aspect.ExperienceMax > 0 && !instance.NoDrops {
+        rand := instance.Chunk.Rand()
+        amount := aspect.ExperienceMin + Experience(rand.Intn(int(aspect.ExperienceMax-aspect.ExperienceMin)+1))
+        if amount > 0 {
+            spawnExperienceInBlock(instance.Chunk, instance.BlockLoc, amount)
+        }
+    }
 }
 
 func (aspect *StandardAspect) Tick(instance *BlockInstance) bool {
@@ -13,8 +13,17 @@ type BlockAttrs struct {
     Solid           bool
     Replaceable     bool
     Attachable      bool
+    // Passable is true for solid blocks that players can move through, such
+    // as doors (which might be open) and vines.
+    Passable bool
+    // Climbable is true for blocks that hold up players inside them, such as
+    // ladders and liquids.
+    Climbable       bool
     BlastResistance float32
     Luminance       int8
+    // Hardness determines how long the block takes to dig. Zero is instant,
+    // and negative values cannot be dug at all.
+    Hardness float32
 }
 
 // The core information about any block type.
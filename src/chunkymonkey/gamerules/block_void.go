@@ -34,6 +34,14 @@ func (aspect *VoidAspect) InventoryClick(instance *BlockInstance, player IPlayer
 func (aspect *VoidAspect) InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient) {
 }
 
+func (aspect *VoidAspect) DigTicks(held *Slot) (ticks Ticks, ok bool) {
+    return 0, false
+}
+
+func (aspect *VoidAspect) CanHarvest(held *Slot) bool {
+    return false
+}
+
 func (aspect *VoidAspect) Destroy(instance *BlockInstance) {
 }
 
@@ -0,0 +1,50 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+func makeWaterAspect() (aspect IBlockAspect) {
+    return &WaterAspect{}
+}
+
+// WaterAspect is the behaviour of water blocks. Boats can be placed on top of
+// water, and float in it.
+type WaterAspect struct {
+    StandardAspect
+}
+
+func (aspect *WaterAspect) Name() string {
+    return "Water"
+}
+
+// isWaterAt returns true if the block at blockLoc is water.
+func isWaterAt(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+    if blockType, _, ok := chunk.BlockAt(blockLoc); ok {
+        _, isWater := blockType.Aspect.(*WaterAspect)
+        return isWater
+    }
+    return false
+}
+
+func (aspect *WaterAspect) AcceptsItem(instance *BlockInstance, item *Slot) bool {
+    if item.ItemTypeId != itemTypeIdBoat {
+        return false
+    }
+
+    // The boat needs room on the surface of the water.
+    aboveLoc := instance.BlockLoc.AddXyz(0, 1, 0)
+    if aboveLoc == nil {
+        return false
+    }
+    isSolid, _ := instance.Chunk.BlockQuery(*aboveLoc)
+    return !isSolid
+}
+
+func (aspect *WaterAspect) PlaceItem(instance *BlockInstance, item *Slot, look LookDegrees) {
+    position := instance.BlockLoc.MidPointToAbsXyz()
+    position.Y = AbsCoord(instance.BlockLoc.Y) + 1
+
+    instance.Chunk.AddEntity(NewBoatAt(position))
+    item.Decrement()
+}
@@ -0,0 +1,204 @@
+package gamerules
+
+import (
+    "math"
+
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+const (
+    // Height of a boat's hull, used to work out how much of it is under water.
+    boatHeight = 0.6
+    // Number of slices of the hull sampled for buoyancy.
+    boatBuoyancySamples = 5
+    // Vertical acceleration of a boat that is fully out of (or in) water.
+    boatBuoyancyAccel = 0.04
+    // Fraction of the rider's movement input added to the boat's velocity each
+    // tick.
+    boatRiderAccel = 0.2
+    // Maximum horizontal speed of a boat, in blocks/tick.
+    boatMaxSpeed = 0.4
+    // Fraction of speed kept each tick in water and on land.
+    boatWaterFriction = 0.99
+    boatLandFriction  = 0.5
+    // Horizontal speed above which a boat breaks when it hits something solid.
+    boatCrashSpeed = 0.2
+    // Damage done to a boat by each attack, and the damage at which it breaks.
+    // Damage decays by one each tick.
+    boatHitDamage = 10
+    boatMaxDamage = 40
+
+    itemTypeIdBoat   = ItemTypeId(333)
+    itemTypeIdPlanks = ItemTypeId(5)
+    itemTypeIdStick  = ItemTypeId(280)
+)
+
+// Boat is an Object that floats on water, and can be ridden and steered by a
+// player.
+type Boat struct {
+    Object
+    vehicle
+    damage int
+    input  AbsVelocity // Most recent movement input from the rider.
+}
+
+func newBoat() *Boat {
+    boat := &Boat{
+        Object: *NewObject(ObjTypeIdBoat),
+    }
+    boat.initVehicle()
+    return boat
+}
+
+func NewBoat() INonPlayerEntity {
+    return newBoat()
+}
+
+// NewBoatAt creates a boat at rest at a position.
+func NewBoatAt(position AbsXyz) *Boat {
+    boat := newBoat()
+    boat.PointObject.Init(position, AbsVelocity{})
+    return boat
+}
+
+func (boat *Boat) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
+    pkts = boat.Object.SpawnPackets(pkts)
+    return boat.riderPackets(pkts, boat.EntityId)
+}
+
+func (boat *Boat) Steer(rider EntityId, input AbsVelocity) {
+    if rider == boat.rider {
+        boat.input = input
+    }
+}
+
+// submergedFraction returns how much of the boat's hull is in water, from 0
+// to 1.
+func (boat *Boat) submergedFraction(chunk IChunkBlock) float64 {
+    p := *boat.Position()
+    inWater := 0
+    for i := 0; i < boatBuoyancySamples; i++ {
+        p.Y = boat.Position().Y + AbsCoord(boatHeight*(float64(i)+0.5)/boatBuoyancySamples)
+        if blockLoc := p.ToBlockXyz(); blockLoc != nil && isWaterAt(chunk, blockLoc) {
+            inWater++
+        }
+    }
+    return float64(inWater) / boatBuoyancySamples
+}
+
+// isBlocked returns true if the boat cannot be at the given position.
+func (boat *Boat) isBlocked(chunk IChunkBlock, position *AbsXyz) bool {
+    blockLoc := position.ToBlockXyz()
+    if blockLoc == nil {
+        return true
+    }
+    isSolid, _ := chunk.BlockQuery(*blockLoc)
+    return isSolid
+}
+
+func (boat *Boat) Tick(chunk IChunkBlock) (leftBlock bool) {
+    if boat.damage > 0 {
+        boat.damage--
+    }
+
+    p := boat.Position()
+    v := boat.Velocity()
+
+    // Float up through water, and fall through air.
+    fraction := boat.submergedFraction(chunk)
+    if fraction < 1 {
+        v.Y += AbsVelocityCoord(boatBuoyancyAccel * (2*fraction - 1))
+    } else {
+        if v.Y < 0 {
+            v.Y /= 2
+        }
+        v.Y += 0.007
+    }
+
+    if boat.rider != EntityIdNull {
+        v.X += boat.input.X * boatRiderAccel
+        v.Z += boat.input.Z * boatRiderAccel
+    }
+    boat.input = AbsVelocity{}
+
+    speed := math.Hypot(float64(v.X), float64(v.Z))
+    if speed > boatMaxSpeed {
+        v.X *= AbsVelocityCoord(boatMaxSpeed / speed)
+        v.Z *= AbsVelocityCoord(boatMaxSpeed / speed)
+        speed = boatMaxSpeed
+    }
+
+    // Move one axis at a time, stopping at anything solid.
+    next := *p
+    collided := false
+    next.X += AbsCoord(v.X)
+    if boat.isBlocked(chunk, &next) {
+        next.X = p.X
+        v.X = 0
+        collided = true
+    }
+    next.Z += AbsCoord(v.Z)
+    if boat.isBlocked(chunk, &next) {
+        next.Z = p.Z
+        v.Z = 0
+        collided = true
+    }
+    onGround := false
+    next.Y += AbsCoord(v.Y)
+    if boat.isBlocked(chunk, &next) {
+        next.Y = p.Y
+        onGround = v.Y < 0
+        v.Y = 0
+    }
+
+    if collided && speed > boatCrashSpeed {
+        // The boat smashes into pieces.
+        blockLoc := *p.ToBlockXyz()
+        boat.ejectRider(chunk)
+        spawnItemInBlock(chunk, blockLoc, itemTypeIdPlanks, 3, 0)
+        spawnItemInBlock(chunk, blockLoc, itemTypeIdStick, 2, 0)
+        chunk.RemoveEntity(boat)
+        return false
+    }
+
+    switch {
+    case fraction > 0:
+        v.X *= boatWaterFriction
+        v.Y *= 0.95
+        v.Z *= boatWaterFriction
+    case onGround:
+        v.X *= boatLandFriction
+        v.Z *= boatLandFriction
+    }
+
+    if next != *p {
+        *p = next
+        boat.moveRider(chunk, next)
+    }
+
+    if next.Y < 0 {
+        return true
+    }
+    if blockLoc := next.ToBlockXyz(); blockLoc != nil {
+        _, isWithinChunk := chunk.BlockQuery(*blockLoc)
+        leftBlock = !isWithinChunk
+    }
+    return
+}
+
+func (boat *Boat) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if !leftClick {
+        boat.toggleRider(player, boat.EntityId)
+        return false
+    }
+
+    boat.damage += boatHitDamage
+    if boat.damage <= boatMaxDamage {
+        return false
+    }
+
+    boat.ejectRider(chunk)
+    spawnItemInBlock(chunk, *boat.Position().ToBlockXyz(), itemTypeIdBoat, 1, 0)
+    return true
+}
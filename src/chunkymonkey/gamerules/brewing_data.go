@@ -0,0 +1,111 @@
+package gamerules
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+
+    . "chunkymonkey/types"
+)
+
+// BrewingData contains data on brewing stand reactions.
+type BrewingData struct {
+    // Ingredients contains a map of ingredient item type to its effect upon the
+    // potions that it is brewed into.
+    Ingredients map[ItemTypeId]BrewingIngredient
+}
+
+// BrewingIngredient describes how an ingredient changes the item data of the
+// potions that it is brewed into.
+type BrewingIngredient struct {
+    // Transitions are tried in order, and the first that matches a potion
+    // changes it.
+    Transitions []PotionTransition
+
+    // Flags set and cleared on potions that have an effect, if no transition
+    // matches them.
+    Set, Clear ItemData
+}
+
+// PotionTransition changes a potion's item data into another.
+type PotionTransition struct {
+    Input, Output ItemData
+    // The bits of the potion data that are matched against Input, and replaced
+    // by Output. All bits are matched if zero.
+    Mask ItemData
+}
+
+// brewingDataDef is used in unmarshalling data from the JSON definition of
+// BrewingData.
+type brewingDataDef struct {
+    Ingredients []struct {
+        Comment     string
+        Id          ItemTypeId
+        Transitions []PotionTransition
+        Set, Clear  ItemData
+    }
+}
+
+// Brew returns the item data of a potion with the given data after the
+// ingredient is brewed into it. ok=false if the ingredient does not change the
+// potion.
+func (ingredient *BrewingIngredient) Brew(data ItemData) (output ItemData, ok bool) {
+    for _, transition := range ingredient.Transitions {
+        mask := transition.Mask
+        if mask == 0 {
+            mask = ^ItemData(0)
+        }
+        if data&mask == transition.Input {
+            output = data&^mask | transition.Output
+            return output, output != data
+        }
+    }
+
+    if _, hasEffect := potionTypes[data&potionEffectMask]; !hasEffect {
+        return data, false
+    }
+
+    output = data&^ingredient.Clear | ingredient.Set
+    return output, output != data
+}
+
+// LoadBrewingData reads BrewingData from the reader.
+func LoadBrewingData(reader io.Reader) (brewingData BrewingData, err error) {
+    decoder := json.NewDecoder(reader)
+
+    var dataDef brewingDataDef
+
+    err = decoder.Decode(&dataDef)
+    if err != nil {
+        return
+    }
+
+    brewingData.Ingredients = make(map[ItemTypeId]BrewingIngredient)
+    for _, ingredientDef := range dataDef.Ingredients {
+        if _, ok := Items[ingredientDef.Id]; !ok {
+            err = fmt.Errorf(
+                "Brewing ingredient %q has unknown item type ID %d",
+                ingredientDef.Comment, ingredientDef.Id)
+            return
+        }
+        brewingData.Ingredients[ingredientDef.Id] = BrewingIngredient{
+            Transitions: ingredientDef.Transitions,
+            Set:         ingredientDef.Set,
+            Clear:       ingredientDef.Clear,
+        }
+    }
+
+    return
+}
+
+// LoadBrewingDataFromFile reads BrewingData from the named file.
+func LoadBrewingDataFromFile(filename string) (brewingData BrewingData, err error) {
+    file, err := os.Open(filename)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    return LoadBrewingData(file)
+}
@@ -0,0 +1,209 @@
+package gamerules
+
+import (
+    "errors"
+
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    // Health restored or taken by instant health and harm effects, before
+    // doubling for each amplifier level.
+    effectInstantHealth = Health(4)
+
+    // Ticks between health changes from poison and regeneration, before
+    // halving for each amplifier level.
+    effectPoisonInterval       = Ticks(25)
+    effectRegenerationInterval = Ticks(50)
+)
+
+// StatusEffect is a status effect on a player or mob.
+type StatusEffect struct {
+    Id        EntityEffect
+    Amplifier int8
+    Duration  Ticks
+}
+
+// IsInstant returns true if the effect happens once rather than over time.
+func (effect *StatusEffect) IsInstant() bool {
+    return effect.Id == EntityEffectHeal || effect.Id == EntityEffectHarm
+}
+
+// instantHealth returns the change in health caused by an instant effect.
+func (effect *StatusEffect) instantHealth() Health {
+    amount := effectInstantHealth << uint(effect.Amplifier)
+    switch effect.Id {
+    case EntityEffectHeal:
+        return amount
+    case EntityEffectHarm:
+        return -amount
+    }
+    return 0
+}
+
+// tickHealth returns the change in health caused by the effect over the tick,
+// given the number of ticks that it has left.
+func (effect *StatusEffect) tickHealth() Health {
+    var interval Ticks
+    var change Health
+    switch effect.Id {
+    case EntityEffectPoison:
+        interval, change = effectPoisonInterval, -1
+    case EntityEffectRegeneration:
+        interval, change = effectRegenerationInterval, 1
+    default:
+        return 0
+    }
+
+    interval >>= uint(effect.Amplifier)
+    if interval < 1 || effect.Duration%interval == 0 {
+        return change
+    }
+    return 0
+}
+
+func (effect *StatusEffect) packet(entityId EntityId) *proto.PacketEntityEffect {
+    duration := effect.Duration
+    if duration > 0x7fff {
+        duration = 0x7fff
+    }
+    return &proto.PacketEntityEffect{
+        EntityId:  entityId,
+        Effect:    effect.Id,
+        Amplifier: effect.Amplifier,
+        Duration:  int16(duration),
+    }
+}
+
+// StatusEffects are the status effects active on a player or mob.
+type StatusEffects struct {
+    active map[EntityEffect]*StatusEffect
+}
+
+// Add applies an effect, returning the change in health caused by an instant
+// effect. A lasting effect replaces an active effect of the same type only if
+// it is stronger, or as strong and lasts longer. changed=true if the active
+// effects changed.
+func (effects *StatusEffects) Add(effect StatusEffect) (healthChange Health, changed bool) {
+    if effect.IsInstant() {
+        return effect.instantHealth(), false
+    }
+
+    if existing, ok := effects.active[effect.Id]; ok {
+        if effect.Amplifier < existing.Amplifier || (effect.Amplifier == existing.Amplifier && effect.Duration <= existing.Duration) {
+            return 0, false
+        }
+    }
+
+    if effects.active == nil {
+        effects.active = make(map[EntityEffect]*StatusEffect)
+    }
+    effects.active[effect.Id] = &effect
+    return 0, true
+}
+
+// Remove removes the effect, returning true if it was active.
+func (effects *StatusEffects) Remove(id EntityEffect) bool {
+    if _, ok := effects.active[id]; ok {
+        delete(effects.active, id)
+        return true
+    }
+    return false
+}
+
+// Clear removes all effects, returning those that were active.
+func (effects *StatusEffects) Clear() (removed []EntityEffect) {
+    for id := range effects.active {
+        removed = append(removed, id)
+    }
+    effects.active = nil
+    return
+}
+
+// Get returns the active effect of the given type.
+func (effects *StatusEffects) Get(id EntityEffect) (effect *StatusEffect, ok bool) {
+    effect, ok = effects.active[id]
+    return
+}
+
+// Tick runs the effects for a tick. It returns the change in health caused by
+// them, and the effects that have worn off.
+func (effects *StatusEffects) Tick() (healthChange Health, expired []EntityEffect) {
+    for id, effect := range effects.active {
+        healthChange += effect.tickHealth()
+
+        effect.Duration--
+        if effect.Duration <= 0 {
+            delete(effects.active, id)
+            expired = append(expired, id)
+        }
+    }
+    return
+}
+
+// Packets appends and returns the packets that tell a client about the active
+// effects on the entity.
+func (effects *StatusEffects) Packets(entityId EntityId, pkts []proto.IPacket) []proto.IPacket {
+    for _, effect := range effects.active {
+        pkts = append(pkts, effect.packet(entityId))
+    }
+    return pkts
+}
+
+// AddPacket returns the packet that tells a client about the active effect.
+func (effects *StatusEffects) AddPacket(entityId EntityId, id EntityEffect) proto.IPacket {
+    if effect, ok := effects.active[id]; ok {
+        return effect.packet(entityId)
+    }
+    return nil
+}
+
+func (effects *StatusEffects) UnmarshalNbt(tag nbt.Compound) (err error) {
+    effectList, ok := tag.Lookup("ActiveEffects").(*nbt.List)
+    if !ok {
+        // Entities without effects have no list.
+        return nil
+    }
+
+    for _, effectITag := range effectList.Value {
+        effectTag, ok := effectITag.(nbt.Compound)
+        if !ok {
+            return errors.New("active effect not a compound")
+        }
+
+        id, idOk := effectTag.Lookup("Id").(*nbt.Byte)
+        amplifier, amplifierOk := effectTag.Lookup("Amplifier").(*nbt.Byte)
+        duration, durationOk := effectTag.Lookup("Duration").(*nbt.Int)
+        if !idOk || !amplifierOk || !durationOk {
+            return errors.New("bad active effect data")
+        }
+
+        effects.Add(StatusEffect{
+            Id:        EntityEffect(id.Value),
+            Amplifier: amplifier.Value,
+            Duration:  Ticks(duration.Value),
+        })
+    }
+
+    return nil
+}
+
+func (effects *StatusEffects) MarshalNbt(tag nbt.Compound) (err error) {
+    if len(effects.active) == 0 {
+        return nil
+    }
+
+    effectList := &nbt.List{nbt.TagCompound, make([]nbt.ITag, 0, len(effects.active))}
+    for _, effect := range effects.active {
+        effectList.Value = append(effectList.Value, nbt.Compound{
+            "Id":        &nbt.Byte{int8(effect.Id)},
+            "Amplifier": &nbt.Byte{effect.Amplifier},
+            "Duration":  &nbt.Int{int32(effect.Duration)},
+        })
+    }
+    tag.Set("ActiveEffects", effectList)
+
+    return nil
+}
@@ -0,0 +1,99 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func TestEffects_Add(t *testing.T) {
+    var effects StatusEffects
+
+    if _, changed := effects.Add(StatusEffect{EntityEffectMoveFaster, 0, 100}); !changed {
+        t.Errorf("expected new effect to be added")
+    }
+    if _, changed := effects.Add(StatusEffect{EntityEffectMoveFaster, 0, 50}); changed {
+        t.Errorf("expected shorter effect to be ignored")
+    }
+    if _, changed := effects.Add(StatusEffect{EntityEffectMoveFaster, 1, 50}); !changed {
+        t.Errorf("expected stronger effect to replace weaker one")
+    }
+    if effect, ok := effects.Get(EntityEffectMoveFaster); !ok || effect.Amplifier != 1 || effect.Duration != 50 {
+        t.Errorf("expected amplifier 1 for 50 ticks, got %+v", effect)
+    }
+
+    if change, changed := effects.Add(StatusEffect{EntityEffectHarm, 1, 0}); change != -8 || changed {
+        t.Errorf("expected instant harm of -8, got %d (changed=%t)", change, changed)
+    }
+}
+
+func TestEffects_Tick(t *testing.T) {
+    var effects StatusEffects
+    effects.Add(StatusEffect{EntityEffectPoison, 0, 100})
+    effects.Add(StatusEffect{EntityEffectMoveFaster, 0, 10})
+
+    var change Health
+    var expired []EntityEffect
+    for i := 0; i < 100; i++ {
+        c, e := effects.Tick()
+        change += c
+        expired = append(expired, e...)
+    }
+
+    if change != -4 {
+        t.Errorf("expected poison to do 4 damage, got %d", -change)
+    }
+    if len(expired) != 2 {
+        t.Errorf("expected both effects to expire, got %v", expired)
+    }
+    if _, ok := effects.Get(EntityEffectPoison); ok {
+        t.Errorf("expected poison to have worn off")
+    }
+}
+
+func TestPotionEffect(t *testing.T) {
+    type Test struct {
+        data     ItemData
+        expectOk bool
+        expect   StatusEffect
+    }
+
+    tests := []Test{
+        {0, false, StatusEffect{}},
+        {8194, true, StatusEffect{EntityEffectMoveFaster, 0, 3600}},
+        {8226, true, StatusEffect{EntityEffectMoveFaster, 1, 1800}},
+        {8258, true, StatusEffect{EntityEffectMoveFaster, 0, 9600}},
+        {8197, true, StatusEffect{EntityEffectHeal, 0, 0}},
+    }
+
+    for _, test := range tests {
+        effect, ok := PotionEffect(test.data)
+        if ok != test.expectOk || effect != test.expect {
+            t.Errorf("data %d: expected %+v (%t), got %+v (%t)", test.data, test.expect, test.expectOk, effect, ok)
+        }
+    }
+}
+
+func TestSplashPotionEffect(t *testing.T) {
+    type Test struct {
+        data     ItemData
+        distance AbsCoord
+        expectOk bool
+        expect   StatusEffect
+    }
+
+    tests := []Test{
+        {16386, 0, true, StatusEffect{EntityEffectMoveFaster, 0, 2700}},
+        {16386, 2, true, StatusEffect{EntityEffectMoveFaster, 0, 1350}},
+        {16386, 4, false, StatusEffect{}},
+        {16389, 3, true, StatusEffect{EntityEffectHeal, 0, 0}},
+        {16384, 0, false, StatusEffect{}},
+    }
+
+    for _, test := range tests {
+        effect, ok := SplashPotionEffect(test.data, test.distance)
+        if ok != test.expectOk || effect != test.expect {
+            t.Errorf("data %d at %v: expected %+v (%t), got %+v (%t)", test.data, test.distance, test.expect, test.expectOk, effect, ok)
+        }
+    }
+}
@@ -0,0 +1,333 @@
+package gamerules
+
+import (
+    "math/rand"
+
+    "nbt"
+)
+
+type EnchantmentId int16
+
+const (
+    EnchantmentProtection = EnchantmentId(0)
+    EnchantmentSharpness  = EnchantmentId(16)
+    EnchantmentEfficiency = EnchantmentId(32)
+    EnchantmentUnbreaking = EnchantmentId(34)
+)
+
+// Types of tool that are worn as armour.
+const (
+    ToolTypeHelmet     = ToolTypeId(6)
+    ToolTypeChestplate = ToolTypeId(7)
+    ToolTypeLeggings   = ToolTypeId(8)
+    ToolTypeBoots      = ToolTypeId(9)
+)
+
+const (
+    // Number of enchantments offered by an enchanting table.
+    EnchantNumOffers = 3
+
+    // Bookshelves beyond this number do not raise the levels offered.
+    enchantMaxBookshelves = 15
+
+    // Protection levels reduce damage by 4% per point, up to this many points.
+    protectionMaxPoints = 20
+)
+
+type enchantmentType struct {
+    id       EnchantmentId
+    maxLevel int16
+    // Relative chance of the enchantment being chosen.
+    weight int
+    // The enchantment level (as modified by the item's enchantability) needed
+    // for level 1 of the enchantment, the extra needed for each further
+    // level, and how far above that the level can still be given.
+    minBase, minPerLevel, levelRange int
+    // Types of item that the enchantment can be put on.
+    toolTypes []ToolTypeId
+}
+
+var (
+    armourToolTypes = []ToolTypeId{ToolTypeHelmet, ToolTypeChestplate, ToolTypeLeggings, ToolTypeBoots}
+    diggerToolTypes = []ToolTypeId{ToolTypeShovel, ToolTypePickaxe, ToolTypeAxe}
+)
+
+// enchantmentTypes are the enchantments that an enchanting table can give.
+var enchantmentTypes = []enchantmentType{
+    {EnchantmentProtection, 4, 10, 1, 11, 20, armourToolTypes},
+    {EnchantmentSharpness, 5, 10, 1, 11, 20, []ToolTypeId{ToolTypeSword}},
+    {EnchantmentEfficiency, 5, 10, 1, 10, 50, diggerToolTypes},
+    {EnchantmentUnbreaking, 3, 5, 5, 8, 50, append(append([]ToolTypeId{ToolTypeSword}, diggerToolTypes...), armourToolTypes...)},
+}
+
+func (enchType *enchantmentType) appliesTo(toolType ToolTypeId) bool {
+    for _, t := range enchType.toolTypes {
+        if t == toolType {
+            return true
+        }
+    }
+    return false
+}
+
+// anvilCostFactor is the number of levels that each level of the enchantment
+// costs when it is added to an item at an anvil. Rarer enchantments cost more.
+func (enchType *enchantmentType) anvilCostFactor() int32 {
+    switch {
+    case enchType.weight >= 10:
+        return 1
+    case enchType.weight >= 5:
+        return 2
+    case enchType.weight >= 2:
+        return 4
+    }
+    return 8
+}
+
+// enchantmentTypeById returns the type of the enchantment with the given ID,
+// or nil if it is unknown.
+func enchantmentTypeById(id EnchantmentId) *enchantmentType {
+    for i := range enchantmentTypes {
+        if enchantmentTypes[i].id == id {
+            return &enchantmentTypes[i]
+        }
+    }
+    return nil
+}
+
+// levelFor returns the highest level of the enchantment that the given
+// enchantment level gives, or 0 if it gives none.
+func (enchType *enchantmentType) levelFor(enchantLevel int) (level int16) {
+    for l := int16(1); l <= enchType.maxLevel; l++ {
+        min := enchType.minBase + int(l-1)*enchType.minPerLevel
+        if enchantLevel >= min && enchantLevel <= min+enchType.levelRange {
+            level = l
+        }
+    }
+    return
+}
+
+// Enchantment is an enchantment on an item.
+type Enchantment struct {
+    Id    EnchantmentId
+    Level int16
+}
+
+// Enchantments returns the enchantments on the item in the slot.
+func (s *Slot) Enchantments() (enchantments []Enchantment) {
+    enchList, ok := s.Nbt.Lookup("ench").(*nbt.List)
+    if !ok {
+        return nil
+    }
+
+    for _, tag := range enchList.Value {
+        enchTag, ok := tag.(nbt.Compound)
+        if !ok {
+            continue
+        }
+        idTag, idOk := enchTag.Lookup("id").(*nbt.Short)
+        lvlTag, lvlOk := enchTag.Lookup("lvl").(*nbt.Short)
+        if idOk && lvlOk {
+            enchantments = append(enchantments, Enchantment{EnchantmentId(idTag.Value), lvlTag.Value})
+        }
+    }
+
+    return
+}
+
+// EnchantmentLevel returns the level of the given enchantment on the item in
+// the slot, or 0 if it does not have it.
+func (s *Slot) EnchantmentLevel(id EnchantmentId) int16 {
+    if len(s.Nbt) == 0 {
+        return 0
+    }
+    for _, enchantment := range s.Enchantments() {
+        if enchantment.Id == id {
+            return enchantment.Level
+        }
+    }
+    return 0
+}
+
+// setEnchantments replaces the enchantments on the item in the slot.
+func (s *Slot) setEnchantments(enchantments []Enchantment) {
+    enchList := &nbt.List{nbt.TagCompound, make([]nbt.ITag, 0, len(enchantments))}
+    for _, enchantment := range enchantments {
+        enchTag := nbt.NewCompound()
+        enchTag.Set("id", &nbt.Short{int16(enchantment.Id)})
+        enchTag.Set("lvl", &nbt.Short{enchantment.Level})
+        enchList.Value = append(enchList.Value, enchTag)
+    }
+
+    s.setNbtTag("ench", enchList)
+}
+
+// IsEnchantable returns true if the item in the slot can be enchanted at an
+// enchanting table.
+func (s *Slot) IsEnchantable() bool {
+    itemType := s.ItemType()
+    if s.IsEmpty() || itemType == nil || itemType.Enchantability <= 0 {
+        return false
+    }
+    return len(s.Enchantments()) == 0
+}
+
+// EnchantmentOffers returns the levels offered by an enchanting table with the
+// given number of bookshelves around it, for enchanting the item. The levels
+// are all 0 if the item cannot be enchanted.
+func EnchantmentOffers(rand *rand.Rand, item *Slot, bookshelves int) (levels [EnchantNumOffers]int32) {
+    if !item.IsEnchantable() {
+        return
+    }
+
+    if bookshelves > enchantMaxBookshelves {
+        bookshelves = enchantMaxBookshelves
+    }
+
+    base := rand.Intn(8) + 1 + bookshelves/2 + rand.Intn(bookshelves+1)
+
+    levels[0] = int32(maxInt(base/3, 1))
+    levels[1] = int32(base*2/3 + 1)
+    levels[2] = int32(maxInt(base, bookshelves*2))
+
+    return
+}
+
+// Enchant puts random enchantments on the item in the slot, chosen by the
+// given enchantment level. Higher levels give stronger enchantments, and more
+// of them.
+func (s *Slot) Enchant(rand *rand.Rand, level int32) {
+    itemType := s.ItemType()
+    if s.IsEmpty() || itemType == nil || itemType.Enchantability <= 0 {
+        return
+    }
+
+    quarter := itemType.Enchantability/4 + 1
+    modified := float64(int(level) + 1 + rand.Intn(quarter) + rand.Intn(quarter))
+    bonus := (rand.Float64() + rand.Float64() - 1) * 0.15
+    enchantLevel := maxInt(int(modified*(1+bonus)+0.5), 1)
+
+    var enchantments []Enchantment
+    for {
+        enchantment, ok := chooseEnchantment(rand, itemType.ToolType, enchantLevel, enchantments)
+        if !ok {
+            break
+        }
+        enchantments = append(enchantments, enchantment)
+
+        // Further enchantments become less likely.
+        if rand.Intn(50) > enchantLevel {
+            break
+        }
+        enchantLevel /= 2
+    }
+
+    if len(enchantments) > 0 {
+        s.setEnchantments(enchantments)
+    }
+}
+
+// chooseEnchantment picks one of the enchantments that can be put on the type
+// of item at the given enchantment level, other than those that it already
+// has.
+func chooseEnchantment(rand *rand.Rand, toolType ToolTypeId, enchantLevel int, have []Enchantment) (chosen Enchantment, ok bool) {
+    var candidates []*enchantmentType
+    var levels []int16
+    totalWeight := 0
+
+candidateLoop:
+    for i := range enchantmentTypes {
+        enchType := &enchantmentTypes[i]
+        if !enchType.appliesTo(toolType) {
+            continue
+        }
+        for _, enchantment := range have {
+            if enchantment.Id == enchType.id {
+                continue candidateLoop
+            }
+        }
+        if level := enchType.levelFor(enchantLevel); level > 0 {
+            candidates = append(candidates, enchType)
+            levels = append(levels, level)
+            totalWeight += enchType.weight
+        }
+    }
+
+    if totalWeight == 0 {
+        return chosen, false
+    }
+
+    pick := rand.Intn(totalWeight)
+    for i, enchType := range candidates {
+        pick -= enchType.weight
+        if pick < 0 {
+            return Enchantment{enchType.id, levels[i]}, true
+        }
+    }
+
+    return chosen, false
+}
+
+// mergeEnchantments adds the enchantments from another item to those of an
+// item of the given type, as done by an anvil. Enchantments that the item
+// already has at the same level go up a level. cost is the levels charged for
+// the enchantments that changed, or 0 if none did.
+func mergeEnchantments(have, add []Enchantment, toolType ToolTypeId) (merged []Enchantment, cost int32) {
+    merged = append([]Enchantment(nil), have...)
+
+addLoop:
+    for _, enchantment := range add {
+        enchType := enchantmentTypeById(enchantment.Id)
+        if enchType == nil || !enchType.appliesTo(toolType) {
+            continue
+        }
+
+        level := enchantment.Level
+        if level > enchType.maxLevel {
+            level = enchType.maxLevel
+        }
+
+        for i := range merged {
+            if merged[i].Id != enchantment.Id {
+                continue
+            }
+            if level == merged[i].Level && level < enchType.maxLevel {
+                level++
+            }
+            if level > merged[i].Level {
+                merged[i].Level = level
+                cost += enchType.anvilCostFactor() * int32(level)
+            }
+            continue addLoop
+        }
+
+        merged = append(merged, Enchantment{enchantment.Id, level})
+        cost += enchType.anvilCostFactor() * int32(level)
+    }
+
+    return
+}
+
+// ProtectionReduction returns the fraction by which the protection
+// enchantments on the given pieces of armour reduce the damage done to their
+// wearer.
+func ProtectionReduction(armour []Slot) float64 {
+    points := 0
+    for i := range armour {
+        if level := int(armour[i].EnchantmentLevel(EnchantmentProtection)); level > 0 {
+            points += (6 + level*level) / 4
+        }
+    }
+
+    if points > protectionMaxPoints {
+        points = protectionMaxPoints
+    }
+
+    return float64(points) * 0.04
+}
+
+func maxInt(a, b int) int {
+    if a > b {
+        return a
+    }
+    return b
+}
@@ -0,0 +1,168 @@
+package gamerules
+
+import (
+    "math/rand"
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func makeEnchantableItemTypes() (pickaxe, sword, helmet, apple ItemTypeId) {
+    pickaxe, sword, helmet, apple = 257, 267, 306, 260
+    Items = make(ItemTypeMap)
+    Items[pickaxe] = &ItemType{Id: pickaxe, MaxStack: 1, ToolType: ToolTypePickaxe, ToolUses: 251, ToolMaterial: ToolMaterialIron, Enchantability: 14}
+    Items[sword] = &ItemType{Id: sword, MaxStack: 1, ToolType: ToolTypeSword, ToolUses: 251, ToolMaterial: ToolMaterialIron, Enchantability: 14}
+    Items[helmet] = &ItemType{Id: helmet, MaxStack: 1, ToolType: ToolTypeHelmet, ToolUses: 132, Enchantability: 9}
+    Items[apple] = &ItemType{Id: apple, MaxStack: 64}
+    return
+}
+
+func enchanted(itemTypeId ItemTypeId, id EnchantmentId, level int16) Slot {
+    slot := Slot{itemTypeId, 1, 0, nil}
+    slot.setEnchantments([]Enchantment{{id, level}})
+    return slot
+}
+
+func TestEnchantmentOffers(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    pickaxe, _, _, apple := makeEnchantableItemTypes()
+    r := rand.New(rand.NewSource(1))
+
+    for i := 0; i < 100; i++ {
+        levels := EnchantmentOffers(r, &Slot{pickaxe, 1, 0, nil}, 30)
+        if levels[0] < 1 || levels[0] > levels[1] || levels[1] > levels[2] {
+            t.Fatalf("expected increasing levels, got %v", levels)
+        }
+        // 15 bookshelves count, making the best offer at least 30.
+        if levels[2] != 30 {
+            t.Fatalf("expected best offer of 30 with 15 bookshelves, got %v", levels)
+        }
+
+        levels = EnchantmentOffers(r, &Slot{pickaxe, 1, 0, nil}, 0)
+        if levels[2] < 1 || levels[2] > 8 {
+            t.Fatalf("expected best offer of 1-8 without bookshelves, got %v", levels)
+        }
+    }
+
+    if levels := EnchantmentOffers(r, &Slot{apple, 1, 0, nil}, 15); levels != [EnchantNumOffers]int32{} {
+        t.Errorf("expected no offers for an apple, got %v", levels)
+    }
+    item := enchanted(pickaxe, EnchantmentEfficiency, 1)
+    if levels := EnchantmentOffers(r, &item, 15); levels != [EnchantNumOffers]int32{} {
+        t.Errorf("expected no offers for an enchanted item, got %v", levels)
+    }
+}
+
+func TestSlot_Enchant(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    pickaxe, sword, helmet, _ := makeEnchantableItemTypes()
+    r := rand.New(rand.NewSource(1))
+
+    allowed := map[ItemTypeId][]EnchantmentId{
+        pickaxe: {EnchantmentEfficiency, EnchantmentUnbreaking},
+        sword:   {EnchantmentSharpness, EnchantmentUnbreaking},
+        helmet:  {EnchantmentProtection, EnchantmentUnbreaking},
+    }
+
+    for itemTypeId, ids := range allowed {
+        for level := int32(1); level <= 30; level++ {
+            slot := Slot{itemTypeId, 1, 0, nil}
+            slot.Enchant(r, level)
+
+            enchantments := slot.Enchantments()
+            if len(enchantments) == 0 {
+                t.Fatalf("item %d at level %d: expected enchantments", itemTypeId, level)
+            }
+            for _, enchantment := range enchantments {
+                found := false
+                for _, id := range ids {
+                    found = found || id == enchantment.Id
+                }
+                if !found || enchantment.Level < 1 {
+                    t.Errorf("item %d at level %d: unexpected enchantment %+v", itemTypeId, level, enchantment)
+                }
+            }
+            if slot.IsEnchantable() {
+                t.Errorf("item %d at level %d: expected enchanted item not to be enchantable", itemTypeId, level)
+            }
+        }
+    }
+}
+
+func TestEnchantmentEffects(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    pickaxe, sword, helmet, _ := makeEnchantableItemTypes()
+
+    stone := &StandardAspect{ToolType: ToolTypePickaxe, ToolRequired: true}
+    stone.setAttrs(&BlockAttrs{Hardness: 1.5})
+    plain := Slot{pickaxe, 1, 0, nil}
+    efficient := enchanted(pickaxe, EnchantmentEfficiency, 2)
+    if ticks, _ := stone.DigTicks(&plain); ticks != 8 {
+        t.Errorf("expected stone to take 8 ticks with an iron pickaxe, got %d", ticks)
+    }
+    if ticks, _ := stone.DigTicks(&efficient); ticks != 5 {
+        t.Errorf("expected stone to take 5 ticks with efficiency II, got %d", ticks)
+    }
+
+    sharp := enchanted(sword, EnchantmentSharpness, 2)
+    if damage := sharp.AttackDamage(); damage != 9 {
+        t.Errorf("expected sharpness II iron sword to do 9 damage, got %d", damage)
+    }
+
+    unbreaking := enchanted(pickaxe, EnchantmentUnbreaking, 3)
+    for i := 0; i < 100; i++ {
+        unbreaking.Wear(1)
+    }
+    if unbreaking.Data < 1 || unbreaking.Data >= 50 {
+        t.Errorf("expected unbreaking III to take about 25 uses of 100, took %d", unbreaking.Data)
+    }
+
+    armour := []Slot{enchanted(helmet, EnchantmentProtection, 4), enchanted(helmet, EnchantmentProtection, 1), {}}
+    if reduction := ProtectionReduction(armour); reduction < 0.239 || reduction > 0.241 {
+        t.Errorf("expected protection to reduce damage by 24%%, got %v", reduction)
+    }
+}
+
+func TestEnchantInventory(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    pickaxe, _, _, apple := makeEnchantableItemTypes()
+
+    inv := NewEnchantInventory(15)
+
+    // Only one item goes in.
+    click := Click{SlotId: enchantSlotItem, Cursor: Slot{apple, 5, 0, nil}}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{apple, 1, 0, nil}, inv.slots[enchantSlotItem])
+    checkSlot(t, Slot{apple, 4, 0, nil}, click.Cursor)
+    if offers := inv.Offers(); offers != [EnchantNumOffers]int32{} {
+        t.Errorf("expected no offers for an apple, got %v", offers)
+    }
+
+    // A single item swaps with the one in the table.
+    click = Click{SlotId: enchantSlotItem, Cursor: Slot{pickaxe, 1, 0, nil}, ExpectedSlot: inv.slots[enchantSlotItem]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{pickaxe, 1, 0, nil}, inv.slots[enchantSlotItem])
+    checkSlot(t, Slot{apple, 1, 0, nil}, click.Cursor)
+
+    offers := inv.Offers()
+    if offers[2] != 30 {
+        t.Errorf("expected best offer of 30, got %v", offers)
+    }
+
+    if _, ok := inv.Enchant(2, 29); ok {
+        t.Errorf("expected enchanting to need 30 levels")
+    }
+    cost, ok := inv.Enchant(2, 30)
+    if !ok || cost != 30 {
+        t.Errorf("expected enchanting to cost 30 levels, got %d %t", cost, ok)
+    }
+    if len(inv.slots[enchantSlotItem].Enchantments()) == 0 {
+        t.Errorf("expected pickaxe to be enchanted")
+    }
+    if offers := inv.Offers(); offers != [EnchantNumOffers]int32{} {
+        t.Errorf("expected no offers after enchanting, got %v", offers)
+    }
+    if _, ok := inv.Enchant(0, 30); ok {
+        t.Errorf("expected enchanted item not to be enchanted again")
+    }
+}
@@ -4,7 +4,6 @@
 package gamerules
 
 import (
-    "chunkymonkey/physics"
     "chunkymonkey/proto"
     . "chunkymonkey/types"
     "nbt"
@@ -37,8 +36,20 @@ type IEntity interface {
 
     // Returns the entity's current position.
     Position() *AbsXyz
+
+    // AwarenessRadius returns the distance within which players are told
+    // about the entity.
+    AwarenessRadius() AbsCoord
 }
 
+// Distances within which players are told about entities of each kind.
+const (
+    PlayerAwarenessRadius = AbsCoord(128)
+    MobAwarenessRadius    = AbsCoord(80)
+    ObjectAwarenessRadius = AbsCoord(80)
+    ItemAwarenessRadius   = AbsCoord(64)
+)
+
 // INonPlayerEntity is the interface for entities other than players which are
 // controlled server-side.
 type INonPlayerEntity interface {
@@ -49,7 +60,67 @@ type INonPlayerEntity interface {
     SetEntityId(EntityId)
 
     // Runs the physics for the entity for a single server tick.
-    Tick(physics.IBlockQuerier) (leftBlock bool)
+    Tick(chunk IChunkBlock) (leftBlock bool)
+}
+
+// IUsableEntity is implemented by non-player entities that players can
+// directly interact with, e.g to ride them.
+type IUsableEntity interface {
+    // Use is called when a player clicks on the entity while holding the given
+    // item. leftClick is true if the player attacked the entity. It returns
+    // true if the entity was destroyed and should be removed from the chunk.
+    Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool)
+}
+
+// ISteerableEntity is implemented by vehicles that their rider can steer,
+// e.g boats.
+type ISteerableEntity interface {
+    // Steer is called with the movement input from the player riding the
+    // entity.
+    Steer(rider EntityId, input AbsVelocity)
+}
+
+// IInventoryEntity is implemented by non-player entities that have an
+// inventory window, e.g storage minecarts.
+type IInventoryEntity interface {
+    // InventoryClick is called when the player clicked on a slot inside the
+    // inventory for the entity.
+    InventoryClick(player IPlayerClient, click *Click)
+
+    // InventoryUnsubscribed is called when the player closes the window for
+    // the inventory of the entity.
+    InventoryUnsubscribed(player IPlayerClient)
+}
+
+// IBreedable is implemented by animals that players can breed by feeding
+// them.
+type IBreedable interface {
+    INonPlayerEntity
+
+    // IsInLove returns true if the animal has been fed, and is looking for a
+    // mate.
+    IsInLove() bool
+
+    // Feed gives the food to the animal, which a player has already used up.
+    // It returns false if the animal does not eat the food.
+    Feed(food *Slot) bool
+
+    // Mate breeds the animal with another that is in love, returning their
+    // baby. ok=false if the two cannot mate, e.g they are different kinds of
+    // animal.
+    Mate(chunk IChunkBlock, other IBreedable) (baby INonPlayerEntity, ok bool)
+
+    animal() *Animal
+}
+
+// IEffectEntity is the interface for entities that status effects (e.g from
+// splash potions) can be applied to.
+type IEffectEntity interface {
+    INonPlayerEntity
+
+    // AddEffect applies a status effect to the entity. killed=true if it
+    // killed the entity, which should then be removed.
+    AddEffect(chunk IChunkBlock, effect StatusEffect) (killed bool)
 }
 
 // ITileEntity is the interface common to entities that are tile-based.
@@ -64,3 +135,11 @@ type ITileEntity interface {
     // Block returns the position of the tile entity.
     Block() BlockXyz
 }
+
+// ITileEntitySpawner is optionally implemented by tile entities that clients
+// need to be told about when loading the chunk containing them, e.g signs.
+type ITileEntitySpawner interface {
+    // SpawnPackets appends and returns the packets required to tell a client
+    // about the tile entity.
+    SpawnPackets([]proto.IPacket) []proto.IPacket
+}
@@ -6,6 +6,9 @@ var EntityCreateByName = map[string]func() INonPlayerEntity{
     // Pick-up items.
     "Item": NewBlankItem,
 
+    // Experience orbs.
+    "XPOrb": NewBlankExperienceOrb,
+
     // Mobs.
     "Hen":      NewHen,
     "Chicken":  NewHen,
@@ -16,6 +19,7 @@ var EntityCreateByName = map[string]func() INonPlayerEntity{
     "Skeleton": NewSkeleton,
     "Squid":    NewSquid,
     "Spider":   NewSpider,
+    "Villager": NewVillager,
     "Wolf":     NewWolf,
     "Zombie":   NewZombie,
 
@@ -51,6 +55,10 @@ var TileEntityCreateByName = map[string]func() ITileEntity{
     "Music":        NewMusicTileEntity,
     "RecordPlayer": NewRecordPlayerTileEntity,
     "Workbench":    NewWorkbenchTileEntity,
+    "Hopper":       NewHopperTileEntity,
+    "Dropper":      NewDropperTileEntity,
+    "Comparator":   NewComparatorTileEntity,
+    "Cauldron":     NewBrewingStandTileEntity,
 }
 
 func NewTileEntityByTypeName(typeName string) ITileEntity {
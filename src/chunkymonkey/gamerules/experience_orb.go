@@ -0,0 +1,150 @@
+package gamerules
+
+import (
+    "chunkymonkey/nbtutil"
+    "chunkymonkey/physics"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    // Experience orbs disappear after five minutes.
+    ExperienceOrbLifetime = Ticks(5 * 60 * TicksPerSecond)
+
+    // Orbs within this distance of each other merge together.
+    ExperienceOrbMergeDistance = AbsCoord(0.5)
+)
+
+// Sizes of experience orbs, largest first. Experience is split into orbs of
+// these sizes so that the client shows them with the right size.
+var experienceOrbSizes = []Experience{2477, 1237, 617, 307, 149, 73, 37, 17, 7, 3, 1}
+
+// ExperienceOrb is an orb that gives players experience when they pick it up.
+type ExperienceOrb struct {
+    EntityId
+    physics.PointObject
+    Value Experience
+    Age   Ticks
+}
+
+func NewBlankExperienceOrb() INonPlayerEntity {
+    return new(ExperienceOrb)
+}
+
+func NewExperienceOrb(value Experience, position AbsXyz, velocity AbsVelocity) (orb *ExperienceOrb) {
+    orb = &ExperienceOrb{
+        Value: value,
+    }
+    orb.PointObject.Init(position, velocity)
+    return
+}
+
+func (orb *ExperienceOrb) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = orb.PointObject.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    value, err := nbtutil.ReadShort(tag, "Value")
+    if err != nil {
+        return
+    }
+    orb.Value = Experience(value)
+
+    age, err := nbtutil.ReadShort(tag, "Age")
+    if err != nil {
+        return
+    }
+    orb.Age = Ticks(age)
+
+    return nil
+}
+
+func (orb *ExperienceOrb) MarshalNbt(tag nbt.Compound) (err error) {
+    if err = orb.PointObject.MarshalNbt(tag); err != nil {
+        return
+    }
+    tag.Set("id", &nbt.String{"XPOrb"})
+    tag.Set("Value", &nbt.Short{int16(orb.Value)})
+    tag.Set("Age", &nbt.Short{int16(orb.Age)})
+    return nil
+}
+
+func (orb *ExperienceOrb) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
+    return append(pkts,
+        &proto.PacketExperienceOrb{
+            EntityId: orb.EntityId,
+            Position: orb.PointObject.LastSentPosition,
+            Count:    int16(orb.Value),
+        },
+        &proto.PacketEntityVelocity{
+            EntityId: orb.EntityId,
+            Velocity: orb.PointObject.LastSentVelocity,
+        },
+    )
+}
+
+func (orb *ExperienceOrb) Tick(chunk IChunkBlock) (leftBlock bool) {
+    orb.Age++
+    return orb.PointObject.Tick(chunk)
+}
+
+// Expired returns true if the orb has been around long enough to disappear.
+func (orb *ExperienceOrb) Expired() bool {
+    return orb.Age >= ExperienceOrbLifetime
+}
+
+// Merge adds the experience of the other orb into this one.
+func (orb *ExperienceOrb) Merge(other *ExperienceOrb) {
+    orb.Value += other.Value
+    if other.Age < orb.Age {
+        orb.Age = other.Age
+    }
+}
+
+func (orb *ExperienceOrb) AwarenessRadius() AbsCoord {
+    return ItemAwarenessRadius
+}
+
+func (orb *ExperienceOrb) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
+    pkts = append(pkts, &proto.PacketEntity{
+        EntityId: orb.EntityId,
+    })
+
+    pkts = orb.PointObject.UpdatePackets(pkts, orb.EntityId, LookBytes{})
+
+    return pkts
+}
+
+// SplitExperience splits an amount of experience into the orb sizes that it
+// should be dropped as.
+func SplitExperience(amount Experience) (values []Experience) {
+    for amount > 0 {
+        for _, size := range experienceOrbSizes {
+            if size <= amount {
+                values = append(values, size)
+                amount -= size
+                break
+            }
+        }
+    }
+    return
+}
+
+// SpawnExperience drops experience orbs at the given position.
+func SpawnExperience(chunk IChunkBlock, amount Experience, position AbsXyz) {
+    rand := chunk.Rand()
+    for _, value := range SplitExperience(amount) {
+        velocity := AbsVelocity{
+            X: AbsVelocityCoord((rand.Float64() - 0.5) * 0.2),
+            Y: AbsVelocityCoord(rand.Float64() * 0.2),
+            Z: AbsVelocityCoord((rand.Float64() - 0.5) * 0.2),
+        }
+        chunk.AddEntity(NewExperienceOrb(value, position, velocity))
+    }
+}
+
+// spawnExperienceInBlock drops experience orbs from the middle of a block.
+func spawnExperienceInBlock(chunk IChunkBlock, blockLoc BlockXyz, amount Experience) {
+    SpawnExperience(chunk, amount, blockLoc.MidPointToAbsXyz())
+}
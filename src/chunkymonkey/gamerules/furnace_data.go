@@ -23,6 +23,8 @@ type FurnaceData struct {
 type Reaction struct {
     Output     ItemTypeId
     OutputData ItemData
+    // Experience given for each item taken from the output.
+    Experience float32
 }
 
 // furnaceDataDef is used in unmarshalling data from the JSON definition of
@@ -37,9 +39,21 @@ type furnaceDataDef struct {
         Input      ItemTypeId
         Output     ItemTypeId
         OutputData ItemData
+        Experience float32
     }
 }
 
+// OutputExperience returns the experience given for each item of the output
+// taken from a furnace.
+func (furnaceData *FurnaceData) OutputExperience(output *Slot) float32 {
+    for _, reaction := range furnaceData.Reactions {
+        if reaction.Output == output.ItemTypeId && reaction.OutputData == output.Data {
+            return reaction.Experience
+        }
+    }
+    return 0
+}
+
 // LoadFurnaceData reads FurnaceData from the reader.
 func LoadFurnaceData(reader io.Reader) (furnaceData FurnaceData, err error) {
     decoder := json.NewDecoder(reader)
@@ -77,6 +91,7 @@ func LoadFurnaceData(reader io.Reader) (furnaceData FurnaceData, err error) {
         furnaceData.Reactions[reactionDef.Input] = Reaction{
             Output:     reactionDef.Output,
             OutputData: reactionDef.OutputData,
+            Experience: reactionDef.Experience,
         }
     }
 
@@ -8,12 +8,13 @@ var (
     Items            ItemTypeMap
     Recipes          *RecipeSet
     FurnaceReactions FurnaceData
+    BrewingRecipes   BrewingData
     // TODO: Commands should maybe be accessible via IGame.
     CommandFramework ICommandFramework
     Permissions      permission.IPermissions
 )
 
-func LoadGameRules(blocksDefFile, itemsDefFile, recipesDefFile, furnaceDefFile, userDefFile, groupDefFile string) (err error) {
+func LoadGameRules(blocksDefFile, itemsDefFile, recipesDefFile, furnaceDefFile, brewingDefFile, userDefFile, groupDefFile string) (err error) {
     Blocks, err = LoadBlocksFromFile(blocksDefFile)
     if err != nil {
         return
@@ -36,6 +37,11 @@ func LoadGameRules(blocksDefFile, itemsDefFile, recipesDefFile, furnaceDefFile,
         return
     }
 
+    BrewingRecipes, err = LoadBrewingDataFromFile(brewingDefFile)
+    if err != nil {
+        return
+    }
+
     Permissions, err = permission.LoadJsonPermissionFromFiles(userDefFile, groupDefFile)
     if err != nil {
         return
@@ -1,7 +1,7 @@
 package gamerules
 
 func init() {
-    if err := LoadGameRules("blocks.json", "items.json", "recipes.json", "furnace.json", "users.json", "groups.json"); err != nil {
+    if err := LoadGameRules("blocks.json", "items.json", "recipes.json", "furnace.json", "brewing.json", "users.json", "groups.json"); err != nil {
         panic(err)
     }
 }
@@ -0,0 +1,303 @@
+package gamerules
+
+import (
+    "strings"
+    "unicode/utf8"
+
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    // The item being repaired or renamed.
+    anvilSlotLeft = SlotId(0)
+    // The material or item that is combined with it.
+    anvilSlotRight  = SlotId(1)
+    anvilSlotOutput = SlotId(2)
+
+    anvilNumSlots = 3
+
+    // Longest name that an item can be given.
+    anvilMaxNameLength = 30
+
+    // Players not in creative mode cannot take an output that costs this many
+    // levels or more.
+    anvilMaxCost = 40
+
+    // Percentage of durability that repairing a tool by combining it with
+    // another gives on top of the two tools' durability.
+    anvilCombineBonus = 12
+)
+
+// AnvilInventory is the inventory of an anvil's window. An item put in the
+// left slot is repaired by material or by another of the same item in the right
+// slot, which also gives it the other item's enchantments. The item may also
+// be renamed. Taking the output costs experience levels. Each player using an
+// anvil has their own inventory.
+type AnvilInventory struct {
+    Inventory
+    // The name to give the item, or empty if it is not being renamed.
+    name string
+    // The levels that the player can spend, and whether they are in creative
+    // mode, where they may take the output regardless of cost.
+    levels   int32
+    creative bool
+
+    cost      int32
+    rightUsed ItemCount
+    lastCost  PrgBarValue
+
+    // Levels owed for outputs taken, not yet paid.
+    owed int32
+    used bool
+}
+
+// NewAnvilInventory creates an inventory for an anvil.
+func NewAnvilInventory() (inv *AnvilInventory) {
+    inv = new(AnvilInventory)
+    inv.Inventory.Init(anvilNumSlots)
+    return inv
+}
+
+// SetLevels sets the levels that the player can spend on taking the output.
+func (inv *AnvilInventory) SetLevels(levels int32, creative bool) {
+    inv.levels = levels
+    inv.creative = creative
+}
+
+// Rename sets the name to give the item in the anvil. ok=false if the name is
+// not allowed.
+func (inv *AnvilInventory) Rename(name string) (ok bool) {
+    if !isValidItemName(name) {
+        return false
+    }
+    inv.name = name
+    inv.update()
+    return true
+}
+
+// TakeCost returns the levels owed for outputs taken from the anvil since it
+// was last called. used=false if no output was taken.
+func (inv *AnvilInventory) TakeCost() (cost int32, used bool) {
+    cost, used = inv.owed, inv.used
+    inv.owed, inv.used = 0, false
+    return
+}
+
+// TakeAllItems empties the anvil, returning the items put into it. The output
+// is not returned, as it has not been made.
+func (inv *AnvilInventory) TakeAllItems() (items []Slot) {
+    inv.slots[anvilSlotOutput].Clear()
+    return inv.Inventory.TakeAllItems()
+}
+
+func (inv *AnvilInventory) Click(click *Click) (txState TxState) {
+    switch {
+    case click.isPut():
+        inv.PutItemInSlot(anvilSlotLeft, &click.Cursor)
+        inv.PutItemInSlot(anvilSlotRight, &click.Cursor)
+        txState = TxStateAccepted
+    case click.Mode == ClickModeDoubleClick:
+        // Items are not collected from the output slot.
+        inv.collect(&click.Cursor, 0, int(anvilSlotOutput))
+        txState = TxStateAccepted
+    case click.SlotId == anvilSlotOutput:
+        txState = inv.takeOutput(click)
+    default:
+        txState = inv.Inventory.Click(click)
+    }
+
+    inv.update()
+
+    return
+}
+
+// takeOutput handles clicks on the output slot. Taking the output uses up the
+// items that it was made from.
+func (inv *AnvilInventory) takeOutput(click *Click) (txState TxState) {
+    output := &inv.slots[anvilSlotOutput]
+    if output.IsEmpty() || !inv.canAfford() {
+        return TxStateRejected
+    }
+
+    txState = inv.Inventory.TakeOnlyClick(click)
+    if !output.IsEmpty() {
+        // The output was not taken.
+        return
+    }
+
+    left := &inv.slots[anvilSlotLeft]
+    left.Clear()
+    inv.slotUpdate(left, anvilSlotLeft)
+
+    right := &inv.slots[anvilSlotRight]
+    if inv.rightUsed > 0 {
+        right.setCount(right.Count - inv.rightUsed)
+        inv.slotUpdate(right, anvilSlotRight)
+    }
+
+    inv.owed += inv.cost
+    inv.used = true
+    inv.name = ""
+
+    return
+}
+
+func (inv *AnvilInventory) canAfford() bool {
+    return inv.creative || (inv.cost < anvilMaxCost && inv.cost <= inv.levels)
+}
+
+// update works out the output of the anvil and its cost, and shows them to
+// the player.
+func (inv *AnvilInventory) update() {
+    var output Slot
+    output, inv.cost, inv.rightUsed = AnvilResult(&inv.slots[anvilSlotLeft], &inv.slots[anvilSlotRight], inv.name)
+
+    if !output.Equals(&inv.slots[anvilSlotOutput]) {
+        inv.SetSlot(anvilSlotOutput, output)
+    }
+
+    if inv.subscriber != nil && PrgBarValue(inv.cost) != inv.lastCost {
+        inv.lastCost = PrgBarValue(inv.cost)
+        inv.subscriber.ProgressUpdate(PrgBarIdAnvilCost, inv.lastCost)
+    }
+}
+
+// AnvilResult returns the item made by an anvil from the left and right items,
+// and given the name if it is not empty. cost is the levels that it costs, and
+// rightUsed is the number of right items used up. The output is empty if the
+// items cannot be combined, or nothing would change.
+func AnvilResult(left, right *Slot, name string) (output Slot, cost int32, rightUsed ItemCount) {
+    itemType := left.ItemType()
+    if left.IsEmpty() || itemType == nil {
+        return
+    }
+
+    output = *left
+
+    // Items cost more each time that they are worked on.
+    priorCost := left.RepairCost()
+
+    // Levels charged for the work done now.
+    var work int32
+
+    if !right.IsEmpty() {
+        if right.RepairCost() > priorCost {
+            priorCost = right.RepairCost()
+        }
+
+        switch {
+        case itemType.ToolUses > 0 && right.ItemTypeId == itemType.RepairMaterial:
+            if left.Data == 0 {
+                // Nothing to repair.
+                return Slot{}, 0, 0
+            }
+
+            // Each unit of material repairs a quarter of the item's
+            // durability.
+            unit := itemType.ToolUses / 4
+            numEnchantments := int32(len(left.Enchantments()))
+            for output.Data > 0 && rightUsed < right.Count {
+                output.Data -= unit
+                if output.Data < 0 {
+                    output.Data = 0
+                }
+                rightUsed++
+                work += 1 + numEnchantments
+            }
+        case itemType.ToolUses > 0 && right.ItemTypeId == left.ItemTypeId:
+            if left.Data > 0 {
+                durability := 2*itemType.ToolUses - left.Data - right.Data + itemType.ToolUses*anvilCombineBonus/100
+                output.Data = itemType.ToolUses - durability
+                if output.Data < 0 {
+                    output.Data = 0
+                }
+                work += 2
+            }
+
+            enchantments, enchantCost := mergeEnchantments(left.Enchantments(), right.Enchantments(), itemType.ToolType)
+            if enchantCost > 0 {
+                output.setEnchantments(enchantments)
+                work += enchantCost
+            }
+
+            if work == 0 {
+                // Neither repaired nor given more enchantments.
+                return Slot{}, 0, 0
+            }
+            rightUsed = 1
+        default:
+            return Slot{}, 0, 0
+        }
+    }
+
+    if name != "" && !left.hasName(name) {
+        output.setDisplayName(name)
+        work++
+    }
+
+    if work == 0 {
+        return Slot{}, 0, 0
+    }
+
+    output.setRepairCost(priorCost + 2)
+
+    return output, left.RepairCost() + right.RepairCost() + work, rightUsed
+}
+
+// DisplayName returns the name that the item in the slot has been given, or
+// an empty string if it has not been named.
+func (s *Slot) DisplayName() string {
+    if nameTag, ok := s.Nbt.Lookup("display/Name").(*nbt.String); ok {
+        return nameTag.Value
+    }
+    return ""
+}
+
+// setDisplayName gives the item in the slot a name.
+func (s *Slot) setDisplayName(name string) {
+    display := nbt.NewCompound()
+    if oldDisplay, ok := s.Nbt.Lookup("display").(nbt.Compound); ok {
+        for n, v := range oldDisplay {
+            display.Set(n, v)
+        }
+    }
+    display.Set("Name", &nbt.String{name})
+    s.setNbtTag("display", display)
+}
+
+// hasName returns true if the item in the slot is already called name, either
+// by being given that name or by the name of its type.
+func (s *Slot) hasName(name string) bool {
+    if displayName := s.DisplayName(); displayName != "" {
+        return name == displayName
+    }
+    itemType := s.ItemType()
+    return itemType != nil && strings.EqualFold(name, itemType.Name)
+}
+
+// RepairCost returns the extra levels that working on the item in the slot at
+// an anvil costs, having been worked on before.
+func (s *Slot) RepairCost() int32 {
+    if costTag, ok := s.Nbt.Lookup("RepairCost").(*nbt.Int); ok {
+        return costTag.Value
+    }
+    return 0
+}
+
+func (s *Slot) setRepairCost(cost int32) {
+    s.setNbtTag("RepairCost", &nbt.Int{cost})
+}
+
+// isValidItemName returns true if an item may be given the name at an anvil.
+func isValidItemName(name string) bool {
+    if utf8.RuneCountInString(name) > anvilMaxNameLength {
+        return false
+    }
+    for _, r := range name {
+        if r < 0x20 || r == 0x7f || r == '\u00a7' {
+            return false
+        }
+    }
+    return true
+}
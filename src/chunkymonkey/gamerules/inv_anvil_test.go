@@ -0,0 +1,129 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func makeRepairableItemTypes() (sword, ironIngot ItemTypeId) {
+    sword, ironIngot = 267, 265
+    Items = make(ItemTypeMap)
+    Items[sword] = &ItemType{Id: sword, Name: "iron sword", MaxStack: 1, ToolType: ToolTypeSword, ToolUses: 200, RepairMaterial: ironIngot}
+    Items[ironIngot] = &ItemType{Id: ironIngot, Name: "iron ingot", MaxStack: 64}
+    return
+}
+
+func TestAnvilResult(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    sword, ironIngot := makeRepairableItemTypes()
+
+    // Each ingot repairs a quarter of the sword's durability.
+    output, cost, rightUsed := AnvilResult(&Slot{sword, 1, 120, nil}, &Slot{ironIngot, 5, 0, nil}, "")
+    if output.Data != 0 || rightUsed != 3 || cost != 3 {
+        t.Errorf("material repair: expected damage 0 using 3 for 3 levels, got damage %d using %d for %d levels", output.Data, rightUsed, cost)
+    }
+    if output.RepairCost() != 2 {
+        t.Errorf("expected the output to cost more to work on again, got repair cost %d", output.RepairCost())
+    }
+
+    // Combining two swords adds their durability, with a bonus.
+    output, cost, rightUsed = AnvilResult(&Slot{sword, 1, 150, nil}, &Slot{sword, 1, 100, nil}, "")
+    if output.Data != 26 || rightUsed != 1 || cost != 2 {
+        t.Errorf("combining: expected damage 26 using 1 for 2 levels, got damage %d using %d for %d levels", output.Data, rightUsed, cost)
+    }
+
+    // Enchantments at the same level go up a level.
+    left := enchanted(sword, EnchantmentSharpness, 2)
+    right := enchanted(sword, EnchantmentSharpness, 2)
+    output, cost, _ = AnvilResult(&left, &right, "")
+    if level := output.EnchantmentLevel(EnchantmentSharpness); level != 3 || cost != 3 {
+        t.Errorf("expected sharpness 3 for 3 levels, got sharpness %d for %d levels", level, cost)
+    }
+    if level := left.EnchantmentLevel(EnchantmentSharpness); level != 2 {
+        t.Errorf("expected the left item to be unchanged, got sharpness %d", level)
+    }
+
+    // Renaming.
+    output, cost, rightUsed = AnvilResult(&Slot{sword, 1, 0, nil}, &Slot{}, "Stabby")
+    if output.DisplayName() != "Stabby" || cost != 1 || rightUsed != 0 {
+        t.Errorf("renaming: expected name Stabby for 1 level, got name %q using %d for %d levels", output.DisplayName(), rightUsed, cost)
+    }
+    output, _, _ = AnvilResult(&output, &Slot{}, "Stabby")
+    if !output.IsEmpty() {
+        t.Errorf("expected no output renaming to the same name, got %v", output)
+    }
+    output, _, _ = AnvilResult(&Slot{sword, 1, 0, nil}, &Slot{}, "Iron Sword")
+    if !output.IsEmpty() {
+        t.Errorf("expected no output renaming to the item's type name, got %v", output)
+    }
+
+    // Nothing to do.
+    tests := []struct {
+        desc        string
+        left, right Slot
+    }{
+        {"empty", Slot{}, Slot{ironIngot, 1, 0, nil}},
+        {"undamaged with material", Slot{sword, 1, 0, nil}, Slot{ironIngot, 1, 0, nil}},
+        {"undamaged and unenchanted", Slot{sword, 1, 0, nil}, Slot{sword, 1, 0, nil}},
+        {"wrong material", Slot{sword, 1, 10, nil}, Slot{ironIngot + 1, 1, 0, nil}},
+    }
+    for _, test := range tests {
+        if output, _, _ := AnvilResult(&test.left, &test.right, ""); !output.IsEmpty() {
+            t.Errorf("%s: expected no output, got %v", test.desc, output)
+        }
+    }
+}
+
+func TestAnvilInventory(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    sword, ironIngot := makeRepairableItemTypes()
+    makeItemType(ironIngot + 1)
+
+    inv := NewAnvilInventory()
+    inv.SetLevels(1, false)
+    inv.slots[anvilSlotLeft] = Slot{sword, 1, 100, nil}
+    click := Click{SlotId: anvilSlotRight, Cursor: Slot{ironIngot, 3, 0, nil}}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+
+    repaired := inv.slots[anvilSlotOutput]
+    if repaired.ItemTypeId != sword || repaired.Data != 0 {
+        t.Fatalf("expected a repaired sword, got %v", repaired)
+    }
+
+    // Two ingots cost two levels.
+    click = Click{SlotId: anvilSlotOutput, ExpectedSlot: repaired}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    checkSlot(t, emptySlot, click.Cursor)
+    if _, used := inv.TakeCost(); used {
+        t.Errorf("expected the anvil not to be used")
+    }
+
+    inv.SetLevels(2, false)
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, repaired, click.Cursor)
+    checkSlot(t, emptySlot, inv.slots[anvilSlotLeft])
+    checkSlot(t, Slot{ironIngot, 1, 0, nil}, inv.slots[anvilSlotRight])
+    checkSlot(t, emptySlot, inv.slots[anvilSlotOutput])
+    if cost, used := inv.TakeCost(); cost != 2 || !used {
+        t.Errorf("expected to owe 2 levels, got %d (used=%t)", cost, used)
+    }
+
+    // The output is not given back when the window closes.
+    inv.slots[anvilSlotLeft] = Slot{sword, 1, 0, nil}
+    inv.slots[anvilSlotRight] = Slot{}
+    if !inv.Rename("Stabby") {
+        t.Fatalf("expected rename to be accepted")
+    }
+    if inv.slots[anvilSlotOutput].DisplayName() != "Stabby" {
+        t.Errorf("expected renamed output, got %v", inv.slots[anvilSlotOutput])
+    }
+    items := inv.TakeAllItems()
+    if len(items) != 1 || items[0].ItemTypeId != sword || items[0].DisplayName() != "" {
+        t.Errorf("expected the unnamed sword put into the anvil, got %v", items)
+    }
+
+    if inv.Rename("§cRed") {
+        t.Errorf("expected name with formatting code to be rejected")
+    }
+}
@@ -0,0 +1,290 @@
+package gamerules
+
+import (
+    "errors"
+
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    // Slots 0 to 2 hold the potions being brewed.
+    brewingNumBottles     = 3
+    brewingSlotIngredient = SlotId(3)
+    brewingNumSlots       = 4
+
+    brewingDuration = Ticks(400)
+)
+
+type BrewingInventory struct {
+    Inventory
+    // Ticks remaining until the potions are brewed, or zero if not brewing.
+    brewTime Ticks
+    // The type of ingredient that is being brewed.
+    brewing ItemTypeId
+
+    lastBrewTime     PrgBarValue
+    ticksSinceUpdate int
+}
+
+// NewBrewingInventory creates a brewing stand inventory.
+func NewBrewingInventory() (inv *BrewingInventory) {
+    inv = new(BrewingInventory)
+    inv.Inventory.Init(brewingNumSlots)
+    return
+}
+
+func (inv *BrewingInventory) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = inv.Inventory.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    if brewTimeTag, ok := tag.Lookup("BrewTime").(*nbt.Short); !ok {
+        return errors.New("Bad or missing BrewTime tag in Cauldron NBT")
+    } else {
+        inv.brewTime = Ticks(brewTimeTag.Value)
+    }
+
+    inv.brewing = inv.slots[brewingSlotIngredient].ItemTypeId
+
+    return nil
+}
+
+func (inv *BrewingInventory) MarshalNbt(tag nbt.Compound) (err error) {
+    tag.Set("id", &nbt.String{"Cauldron"})
+    tag.Set("BrewTime", &nbt.Short{int16(inv.brewTime)})
+    return inv.Inventory.MarshalNbt(tag)
+}
+
+func (inv *BrewingInventory) Click(click *Click) (txState TxState) {
+    switch {
+    case click.isPut():
+        inv.putItem(&click.Cursor)
+        txState = TxStateAccepted
+    case click.Mode == ClickModeDoubleClick:
+        txState = inv.Inventory.Click(click)
+    default:
+        txState = inv.slotClick(click)
+    }
+
+    inv.stateCheck()
+
+    inv.sendProgressUpdates()
+
+    return
+}
+
+// putItem puts items that are shift-clicked into the brewing stand into the
+// ingredient slot if they are an ingredient, or otherwise into the empty
+// bottle slots if they are potions.
+func (inv *BrewingInventory) putItem(item *Slot) {
+    if _, ok := BrewingRecipes.Ingredients[item.ItemTypeId]; ok {
+        inv.PutItemInSlot(brewingSlotIngredient, item)
+    } else if item.ItemTypeId == ItemTypeIdPotion {
+        for slotId := SlotId(0); slotId < brewingNumBottles && !item.IsEmpty(); slotId++ {
+            inv.PutItemInSlot(slotId, item)
+        }
+    }
+}
+
+// slotClick handles clicks on a particular slot of the brewing stand. Only
+// potions may be put into the bottle slots, and only ingredients into the
+// ingredient slot.
+func (inv *BrewingInventory) slotClick(click *Click) TxState {
+    if click.SlotId < 0 || click.SlotId >= brewingNumSlots {
+        return TxStateRejected
+    }
+
+    if click.Mode != ClickModeDrop && !click.Cursor.IsEmpty() && !inv.accepts(click.SlotId, &click.Cursor) {
+        return TxStateRejected
+    }
+
+    return inv.Inventory.Click(click)
+}
+
+// accepts returns true if the item may be put into the given slot.
+func (inv *BrewingInventory) accepts(slotId SlotId, item *Slot) bool {
+    if slotId == brewingSlotIngredient {
+        _, ok := BrewingRecipes.Ingredients[item.ItemTypeId]
+        return ok
+    }
+    return item.ItemTypeId == ItemTypeIdPotion
+}
+
+// InsertItem puts ingredients from above into the ingredient slot, and potions
+// from the sides into an empty bottle slot.
+func (inv *BrewingInventory) InsertItem(item *Slot, face Face) bool {
+    var slotId SlotId
+    switch face {
+    case FaceTop:
+        slotId = brewingSlotIngredient
+    case FaceBottom:
+        return false
+    default:
+        slotId = -1
+        for i := SlotId(0); i < brewingNumBottles; i++ {
+            if inv.slots[i].IsEmpty() {
+                slotId = i
+                break
+            }
+        }
+        if slotId < 0 {
+            return false
+        }
+    }
+
+    if !inv.accepts(slotId, item) {
+        return false
+    }
+
+    var one Slot
+    if !one.AddOne(item) {
+        return false
+    }
+
+    inv.PutItemInSlot(slotId, &one)
+
+    if !one.IsEmpty() {
+        item.Add(&one)
+        return false
+    }
+
+    inv.stateCheck()
+    inv.sendProgressUpdates()
+
+    return true
+}
+
+// ExtractItem takes potions from the bottle slots, out of the bottom of the
+// brewing stand.
+func (inv *BrewingInventory) ExtractItem(into *Slot, face Face) bool {
+    if face != FaceBottom {
+        return false
+    }
+
+    for slotId := SlotId(0); slotId < brewingNumBottles; slotId++ {
+        slot := &inv.slots[slotId]
+        if !slot.IsEmpty() && into.AddOne(slot) {
+            inv.slotUpdate(slot, slotId)
+
+            inv.stateCheck()
+            inv.sendProgressUpdates()
+
+            return true
+        }
+    }
+
+    return false
+}
+
+// canBrew returns true if the ingredient would change at least one of the
+// potions.
+func (inv *BrewingInventory) canBrew() bool {
+    ingredient, ok := BrewingRecipes.Ingredients[inv.slots[brewingSlotIngredient].ItemTypeId]
+    if !ok {
+        return false
+    }
+
+    for slotId := SlotId(0); slotId < brewingNumBottles; slotId++ {
+        bottle := &inv.slots[slotId]
+        if bottle.ItemTypeId != ItemTypeIdPotion {
+            continue
+        }
+        if _, ok := ingredient.Brew(bottle.Data); ok {
+            return true
+        }
+    }
+
+    return false
+}
+
+func (inv *BrewingInventory) stateCheck() {
+    if !inv.canBrew() {
+        inv.brewTime = 0
+        return
+    }
+
+    // Brewing restarts if the ingredient changes.
+    ingredientId := inv.slots[brewingSlotIngredient].ItemTypeId
+    if inv.brewTime == 0 || inv.brewing != ingredientId {
+        inv.brewing = ingredientId
+        inv.brewTime = brewingDuration
+    }
+}
+
+// brew brews one of the ingredient into the potions. The ingredient is only
+// used up if it changes at least one of them.
+func (inv *BrewingInventory) brew() {
+    ingredientSlot := &inv.slots[brewingSlotIngredient]
+    ingredient, ok := BrewingRecipes.Ingredients[ingredientSlot.ItemTypeId]
+    if !ok {
+        return
+    }
+
+    brewed := false
+    for slotId := SlotId(0); slotId < brewingNumBottles; slotId++ {
+        bottle := &inv.slots[slotId]
+        if bottle.ItemTypeId != ItemTypeIdPotion {
+            continue
+        }
+        if data, ok := ingredient.Brew(bottle.Data); ok {
+            bottle.Data = data
+            inv.slotUpdate(bottle, slotId)
+            brewed = true
+        }
+    }
+
+    if brewed {
+        ingredientSlot.Decrement()
+        inv.slotUpdate(ingredientSlot, brewingSlotIngredient)
+    }
+}
+
+// sendProgressUpdates sends an update to the subscriber. Not every time,
+// however - to cut down on unnecessary communication.
+func (inv *BrewingInventory) sendProgressUpdates() {
+    if inv.subscriber == nil {
+        return
+    }
+
+    inv.ticksSinceUpdate++
+    if inv.ticksSinceUpdate > 5 || !inv.IsBrewing() {
+        inv.ticksSinceUpdate = 0
+
+        curBrewTime := PrgBarValue(inv.brewTime)
+        if inv.lastBrewTime != curBrewTime {
+            inv.lastBrewTime = curBrewTime
+            inv.subscriber.ProgressUpdate(PrgBarIdBrewingTime, curBrewTime)
+        }
+    }
+}
+
+func (inv *BrewingInventory) IsBrewing() bool {
+    return inv.brewTime > 0
+}
+
+// BottleBits returns the block data that shows which of the bottle slots hold
+// potions.
+func (inv *BrewingInventory) BottleBits() (data byte) {
+    for slotId := SlotId(0); slotId < brewingNumBottles; slotId++ {
+        if !inv.slots[slotId].IsEmpty() {
+            data |= 1 << uint(slotId)
+        }
+    }
+    return
+}
+
+// Tick runs the brewing stand for a single tick.
+func (inv *BrewingInventory) Tick() {
+    if inv.brewTime > 0 {
+        inv.brewTime--
+
+        if inv.brewTime == 0 {
+            inv.brew()
+        }
+
+        inv.stateCheck()
+
+        inv.sendProgressUpdates()
+    }
+}
@@ -0,0 +1,107 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+const (
+    netherWartId   = ItemTypeId(372)
+    sugarId        = ItemTypeId(353)
+    redstoneId     = ItemTypeId(331)
+    gunpowderId    = ItemTypeId(289)
+    fermentedEyeId = ItemTypeId(376)
+)
+
+func TestBrewingIngredient_Brew(t *testing.T) {
+    type Test struct {
+        desc       string
+        ingredient ItemTypeId
+        input      ItemData
+        expectOk   bool
+        expect     ItemData
+    }
+
+    tests := []Test{
+        {"nether wart into water", netherWartId, 0, true, 16},
+        {"nether wart into awkward potion", netherWartId, 16, false, 16},
+        {"sugar into awkward potion", sugarId, 16, true, 8194},
+        {"sugar into water", sugarId, 0, true, 8192},
+        {"sugar into swiftness", sugarId, 8194, false, 8194},
+        {"redstone into swiftness", redstoneId, 8194, true, 8194 | potionExtendedFlag},
+        {"redstone into strong swiftness", redstoneId, 8194 | potionStrongFlag, true, 8194 | potionExtendedFlag},
+        {"redstone into extended swiftness", redstoneId, 8194 | potionExtendedFlag, false, 8194 | potionExtendedFlag},
+        {"gunpowder into swiftness", gunpowderId, 8194, true, 16386},
+        {"gunpowder into awkward potion", gunpowderId, 16, false, 16},
+        {"fermented spider eye into extended swiftness", fermentedEyeId, 8194 | potionExtendedFlag, true, 8202 | potionExtendedFlag},
+        {"fermented spider eye into water", fermentedEyeId, 0, true, 8200},
+    }
+
+    for _, test := range tests {
+        ingredient, ok := BrewingRecipes.Ingredients[test.ingredient]
+        if !ok {
+            t.Errorf("%s: ingredient %d not loaded", test.desc, test.ingredient)
+            continue
+        }
+        result, ok := ingredient.Brew(test.input)
+        if ok != test.expectOk || result != test.expect {
+            t.Errorf("%s: expected (%d, %t), got (%d, %t)", test.desc, test.expect, test.expectOk, result, ok)
+        }
+    }
+}
+
+func TestBrewingInventory(t *testing.T) {
+    water := Slot{ItemTypeIdPotion, 1, 0, nil}
+    inv := NewBrewingInventory()
+
+    // Potions are shift-clicked into the empty bottle slots.
+    click := Click{Cursor: water, Mode: ClickModeShift}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, water, inv.slots[0])
+    click = Click{Cursor: water, Mode: ClickModeShift}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, water, inv.slots[1])
+    if inv.IsBrewing() {
+        t.Errorf("expected not to brew without an ingredient")
+    }
+    if data := inv.BottleBits(); data != 3 {
+        t.Errorf("expected bottle bits 3, got %d", data)
+    }
+
+    // Only ingredients go in the ingredient slot.
+    click = Click{SlotId: brewingSlotIngredient, Cursor: water}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    click = Click{SlotId: brewingSlotIngredient, Cursor: Slot{netherWartId, 2, 0, nil}}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    if !inv.IsBrewing() {
+        t.Fatalf("expected to brew")
+    }
+
+    for i := Ticks(1); i < brewingDuration; i++ {
+        inv.Tick()
+    }
+    checkSlot(t, water, inv.slots[0])
+    inv.Tick()
+
+    // Both potions are brewed from one nether wart, which is then of no
+    // further use.
+    awkward := Slot{ItemTypeIdPotion, 1, 16, nil}
+    checkSlot(t, awkward, inv.slots[0])
+    checkSlot(t, awkward, inv.slots[1])
+    checkSlot(t, Slot{netherWartId, 1, 0, nil}, inv.slots[brewingSlotIngredient])
+    if inv.IsBrewing() {
+        t.Errorf("expected brewing to stop")
+    }
+
+    // Hoppers take the potions from below.
+    var into Slot
+    if inv.ExtractItem(&into, FaceTop) {
+        t.Errorf("expected not to extract from the top")
+    }
+    if !inv.ExtractItem(&into, FaceBottom) {
+        t.Fatalf("expected to extract from the bottom")
+    }
+    checkSlot(t, awkward, into)
+    checkSlot(t, emptySlot, inv.slots[0])
+}
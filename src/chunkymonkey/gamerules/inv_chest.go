@@ -5,6 +5,10 @@ import "nbt"
 const (
     chestInvWidth  = 9
     chestInvHeight = 3
+
+    // ChestNumSlots is the number of slots in a single chest. A large chest
+    // has twice as many.
+    ChestNumSlots = chestInvWidth * chestInvHeight
 )
 
 type ChestInventory struct {
@@ -14,7 +18,7 @@ type ChestInventory struct {
 // NewChestInventory creates a 9x3 chest inventory.
 func NewChestInventory() (inv *ChestInventory) {
     inv = new(ChestInventory)
-    inv.Inventory.Init(chestInvWidth * chestInvHeight)
+    inv.Inventory.Init(ChestNumSlots)
     return inv
 }
 
@@ -52,10 +52,22 @@ func (inv *CraftingInventory) MarshalNbt(tag nbt.Compound) (err error) {
 
 // Click handles window clicks from a user with special handling for crafting.
 func (inv *CraftingInventory) Click(click *Click) (txState TxState) {
-    if click.SlotId == 0 {
+    outputTaken := false
+
+    switch {
+    case click.isPut():
+        // Items are not shifted into the crafting grid.
+        return TxStateRejected
+    case click.Mode == ClickModeDoubleClick:
+        // Items are not collected from the output slot.
+        inv.collect(&click.Cursor, 1, len(inv.slots))
+        txState = TxStateAccepted
+    case click.SlotId == 0:
         // Player may only *take* the *whole* stack from the output slot.
+        hadOutput := !inv.slots[0].IsEmpty()
         txState = inv.Inventory.TakeOnlyClick(click)
-    } else {
+        outputTaken = hadOutput && inv.slots[0].IsEmpty()
+    default:
         // Player may interact with the input slots like any other slot.
         txState = inv.Inventory.Click(click)
     }
@@ -64,7 +76,7 @@ func (inv *CraftingInventory) Click(click *Click) (txState TxState) {
         return
     }
 
-    if click.SlotId == 0 {
+    if outputTaken {
         // Player took items from the output slot. Subtract 1 count from each
         // non-empty input slot.
         for i := 1; i < len(inv.slots); i++ {
@@ -0,0 +1,60 @@
+package gamerules
+
+import (
+    "math/rand"
+
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    dropperInvWidth  = 3
+    dropperInvHeight = 3
+)
+
+type DropperInventory struct {
+    Inventory
+}
+
+// NewDropperInventory creates a 3x3 dropper inventory.
+func NewDropperInventory() (inv *DropperInventory) {
+    inv = new(DropperInventory)
+    inv.Inventory.Init(dropperInvWidth * dropperInvHeight)
+    return inv
+}
+
+func (inv *DropperInventory) MarshalNbt(tag nbt.Compound) (err error) {
+    tag.Set("id", &nbt.String{"Dropper"})
+    return inv.Inventory.MarshalNbt(tag)
+}
+
+// ChooseSlot picks one of the non-empty slots at random for the dropper to
+// eject an item from. ok=false if the dropper is empty.
+func (inv *DropperInventory) ChooseSlot(rand *rand.Rand) (slotId SlotId, ok bool) {
+    var choices []SlotId
+    for slotIndex := range inv.slots {
+        if !inv.slots[slotIndex].IsEmpty() {
+            choices = append(choices, SlotId(slotIndex))
+        }
+    }
+
+    if len(choices) == 0 {
+        return 0, false
+    }
+
+    return choices[rand.Intn(len(choices))], true
+}
+
+// InsertFrom moves one item from the given slot into the first of the given
+// inventories that will take it, through their given face. Returns true if an
+// item was moved.
+func (inv *DropperInventory) InsertFrom(slotId SlotId, targets []ITransferInventory, face Face) bool {
+    slot := &inv.slots[slotId]
+    for _, target := range targets {
+        if target.InsertItem(slot, face) {
+            inv.slotUpdate(slot, slotId)
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,122 @@
+package gamerules
+
+import (
+    "math/rand"
+
+    . "chunkymonkey/types"
+)
+
+const (
+    // The slot holding the item to enchant.
+    enchantSlotItem = SlotId(0)
+
+    enchantNumSlots = 1
+)
+
+// EnchantInventory is the inventory of an enchanting table's window. It holds
+// one item, which is offered enchantments at levels that depend on the number
+// of bookshelves around the table. Each player using a table has their own
+// inventory.
+type EnchantInventory struct {
+    Inventory
+    bookshelves int
+    rand        *rand.Rand
+    offers      [EnchantNumOffers]int32
+}
+
+// NewEnchantInventory creates an inventory for an enchanting table with the
+// given number of bookshelves around it.
+func NewEnchantInventory(bookshelves int) (inv *EnchantInventory) {
+    inv = &EnchantInventory{
+        bookshelves: bookshelves,
+        rand:        rand.New(rand.NewSource(rand.Int63())),
+    }
+    inv.Inventory.Init(enchantNumSlots)
+    return inv
+}
+
+// Click only allows one item at a time into the inventory.
+func (inv *EnchantInventory) Click(click *Click) (txState TxState) {
+    slot := &inv.slots[enchantSlotItem]
+    before := *slot
+
+    switch {
+    case click.isPut():
+        if slot.IsEmpty() && slot.AddOne(&click.Cursor) {
+            inv.slotUpdate(slot, enchantSlotItem)
+        }
+        txState = TxStateAccepted
+    case click.Mode == ClickModeNormal && !click.Cursor.IsEmpty():
+        if click.SlotId != enchantSlotItem || !click.ExpectedSlot.Equals(slot) {
+            return TxStateRejected
+        }
+        if slot.IsEmpty() {
+            slot.AddOne(&click.Cursor)
+        } else if click.Cursor.Count == 1 {
+            slot.Swap(&click.Cursor)
+        }
+        inv.slotUpdate(slot, enchantSlotItem)
+        txState = TxStateAccepted
+    case click.Mode == ClickModeDrag:
+        if slot.IsEmpty() && slot.AddOne(&click.Cursor) {
+            inv.slotUpdate(slot, enchantSlotItem)
+        }
+        txState = TxStateAccepted
+    case click.Mode == ClickModeNumberKey && click.Cursor.Count > 1:
+        txState = TxStateRejected
+    default:
+        txState = inv.Inventory.Click(click)
+    }
+
+    if !before.Equals(slot) {
+        inv.offer()
+    }
+
+    return
+}
+
+// offer works out the enchantment levels offered for the item in the
+// inventory, and shows them to the player.
+func (inv *EnchantInventory) offer() {
+    inv.offers = EnchantmentOffers(inv.rand, &inv.slots[enchantSlotItem], inv.bookshelves)
+    inv.sendOffers()
+}
+
+func (inv *EnchantInventory) sendOffers() {
+    if inv.subscriber == nil {
+        return
+    }
+    for i, level := range inv.offers {
+        // The progress bars of the window hold the offered levels.
+        inv.subscriber.ProgressUpdate(PrgBarId(i), PrgBarValue(level))
+    }
+}
+
+// Offers returns the enchantment levels currently offered.
+func (inv *EnchantInventory) Offers() [EnchantNumOffers]int32 {
+    return inv.offers
+}
+
+// Enchant enchants the item with the offer that the player chose, if the
+// player's experience level is high enough. It returns the number of levels
+// that the enchantment costs. ok=false if the offer could not be taken.
+func (inv *EnchantInventory) Enchant(choice int, playerLevel int32) (cost int32, ok bool) {
+    if choice < 0 || choice >= EnchantNumOffers {
+        return 0, false
+    }
+
+    cost = inv.offers[choice]
+    slot := &inv.slots[enchantSlotItem]
+    if cost <= 0 || cost > playerLevel || !slot.IsEnchantable() {
+        return 0, false
+    }
+
+    slot.Enchant(inv.rand, cost)
+    inv.slotUpdate(slot, enchantSlotItem)
+
+    // The item cannot be enchanted again.
+    inv.offers = [EnchantNumOffers]int32{}
+    inv.sendOffers()
+
+    return cost, true
+}
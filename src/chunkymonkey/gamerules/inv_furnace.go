@@ -23,6 +23,9 @@ type FurnaceInventory struct {
     burnTime    Ticks
     cookTime    Ticks
 
+    // Experience earned from items taken from the output, not yet given out.
+    experience float32
+
     lastCurFuel           PrgBarValue
     lastReactionRemaining PrgBarValue
     ticksSinceUpdate      int
@@ -71,6 +74,94 @@ func (inv *FurnaceInventory) MarshalNbt(tag nbt.Compound) (err error) {
 }
 
 func (inv *FurnaceInventory) Click(click *Click) (txState TxState) {
+    switch {
+    case click.isPut():
+        inv.putItem(&click.Cursor)
+        txState = TxStateAccepted
+    case click.Mode == ClickModeDoubleClick:
+        // Items are not collected from the output slot.
+        inv.collect(&click.Cursor, 0, int(furnaceSlotOutput))
+        txState = TxStateAccepted
+    default:
+        txState = inv.slotClick(click)
+    }
+
+    inv.stateCheck()
+
+    inv.sendProgressUpdates()
+
+    return
+}
+
+// putItem puts items that are shift-clicked into the furnace into the reagent
+// slot if they can be smelted, or otherwise the fuel slot if they are fuel.
+func (inv *FurnaceInventory) putItem(item *Slot) {
+    if _, ok := FurnaceReactions.Reactions[item.ItemTypeId]; ok {
+        slotBefore := inv.slots[furnaceSlotReagent]
+        inv.PutItemInSlot(furnaceSlotReagent, item)
+        if !slotBefore.IsSameType(&inv.slots[furnaceSlotReagent]) {
+            inv.cookTime = reactionDuration
+        }
+    } else if _, ok := FurnaceReactions.Fuels[item.ItemTypeId]; ok {
+        inv.PutItemInSlot(furnaceSlotFuel, item)
+    }
+}
+
+// InsertItem puts items from above into the reagent slot, and fuel from the
+// sides into the fuel slot.
+func (inv *FurnaceInventory) InsertItem(item *Slot, face Face) bool {
+    var one Slot
+    if !one.AddOne(item) {
+        return false
+    }
+
+    switch face {
+    case FaceTop:
+        slotBefore := inv.slots[furnaceSlotReagent]
+        inv.PutItemInSlot(furnaceSlotReagent, &one)
+        if !slotBefore.IsSameType(&inv.slots[furnaceSlotReagent]) {
+            inv.cookTime = reactionDuration
+        }
+    case FaceBottom:
+    default:
+        if _, ok := FurnaceReactions.Fuels[one.ItemTypeId]; ok {
+            inv.PutItemInSlot(furnaceSlotFuel, &one)
+        }
+    }
+
+    if !one.IsEmpty() {
+        item.Add(&one)
+        return false
+    }
+
+    inv.stateCheck()
+    inv.sendProgressUpdates()
+
+    return true
+}
+
+// ExtractItem takes items from the output slot, out of the bottom of the
+// furnace.
+func (inv *FurnaceInventory) ExtractItem(into *Slot, face Face) bool {
+    if face != FaceBottom {
+        return false
+    }
+
+    output := &inv.slots[furnaceSlotOutput]
+    if output.IsEmpty() || !into.AddOne(output) {
+        return false
+    }
+    inv.slotUpdate(output, furnaceSlotOutput)
+
+    inv.stateCheck()
+    inv.sendProgressUpdates()
+
+    return true
+}
+
+// slotClick handles clicks on a particular slot of the furnace.
+func (inv *FurnaceInventory) slotClick(click *Click) (txState TxState) {
+    txState = TxStateRejected
 
     switch click.SlotId {
     case furnaceSlotReagent:
@@ -92,13 +183,23 @@ func (inv *FurnaceInventory) Click(click *Click) (txState TxState) {
         }
     case furnaceSlotOutput:
         // Player may only *take* the *whole* stack from the output slot.
+        slotBefore := inv.slots[furnaceSlotOutput]
+
         txState = inv.Inventory.TakeOnlyClick(click)
-    }
 
-    inv.stateCheck()
+        if taken := slotBefore.Count - inv.slots[furnaceSlotOutput].Count; taken > 0 {
+            inv.experience += float32(taken) * FurnaceReactions.OutputExperience(&slotBefore)
+        }
+    }
 
-    inv.sendProgressUpdates()
+    return
+}
 
+// TakeExperience returns the whole experience earned from items taken from the
+// output. Any fraction is kept until more items are taken.
+func (inv *FurnaceInventory) TakeExperience() (amount Experience) {
+    amount = Experience(inv.experience)
+    inv.experience -= float32(amount)
     return
 }
 
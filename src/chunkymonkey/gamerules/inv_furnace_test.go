@@ -85,13 +85,13 @@ func loadedFurnace(t *testing.T, numFuel, numOre ItemCount) (furnace *FurnaceInv
 
     checkLit(t, furnace, false)
 
-    fuelInput := Slot{plankId, numFuel, 0}
+    fuelInput := Slot{plankId, numFuel, 0, nil}
 
     // Put a plank into the fuel slot.
     click = Click{
         furnaceSlotFuel,
         fuelInput,
-        false, false,
+        false, ClickModeNormal, 0,
         0,
         emptySlot,
     }
@@ -104,8 +104,8 @@ func loadedFurnace(t *testing.T, numFuel, numOre ItemCount) (furnace *FurnaceInv
     // Put iron ore into the reagent slot.
     click = Click{
         furnaceSlotReagent,
-        Slot{ironOreId, numOre, 0},
-        false, false,
+        Slot{ironOreId, numOre, 0, nil},
+        false, ClickModeNormal, 0,
         0,
         emptySlot,
     }
@@ -117,7 +117,7 @@ func loadedFurnace(t *testing.T, numFuel, numOre ItemCount) (furnace *FurnaceInv
     checkLit(t, furnace, true)
 
     // One unit of fuel from the fuel slot should have been consumed.
-    expectedFuel := Slot{plankId, numFuel - 1, 0}
+    expectedFuel := Slot{plankId, numFuel - 1, 0, nil}
     expectedFuel.Normalize()
     checkSlot(t, expectedFuel, furnace.slots[furnaceSlotFuel])
 
@@ -139,7 +139,7 @@ func Test_FurnaceProducesIronIngot(t *testing.T) {
     // One tick later there should be an iron ingot present, and the furnace
     // should still be lit.
     runner.runFor(1)
-    checkSlot(t, Slot{ironIngotId, 1, 0}, furnace.slots[furnaceSlotOutput])
+    checkSlot(t, Slot{ironIngotId, 1, 0, nil}, furnace.slots[furnaceSlotOutput])
 }
 
 func Test_FurnaceFinishBurning(t *testing.T) {
@@ -148,7 +148,7 @@ func Test_FurnaceFinishBurning(t *testing.T) {
     // 299 ticks later there should be an iron ingot present, and the furnace
     // should still be lit.
     runner.runFor(plankFuelTime - 1)
-    checkSlot(t, Slot{ironIngotId, 1, 0}, furnace.slots[furnaceSlotOutput])
+    checkSlot(t, Slot{ironIngotId, 1, 0, nil}, furnace.slots[furnaceSlotOutput])
     checkLit(t, furnace, true)
 
     // One more tick later and the furnace should be unlit.
@@ -162,9 +162,9 @@ func Test_FurnaceBurnsMultipleFuel(t *testing.T) {
     // 299 ticks later there should be an iron ingot present, and the furnace
     // should still be lit with one unit of fuel left to consume.
     runner.runUntil(plankFuelTime - 1)
-    checkSlot(t, Slot{ironIngotId, 1, 0}, furnace.slots[furnaceSlotOutput])
+    checkSlot(t, Slot{ironIngotId, 1, 0, nil}, furnace.slots[furnaceSlotOutput])
     checkLit(t, furnace, true)
-    checkSlot(t, Slot{plankId, 1, 0}, furnace.slots[furnaceSlotFuel])
+    checkSlot(t, Slot{plankId, 1, 0, nil}, furnace.slots[furnaceSlotFuel])
 
     // One more tick later and the furnace should still be lit, as there is a
     // second unit of fuel to consume, leaving the fuel input slot empty.
@@ -175,12 +175,12 @@ func Test_FurnaceBurnsMultipleFuel(t *testing.T) {
     // Check that a single ingot is present just before the second one should be
     // produced.
     runner.runUntil(2*reactionDuration - 1)
-    checkSlot(t, Slot{ironIngotId, 1, 0}, furnace.slots[furnaceSlotOutput])
+    checkSlot(t, Slot{ironIngotId, 1, 0, nil}, furnace.slots[furnaceSlotOutput])
 
     // The reaction should produce a second iron ingot after a total of 2 *
     // reactionDuration ticks.
     runner.runUntil(2 * reactionDuration)
-    checkSlot(t, Slot{ironIngotId, 2, 0}, furnace.slots[furnaceSlotOutput])
+    checkSlot(t, Slot{ironIngotId, 2, 0, nil}, furnace.slots[furnaceSlotOutput])
     checkLit(t, furnace, true)
 
     // The furnace should be lit after a total of 599 ticks, just before the
@@ -192,3 +192,45 @@ func Test_FurnaceBurnsMultipleFuel(t *testing.T) {
     runner.runUntil(plankFuelTime * 2)
     checkLit(t, furnace, false)
 }
+
+func Test_FurnaceTransferFaces(t *testing.T) {
+    furnace := NewFurnaceInventory()
+
+    // Items from above go to the reagent slot, and fuel from the sides goes
+    // to the fuel slot. Nothing goes in from below.
+    ore := Slot{ironOreId, 2, 0, nil}
+    if !furnace.InsertItem(&ore, FaceTop) {
+        t.Errorf("expected ore to be inserted from above")
+    }
+    checkSlot(t, Slot{ironOreId, 1, 0, nil}, furnace.slots[furnaceSlotReagent])
+    if furnace.InsertItem(&ore, FaceEast) {
+        t.Errorf("expected ore to be refused from the side")
+    }
+    if furnace.InsertItem(&ore, FaceBottom) {
+        t.Errorf("expected ore to be refused from below")
+    }
+    checkSlot(t, Slot{ironOreId, 1, 0, nil}, ore)
+
+    plank := Slot{plankId, 1, 0, nil}
+    if !furnace.InsertItem(&plank, FaceNorth) {
+        t.Errorf("expected fuel to be inserted from the side")
+    }
+    checkSlot(t, emptySlot, plank)
+    checkLit(t, furnace, true)
+
+    // Only the output can be taken, and only from below.
+    var into Slot
+    if furnace.ExtractItem(&into, FaceBottom) {
+        t.Errorf("expected nothing to be extracted from an empty output")
+    }
+    runner := &furnaceRunner{t, furnace, 0}
+    runner.runFor(reactionDuration)
+    if furnace.ExtractItem(&into, FaceTop) {
+        t.Errorf("expected nothing to be extracted from above")
+    }
+    if !furnace.ExtractItem(&into, FaceBottom) {
+        t.Errorf("expected output to be extracted from below")
+    }
+    checkSlot(t, Slot{ironIngotId, 1, 0, nil}, into)
+    checkSlot(t, emptySlot, furnace.slots[furnaceSlotOutput])
+}
@@ -0,0 +1,102 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    hopperNumSlots = 5
+
+    // Ticks that a hopper waits after moving items before it moves any more.
+    hopperTransferCooldown = Ticks(8)
+)
+
+type HopperInventory struct {
+    Inventory
+    cooldown Ticks
+}
+
+// NewHopperInventory creates a 5 slot hopper inventory.
+func NewHopperInventory() (inv *HopperInventory) {
+    inv = new(HopperInventory)
+    inv.Inventory.Init(hopperNumSlots)
+    return inv
+}
+
+func (inv *HopperInventory) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = inv.Inventory.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    // Older saves may not have a cooldown.
+    if cooldownTag, ok := tag.Lookup("TransferCooldown").(*nbt.Int); ok {
+        inv.cooldown = Ticks(cooldownTag.Value)
+    }
+
+    return nil
+}
+
+func (inv *HopperInventory) MarshalNbt(tag nbt.Compound) (err error) {
+    tag.Set("id", &nbt.String{"Hopper"})
+    tag.Set("TransferCooldown", &nbt.Int{int32(inv.cooldown)})
+    return inv.Inventory.MarshalNbt(tag)
+}
+
+// CoolingDown counts down the wait between transfers, returning true if the
+// hopper must wait longer before moving items.
+func (inv *HopperInventory) CoolingDown() bool {
+    if inv.cooldown > 0 {
+        inv.cooldown--
+        return true
+    }
+    return false
+}
+
+// Transferred starts the wait before the next transfer.
+func (inv *HopperInventory) Transferred() {
+    inv.cooldown = hopperTransferCooldown
+}
+
+// PushInto moves one item from the hopper into the first of the given
+// inventories that will take it, through their given face. Returns true if an
+// item was moved.
+func (inv *HopperInventory) PushInto(targets []ITransferInventory, face Face) bool {
+    for slotIndex := range inv.slots {
+        slot := &inv.slots[slotIndex]
+        if slot.IsEmpty() {
+            continue
+        }
+        for _, target := range targets {
+            if target.InsertItem(slot, face) {
+                inv.slotUpdate(slot, SlotId(slotIndex))
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// PullFrom moves one item into the hopper from the first of the given
+// inventories that has an item that fits, out of their bottom face. Returns
+// true if an item was moved.
+func (inv *HopperInventory) PullFrom(sources []ITransferInventory) bool {
+    for _, source := range sources {
+        for slotIndex := range inv.slots {
+            slot := &inv.slots[slotIndex]
+            if source.ExtractItem(slot, FaceBottom) {
+                inv.slotUpdate(slot, SlotId(slotIndex))
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Absorb puts as much as possible of an item entity's stack into the hopper.
+// Returns true if any items were taken.
+func (inv *HopperInventory) Absorb(item *Slot) bool {
+    count := item.Count
+    inv.PutItem(item)
+    return item.Count != count
+}
@@ -0,0 +1,52 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func TestHopperInventory_Transfer(t *testing.T) {
+    hopper := NewHopperInventory()
+    chest := NewChestInventory()
+    chest.slots[3] = Slot{5, 2, 0, nil}
+
+    if !hopper.PullFrom([]ITransferInventory{chest}) {
+        t.Errorf("expected an item to be pulled")
+    }
+    checkSlot(t, Slot{5, 1, 0, nil}, hopper.slots[0])
+    checkSlot(t, Slot{5, 1, 0, nil}, chest.slots[3])
+
+    // A full large chest's first half is skipped.
+    full, target := NewChestInventory(), NewChestInventory()
+    for i := range full.slots {
+        full.slots[i] = Slot{280, 64, 0, nil}
+    }
+    if !hopper.PushInto([]ITransferInventory{full, target}, FaceTop) {
+        t.Errorf("expected an item to be pushed")
+    }
+    checkSlot(t, emptySlot, hopper.slots[0])
+    checkSlot(t, Slot{5, 1, 0, nil}, target.slots[0])
+
+    if hopper.PushInto([]ITransferInventory{target}, FaceTop) {
+        t.Errorf("expected nothing to be pushed from an empty hopper")
+    }
+
+    item := Slot{5, 70, 0, nil}
+    if !hopper.Absorb(&item) {
+        t.Errorf("expected item to be absorbed")
+    }
+    checkSlot(t, emptySlot, item)
+    checkSlot(t, Slot{5, 64, 0, nil}, hopper.slots[0])
+    checkSlot(t, Slot{5, 6, 0, nil}, hopper.slots[1])
+
+    hopper.Transferred()
+    for i := Ticks(0); i < hopperTransferCooldown; i++ {
+        if !hopper.CoolingDown() {
+            t.Fatalf("expected hopper to be cooling down after %d ticks", i)
+        }
+    }
+    if hopper.CoolingDown() {
+        t.Errorf("expected hopper to have cooled down")
+    }
+}
@@ -0,0 +1,259 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+const (
+    // The items paid to the villager.
+    merchantSlotPayment       = SlotId(0)
+    merchantSlotPaymentSecond = SlotId(1)
+    merchantSlotOutput        = SlotId(2)
+
+    merchantNumSlots = 3
+)
+
+// MerchantTrade is a trade that the player has paid for, but which the
+// villager has yet to agree to.
+type MerchantTrade struct {
+    // Index is the index of the offer traded, and Offer is the player's copy
+    // of it.
+    Index int
+    Offer MerchantOffer
+    // Payment holds the items taken from the payment slots, to be given back
+    // if the villager refuses the trade.
+    Payment []Slot
+    // Click is the click that took the output. Its Cursor is empty, as the
+    // player keeps their cursor while the trade is waiting.
+    Click Click
+}
+
+// MerchantInventory is the inventory of a villager's trading window. The
+// output is an offer's item when the items put in the payment slots pay for
+// it. The offer that the player has selected is preferred, but any unlocked
+// offer that is paid for will do. Each player trading has their own
+// inventory, with a copy of the villager's offers.
+//
+// Taking the output takes the payment for it, but the item is only handed out
+// once the villager has agreed to the trade, as the player's copy of the
+// offers may be out of date.
+type MerchantInventory struct {
+    Inventory
+    offers   []MerchantOffer
+    selected int
+
+    // The offer in the output slot, or -1 if there is none, and whether the
+    // payment slots are used the other way around to pay for it.
+    current int
+    swapped bool
+
+    // The trade paid for, not yet taken to be put to the villager, and
+    // whether a trade is waiting for the villager to agree to it.
+    trade   *MerchantTrade
+    trading bool
+}
+
+// NewMerchantInventory creates an inventory for trading the given offers.
+func NewMerchantInventory(offers []MerchantOffer) (inv *MerchantInventory) {
+    inv = &MerchantInventory{
+        offers:  offers,
+        current: -1,
+    }
+    inv.Inventory.Init(merchantNumSlots)
+    return inv
+}
+
+// Offers returns the offers that can be traded.
+func (inv *MerchantInventory) Offers() []MerchantOffer {
+    return inv.offers
+}
+
+// SetOffers replaces the offers that can be traded, e.g with the villager's
+// after it has recorded a trade.
+func (inv *MerchantInventory) SetOffers(offers []MerchantOffer) {
+    inv.offers = offers
+    inv.update()
+}
+
+// SelectOffer sets the offer that the player has chosen. ok=false if there is
+// no such offer.
+func (inv *MerchantInventory) SelectOffer(index int) (ok bool) {
+    if index < 0 || index >= len(inv.offers) {
+        return false
+    }
+    inv.selected = index
+    inv.update()
+    return true
+}
+
+// TakeTrade returns the trade paid for since it was last called, if any.
+// Nothing more can be traded until EndTrade is called.
+func (inv *MerchantInventory) TakeTrade() (trade *MerchantTrade, ok bool) {
+    trade, inv.trade = inv.trade, nil
+    return trade, trade != nil
+}
+
+// SetTrading sets whether the player has a trade waiting for a villager, and
+// so cannot trade.
+func (inv *MerchantInventory) SetTrading(trading bool) {
+    inv.trading = trading
+    inv.update()
+}
+
+// EndTrade allows trading again once the villager has agreed to or refused
+// the trade. The payment for a refused trade is put back into the payment
+// slots. Any items that do not fit are left in payment.
+func (inv *MerchantInventory) EndTrade(accepted bool, payment []Slot) {
+    if !accepted {
+        for i := range payment {
+            inv.PutItemInSlot(merchantSlotPayment, &payment[i])
+            inv.PutItemInSlot(merchantSlotPaymentSecond, &payment[i])
+        }
+    }
+    inv.SetTrading(false)
+}
+
+// TakeAllItems empties the trading window, returning the items put into it.
+// The output is not returned, as it has not been paid for.
+func (inv *MerchantInventory) TakeAllItems() (items []Slot) {
+    inv.slots[merchantSlotOutput].Clear()
+    return inv.Inventory.TakeAllItems()
+}
+
+func (inv *MerchantInventory) Click(click *Click) (txState TxState) {
+    switch {
+    case click.isPut():
+        inv.PutItemInSlot(merchantSlotPayment, &click.Cursor)
+        inv.PutItemInSlot(merchantSlotPaymentSecond, &click.Cursor)
+        txState = TxStateAccepted
+    case click.Mode == ClickModeDoubleClick:
+        // Items are not collected from the output slot.
+        inv.collect(&click.Cursor, 0, int(merchantSlotOutput))
+        txState = TxStateAccepted
+    case click.SlotId == merchantSlotOutput:
+        txState = inv.takeOutput(click)
+    default:
+        txState = inv.Inventory.Click(click)
+    }
+
+    inv.update()
+
+    return
+}
+
+// takeOutput handles clicks on the output slot. Taking the output takes the
+// payment for it, and starts a trade that waits for the villager to agree to
+// it.
+func (inv *MerchantInventory) takeOutput(click *Click) (txState TxState) {
+    output := &inv.slots[merchantSlotOutput]
+    if output.IsEmpty() || inv.current < 0 || !click.ExpectedSlot.Equals(output) {
+        return TxStateRejected
+    }
+
+    switch click.Mode {
+    case ClickModeNormal:
+        // The item will go onto the cursor, which must be able to take it.
+        if !click.Cursor.IsEmpty() && (!click.Cursor.IsSameType(output) || click.Cursor.Count+output.Count > output.MaxStack()) {
+            return TxStateRejected
+        }
+    case ClickModeShift, ClickModeNumberKey, ClickModeDrop:
+        // The window has nothing to swap with the item that it could keep
+        // while the trade waits.
+        if !click.Cursor.IsEmpty() {
+            return TxStateRejected
+        }
+    default:
+        return TxStateRejected
+    }
+
+    offer := &inv.offers[inv.current]
+    trade := &MerchantTrade{
+        Index: inv.current,
+        Offer: *offer,
+        Click: *click,
+    }
+    trade.Click.Cursor.Clear()
+
+    firstId, secondId := merchantSlotPayment, merchantSlotPaymentSecond
+    if inv.swapped {
+        firstId, secondId = secondId, firstId
+    }
+    trade.Payment = append(trade.Payment, inv.takePayment(firstId, offer.Buy.Count))
+    if !offer.BuySecond.IsEmpty() {
+        trade.Payment = append(trade.Payment, inv.takePayment(secondId, offer.BuySecond.Count))
+    }
+
+    // The offer is used here as well as by the villager, so that it is
+    // locked without waiting to hear back from the villager.
+    offer.Uses++
+    inv.trade = trade
+    inv.trading = true
+
+    return TxStateDeferred
+}
+
+// takePayment takes count items out of the given payment slot.
+func (inv *MerchantInventory) takePayment(slotId SlotId, count ItemCount) (payment Slot) {
+    slot := &inv.slots[slotId]
+    payment = *slot
+    payment.Count = count
+    slot.setCount(slot.Count - count)
+    inv.slotUpdate(slot, slotId)
+    return
+}
+
+// update works out which offer is paid for by the items in the payment slots,
+// and puts its item in the output slot.
+func (inv *MerchantInventory) update() {
+    inv.current, inv.swapped = -1, false
+    if !inv.trading {
+        inv.current, inv.swapped = inv.paidOffer()
+    }
+
+    var output Slot
+    if inv.current >= 0 {
+        output = inv.offers[inv.current].Sell
+    }
+
+    if !output.Equals(&inv.slots[merchantSlotOutput]) {
+        inv.SetSlot(merchantSlotOutput, output)
+    }
+}
+
+// paidOffer returns the index of the unlocked offer that the items in the
+// payment slots pay for, trying the selected offer first. index=-1 if there is
+// none.
+func (inv *MerchantInventory) paidOffer() (index int, swapped bool) {
+    first := &inv.slots[merchantSlotPayment]
+    second := &inv.slots[merchantSlotPaymentSecond]
+
+    if first.IsEmpty() && second.IsEmpty() {
+        return -1, false
+    }
+
+    paidBy := func(offer *MerchantOffer) (ok bool, swapped bool) {
+        if offer.IsLocked() {
+            return false, false
+        }
+        if offer.payment(first, second) {
+            return true, false
+        }
+        if offer.payment(second, first) {
+            return true, true
+        }
+        return false, false
+    }
+
+    if inv.selected < len(inv.offers) {
+        if ok, swapped := paidBy(&inv.offers[inv.selected]); ok {
+            return inv.selected, swapped
+        }
+    }
+    for i := range inv.offers {
+        if ok, swapped := paidBy(&inv.offers[i]); ok {
+            return i, swapped
+        }
+    }
+
+    return -1, false
+}
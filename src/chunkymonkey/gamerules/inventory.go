@@ -3,6 +3,7 @@ package gamerules
 import (
     "errors"
     "fmt"
+    "math"
 
     "chunkymonkey/proto"
     . "chunkymonkey/types"
@@ -31,15 +32,75 @@ type IInventory interface {
     SlotUnmarshalNbt(tag nbt.Compound, slotId SlotId) (err error)
 }
 
+// ITransferInventory is implemented by the inventories of containers, which
+// hoppers move items into and out of, and comparators read. face is the face
+// of the container's block that the items pass through.
+type ITransferInventory interface {
+    IInventory
+
+    // InsertItem moves one item from `item` into the inventory, returning
+    // true if there was room for it.
+    InsertItem(item *Slot, face Face) bool
+
+    // ExtractItem moves one item out of the inventory into `into`, returning
+    // true if there was an item that `into` could take.
+    ExtractItem(into *Slot, face Face) bool
+
+    // Fullness returns the sum over all slots of how full each slot is, from
+    // 0 (empty) to 1 (a full stack).
+    Fullness() float64
+}
+
+// Click is a click on a slot of an inventory. How an inventory treats the
+// click depends on its Mode:
+//
+// ClickModeNormal: Cursor is the player's cursor, which is clicked onto the
+// slot.
+//
+// ClickModeShift: If Cursor is empty, the whole stack is taken out of the slot
+// into Cursor. Otherwise Cursor holds items to be put into the inventory
+// wherever they fit, and SlotId is ignored.
+//
+// ClickModeNumberKey: Cursor holds the contents of the hotbar slot given by
+// Button, which are swapped with the slot.
+//
+// ClickModeDrag: Cursor holds items to add to the slot.
+//
+// ClickModeDoubleClick: Cursor is the player's cursor, which collects items of
+// the same type from the inventory. SlotId is ignored.
+//
+// ClickModeDrop: One item, or the whole stack if Button is 1, is taken out of
+// the slot into Cursor to be thrown out of the window.
+//
+// For the transfer modes (see IsTransfer), Cursor is left holding the items
+// that were taken out or that did not fit.
 type Click struct {
     SlotId       SlotId
     Cursor       Slot
     RightClick   bool
-    ShiftClick   bool
+    Mode         ClickMode
+    Button       byte
     TxId         TxId
     ExpectedSlot Slot
 }
 
+// IsTransfer returns true if the click moves items between inventories, in
+// which case Cursor holds the items being moved rather than the player's
+// cursor.
+func (click *Click) IsTransfer() bool {
+    switch click.Mode {
+    case ClickModeShift, ClickModeNumberKey, ClickModeDrag, ClickModeDrop:
+        return true
+    }
+    return false
+}
+
+// isPut returns true if the click puts the items in Cursor into the
+// inventory.
+func (click *Click) isPut() bool {
+    return click.Mode == ClickModeShift && !click.Cursor.IsEmpty()
+}
+
 type Inventory struct {
     slots      []Slot
     subscriber IInventorySubscriber
@@ -63,12 +124,56 @@ func (inv *Inventory) SetSubscriber(subscriber IInventorySubscriber) {
 // Click takes the default actions upon a click event from a player. The Cursor
 // attribute of click may be modified to represent the cursors new contents.
 func (inv *Inventory) Click(click *Click) TxState {
-    if click.SlotId < 0 || int(click.SlotId) > len(inv.slots) {
+    switch click.Mode {
+    case ClickModeShift:
+        if click.isPut() {
+            inv.PutItem(&click.Cursor)
+            return TxStateAccepted
+        }
+    case ClickModeDoubleClick:
+        inv.collect(&click.Cursor, 0, len(inv.slots))
+        return TxStateAccepted
+    }
+
+    if click.SlotId < 0 || int(click.SlotId) >= len(inv.slots) {
         return TxStateRejected
     }
 
     clickedSlot := &inv.slots[click.SlotId]
 
+    switch click.Mode {
+    case ClickModeNormal:
+    case ClickModeShift, ClickModeNumberKey:
+        if !click.ExpectedSlot.Equals(clickedSlot) {
+            return TxStateRejected
+        }
+        if clickedSlot.Swap(&click.Cursor) {
+            inv.slotUpdate(clickedSlot, click.SlotId)
+        }
+        return TxStateAccepted
+    case ClickModeDrag:
+        if clickedSlot.Add(&click.Cursor) {
+            inv.slotUpdate(clickedSlot, click.SlotId)
+        }
+        return TxStateAccepted
+    case ClickModeDrop:
+        if !click.ExpectedSlot.Equals(clickedSlot) {
+            return TxStateRejected
+        }
+        var changed bool
+        if click.Button == 1 {
+            changed = click.Cursor.Add(clickedSlot)
+        } else {
+            changed = click.Cursor.AddOne(clickedSlot)
+        }
+        if changed {
+            inv.slotUpdate(clickedSlot, click.SlotId)
+        }
+        return TxStateAccepted
+    default:
+        return TxStateRejected
+    }
+
     if !click.ExpectedSlot.Equals(clickedSlot) {
         return TxStateRejected
     }
@@ -106,7 +211,17 @@ func (inv *Inventory) Click(click *Click) TxState {
 // items are taken at all. This is intended for use by crafting/furnace output
 // slots.
 func (inv *Inventory) TakeOnlyClick(click *Click) TxState {
-    if click.SlotId < 0 || int(click.SlotId) > len(inv.slots) {
+    if click.SlotId < 0 || int(click.SlotId) >= len(inv.slots) {
+        return TxStateRejected
+    }
+
+    switch click.Mode {
+    case ClickModeNormal, ClickModeNumberKey, ClickModeDrop:
+    case ClickModeShift:
+        if click.isPut() {
+            return TxStateRejected
+        }
+    default:
         return TxStateRejected
     }
 
@@ -137,19 +252,121 @@ func (inv *Inventory) TakeOneItem(slotId SlotId, into *Slot) {
     }
 }
 
-// PutItem attempts to put the given item into the inventory.
+// TakeItems moves as many items as will fit from the given slot into `into`.
+func (inv *Inventory) TakeItems(slotId SlotId, into *Slot) {
+    slot := &inv.slots[slotId]
+    if into.Add(slot) {
+        inv.slotUpdate(slot, slotId)
+    }
+}
+
+// SetSlot replaces the contents of the given slot.
+func (inv *Inventory) SetSlot(slotId SlotId, item Slot) {
+    slot := &inv.slots[slotId]
+    *slot = item
+    slot.Normalize()
+    inv.slotUpdate(slot, slotId)
+}
+
+// WearItem adds wear to the tool in the given slot, removing it if it wears
+// out.
+func (inv *Inventory) WearItem(slotId SlotId, uses ItemData) {
+    slot := &inv.slots[slotId]
+    if slot.Wear(uses) {
+        inv.slotUpdate(slot, slotId)
+    }
+}
+
+// PutItem attempts to put the given item into the inventory. Stacks of the
+// same type are filled before empty slots are used.
 func (inv *Inventory) PutItem(item *Slot) {
     // TODO optimize this algorithm, maybe by maintaining a map of non-full
-    // slots containing an item of various item type IDs. Additionally, it
-    // should prefer to put stackable items into stacks of the same type,
-    // rather than in empty slots.
-    for slotIndex := range inv.slots {
-        if item.Count <= 0 {
-            break
+    // slots containing an item of various item type IDs.
+    for _, intoEmpty := range [...]bool{false, true} {
+        for slotIndex := range inv.slots {
+            if item.Count <= 0 {
+                return
+            }
+            slot := &inv.slots[slotIndex]
+            if slot.IsEmpty() != intoEmpty {
+                continue
+            }
+            if slot.Add(item) {
+                inv.slotUpdate(slot, SlotId(slotIndex))
+            }
         }
+    }
+}
+
+// PutItemInSlot attempts to put the given item into a particular slot.
+func (inv *Inventory) PutItemInSlot(slotId SlotId, item *Slot) {
+    slot := &inv.slots[slotId]
+    if slot.Add(item) {
+        inv.slotUpdate(slot, slotId)
+    }
+}
+
+// InsertItem puts one item into the inventory, on any side.
+func (inv *Inventory) InsertItem(item *Slot, face Face) bool {
+    var one Slot
+    if !one.AddOne(item) {
+        return false
+    }
+
+    inv.PutItem(&one)
+    if one.IsEmpty() {
+        return true
+    }
+
+    // No room, so give the item back.
+    item.Add(&one)
+    return false
+}
+
+// ExtractItem takes one item from the first slot that into can take from, on
+// any side.
+func (inv *Inventory) ExtractItem(into *Slot, face Face) bool {
+    for slotIndex := range inv.slots {
         slot := &inv.slots[slotIndex]
-        if slot.Add(item) {
+        if !slot.IsEmpty() && into.AddOne(slot) {
             inv.slotUpdate(slot, SlotId(slotIndex))
+            return true
+        }
+    }
+    return false
+}
+
+// Fullness implements ITransferInventory.Fullness.
+func (inv *Inventory) Fullness() (fullness float64) {
+    for slotIndex := range inv.slots {
+        slot := &inv.slots[slotIndex]
+        // Items of unknown type, which cannot stack, do not count.
+        if maxStack := slot.MaxStack(); !slot.IsEmpty() && maxStack > 0 {
+            fullness += math.Min(float64(slot.Count)/float64(maxStack), 1)
+        }
+    }
+    return
+}
+
+// collect takes items of the same type as cursor from the slots in the range
+// [first, end) into cursor, until it is full. Part stacks are taken before
+// full ones.
+func (inv *Inventory) collect(cursor *Slot, first, end int) {
+    if cursor.IsEmpty() {
+        return
+    }
+    for _, fromFull := range [...]bool{false, true} {
+        for slotIndex := first; slotIndex < end; slotIndex++ {
+            if cursor.Count >= cursor.MaxStack() {
+                return
+            }
+            slot := &inv.slots[slotIndex]
+            if slot.IsEmpty() || (slot.Count >= slot.MaxStack()) != fromFull {
+                continue
+            }
+            if slot.IsSameType(cursor) && cursor.Add(slot) {
+                inv.slotUpdate(slot, SlotId(slotIndex))
+            }
         }
     }
 }
@@ -185,12 +402,7 @@ func (inv *Inventory) MakeProtoSlots() proto.ItemSlotSlice {
 // Precondition: len(slots) == len(inv.slots)
 func (inv *Inventory) GetProtoSlots(slots proto.ItemSlotSlice) {
     for i := range inv.slots {
-        src := &inv.slots[i]
-        slots[i] = proto.ItemSlot{
-            ItemTypeId: src.ItemTypeId,
-            Count:      src.Count,
-            Data:       src.Data,
-        }
+        slots[i] = inv.slots[i].ItemSlot()
     }
 }
 
@@ -225,6 +437,12 @@ func (inv *Inventory) UnmarshalNbt(tag nbt.Compound) (err error) {
         return errors.New("bad inventory - not a list")
     }
 
+    return inv.UnmarshalNbtList(itemList)
+}
+
+// UnmarshalNbtList reads the inventory's items from a list of slot compounds,
+// as written by MarshalNbtList.
+func (inv *Inventory) UnmarshalNbtList(itemList *nbt.List) (err error) {
     for _, slotTagITag := range itemList.Value {
         slotTag, ok := slotTagITag.(nbt.Compound)
         if !ok {
@@ -246,6 +464,18 @@ func (inv *Inventory) UnmarshalNbt(tag nbt.Compound) (err error) {
 }
 
 func (inv *Inventory) MarshalNbt(tag nbt.Compound) (err error) {
+    itemList, err := inv.MarshalNbtList()
+    if err != nil {
+        return
+    }
+
+    tag.Set("Items", itemList)
+
+    return nil
+}
+
+// MarshalNbtList writes the inventory's items as a list of slot compounds.
+func (inv *Inventory) MarshalNbtList() (itemList *nbt.List, err error) {
     occupiedSlots := 0
     for i := range inv.slots {
         if inv.slots[i].Count > 0 {
@@ -253,7 +483,7 @@ func (inv *Inventory) MarshalNbt(tag nbt.Compound) (err error) {
         }
     }
 
-    itemList := &nbt.List{nbt.TagCompound, make([]nbt.ITag, 0, occupiedSlots)}
+    itemList = &nbt.List{nbt.TagCompound, make([]nbt.ITag, 0, occupiedSlots)}
     for i := range inv.slots {
         slot := &inv.slots[i]
         if slot.Count > 0 {
@@ -266,9 +496,7 @@ func (inv *Inventory) MarshalNbt(tag nbt.Compound) (err error) {
         }
     }
 
-    tag.Set("Items", itemList)
-
-    return nil
+    return
 }
 
 func (inv *Inventory) SlotUnmarshalNbt(tag nbt.Compound, slotId SlotId) (err error) {
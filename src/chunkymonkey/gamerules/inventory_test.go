@@ -2,6 +2,8 @@ package gamerules
 
 import (
     "testing"
+
+    . "chunkymonkey/types"
 )
 
 func TestInventory_Init(t *testing.T) {
@@ -14,3 +16,171 @@ func TestInventory_Init(t *testing.T) {
         }
     }
 }
+
+func TestInventory_Click_Modes(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+    apple := ItemTypeId(1)
+    orange := ItemTypeId(2)
+    makeItemType(apple)
+    makeItemType(orange)
+
+    newInv := func() *Inventory {
+        inv := new(Inventory)
+        inv.Init(4)
+        inv.slots[0] = Slot{apple, 60, 0, nil}
+        inv.slots[1] = Slot{orange, 5, 0, nil}
+        inv.slots[3] = Slot{apple, 10, 0, nil}
+        return inv
+    }
+
+    // Shift-click with nothing being transferred takes the stack out.
+    inv := newInv()
+    click := Click{SlotId: 1, Mode: ClickModeShift, ExpectedSlot: inv.slots[1]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{orange, 5, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{}, inv.slots[1])
+
+    // Shift-click carrying items puts them into part stacks first.
+    inv = newInv()
+    click = Click{Cursor: Slot{apple, 20, 0, nil}, Mode: ClickModeShift}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{}, click.Cursor)
+    checkSlot(t, Slot{apple, 64, 0, nil}, inv.slots[0])
+    checkSlot(t, Slot{}, inv.slots[2])
+    checkSlot(t, Slot{apple, 26, 0, nil}, inv.slots[3])
+
+    // Shift-click on a slot that the client has the wrong idea of is rejected.
+    inv = newInv()
+    click = Click{SlotId: 1, Mode: ClickModeShift, ExpectedSlot: Slot{apple, 1, 0, nil}}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    checkSlot(t, Slot{orange, 5, 0, nil}, inv.slots[1])
+
+    // Number key swaps the hotbar's items with the slot.
+    inv = newInv()
+    click = Click{SlotId: 1, Cursor: Slot{apple, 3, 0, nil}, Mode: ClickModeNumberKey, ExpectedSlot: inv.slots[1]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{orange, 5, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{apple, 3, 0, nil}, inv.slots[1])
+
+    // Dragging adds to a slot, leaving what does not fit.
+    inv = newInv()
+    click = Click{SlotId: 0, Cursor: Slot{apple, 6, 0, nil}, Mode: ClickModeDrag}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{apple, 2, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{apple, 64, 0, nil}, inv.slots[0])
+
+    // Double click collects from part stacks before full ones.
+    inv = newInv()
+    inv.slots[0].Count = 64
+    click = Click{Cursor: Slot{apple, 1, 0, nil}, Mode: ClickModeDoubleClick}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{apple, 64, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{apple, 11, 0, nil}, inv.slots[0])
+    checkSlot(t, Slot{}, inv.slots[3])
+
+    // Dropping takes one item out of the slot, or the whole stack.
+    inv = newInv()
+    click = Click{SlotId: 0, Mode: ClickModeDrop, ExpectedSlot: inv.slots[0]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{apple, 1, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{apple, 59, 0, nil}, inv.slots[0])
+    click = Click{SlotId: 0, Mode: ClickModeDrop, Button: 1, ExpectedSlot: inv.slots[0]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, Slot{apple, 59, 0, nil}, click.Cursor)
+    checkSlot(t, Slot{}, inv.slots[0])
+
+    // Clicks with an unknown mode are rejected.
+    inv = newInv()
+    click = Click{SlotId: 0, Mode: ClickModeMiddle}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+}
+
+func TestCraftingInventory_Click_Modes(t *testing.T) {
+    inv := NewWorkbenchInventory()
+    inv.slots[0] = Slot{5, 4, 0, nil}
+
+    // Items are not shifted into the crafting grid.
+    click := Click{Cursor: Slot{5, 1, 0, nil}, Mode: ClickModeShift}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    checkSlot(t, Slot{5, 1, 0, nil}, click.Cursor)
+
+    // Nothing can be dragged into the output.
+    click = Click{SlotId: 0, Cursor: Slot{5, 1, 0, nil}, Mode: ClickModeDrag}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    checkSlot(t, Slot{5, 4, 0, nil}, inv.slots[0])
+}
+
+func TestInventory_NbtList(t *testing.T) {
+    var inv Inventory
+    inv.Init(ChestNumSlots)
+    inv.slots[0] = Slot{5, 4, 0, nil}
+    inv.slots[26] = Slot{280, 64, 3, nil}
+
+    itemList, err := inv.MarshalNbtList()
+    if err != nil {
+        t.Fatalf("MarshalNbtList: %v", err)
+    }
+    if len(itemList.Value) != 2 {
+        t.Errorf("expected 2 items in list, got %d", len(itemList.Value))
+    }
+
+    var result Inventory
+    result.Init(ChestNumSlots)
+    if err = result.UnmarshalNbtList(itemList); err != nil {
+        t.Fatalf("UnmarshalNbtList: %v", err)
+    }
+    checkSlot(t, inv.slots[0], result.slots[0])
+    checkSlot(t, Slot{}, result.slots[1])
+    checkSlot(t, inv.slots[26], result.slots[26])
+}
+
+func TestInventory_Transfer(t *testing.T) {
+    var inv Inventory
+    inv.Init(2)
+    inv.slots[0] = Slot{5, 63, 0, nil}
+
+    // Items top up the stack before going into an empty slot.
+    item := Slot{5, 3, 0, nil}
+    if !inv.InsertItem(&item, FaceTop) {
+        t.Errorf("expected item to be inserted")
+    }
+    checkSlot(t, Slot{5, 2, 0, nil}, item)
+    checkSlot(t, Slot{5, 64, 0, nil}, inv.slots[0])
+
+    inv.InsertItem(&item, FaceTop)
+    checkSlot(t, Slot{5, 1, 0, nil}, inv.slots[1])
+
+    inv.slots[1] = Slot{280, 64, 0, nil}
+    if inv.InsertItem(&item, FaceTop) {
+        t.Errorf("expected item to be refused by full inventory")
+    }
+    checkSlot(t, Slot{5, 1, 0, nil}, item)
+
+    if fullness := inv.Fullness(); fullness != 2 {
+        t.Errorf("expected fullness 2, got %v", fullness)
+    }
+
+    // Items are only extracted into a slot that can take them.
+    into := Slot{280, 1, 0, nil}
+    if !inv.ExtractItem(&into, FaceBottom) {
+        t.Errorf("expected item to be extracted")
+    }
+    checkSlot(t, Slot{280, 2, 0, nil}, into)
+    checkSlot(t, Slot{5, 64, 0, nil}, inv.slots[0])
+    checkSlot(t, Slot{280, 63, 0, nil}, inv.slots[1])
+
+    inv.slots[1] = Slot{}
+    if inv.ExtractItem(&into, FaceBottom) {
+        t.Errorf("expected no item to be extracted")
+    }
+    if fullness := inv.Fullness(); fullness != 1 {
+        t.Errorf("expected fullness 1, got %v", fullness)
+    }
+
+    // Items of unknown type do not make the inventory any fuller.
+    inv.slots[1] = Slot{9999, 1, 0, nil}
+    if fullness := inv.Fullness(); fullness != 1 {
+        t.Errorf("expected fullness 1 with unknown item, got %v", fullness)
+    }
+}
@@ -22,12 +22,14 @@ func NewBlankItem() INonPlayerEntity {
 }
 
 func NewItem(itemTypeId ItemTypeId, count ItemCount, data ItemData, position AbsXyz, velocity AbsVelocity, pickupImmunity Ticks) (item *Item) {
+    return NewItemFromSlot(Slot{ItemTypeId: itemTypeId, Count: count, Data: data}, position, velocity, pickupImmunity)
+}
+
+// NewItemFromSlot creates an item entity holding the contents of slot,
+// including any NBT data on it.
+func NewItemFromSlot(slot Slot, position AbsXyz, velocity AbsVelocity, pickupImmunity Ticks) (item *Item) {
     item = &Item{
-        Slot: Slot{
-            ItemTypeId: itemTypeId,
-            Count:      count,
-            Data:       data,
-        },
+        Slot:           slot,
         PickupImmunity: pickupImmunity,
     }
     item.PointObject.Init(position, velocity)
@@ -44,20 +46,10 @@ func (item *Item) UnmarshalNbt(tag nbt.Compound) (err error) {
         return errors.New("bad item data")
     }
 
-    // Grab the basic item data
-    id, idOk := itemInfo.Lookup("id").(*nbt.Short)
-    count, countOk := itemInfo.Lookup("Count").(*nbt.Byte)
-    data, dataOk := itemInfo.Lookup("Damage").(*nbt.Short)
-    if !idOk || !countOk || !dataOk {
+    if err = item.Slot.UnmarshalNbt(itemInfo); err != nil {
         return errors.New("bad item data")
     }
 
-    item.Slot = Slot{
-        ItemTypeId: ItemTypeId(id.Value),
-        Count:      ItemCount(count.Value),
-        Data:       ItemData(data.Value),
-    }
-
     return nil
 }
 
@@ -66,11 +58,11 @@ func (item *Item) MarshalNbt(tag nbt.Compound) (err error) {
         return
     }
     tag.Set("id", &nbt.String{"Item"})
-    tag.Set("Item", nbt.Compound{
-        "id":     &nbt.Short{int16(item.ItemTypeId)},
-        "Count":  &nbt.Byte{int8(item.Count)},
-        "Damage": &nbt.Short{int16(item.Data)},
-    })
+    itemInfo := nbt.NewCompound()
+    if err = item.Slot.MarshalNbt(itemInfo); err != nil {
+        return
+    }
+    tag.Set("Item", itemInfo)
     return nil
 }
 
@@ -95,6 +87,14 @@ func (item *Item) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
     )
 }
 
+func (item *Item) Tick(chunk IChunkBlock) (leftBlock bool) {
+    return item.PointObject.Tick(chunk)
+}
+
+func (item *Item) AwarenessRadius() AbsCoord {
+    return ItemAwarenessRadius
+}
+
 func (item *Item) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
     pkts = append(pkts, &proto.PacketEntity{
         EntityId: item.EntityId,
@@ -16,6 +16,36 @@ type ItemType struct {
     MaxStack ItemCount
     ToolType ToolTypeId
     ToolUses ItemData
+    // ToolMaterial is what a digging tool is made of, which determines how
+    // quickly it digs and which blocks it can harvest.
+    ToolMaterial ToolMaterialId
+    // PlacesBlock is the block type placed when a non-block item is used
+    // against a block (e.g a bed item places a bed block). Zero if the item
+    // does not place a block.
+    PlacesBlock BlockId
+    // Enchantability is how readily the item takes enchantments at an
+    // enchanting table. Zero if the item cannot be enchanted.
+    Enchantability int
+    // RepairMaterial is the item that repairs the item at an anvil. Zero if
+    // the item can only be repaired with another of the same item.
+    RepairMaterial ItemTypeId
+    // FoodPoints is the food restored by eating the item, and FoodSaturation
+    // is how much saturation it gives for each point. Zero if the item is not
+    // food.
+    FoodPoints     FoodUnits
+    FoodSaturation float32
+    // FoodLeaves is the item left behind after eating the item (e.g a bowl),
+    // or zero if nothing is left.
+    FoodLeaves ItemTypeId
+    // FoodEffect is a status effect that eating the item may cause, or nil.
+    FoodEffect *FoodEffect
+}
+
+// FoodEffect is a status effect caused by eating food.
+type FoodEffect struct {
+    StatusEffect
+    // Percentage chance of the effect happening.
+    Probability byte
 }
 
 type ItemTypeMap map[ItemTypeId]*ItemType
@@ -0,0 +1,334 @@
+package gamerules
+
+import (
+    "math"
+
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    // Acceleration of a minecart down a sloped rail, in blocks/tick^2.
+    minecartSlopeAccel = 0.0078125
+    // Acceleration given by a powered rail, in blocks/tick^2.
+    minecartBoostAccel = 0.06
+    // Maximum speed of a minecart along rails, in blocks/tick.
+    minecartMaxSpeed = 0.4
+    // Fraction of speed kept each tick for minecarts with and without riders.
+    minecartRiddenFriction = 0.997
+    minecartEmptyFriction  = 0.96
+    // Speed below which a minecart comes to a halt.
+    minecartMinSpeed = 0.001
+
+    itemTypeIdMinecart        = ItemTypeId(328)
+    itemTypeIdStorageMinecart = ItemTypeId(342)
+    itemTypeIdPoweredMinecart = ItemTypeId(343)
+    itemTypeIdChest           = ItemTypeId(54)
+    itemTypeIdFurnace         = ItemTypeId(61)
+)
+
+// minecartTypeByItem maps items that can be placed on rails to the type of
+// minecart that they create.
+var minecartTypeByItem = map[ItemTypeId]ObjTypeId{
+    itemTypeIdMinecart:        ObjTypeIdMinecart,
+    itemTypeIdStorageMinecart: ObjTypeIdStorageCart,
+    itemTypeIdPoweredMinecart: ObjTypeIdPoweredCart,
+}
+
+// Values of the "Type" NBT field of a minecart.
+var minecartNbtTypes = map[ObjTypeId]int32{
+    ObjTypeIdMinecart:    0,
+    ObjTypeIdStorageCart: 1,
+    ObjTypeIdPoweredCart: 2,
+}
+
+// Minecart is an Object that runs along rails. Plain minecarts can be ridden
+// by a player, and storage minecarts have an inventory.
+type Minecart struct {
+    Object
+    vehicle
+    inventory *blockInventory // Only present for storage minecarts.
+    fuel      int16
+}
+
+func newMinecart(objTypeId ObjTypeId) *Minecart {
+    cart := &Minecart{
+        Object: *NewObject(objTypeId),
+    }
+    cart.initVehicle()
+    cart.initType()
+    return cart
+}
+
+func NewMinecart() INonPlayerEntity {
+    return newMinecart(ObjTypeIdMinecart)
+}
+
+func NewStorageCart() INonPlayerEntity {
+    return newMinecart(ObjTypeIdStorageCart)
+}
+
+func NewPoweredCart() INonPlayerEntity {
+    return newMinecart(ObjTypeIdPoweredCart)
+}
+
+// NewMinecartAt creates a minecart of the given type at rest at a position.
+func NewMinecartAt(objTypeId ObjTypeId, position AbsXyz) *Minecart {
+    cart := newMinecart(objTypeId)
+    cart.PointObject.Init(position, AbsVelocity{})
+    return cart
+}
+
+// initType sets up the parts of the minecart particular to its type.
+func (cart *Minecart) initType() {
+    if cart.ObjTypeId == ObjTypeIdStorageCart && cart.inventory == nil {
+        cart.inventory = newBlockInventory(nil, NewChestInventory(), false, InvTypeIdChest)
+    }
+}
+
+func (cart *Minecart) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = cart.Object.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    // Carts saved with the "Minecart" ID use "Type" to give their kind.
+    if typeTag, ok := tag.Lookup("Type").(*nbt.Int); ok {
+        for objTypeId, nbtType := range minecartNbtTypes {
+            if nbtType == typeTag.Value {
+                cart.ObjTypeId = objTypeId
+            }
+        }
+    }
+    cart.initType()
+
+    if cart.inventory != nil && tag.Lookup("Items") != nil {
+        if err = cart.inventory.inv.UnmarshalNbt(tag); err != nil {
+            return
+        }
+    }
+
+    if fuelTag, ok := tag.Lookup("Fuel").(*nbt.Short); ok {
+        cart.fuel = fuelTag.Value
+    }
+
+    return nil
+}
+
+func (cart *Minecart) MarshalNbt(tag nbt.Compound) (err error) {
+    if cart.inventory != nil {
+        if err = cart.inventory.inv.MarshalNbt(tag); err != nil {
+            return
+        }
+    }
+
+    if err = cart.Object.MarshalNbt(tag); err != nil {
+        return
+    }
+
+    tag.Set("Type", &nbt.Int{minecartNbtTypes[cart.ObjTypeId]})
+    if cart.ObjTypeId == ObjTypeIdPoweredCart {
+        tag.Set("Fuel", &nbt.Short{cart.fuel})
+    }
+
+    return nil
+}
+
+func (cart *Minecart) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
+    pkts = cart.Object.SpawnPackets(pkts)
+    return cart.riderPackets(pkts, cart.EntityId)
+}
+
+// railUnder finds the rail that the minecart is running on, if any.
+func (cart *Minecart) railUnder(chunk IChunkBlock) (railLoc *BlockXyz, data byte, rail *RailAspect, ok bool) {
+    railLoc = cart.Position().ToBlockXyz()
+    for i := 0; i < 2 && railLoc != nil; i++ {
+        var blockType *BlockType
+        if blockType, data, ok = chunk.BlockAt(railLoc); ok {
+            if rail, ok = blockType.Aspect.(*RailAspect); ok {
+                return
+            }
+        }
+        railLoc = railLoc.AddXyz(0, -1, 0)
+    }
+    return nil, 0, nil, false
+}
+
+func (cart *Minecart) Tick(chunk IChunkBlock) (leftBlock bool) {
+    lastPosition := *cart.Position()
+
+    if railLoc, data, rail, ok := cart.railUnder(chunk); ok {
+        leftBlock = cart.tickOnRail(chunk, railLoc, data, rail)
+    } else {
+        leftBlock = cart.PointObject.Tick(chunk)
+    }
+
+    if position := *cart.Position(); position != lastPosition {
+        cart.moveRider(chunk, position)
+    }
+
+    return
+}
+
+// tickOnRail moves the minecart along the rail that it is on.
+func (cart *Minecart) tickOnRail(chunk IChunkBlock, railLoc *BlockXyz, data byte, rail *RailAspect) (leftBlock bool) {
+    p := cart.Position()
+    v := cart.Velocity()
+    shape := rail.Shape(data)
+    dirs := railShapeDirs[shape]
+    centreX := AbsCoord(railLoc.X) + 0.5
+    centreZ := AbsCoord(railLoc.Z) + 0.5
+
+    // Work out which way along the rail the cart is travelling.
+    var out railDir
+    var speed float64
+    if railShapeIsCurve(shape) {
+        // Leave through whichever end the cart is heading towards.
+        speed = math.Hypot(float64(v.X), float64(v.Z))
+        ax, az := dirs[0].dxz()
+        bx, bz := dirs[1].dxz()
+        if float64(v.X)*float64(ax)+float64(v.Z)*float64(az) >= float64(v.X)*float64(bx)+float64(v.Z)*float64(bz) {
+            out = dirs[0]
+        } else {
+            out = dirs[1]
+        }
+    } else {
+        out = dirs[1]
+        dx, dz := out.dxz()
+        speed = float64(v.X)*float64(dx) + float64(v.Z)*float64(dz)
+        if railShapeIsAscending(shape) {
+            // Gravity pulls the cart down the slope.
+            speed -= minecartSlopeAccel
+        }
+        if speed < 0 {
+            out = out.opposite()
+            speed = -speed
+        }
+    }
+
+    if rail.Boosts {
+        if rail.Powered(data) {
+            if speed > minecartMinSpeed {
+                speed += minecartBoostAccel
+            }
+        } else {
+            // Unpowered booster rails act as brakes.
+            speed *= 0.5
+        }
+    }
+
+    if cart.rider != EntityIdNull {
+        speed *= minecartRiddenFriction
+    } else {
+        speed *= minecartEmptyFriction
+    }
+    if speed > minecartMaxSpeed {
+        speed = minecartMaxSpeed
+    } else if speed < minecartMinSpeed {
+        speed = 0
+    }
+
+    dx, dz := out.dxz()
+    v.X = AbsVelocityCoord(speed * float64(dx))
+    v.Y = 0
+    v.Z = AbsVelocityCoord(speed * float64(dz))
+
+    // Keep the cart in the middle of the rail across its direction of travel.
+    if dx == 0 {
+        p.X = centreX
+    } else {
+        p.Z = centreZ
+    }
+
+    if speed == 0 {
+        return false
+    }
+
+    lastPosition := *p
+    p.X += AbsCoord(v.X)
+    p.Z += AbsCoord(v.Z)
+
+    // Follow the height of sloped rails.
+    p.Y = AbsCoord(railLoc.Y)
+    if railShapeIsAscending(shape) {
+        var along AbsCoord
+        switch dirs[1] {
+        case railDirEast:
+            along = p.X - AbsCoord(railLoc.X)
+        case railDirWest:
+            along = AbsCoord(railLoc.X+1) - p.X
+        case railDirSouth:
+            along = p.Z - AbsCoord(railLoc.Z)
+        case railDirNorth:
+            along = AbsCoord(railLoc.Z+1) - p.Z
+        }
+        p.Y += AbsCoord(math.Max(0, math.Min(1, float64(along))))
+    }
+
+    // Stop at anything solid in the way.
+    isSolid, isWithinChunk := chunk.BlockQuery(*p.ToBlockXyz())
+    if isSolid {
+        *p = lastPosition
+        v.X, v.Z = 0, 0
+        return false
+    }
+
+    return !isWithinChunk
+}
+
+func (cart *Minecart) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if leftClick {
+        cart.destroy(chunk)
+        return true
+    }
+
+    switch cart.ObjTypeId {
+    case ObjTypeIdMinecart:
+        cart.toggleRider(player, cart.EntityId)
+    case ObjTypeIdStorageCart:
+        if len(cart.inventory.subscribers) == 0 {
+            // The inventory is identified to players by the block that the
+            // cart is in when opened.
+            cart.inventory.chunk = chunk
+            cart.inventory.blockLoc = *cart.Position().ToBlockXyz()
+            cart.inventory.entityId = cart.EntityId
+        }
+        cart.inventory.AddSubscriber(player)
+    }
+
+    return false
+}
+
+func (cart *Minecart) InventoryClick(player IPlayerClient, click *Click) {
+    if cart.inventory != nil {
+        cart.inventory.Click(player, click)
+    }
+}
+
+func (cart *Minecart) InventoryUnsubscribed(player IPlayerClient) {
+    if cart.inventory != nil && cart.inventory.chunk != nil {
+        cart.inventory.RemoveSubscriber(player.GetEntityId())
+    }
+}
+
+// destroy breaks the minecart up, dropping it and its contents as items.
+func (cart *Minecart) destroy(chunk IChunkBlock) {
+    blockLoc := *cart.Position().ToBlockXyz()
+
+    cart.ejectRider(chunk)
+
+    spawnItemInBlock(chunk, blockLoc, itemTypeIdMinecart, 1, 0)
+
+    switch cart.ObjTypeId {
+    case ObjTypeIdStorageCart:
+        spawnItemInBlock(chunk, blockLoc, itemTypeIdChest, 1, 0)
+        cart.inventory.chunk = chunk
+        cart.inventory.blockLoc = blockLoc
+        cart.inventory.EjectItems()
+        if len(cart.inventory.subscribers) > 0 {
+            cart.inventory.Destroyed()
+        }
+    case ObjTypeIdPoweredCart:
+        spawnItemInBlock(chunk, blockLoc, itemTypeIdFurnace, 1, 0)
+    }
+}
@@ -26,14 +26,23 @@ type Mob struct {
     physics.PointObject
     mobType EntityMobType
     look    LookDegrees
-    // TODO(nictuku): Move to a more structured form.
-    metadata map[byte]byte
+    health  Health
+    effects StatusEffects
+    // Packets about changes to effects, status and metadata, to be sent with
+    // the next update.
+    effectPkts []proto.IPacket
+    // Metadata sent to clients, by index. Values are byte, int16, int32,
+    // float32 or string, which sets the type that they are sent as.
+    metadata map[byte]interface{}
     // TODO: Change to an AABB object when we have that.
 }
 
 func (mob *Mob) Init(id EntityMobType) {
     mob.mobType = id
-    mob.metadata = map[byte]byte{
+    if mobType, ok := Mobs[id]; ok {
+        mob.health = mobType.MaxHealth
+    }
+    mob.metadata = map[byte]interface{}{
         0:  byte(0),
         16: byte(0),
     }
@@ -56,7 +65,15 @@ func (mob *Mob) UnmarshalNbt(tag nbt.Compound) (err error) {
     _ = tag.Lookup("DeathTime").(*nbt.Short).Value
     _ = tag.Lookup("FallDistance").(*nbt.Float).Value
     _ = tag.Lookup("Fire").(*nbt.Short).Value
-    _ = tag.Lookup("Health").(*nbt.Short).Value
+    mob.health = Health(tag.Lookup("Health").(*nbt.Short).Value)
+    if mobType, ok := Mobs[mob.mobType]; ok && mob.health <= 0 {
+        // Mobs saved as they died would otherwise be undying.
+        mob.health = mobType.MaxHealth
+    }
+
+    if err = mob.effects.UnmarshalNbt(tag); err != nil {
+        return
+    }
     _ = tag.Lookup("HurtTime").(*nbt.Short).Value
 
     return nil
@@ -81,9 +98,9 @@ func (mob *Mob) MarshalNbt(tag nbt.Compound) (err error) {
     tag.Set("DeathTime", &nbt.Short{0})
     tag.Set("FallDistance", &nbt.Float{0})
     tag.Set("Fire", &nbt.Short{0})
-    tag.Set("Health", &nbt.Short{0})
+    tag.Set("Health", &nbt.Short{int16(mob.health)})
     tag.Set("HurtTime", &nbt.Short{0})
-    return nil
+    return mob.effects.MarshalNbt(tag)
 }
 
 func (mob *Mob) SetLook(look LookDegrees) {
@@ -91,48 +108,149 @@ func (mob *Mob) SetLook(look LookDegrees) {
 }
 
 func (mob *Mob) SetBurning(burn bool) {
+    flags := mob.metadataByte(0)
     if burn {
-        mob.metadata[0] |= 0x01
+        flags |= 0x01
     } else {
-        mob.metadata[0] ^= 0x01
+        flags &^= 0x01
     }
+    mob.setMetadata(0, flags)
+}
+
+// metadataByte returns the byte metadata at the given index, or 0 if there is
+// none.
+func (mob *Mob) metadataByte(index byte) byte {
+    value, _ := mob.metadata[index].(byte)
+    return value
 }
 
-func (mob *Mob) Tick(blockQuerier physics.IBlockQuerier) (leftBlock bool) {
+// setMetadata changes the metadata at the given index, and tells clients
+// about the change with the next update.
+func (mob *Mob) setMetadata(index byte, value interface{}) {
+    if mob.metadata[index] == value {
+        return
+    }
+    mob.metadata[index] = value
+    mob.effectPkts = append(mob.effectPkts, &proto.PacketEntityMetadata{
+        EntityId: mob.EntityId,
+        Metadata: proto.EntityMetadataTable{{metadataType(value), index, value}},
+    })
+}
+
+func (mob *Mob) Tick(chunk IChunkBlock) (leftBlock bool) {
+    healthChange, expired := mob.effects.Tick()
+    mob.changeHealth(healthChange, false)
+    for _, id := range expired {
+        mob.effectPkts = append(mob.effectPkts, &proto.PacketEntityRemoveEffect{mob.EntityId, id})
+    }
+
     // TODO: Spontaneous mob movement.
-    return mob.PointObject.Tick(blockQuerier)
+    return mob.PointObject.Tick(chunk)
+}
+
+// AddEffect implements IEffectEntity.AddEffect. Mobs killed by instant harm
+// drop experience.
+func (mob *Mob) AddEffect(chunk IChunkBlock, effect StatusEffect) (killed bool) {
+    healthChange, changed := mob.effects.Add(effect)
+    if changed {
+        mob.effectPkts = append(mob.effectPkts, mob.effects.AddPacket(mob.EntityId, effect.Id))
+    }
+    if mob.changeHealth(healthChange, true) {
+        mob.dropExperience(chunk)
+        return true
+    }
+    return false
+}
+
+// changeHealth applies a change in health from effects, returning true if it
+// killed the mob. Effects over time (e.g poison) do not take the last point of
+// health, unlike instant harm.
+func (mob *Mob) changeHealth(change Health, canKill bool) (killed bool) {
+    mob.health += change
+    if mobType, ok := Mobs[mob.mobType]; ok && mob.health > mobType.MaxHealth {
+        mob.health = mobType.MaxHealth
+    }
+    if mob.health < 1 && !canKill {
+        mob.health = 1
+    }
+    return mob.health <= 0
+}
+
+// dropExperience spawns the experience that the mob drops when it is killed.
+func (mob *Mob) dropExperience(chunk IChunkBlock) {
+    if mobType, ok := Mobs[mob.mobType]; ok && mobType.ExperienceMax > 0 {
+        amount := mobType.ExperienceMin + Experience(chunk.Rand().Intn(int(mobType.ExperienceMax-mobType.ExperienceMin)+1))
+        SpawnExperience(chunk, amount, *mob.Position())
+    }
+}
+
+// Use is called when a player attacks or otherwise clicks on the mob. Mobs
+// that are killed drop experience.
+func (mob *Mob) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if !leftClick {
+        return false
+    }
+
+    mob.health -= held.AttackDamage()
+    if mob.health > 0 {
+        return false
+    }
+
+    mob.dropExperience(chunk)
+    return true
 }
 
 func (mob *Mob) FormatMetadata() proto.EntityMetadataTable {
     x := make(proto.EntityMetadataTable, len(mob.metadata))
     i := 0
     for k, v := range mob.metadata {
-        x[i] = proto.EntityMetadata{0, k, v}
+        x[i] = proto.EntityMetadata{metadataType(v), k, v}
         i++
     }
     return x
 }
 
+// metadataType returns the type that a metadata value is sent to clients as.
+func metadataType(value interface{}) byte {
+    switch value.(type) {
+    case int16:
+        return 1
+    case int32:
+        return 2
+    case float32:
+        return 3
+    case string:
+        return 4
+    }
+    return 0
+}
+
+func (mob *Mob) AwarenessRadius() AbsCoord {
+    return MobAwarenessRadius
+}
+
 func (mob *Mob) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
     pkts = append(pkts, &proto.PacketEntity{mob.EntityId})
     pkts = mob.PointObject.UpdatePackets(pkts, mob.EntityId, mob.look.ToLookBytes())
+    pkts = append(pkts, mob.effectPkts...)
+    mob.effectPkts = nil
     return pkts
 }
 
 func (mob *Mob) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
+    look := mob.look.ToLookBytes()
     pkts = append(pkts,
-        // &proto.PacketMobSpawn{ @TODO 1.5.1
-        //     EntityId: mob.EntityId,
-        //     MobType:  mob.mobType,
-        //     Position: mob.PointObject.LastSentPosition,
-        //     Look:     mob.look.ToLookBytes(),
-        //     Metadata: mob.FormatMetadata(),
-        // },
-        &proto.PacketEntityVelocity{
+        &proto.PacketMobSpawn{
             EntityId: mob.EntityId,
+            MobType:  mob.mobType,
+            Position: mob.PointObject.LastSentPosition,
+            // Mobs' heads face the way that they are looking.
+            Look:     MobLookBytes{look.Yaw, look.Pitch, look.Yaw},
             Velocity: mob.PointObject.LastSentVelocity,
+            Metadata: mob.FormatMetadata(),
         },
     )
+    pkts = mob.effects.Packets(mob.EntityId, pkts)
 
     return pkts
 }
@@ -157,11 +275,11 @@ func NewCreeper() INonPlayerEntity {
 }
 
 func (c *Creeper) SetNormalStatus() {
-    c.Mob.metadata[17] = creeperNormal
+    c.Mob.setMetadata(17, creeperNormal)
 }
 
 func (c *Creeper) CreeperSetBlueAura() {
-    c.Mob.metadata[17] = creeperBlueAura
+    c.Mob.setMetadata(17, creeperBlueAura)
 }
 
 type Skeleton struct {
@@ -196,43 +314,318 @@ func NewZombie() INonPlayerEntity {
 
 // Passive mobs.
 
-type Pig struct {
+const (
+    // Ticks that a baby animal takes to grow up.
+    animalGrowUpTicks = 24000
+    // Ticks after breeding before an animal can breed again.
+    animalBreedCooldownTicks = 6000
+    // Ticks that an animal stays in love after being fed.
+    animalLoveTicks = 600
+
+    // Distance within which animals in love mate.
+    AnimalMateDistance = AbsCoord(8)
+
+    itemTypeIdBucket     = ItemTypeId(325)
+    itemTypeIdMilkBucket = ItemTypeId(335)
+    itemTypeIdShears     = ItemTypeId(359)
+    itemTypeIdWool       = ItemTypeId(35)
+
+    // Metadata index of an animal's age, which clients use to tell if it is a
+    // baby.
+    animalMetadataAge = 12
+)
+
+// Animal is a passive mob that players can breed by feeding it. Baby animals
+// grow up over time, and grow up faster when fed.
+type Animal struct {
     Mob
+    // age is negative while the animal is a baby, counting up to zero when it
+    // grows up. It is positive after the animal has bred, counting down to
+    // zero when it can breed again.
+    age int32
+    // Ticks remaining that the animal is in love.
+    inLove int32
+}
+
+func (animal *Animal) Init(id EntityMobType) {
+    animal.Mob.Init(id)
+    animal.metadata[animalMetadataAge] = int32(0)
+}
+
+func (animal *Animal) animal() *Animal {
+    return animal
+}
+
+func (animal *Animal) IsBaby() bool {
+    return animal.age < 0
+}
+
+func (animal *Animal) IsInLove() bool {
+    return animal.inLove > 0
+}
+
+func (animal *Animal) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = animal.Mob.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    animal.age = 0
+    if ageTag, ok := tag.Lookup("Age").(*nbt.Int); ok {
+        animal.age = ageTag.Value
+    }
+    animal.metadata[animalMetadataAge] = animal.age
+    animal.inLove = 0
+    if inLoveTag, ok := tag.Lookup("InLove").(*nbt.Int); ok {
+        animal.inLove = inLoveTag.Value
+    }
+
+    return
+}
+
+func (animal *Animal) MarshalNbt(tag nbt.Compound) (err error) {
+    if err = animal.Mob.MarshalNbt(tag); err != nil {
+        return
+    }
+    tag.Set("Age", &nbt.Int{animal.age})
+    tag.Set("InLove", &nbt.Int{animal.inLove})
+    return
+}
+
+// setAge changes the age of the animal. Clients are only told about the
+// change when the animal becomes or stops being a baby.
+func (animal *Animal) setAge(age int32) {
+    wasBaby := animal.IsBaby()
+    animal.age = age
+    if animal.IsBaby() != wasBaby {
+        animal.setMetadata(animalMetadataAge, age)
+    }
+}
+
+func (animal *Animal) Tick(chunk IChunkBlock) (leftBlock bool) {
+    switch {
+    case animal.age < 0:
+        animal.setAge(animal.age + 1)
+    case animal.age > 0:
+        animal.age--
+    }
+    if animal.inLove > 0 {
+        animal.inLove--
+    }
+
+    return animal.Mob.Tick(chunk)
+}
+
+// Use asks the player to feed the animal when they right-click on it holding
+// its breeding food. The animal is only fed once the player has used up the
+// food.
+func (animal *Animal) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if leftClick || !animal.eats(held) {
+        return animal.Mob.Use(chunk, player, held, leftClick)
+    }
+
+    player.FeedEntity(*held, animal.EntityId)
+    return false
+}
+
+// eats returns true if the animal would eat the given food.
+func (animal *Animal) eats(food *Slot) bool {
+    mobType, ok := Mobs[animal.mobType]
+    if !ok || mobType.BreedingFood == 0 || food.ItemTypeId != mobType.BreedingFood {
+        return false
+    }
+    return animal.age < 0 || (animal.age == 0 && animal.inLove == 0)
+}
+
+// Feed gives the food to the animal. A baby animal grows up a little, and an
+// adult falls in love if it is ready to breed. It returns false if the animal
+// does not eat the food.
+func (animal *Animal) Feed(food *Slot) bool {
+    if !animal.eats(food) {
+        return false
+    }
+
+    if animal.age < 0 {
+        animal.setAge(animal.age - animal.age/10)
+    } else {
+        animal.inLove = animalLoveTicks
+        animal.effectPkts = append(animal.effectPkts, &proto.PacketEntityStatus{animal.EntityId, EntityStatusInLove})
+    }
+
+    return true
+}
+
+// Mate breeds the animal with another of the same kind. Both must be in love.
+func (animal *Animal) Mate(chunk IChunkBlock, other IBreedable) (baby INonPlayerEntity, ok bool) {
+    mate := other.animal()
+    if mate == animal || mate.mobType != animal.mobType || !animal.IsInLove() || !mate.IsInLove() {
+        return nil, false
+    }
+
+    baby = NewEntityByTypeName(MobNameByType[animal.mobType])
+    breedable, ok := baby.(IBreedable)
+    if !ok {
+        return nil, false
+    }
+    babyAnimal := breedable.animal()
+    babyAnimal.age = -animalGrowUpTicks
+    babyAnimal.metadata[animalMetadataAge] = babyAnimal.age
+    babyAnimal.PointObject.Init(*animal.Position(), AbsVelocity{})
+
+    animal.inLove, mate.inLove = 0, 0
+    animal.age, mate.age = animalBreedCooldownTicks, animalBreedCooldownTicks
+
+    return baby, true
+}
+
+type Pig struct {
+    Animal
 }
 
 func NewPig() INonPlayerEntity {
     p := new(Pig)
-    p.Mob.Init(PigType.Id)
+    p.Animal.Init(PigType.Id)
     return p
 }
 
+// Sheep have wool of a color, which players can shear from them. The color is
+// in the lower bits of metadata 16, and the upper bit is set when sheared.
 type Sheep struct {
-    Mob
+    Animal
 }
 
+const (
+    sheepMetadataWool = 16
+    sheepColorMask    = 0x0f
+    sheepShearedFlag  = 0x10
+)
+
 func NewSheep() INonPlayerEntity {
     s := new(Sheep)
-    s.Mob.Init(SheepType.Id)
+    s.Animal.Init(SheepType.Id)
     return s
 }
 
+func (s *Sheep) Color() ItemData {
+    return ItemData(s.metadataByte(sheepMetadataWool) & sheepColorMask)
+}
+
+func (s *Sheep) SetColor(color ItemData) {
+    s.setMetadata(sheepMetadataWool, s.metadataByte(sheepMetadataWool)&^sheepColorMask|byte(color)&sheepColorMask)
+}
+
+func (s *Sheep) IsSheared() bool {
+    return s.metadataByte(sheepMetadataWool)&sheepShearedFlag != 0
+}
+
+func (s *Sheep) SetSheared(sheared bool) {
+    wool := s.metadataByte(sheepMetadataWool)
+    if sheared {
+        wool |= sheepShearedFlag
+    } else {
+        wool &^= sheepShearedFlag
+    }
+    s.setMetadata(sheepMetadataWool, wool)
+}
+
+func (s *Sheep) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = s.Animal.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    var wool byte
+    if colorTag, ok := tag.Lookup("Color").(*nbt.Byte); ok {
+        wool = byte(colorTag.Value) & sheepColorMask
+    }
+    if shearedTag, ok := tag.Lookup("Sheared").(*nbt.Byte); ok && shearedTag.Value != 0 {
+        wool |= sheepShearedFlag
+    }
+    s.metadata[sheepMetadataWool] = wool
+
+    return
+}
+
+func (s *Sheep) MarshalNbt(tag nbt.Compound) (err error) {
+    if err = s.Animal.MarshalNbt(tag); err != nil {
+        return
+    }
+
+    var sheared int8
+    if s.IsSheared() {
+        sheared = 1
+    }
+    tag.Set("Color", &nbt.Byte{int8(s.Color())})
+    tag.Set("Sheared", &nbt.Byte{sheared})
+
+    return
+}
+
+// Use shears the sheep when a player right-clicks on it holding shears,
+// dropping its wool.
+func (s *Sheep) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if leftClick || held.ItemTypeId != itemTypeIdShears || s.IsSheared() || s.IsBaby() {
+        return s.Animal.Use(chunk, player, held, leftClick)
+    }
+
+    blockLoc := s.Position().ToBlockXyz()
+    if blockLoc == nil {
+        return false
+    }
+
+    s.SetSheared(true)
+    count := ItemCount(1 + chunk.Rand().Intn(3))
+    spawnItemInBlock(chunk, *blockLoc, itemTypeIdWool, count, s.Color())
+    player.WearHeldItem(*held, 1)
+
+    return false
+}
+
+// Mate breeds the sheep with another, giving the baby the wool color of one of
+// its parents.
+func (s *Sheep) Mate(chunk IChunkBlock, other IBreedable) (baby INonPlayerEntity, ok bool) {
+    mate, ok := other.(*Sheep)
+    if !ok {
+        return nil, false
+    }
+    if baby, ok = s.Animal.Mate(chunk, other); !ok {
+        return
+    }
+
+    color := s.Color()
+    if chunk.Rand().Intn(2) == 0 {
+        color = mate.Color()
+    }
+    baby.(*Sheep).SetColor(color)
+
+    return
+}
+
 type Cow struct {
-    Mob
+    Animal
 }
 
 func NewCow() INonPlayerEntity {
     c := new(Cow)
-    c.Mob.Init(CowType.Id)
+    c.Animal.Init(CowType.Id)
     return c
 }
 
+// Use milks the cow when a player right-clicks on it holding a bucket.
+func (c *Cow) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if leftClick || held.ItemTypeId != itemTypeIdBucket || c.IsBaby() {
+        return c.Animal.Use(chunk, player, held, leftClick)
+    }
+
+    player.ConsumeHeldItem(*held, Slot{ItemTypeId: itemTypeIdMilkBucket, Count: 1})
+    return false
+}
+
 type Hen struct {
-    Mob
+    Animal
 }
 
 func NewHen() INonPlayerEntity {
     h := new(Hen)
-    h.Mob.Init(HenType.Id)
+    h.Animal.Init(HenType.Id)
     return h
 }
 
@@ -254,8 +647,8 @@ func NewWolf() INonPlayerEntity {
     w := new(Wolf)
     w.Mob.Init(WolfType.Id)
     // TODO(nictuku): String with an optional owner's username.
-    w.Mob.metadata[17] = 0
-    w.Mob.metadata[16] = 0
-    w.Mob.metadata[18] = 0
+    w.Mob.metadata[17] = byte(0)
+    w.Mob.metadata[16] = byte(0)
+    w.Mob.metadata[18] = byte(0)
     return w
 }
@@ -2,10 +2,12 @@ package gamerules
 
 import (
     "bytes"
+    "math/rand"
     "testing"
 
     "chunkymonkey/proto"
     "chunkymonkey/types"
+    "nbt"
     te "testencoding"
 )
 
@@ -35,15 +37,15 @@ func TestMobSpawn(t *testing.T) {
                     "\x00\x00\x12\x34"+ // EntityId
                     "Z"+ // EntityMobType
                     "\x00\x00\x01`\x00\x00\b\xc0\xff\xff\xea\x80"+ // X, Y, Z
-                    "\a\x0e", // Yaw, Pitch
+                    "\a\x0e\a"+ // Yaw, Pitch, Head yaw
+                    "\x00\x00\x00\x00\x00\x00", // Velocity
                 ),
                 te.AnyOrder(
-                    te.LiteralString("\x00\x00"), // burning=false
-                    te.LiteralString("\x10\x00"), // 16=0 (?)
+                    te.LiteralString("\x00\x00"),             // burning=false
+                    te.LiteralString("\x10\x00"),             // 16=0 (?)
+                    te.LiteralString("\x4c\x00\x00\x00\x00"), // age=0 (int)
                 ),
                 te.LiteralString("\x7f"), // 127 = end of metadata
-                // packetIdEntityVelocity
-                te.LiteralString("\x1c\x00\x00\x12\x34\x00\x00\x00\x00\x00\x00"),
             ),
         },
         {
@@ -64,7 +66,8 @@ func TestMobSpawn(t *testing.T) {
                     "\x00\x00\x56\x78"+ // EntityId
                     "2"+ // EntityMobType
                     "\x00\x00\x01\x60\x00\x00\x08\xc0\xff\xff\xea\x80"+ // X, Y, Z
-                    "\x00\x8d", // Yaw, Pitch
+                    "\x00\x8d\x00"+ // Yaw, Pitch, Head yaw
+                    "\x00\x00\x00\x00\x00\x00", // Velocity
                 ),
                 te.AnyOrder(
                     te.LiteralString("\x00\x01"), // burning=true
@@ -72,8 +75,6 @@ func TestMobSpawn(t *testing.T) {
                     te.LiteralString("\x11\x01"), // blue aura=true
                 ),
                 te.LiteralString("\x7f"), // 127 = end of metadata
-                // packetIdEntityVelocity
-                te.LiteralString("\x1c\x00\x00\x56\x78\x00\x00\x00\x00\x00\x00"),
             ),
         },
     }
@@ -86,3 +87,214 @@ func TestMobSpawn(t *testing.T) {
         }
     }
 }
+
+func TestAnimal_breed(t *testing.T) {
+    carrot := Slot{ItemTypeId: PigType.BreedingFood, Count: 1}
+    wheat := Slot{ItemTypeId: CowType.BreedingFood, Count: 1}
+
+    pig := NewPig().(*Pig)
+    pig.PointObject.Init(types.AbsXyz{1, 64, 1}, types.AbsVelocity{})
+    other := NewPig().(*Pig)
+    cow := NewCow().(*Cow)
+
+    if pig.Feed(&wheat) {
+        t.Errorf("expected pig not to eat wheat")
+    }
+    if _, ok := pig.Mate(nil, other); ok {
+        t.Errorf("expected pigs not in love not to mate")
+    }
+
+    for _, animal := range []*Animal{&pig.Animal, &other.Animal, &cow.Animal} {
+        food := &carrot
+        if animal == &cow.Animal {
+            food = &wheat
+        }
+        if !animal.Feed(food) || !animal.IsInLove() {
+            t.Fatalf("expected animal to fall in love when fed")
+        }
+    }
+    if pig.Feed(&carrot) {
+        t.Errorf("expected pig already in love not to eat")
+    }
+
+    if _, ok := pig.Mate(nil, cow); ok {
+        t.Errorf("expected pig not to mate with a cow")
+    }
+
+    baby, ok := pig.Mate(nil, other)
+    if !ok {
+        t.Fatalf("expected pigs in love to mate")
+    }
+    babyPig, ok := baby.(*Pig)
+    if !ok {
+        t.Fatalf("expected a baby pig, got %T", baby)
+    }
+    if !babyPig.IsBaby() || *babyPig.Position() != *pig.Position() {
+        t.Errorf("expected a baby at %v, got age %d at %v", *pig.Position(), babyPig.age, *babyPig.Position())
+    }
+    if pig.IsInLove() || other.IsInLove() || pig.age != animalBreedCooldownTicks {
+        t.Errorf("expected parents to stop being in love and wait to breed again")
+    }
+    if pig.Feed(&carrot) {
+        t.Errorf("expected pig that has just bred not to eat")
+    }
+
+    // Feeding a baby makes it grow up faster.
+    if !babyPig.Feed(&carrot) || babyPig.age != -animalGrowUpTicks*9/10 {
+        t.Errorf("expected fed baby to have age %d, got %d", -animalGrowUpTicks*9/10, babyPig.age)
+    }
+}
+
+// feedingPlayer records the entities that it is asked to feed, and the items
+// it is given for using up its held item.
+type feedingPlayer struct {
+    IPlayerClient
+    fed    []types.EntityId
+    leaves []Slot
+}
+
+func (player *feedingPlayer) FeedEntity(wasHeld Slot, target types.EntityId) {
+    player.fed = append(player.fed, target)
+}
+
+func (player *feedingPlayer) ConsumeHeldItem(wasHeld Slot, leaves Slot) {
+    player.leaves = append(player.leaves, leaves)
+}
+
+func TestAnimal_Use(t *testing.T) {
+    carrot := Slot{ItemTypeId: PigType.BreedingFood, Count: 1}
+    player := &feedingPlayer{}
+
+    // The pig is only fed once the player has used up the carrot.
+    pig := NewPig().(*Pig)
+    pig.EntityId = 5
+    pig.Use(nil, player, &carrot, false)
+    if len(player.fed) != 1 || player.fed[0] != 5 {
+        t.Errorf("expected player to be asked to feed the pig, got %v", player.fed)
+    }
+    if pig.IsInLove() {
+        t.Errorf("expected pig not to be fed before the carrot is used up")
+    }
+
+    pig.Feed(&carrot)
+    pig.Use(nil, player, &carrot, false)
+    if len(player.fed) != 1 {
+        t.Errorf("expected pig in love not to be offered food, got %v", player.fed)
+    }
+
+    // Milking a cow leaves the player with a bucket of milk.
+    cow := NewCow().(*Cow)
+    cow.Use(nil, player, &Slot{ItemTypeId: itemTypeIdBucket, Count: 1}, false)
+    milk := Slot{ItemTypeId: itemTypeIdMilkBucket, Count: 1}
+    if len(player.leaves) != 1 || !player.leaves[0].Equals(&milk) {
+        t.Errorf("expected player to be given %v, got %v", milk, player.leaves)
+    }
+}
+
+// spawnChunk records the entities added to it.
+type spawnChunk struct {
+    randChunk
+    spawned []INonPlayerEntity
+}
+
+func (chunk *spawnChunk) AddEntity(entity INonPlayerEntity) {
+    chunk.spawned = append(chunk.spawned, entity)
+}
+
+func TestMob_AddEffect(t *testing.T) {
+    chunk := &spawnChunk{randChunk: randChunk{rand: rand.New(rand.NewSource(1))}}
+    pig := NewPig().(*Pig)
+    pig.PointObject.Init(types.AbsXyz{1, 64, 1}, types.AbsVelocity{})
+
+    if pig.AddEffect(chunk, StatusEffect{types.EntityEffectPoison, 0, 100}) {
+        t.Errorf("expected poison not to kill pig")
+    }
+    if pkts := pig.UpdatePackets(nil); len(pkts) == 0 {
+        t.Errorf("expected effect packets to be sent")
+    }
+
+    // Poison does not take the last point of health.
+    if pig.changeHealth(-PigType.MaxHealth, false) || pig.health != 1 {
+        t.Errorf("expected poisoned pig to have health 1, got %d", pig.health)
+    }
+
+    pig.health = PigType.MaxHealth
+    if pig.AddEffect(chunk, StatusEffect{types.EntityEffectHeal, 0, 0}) || pig.health != PigType.MaxHealth {
+        t.Errorf("expected healthy pig to stay at health %d, got %d", PigType.MaxHealth, pig.health)
+    }
+
+    // Instant harm kills, and the pig drops experience.
+    if pig.AddEffect(chunk, StatusEffect{types.EntityEffectHarm, 0, 0}) || pig.health != PigType.MaxHealth-4 {
+        t.Errorf("expected harmed pig to have health %d, got %d", PigType.MaxHealth-4, pig.health)
+    }
+    if !pig.AddEffect(chunk, StatusEffect{types.EntityEffectHarm, 1, 0}) {
+        t.Errorf("expected instant harm II to kill pig with health %d", PigType.MaxHealth-4)
+    }
+    if len(chunk.spawned) == 0 {
+        t.Errorf("expected killed pig to drop experience")
+    }
+}
+
+func TestSheep_Nbt(t *testing.T) {
+    s := NewSheep().(*Sheep)
+    s.PointObject.Init(types.AbsXyz{1, 64, 1}, types.AbsVelocity{})
+    s.SetColor(14)
+    s.SetSheared(true)
+    s.age = -100
+
+    if wool := s.metadataByte(sheepMetadataWool); wool != 14|sheepShearedFlag {
+        t.Errorf("expected wool metadata %#x, got %#x", 14|sheepShearedFlag, wool)
+    }
+
+    tag := nbt.NewCompound()
+    if err := s.MarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error marshalling sheep: %v", err)
+    }
+
+    loaded := NewSheep().(*Sheep)
+    if err := loaded.UnmarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error unmarshalling sheep: %v", err)
+    }
+    if loaded.Color() != 14 || !loaded.IsSheared() || loaded.age != -100 {
+        t.Errorf("expected sheared baby sheep of color 14, got sheared=%t color %d age %d", loaded.IsSheared(), loaded.Color(), loaded.age)
+    }
+
+    // Sheep saved with no health come back at full health.
+    tag.Set("Health", &nbt.Short{0})
+    if err := loaded.UnmarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error unmarshalling sheep: %v", err)
+    }
+    if maxHealth := Mobs[types.MobTypeIdSheep].MaxHealth; loaded.health != maxHealth {
+        t.Errorf("expected dead sheep to be loaded with health %d, got %d", maxHealth, loaded.health)
+    }
+}
+
+func TestAnimal_metadataUpdates(t *testing.T) {
+    metadataPkts := func(mob *Mob) (table proto.EntityMetadataTable) {
+        for _, pkt := range mob.UpdatePackets(nil) {
+            if pkt, ok := pkt.(*proto.PacketEntityMetadata); ok {
+                table = append(table, pkt.Metadata...)
+            }
+        }
+        return
+    }
+
+    s := NewSheep().(*Sheep)
+    s.SetColor(3)
+    s.SetSheared(true)
+    if table := metadataPkts(&s.Mob); len(table) != 2 || table[1] != (proto.EntityMetadata{0, sheepMetadataWool, byte(3 | sheepShearedFlag)}) {
+        t.Errorf("expected wool color and shearing to be sent, got %v", table)
+    }
+
+    // Only the animal becoming a baby or growing up is sent.
+    pig := NewPig().(*Pig)
+    pig.setAge(-10)
+    pig.setAge(-5)
+    if table := metadataPkts(&pig.Mob); len(table) != 1 || table[0] != (proto.EntityMetadata{2, animalMetadataAge, int32(-10)}) {
+        t.Errorf("expected pig becoming a baby to be sent, got %v", table)
+    }
+    pig.setAge(0)
+    if table := metadataPkts(&pig.Mob); len(table) != 1 || table[0] != (proto.EntityMetadata{2, animalMetadataAge, int32(0)}) {
+        t.Errorf("expected pig growing up to be sent, got %v", table)
+    }
+}
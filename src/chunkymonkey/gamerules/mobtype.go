@@ -5,8 +5,15 @@ import (
 )
 
 type MobType struct {
-    Id   EntityMobType
-    Name string
+    Id        EntityMobType
+    Name      string
+    MaxHealth Health
+    // Range of experience dropped when the mob is killed.
+    ExperienceMin Experience
+    ExperienceMax Experience
+    // BreedingFood is the item that animals of the type are fed to breed
+    // them. Zero if the mob cannot be bred.
+    BreedingFood ItemTypeId
 }
 
 type MobTypeMap map[EntityMobType]*MobType
@@ -27,19 +34,21 @@ var Mobs = MobTypeMap{
     MobTypeIdHen:          &HenType,
     MobTypeIdSquid:        &SquidType,
     MobTypeIdWolf:         &WolfType,
+    MobTypeIdVillager:     &VillagerType,
 }
 
-var CreeperType = MobType{MobTypeIdCreeper, "creeper"}
-var SkeletonType = MobType{MobTypeIdSkeleton, "skeleton"}
-var SpiderType = MobType{MobTypeIdSpider, "spider"}
-var GiantZombieType = MobType{MobTypeIdGiantZombie, "giantzombie"}
-var ZombieType = MobType{MobTypeIdZombie, "zombie"}
-var SlimeType = MobType{MobTypeIdSlime, "slime"}
-var GhastType = MobType{MobTypeIdGhast, "ghast"}
-var ZombiePigmanType = MobType{MobTypeIdZombiePigman, "zombiepigman"}
-var PigType = MobType{MobTypeIdPig, "pig"}
-var SheepType = MobType{MobTypeIdSheep, "sheep"}
-var CowType = MobType{MobTypeIdCow, "cow"}
-var HenType = MobType{MobTypeIdHen, "hen"}
-var SquidType = MobType{MobTypeIdSquid, "squid"}
-var WolfType = MobType{MobTypeIdWolf, "wolf"}
+var CreeperType = MobType{MobTypeIdCreeper, "creeper", 20, 5, 5, 0}
+var SkeletonType = MobType{MobTypeIdSkeleton, "skeleton", 20, 5, 5, 0}
+var SpiderType = MobType{MobTypeIdSpider, "spider", 16, 5, 5, 0}
+var GiantZombieType = MobType{MobTypeIdGiantZombie, "giantzombie", 100, 5, 5, 0}
+var ZombieType = MobType{MobTypeIdZombie, "zombie", 20, 5, 5, 0}
+var SlimeType = MobType{MobTypeIdSlime, "slime", 16, 1, 4, 0}
+var GhastType = MobType{MobTypeIdGhast, "ghast", 10, 5, 5, 0}
+var ZombiePigmanType = MobType{MobTypeIdZombiePigman, "zombiepigman", 20, 5, 5, 0}
+var PigType = MobType{MobTypeIdPig, "pig", 10, 1, 3, 391}
+var SheepType = MobType{MobTypeIdSheep, "sheep", 8, 1, 3, 296}
+var CowType = MobType{MobTypeIdCow, "cow", 10, 1, 3, 296}
+var HenType = MobType{MobTypeIdHen, "hen", 4, 1, 3, 295}
+var SquidType = MobType{MobTypeIdSquid, "squid", 10, 1, 3, 0}
+var WolfType = MobType{MobTypeIdWolf, "wolf", 8, 1, 3, 0}
+var VillagerType = MobType{MobTypeIdVillager, "villager", 20, 0, 0, 0}
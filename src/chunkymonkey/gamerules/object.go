@@ -79,6 +79,14 @@ func (object *Object) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
     )
 }
 
+func (object *Object) Tick(chunk IChunkBlock) (leftBlock bool) {
+    return object.PointObject.Tick(chunk)
+}
+
+func (object *Object) AwarenessRadius() AbsCoord {
+    return ObjectAwarenessRadius
+}
+
 func (object *Object) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
     pkts = append(pkts, &proto.PacketEntity{object.EntityId})
 
@@ -88,22 +96,6 @@ func (object *Object) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
     return pkts
 }
 
-func NewBoat() INonPlayerEntity {
-    return NewObject(ObjTypeIdBoat)
-}
-
-func NewMinecart() INonPlayerEntity {
-    return NewObject(ObjTypeIdMinecart)
-}
-
-func NewStorageCart() INonPlayerEntity {
-    return NewObject(ObjTypeIdStorageCart)
-}
-
-func NewPoweredCart() INonPlayerEntity {
-    return NewObject(ObjTypeIdPoweredCart)
-}
-
 func NewEnderCrystal() INonPlayerEntity {
     return NewObject(ObjTypeIdEnderCrystal)
 }
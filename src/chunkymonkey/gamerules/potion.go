@@ -0,0 +1,100 @@
+package gamerules
+
+import (
+    . "chunkymonkey/types"
+)
+
+const (
+    ItemTypeIdPotion      = ItemTypeId(373)
+    ItemTypeIdGlassBottle = ItemTypeId(374)
+)
+
+// Bits of potion item data.
+const (
+    // The lower bits of the data choose the effect.
+    potionEffectMask = 0xf
+    // Strong potions have a more powerful effect for a shorter time.
+    potionStrongFlag = 0x20
+    // Extended potions last longer.
+    potionExtendedFlag = 0x40
+    // Splash potions are thrown rather than drunk.
+    potionSplashFlag = 0x4000
+)
+
+const (
+    // Players and mobs within this distance of where a splash potion lands are
+    // affected by it.
+    PotionSplashRadius = AbsCoord(4)
+)
+
+type potionType struct {
+    effect           EntityEffect
+    duration         Ticks
+    extendedDuration Ticks
+}
+
+// Potion types by the lower bits of their item data. Instant effects have no
+// duration.
+var potionTypes = map[ItemData]potionType{
+    1:  {EntityEffectRegeneration, 45 * TicksPerSecond, 120 * TicksPerSecond},
+    2:  {EntityEffectMoveFaster, 180 * TicksPerSecond, 480 * TicksPerSecond},
+    3:  {EntityEffectFireResistance, 180 * TicksPerSecond, 480 * TicksPerSecond},
+    4:  {EntityEffectPoison, 45 * TicksPerSecond, 120 * TicksPerSecond},
+    5:  {EntityEffectHeal, 0, 0},
+    6:  {EntityEffectNightVision, 180 * TicksPerSecond, 480 * TicksPerSecond},
+    8:  {EntityEffectWeakness, 90 * TicksPerSecond, 240 * TicksPerSecond},
+    9:  {EntityEffectDamageBoost, 180 * TicksPerSecond, 480 * TicksPerSecond},
+    10: {EntityEffectMoveSlower, 90 * TicksPerSecond, 240 * TicksPerSecond},
+    12: {EntityEffectHarm, 0, 0},
+    14: {EntityEffectInvisibility, 180 * TicksPerSecond, 480 * TicksPerSecond},
+}
+
+// IsDrinkablePotion returns true if the slot holds a potion that can be
+// drunk, including water bottles and potions without an effect.
+func (s *Slot) IsDrinkablePotion() bool {
+    return s.ItemTypeId == ItemTypeIdPotion && s.Data&potionSplashFlag == 0
+}
+
+// IsSplashPotion returns true if the slot holds a potion that is thrown.
+func (s *Slot) IsSplashPotion() bool {
+    return s.ItemTypeId == ItemTypeIdPotion && s.Data&potionSplashFlag != 0
+}
+
+// PotionEffect returns the effect of drinking a potion with the given item
+// data. ok=false if the potion has no effect.
+func PotionEffect(data ItemData) (effect StatusEffect, ok bool) {
+    potion, ok := potionTypes[data&potionEffectMask]
+    if !ok {
+        return
+    }
+
+    effect.Id = potion.effect
+    switch {
+    case data&potionStrongFlag != 0:
+        effect.Amplifier = 1
+        effect.Duration = potion.duration / 2
+    case data&potionExtendedFlag != 0:
+        effect.Duration = potion.extendedDuration
+    default:
+        effect.Duration = potion.duration
+    }
+
+    return effect, true
+}
+
+// SplashPotionEffect returns the effect of a splash potion with the given item
+// data on a player or mob at the given distance from where it landed. Lasting
+// effects last three quarters as long as those of drunk potions, and less the
+// further away from the splash. ok=false if the potion has no effect there.
+func SplashPotionEffect(data ItemData, distance AbsCoord) (effect StatusEffect, ok bool) {
+    if distance >= PotionSplashRadius {
+        return effect, false
+    }
+    if effect, ok = PotionEffect(data); !ok || effect.IsInstant() {
+        return
+    }
+
+    scale := 1 - float64(distance/PotionSplashRadius)
+    effect.Duration = Ticks(float64(effect.Duration) * 3 / 4 * scale)
+    return effect, effect.Duration > 0
+}
@@ -31,8 +31,8 @@ func Benchmark_RecipeSet_Match_Simple2x2(b *testing.B) {
         panic(err)
     }
 
-    empty := Slot{0, 0, 0}
-    log := Slot{17, 1, 0}
+    empty := Slot{0, 0, 0, nil}
+    log := Slot{17, 1, 0, nil}
 
     inputs := Slots(log, empty, empty, empty)
 
@@ -53,7 +53,7 @@ func Benchmark_RecipeSet_Match_Nothing2x2(b *testing.B) {
         panic(err)
     }
 
-    log := Slot{17, 1, 0}
+    log := Slot{17, 1, 0, nil}
 
     inputs := Slots(log, log, log, log)
 
@@ -110,9 +110,9 @@ func TestLoadRecipes(t *testing.T) {
             Width:   1,
             Height:  1,
             Input: []Slot{
-                {17, 0, 0},
+                {17, 0, 0, nil},
             },
-            Output: Slot{5, 4, 0},
+            Output: Slot{5, 4, 0, nil},
         },
         &recipes.recipes[0],
     )
@@ -124,9 +124,9 @@ func TestLoadRecipes(t *testing.T) {
             Width:   1,
             Height:  1,
             Input: []Slot{
-                {17, 0, 1},
+                {17, 0, 1, nil},
             },
-            Output: Slot{5, 4, 0},
+            Output: Slot{5, 4, 0, nil},
         },
         &recipes.recipes[1],
     )
@@ -138,9 +138,9 @@ func TestLoadRecipes(t *testing.T) {
             Width:   1,
             Height:  1,
             Input: []Slot{
-                {17, 0, 2},
+                {17, 0, 2, nil},
             },
-            Output: Slot{5, 4, 0},
+            Output: Slot{5, 4, 0, nil},
         },
         &recipes.recipes[2],
     )
@@ -152,17 +152,17 @@ func TestLoadRecipes(t *testing.T) {
             Width:   3,
             Height:  3,
             Input: []Slot{
-                {289, 0, 0},
-                {12, 0, 0},
-                {289, 0, 0},
-                {12, 0, 0},
-                {289, 0, 0},
-                {12, 0, 0},
-                {289, 0, 0},
-                {12, 0, 0},
-                {289, 0, 0},
+                {289, 0, 0, nil},
+                {12, 0, 0, nil},
+                {289, 0, 0, nil},
+                {12, 0, 0, nil},
+                {289, 0, 0, nil},
+                {12, 0, 0, nil},
+                {289, 0, 0, nil},
+                {12, 0, 0, nil},
+                {289, 0, 0, nil},
             },
-            Output: Slot{46, 1, 0},
+            Output: Slot{46, 1, 0, nil},
         },
         &recipes.recipes[3],
     )
@@ -174,12 +174,12 @@ func TestLoadRecipes(t *testing.T) {
             Width:   2,
             Height:  2,
             Input: []Slot{
-                {318, 0, 0},
-                {0, 0, 0},
-                {0, 0, 0},
-                {265, 0, 0},
+                {318, 0, 0, nil},
+                {0, 0, 0, nil},
+                {0, 0, 0, nil},
+                {265, 0, 0, nil},
             },
-            Output: Slot{259, 1, 0},
+            Output: Slot{259, 1, 0, nil},
         },
         &recipes.recipes[4],
     )
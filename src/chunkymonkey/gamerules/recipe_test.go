@@ -30,12 +30,12 @@ func TestRecipeSet_Match(t *testing.T) {
         t.Fatal("Failed to load recipes for match test")
     }
 
-    empty := Slot{0, 0, 0}
-    plank := Slot{5, 1, 0}
-    log := Slot{17, 1, 0}
-    flintAndSteel := Slot{259, 1, 0}
-    iron := Slot{265, 1, 0}
-    flint := Slot{318, 1, 0}
+    empty := Slot{0, 0, 0, nil}
+    plank := Slot{5, 1, 0, nil}
+    log := Slot{17, 1, 0, nil}
+    flintAndSteel := Slot{259, 1, 0, nil}
+    iron := Slot{265, 1, 0, nil}
+    flint := Slot{318, 1, 0, nil}
 
     tests := []struct {
         comment string
@@ -74,25 +74,25 @@ func TestRecipeSet_Match(t *testing.T) {
             "L.\n..",
             2, 2,
             Slots(log, empty, empty, empty),
-            &Slot{5, 4, 0},
+            &Slot{5, 4, 0, nil},
         },
         {
             ".L\n..",
             2, 2,
             Slots(empty, log, empty, empty),
-            &Slot{5, 4, 0},
+            &Slot{5, 4, 0, nil},
         },
         {
             "..\nL.",
             2, 2,
             Slots(empty, empty, log, empty),
-            &Slot{5, 4, 0},
+            &Slot{5, 4, 0, nil},
         },
         {
             "..\n.L",
             2, 2,
             Slots(empty, empty, empty, log),
-            &Slot{5, 4, 0},
+            &Slot{5, 4, 0, nil},
         },
         // Flint and steel
         {
@@ -2,6 +2,7 @@ package gamerules
 
 import (
     "errors"
+    "reflect"
 
     "chunkymonkey/proto"
     . "chunkymonkey/types"
@@ -14,23 +15,39 @@ type Slot struct {
     ItemTypeId ItemTypeId
     Count      ItemCount
     Data       ItemData
+    // Nbt holds extra item data such as names, lore and enchantments. It is
+    // nil for most items. It is shared between slots when items are moved or
+    // split, so it must be replaced rather than modified in place.
+    Nbt nbt.Compound
 }
 
 func (s *Slot) Clear() {
     s.ItemTypeId = 0
     s.Count = 0
     s.Data = 0
+    s.Nbt = nil
 }
 
 func (s *Slot) Equals(other *Slot) bool {
     return (s.ItemTypeId == other.ItemTypeId &&
         s.Count == other.Count &&
-        s.Data == other.Data)
+        s.Data == other.Data &&
+        nbtEquals(s.Nbt, other.Nbt))
 }
 
+// IsSameType returns true if items in the two slots can stack together.
 func (s *Slot) IsSameType(other *Slot) bool {
     return (s.ItemTypeId == other.ItemTypeId &&
-        s.Data == other.Data)
+        s.Data == other.Data &&
+        nbtEquals(s.Nbt, other.Nbt))
+}
+
+// nbtEquals compares item NBT data. A nil compound is the same as an empty one.
+func nbtEquals(a, b nbt.Compound) bool {
+    if len(a) == 0 || len(b) == 0 {
+        return len(a) == len(b)
+    }
+    return reflect.DeepEqual(a, b)
 }
 
 func (s *Slot) IsValidType() (ok bool) {
@@ -62,6 +79,7 @@ func (s *Slot) Normalize() {
     if s.Count == 0 || s.ItemTypeId == 0 {
         s.Count = 0
         s.ItemTypeId = 0
+        s.Nbt = nil
     }
 }
 
@@ -77,31 +95,54 @@ func (s *Slot) ItemType() (itemType *ItemType) {
     return
 }
 
+// IsTool returns true if the item in the slot is a tool. Tools are not used up
+// when they place blocks (e.g flint and steel lighting fire).
+func (s *Slot) IsTool() bool {
+    itemType := s.ItemType()
+    return itemType != nil && itemType.ToolType != 0
+}
+
+// PlacedBlockId returns the type of block that the item in the slot places
+// when used against a block. ok=false if the item does not place a block.
+func (s *Slot) PlacedBlockId() (blockId BlockId, ok bool) {
+    if blockId, ok = s.ItemTypeId.ToBlockId(); ok {
+        return
+    }
+    if itemType := s.ItemType(); itemType != nil && itemType.PlacesBlock != BlockIdAir {
+        return itemType.PlacesBlock, true
+    }
+    return BlockIdAir, false
+}
+
 func (s *Slot) Attr() (ItemTypeId, ItemCount, ItemData) {
     return s.ItemTypeId, s.Count, s.Data
 }
 
 func (s *Slot) SetItemSlot(itemSlot *proto.ItemSlot) {
     if itemSlot.ItemTypeId == -1 || itemSlot.ItemTypeId == 0 {
-        s.ItemTypeId = 0
-        s.Count = 0
-        s.Data = 0
+        s.Clear()
     } else {
         s.ItemTypeId = itemSlot.ItemTypeId
         s.Count = itemSlot.Count
         s.Data = itemSlot.Data
+        s.Nbt = itemSlot.Nbt
+        if len(s.Nbt) == 0 {
+            s.Nbt = nil
+        }
     }
 }
 
 func (s *Slot) SetWindowSlot(windowSlot *proto.ItemSlot) {
-    if windowSlot.ItemTypeId == -1 || windowSlot.ItemTypeId == 0 {
-        s.ItemTypeId = 0
-        s.Count = 0
-        s.Data = 0
-    } else {
-        s.ItemTypeId = windowSlot.ItemTypeId
-        s.Count = windowSlot.Count
-        s.Data = windowSlot.Data
+    s.SetItemSlot(windowSlot)
+}
+
+// ItemSlot returns the proto version of the slot's contents.
+func (s *Slot) ItemSlot() proto.ItemSlot {
+    return proto.ItemSlot{
+        ItemTypeId: s.ItemTypeId,
+        Count:      s.Count,
+        Data:       s.Data,
+        Nbt:        s.Nbt,
     }
 }
 
@@ -109,24 +150,15 @@ func (s *Slot) UpdatePacket(windowId WindowId, slotIndex SlotId) *proto.PacketWi
     return &proto.PacketWindowSetSlot{
         WindowId:  windowId,
         SlotIndex: slotIndex,
-        Item: proto.ItemSlot{
-            ItemTypeId: s.ItemTypeId,
-            Count:      s.Count,
-            Data:       s.Data,
-        },
+        Item:      s.ItemSlot(),
     }
 }
 
 func (s *Slot) EquipmentUpdatePacket(entityId EntityId, slotId SlotId) *proto.PacketEntityEquipment {
-    sid := proto.ItemSlot{
-        ItemTypeId: s.ItemTypeId,
-        Count:      s.Count,
-        Data:       s.Data,
-    }
     return &proto.PacketEntityEquipment{
         EntityId: entityId,
         Slot:     slotId,
-        Item:     sid,
+        Item:     s.ItemSlot(),
     }
 }
 
@@ -135,6 +167,7 @@ func (s *Slot) setCount(count ItemCount) {
     if s.Count == 0 {
         s.ItemTypeId = 0
         s.Data = 0
+        s.Nbt = nil
     }
 }
 
@@ -164,6 +197,7 @@ func (s *Slot) Add(src *Slot) (changed bool) {
         changed = true
 
         s.Data = src.Data
+        s.Nbt = src.Nbt
 
         s.setCount(s.Count + toTransfer)
         src.setCount(src.Count - toTransfer)
@@ -219,6 +253,11 @@ func (s *Slot) Swap(src *Slot) (changed bool) {
         changed = true
     }
 
+    if !nbtEquals(s.Nbt, src.Nbt) {
+        s.Nbt, src.Nbt = src.Nbt, s.Nbt
+        changed = true
+    }
+
     return
 }
 
@@ -234,6 +273,7 @@ func (s *Slot) Split(src *Slot) (changed bool) {
     changed = true
     src.ItemTypeId = s.ItemTypeId
     src.Data = s.Data
+    src.Nbt = s.Nbt
 
     count := s.Count >> 1
     odd := s.Count & 1
@@ -265,6 +305,7 @@ func (s *Slot) AddOne(src *Slot) (changed bool) {
     s.setCount(s.Count + 1)
     s.ItemTypeId = src.ItemTypeId
     s.Data = src.Data
+    s.Nbt = src.Nbt
     src.setCount(src.Count - 1)
 
     return
@@ -300,6 +341,16 @@ func (s *Slot) UnmarshalNbt(tag nbt.Compound) (err error) {
     s.Count = ItemCount(countTag.Value)
     s.Data = ItemData(damageTag.Value)
 
+    s.Nbt = nil
+    if extraTag := tag.Lookup("tag"); extraTag != nil {
+        if s.Nbt, ok = extraTag.(nbt.Compound); !ok {
+            return errors.New("tag tag not Compound")
+        }
+        if len(s.Nbt) == 0 {
+            s.Nbt = nil
+        }
+    }
+
     return
 }
 
@@ -307,5 +358,19 @@ func (s *Slot) MarshalNbt(tag nbt.Compound) (err error) {
     tag.Set("id", &nbt.Short{int16(s.ItemTypeId)})
     tag.Set("Count", &nbt.Byte{int8(s.Count)})
     tag.Set("Damage", &nbt.Short{int16(s.Data)})
+    if len(s.Nbt) > 0 {
+        tag.Set("tag", s.Nbt)
+    }
     return nil
 }
+
+// setNbtTag sets a named tag in the item's NBT. The item gets a new NBT
+// compound, as the old one may be shared with other slots.
+func (s *Slot) setNbtTag(name string, value nbt.ITag) {
+    tag := nbt.NewCompound()
+    for n, v := range s.Nbt {
+        tag.Set(n, v)
+    }
+    tag.Set(name, value)
+    s.Nbt = tag
+}
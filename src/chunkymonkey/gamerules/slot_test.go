@@ -1,10 +1,13 @@
 package gamerules
 
 import (
+    "bytes"
     "fmt"
     "testing"
 
+    "chunkymonkey/proto"
     . "chunkymonkey/types"
+    "nbt"
 )
 
 func slotEq(s1, s2 *Slot) bool {
@@ -68,6 +71,7 @@ func makeItemType(id ItemTypeId) {
 
 // Tests cases that are common to both Slot.Add and Slot.AddWhole.
 func TestSlot_Add_Common(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
     Items = make(ItemTypeMap)
     apple := ItemTypeId(1)
     orange := ItemTypeId(2)
@@ -78,70 +82,70 @@ func TestSlot_Add_Common(t *testing.T) {
     tests := []slotTest{
         {
             "one empty slot added to another",
-            Slot{0, 0, 0}, Slot{0, 0, 0},
-            Slot{0, 0, 0}, Slot{0, 0, 0},
+            Slot{0, 0, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{0, 0, 0, nil}, Slot{0, 0, 0, nil},
             false,
         },
         // Tests involving the same item types: (or empty plus an item)
         {
             "1 + 0 => 1 + 0",
-            Slot{apple, 1, 0}, Slot{0, 0, 0},
-            Slot{apple, 1, 0}, Slot{0, 0, 0},
+            Slot{apple, 1, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{apple, 1, 0, nil}, Slot{0, 0, 0, nil},
             false,
         },
         {
             "1 + 1 => 2 + 0",
-            Slot{apple, 1, 0}, Slot{apple, 1, 0},
-            Slot{apple, 2, 0}, Slot{0, 0, 0},
+            Slot{apple, 1, 0, nil}, Slot{apple, 1, 0, nil},
+            Slot{apple, 2, 0, nil}, Slot{0, 0, 0, nil},
             true,
         },
         {
             "0 + 20 => 20 + 0",
-            Slot{0, 0, 0}, Slot{apple, 20, 0},
-            Slot{apple, 20, 0}, Slot{0, 0, 0},
+            Slot{0, 0, 0, nil}, Slot{apple, 20, 0, nil},
+            Slot{apple, 20, 0, nil}, Slot{0, 0, 0, nil},
             true,
         },
         {
             "0 + 64 => 64 + 0",
-            Slot{0, 0, 0}, Slot{apple, 64, 0},
-            Slot{apple, 64, 0}, Slot{0, 0, 0},
+            Slot{0, 0, 0, nil}, Slot{apple, 64, 0, nil},
+            Slot{apple, 64, 0, nil}, Slot{0, 0, 0, nil},
             true,
         },
         {
             "65 + 1 => 65 + 1 (already above max count)",
-            Slot{apple, 65, 0}, Slot{apple, 1, 0},
-            Slot{apple, 65, 0}, Slot{apple, 1, 0},
+            Slot{apple, 65, 0, nil}, Slot{apple, 1, 0, nil},
+            Slot{apple, 65, 0, nil}, Slot{apple, 1, 0, nil},
             false,
         },
         {
             "64 + 64 => 64 + 64",
-            Slot{apple, 64, 0}, Slot{apple, 64, 0},
-            Slot{apple, 64, 0}, Slot{apple, 64, 0},
+            Slot{apple, 64, 0, nil}, Slot{apple, 64, 0, nil},
+            Slot{apple, 64, 0, nil}, Slot{apple, 64, 0, nil},
             false,
         },
         {
             "1 + 1 => 1 + 1 where items' \"Data\" value differs",
-            Slot{apple, 1, 5}, Slot{apple, 1, 6},
-            Slot{apple, 1, 5}, Slot{apple, 1, 6},
+            Slot{apple, 1, 5, nil}, Slot{apple, 1, 6, nil},
+            Slot{apple, 1, 5, nil}, Slot{apple, 1, 6, nil},
             false,
         },
         {
             "1 + 1 => 2 + 0 where items' \"Data\" value is the same",
-            Slot{apple, 1, 5}, Slot{apple, 1, 5},
-            Slot{apple, 2, 5}, Slot{0, 0, 0},
+            Slot{apple, 1, 5, nil}, Slot{apple, 1, 5, nil},
+            Slot{apple, 2, 5, nil}, Slot{0, 0, 0, nil},
             true,
         },
         {
             "0 + 1 => 1 + 0 - carrying the \"Data\" value",
-            Slot{0, 0, 0}, Slot{apple, 1, 5},
-            Slot{apple, 1, 5}, Slot{0, 0, 0},
+            Slot{0, 0, 0, nil}, Slot{apple, 1, 5, nil},
+            Slot{apple, 1, 5, nil}, Slot{0, 0, 0, nil},
             true,
         },
         // Tests involving different item types:
         {
             "different item types don't mingle",
-            Slot{apple, 5, 0}, Slot{orange, 5, 0},
-            Slot{apple, 5, 0}, Slot{orange, 5, 0},
+            Slot{apple, 5, 0, nil}, Slot{orange, 5, 0, nil},
+            Slot{apple, 5, 0, nil}, Slot{orange, 5, 0, nil},
             false,
         },
     }
@@ -164,6 +168,7 @@ func TestSlot_Add_Common(t *testing.T) {
 }
 
 func TestSlot_Add(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
     Items = make(ItemTypeMap)
     apple := ItemTypeId(1)
     makeItemType(apple)
@@ -171,8 +176,8 @@ func TestSlot_Add(t *testing.T) {
     tests := []slotTest{
         {
             "32 + 33 => 64 + 1 (hitting max count)",
-            Slot{apple, 32, 0}, Slot{apple, 33, 0},
-            Slot{apple, 64, 0}, Slot{apple, 1, 0},
+            Slot{apple, 32, 0, nil}, Slot{apple, 33, 0, nil},
+            Slot{apple, 64, 0, nil}, Slot{apple, 1, 0, nil},
             true,
         },
     }
@@ -186,6 +191,7 @@ func TestSlot_Add(t *testing.T) {
 }
 
 func TestSlot_AddWhole(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
     Items = make(ItemTypeMap)
     apple := ItemTypeId(1)
     makeItemType(apple)
@@ -193,8 +199,8 @@ func TestSlot_AddWhole(t *testing.T) {
     tests := []slotTest{
         {
             "32 + 33 => 32 + 33 (hitting max count)",
-            Slot{apple, 32, 0}, Slot{apple, 33, 0},
-            Slot{apple, 32, 0}, Slot{apple, 33, 0},
+            Slot{apple, 32, 0, nil}, Slot{apple, 33, 0, nil},
+            Slot{apple, 32, 0, nil}, Slot{apple, 33, 0, nil},
             false,
         },
     }
@@ -208,6 +214,7 @@ func TestSlot_AddWhole(t *testing.T) {
 }
 
 func TestSlot_Swap(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
     Items = make(ItemTypeMap)
     apple := ItemTypeId(1)
     orange := ItemTypeId(2)
@@ -218,14 +225,14 @@ func TestSlot_Swap(t *testing.T) {
     tests := []slotTest{
         {
             "swapping unequal slots",
-            Slot{apple, 2, 3}, Slot{orange, 5, 6},
-            Slot{orange, 5, 6}, Slot{apple, 2, 3},
+            Slot{apple, 2, 3, nil}, Slot{orange, 5, 6, nil},
+            Slot{orange, 5, 6, nil}, Slot{apple, 2, 3, nil},
             true,
         },
         {
             "swapping equal slots",
-            Slot{apple, 2, 3}, Slot{apple, 2, 3},
-            Slot{apple, 2, 3}, Slot{apple, 2, 3},
+            Slot{apple, 2, 3, nil}, Slot{apple, 2, 3, nil},
+            Slot{apple, 2, 3, nil}, Slot{apple, 2, 3, nil},
             false,
         },
     }
@@ -239,6 +246,7 @@ func TestSlot_Swap(t *testing.T) {
 }
 
 func TestSlot_Split(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
     Items = make(ItemTypeMap)
     apple := ItemTypeId(1)
     orange := ItemTypeId(2)
@@ -250,59 +258,59 @@ func TestSlot_Split(t *testing.T) {
         // No-op tests.
         {
             "splitting an empty slot",
-            Slot{0, 0, 0}, Slot{0, 0, 0},
-            Slot{0, 0, 0}, Slot{0, 0, 0},
+            Slot{0, 0, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{0, 0, 0, nil}, Slot{0, 0, 0, nil},
             false,
         },
         {
             "splitting from a non-empty slot to another non-empty",
-            Slot{apple, 2, 0}, Slot{apple, 3, 0},
-            Slot{apple, 2, 0}, Slot{apple, 3, 0},
+            Slot{apple, 2, 0, nil}, Slot{apple, 3, 0, nil},
+            Slot{apple, 2, 0, nil}, Slot{apple, 3, 0, nil},
             false,
         },
         {
             "splitting from an empty slot to a non-empty",
-            Slot{0, 0, 0}, Slot{apple, 3, 0},
-            Slot{0, 0, 0}, Slot{apple, 3, 0},
+            Slot{0, 0, 0, nil}, Slot{apple, 3, 0, nil},
+            Slot{0, 0, 0, nil}, Slot{apple, 3, 0, nil},
             false,
         },
         {
             "splitting from a non-empty slot to another non-empty with" +
                 " incompatible types",
-            Slot{apple, 2, 0}, Slot{orange, 3, 0},
-            Slot{apple, 2, 0}, Slot{orange, 3, 0},
+            Slot{apple, 2, 0, nil}, Slot{orange, 3, 0, nil},
+            Slot{apple, 2, 0, nil}, Slot{orange, 3, 0, nil},
             false,
         },
         // Remaining tests should all result in changes. They all take from a
         // non-empty subject slot and into the src empty slot.
         {
             "splitting even-numbered stack",
-            Slot{apple, 64, 0}, Slot{0, 0, 0},
-            Slot{apple, 32, 0}, Slot{apple, 32, 0},
+            Slot{apple, 64, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{apple, 32, 0, nil}, Slot{apple, 32, 0, nil},
             true,
         },
         {
             "splitting odd-numbered stack",
-            Slot{apple, 5, 0}, Slot{0, 0, 0},
-            Slot{apple, 2, 0}, Slot{apple, 3, 0},
+            Slot{apple, 5, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{apple, 2, 0, nil}, Slot{apple, 3, 0, nil},
             true,
         },
         {
             "splitting single-item stack",
-            Slot{apple, 1, 0}, Slot{0, 0, 0},
-            Slot{0, 0, 0}, Slot{apple, 1, 0},
+            Slot{apple, 1, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{0, 0, 0, nil}, Slot{apple, 1, 0, nil},
             true,
         },
         {
             "item type and data copy",
-            Slot{apple, 64, 5}, Slot{0, 0, 0},
-            Slot{apple, 32, 5}, Slot{apple, 32, 5},
+            Slot{apple, 64, 5, nil}, Slot{0, 0, 0, nil},
+            Slot{apple, 32, 5, nil}, Slot{apple, 32, 5, nil},
             true,
         },
         {
             "item type and data move",
-            Slot{apple, 1, 5}, Slot{0, 0, 0},
-            Slot{0, 0, 0}, Slot{apple, 1, 5},
+            Slot{apple, 1, 5, nil}, Slot{0, 0, 0, nil},
+            Slot{0, 0, 0, nil}, Slot{apple, 1, 5, nil},
             true,
         },
     }
@@ -316,6 +324,7 @@ func TestSlot_Split(t *testing.T) {
 }
 
 func TestSlot_AddOne(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
     Items = make(ItemTypeMap)
     apple := ItemTypeId(1)
     orange := ItemTypeId(2)
@@ -327,52 +336,52 @@ func TestSlot_AddOne(t *testing.T) {
         // No-op tests.
         {
             "adding from empty to empty",
-            Slot{0, 0, 0}, Slot{0, 0, 0},
-            Slot{0, 0, 0}, Slot{0, 0, 0},
+            Slot{0, 0, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{0, 0, 0, nil}, Slot{0, 0, 0, nil},
             false,
         },
         {
             "adding from empty to non-empty",
-            Slot{apple, 1, 0}, Slot{0, 0, 0},
-            Slot{apple, 1, 0}, Slot{0, 0, 0},
+            Slot{apple, 1, 0, nil}, Slot{0, 0, 0, nil},
+            Slot{apple, 1, 0, nil}, Slot{0, 0, 0, nil},
             false,
         },
         {
             "adding incompatible types",
-            Slot{apple, 1, 0}, Slot{orange, 4, 0},
-            Slot{apple, 1, 0}, Slot{orange, 4, 0},
+            Slot{apple, 1, 0, nil}, Slot{orange, 4, 0, nil},
+            Slot{apple, 1, 0, nil}, Slot{orange, 4, 0, nil},
             false,
         },
         {
             "adding incompatible data values",
-            Slot{apple, 1, 0}, Slot{apple, 1, 2},
-            Slot{apple, 1, 0}, Slot{apple, 1, 2},
+            Slot{apple, 1, 0, nil}, Slot{apple, 1, 2, nil},
+            Slot{apple, 1, 0, nil}, Slot{apple, 1, 2, nil},
             false,
         },
         {
             "adding to an already full stack",
-            Slot{apple, 64, 0}, Slot{apple, 10, 0},
-            Slot{apple, 64, 0}, Slot{apple, 10, 0},
+            Slot{apple, 64, 0, nil}, Slot{apple, 10, 0, nil},
+            Slot{apple, 64, 0, nil}, Slot{apple, 10, 0, nil},
             false,
         },
         // Remaining tests should all result in changes. They all take from a
         // non-empty src slot into a compatible subject slot.
         {
             "adding item to empty, copies type and data",
-            Slot{0, 0, 0}, Slot{apple, 3, 0},
-            Slot{apple, 1, 0}, Slot{apple, 2, 0},
+            Slot{0, 0, 0, nil}, Slot{apple, 3, 0, nil},
+            Slot{apple, 1, 0, nil}, Slot{apple, 2, 0, nil},
             true,
         },
         {
             "adding item to non-empty",
-            Slot{apple, 5, 0}, Slot{apple, 3, 0},
-            Slot{apple, 6, 0}, Slot{apple, 2, 0},
+            Slot{apple, 5, 0, nil}, Slot{apple, 3, 0, nil},
+            Slot{apple, 6, 0, nil}, Slot{apple, 2, 0, nil},
             true,
         },
         {
             "adding item to non-empty, empties src",
-            Slot{apple, 5, 2}, Slot{apple, 1, 2},
-            Slot{apple, 6, 2}, Slot{0, 0, 0},
+            Slot{apple, 5, 2, nil}, Slot{apple, 1, 2, nil},
+            Slot{apple, 6, 2, nil}, Slot{0, 0, 0, nil},
             true,
         },
     }
@@ -384,3 +393,123 @@ func TestSlot_AddOne(t *testing.T) {
         },
     )
 }
+
+func namedItemNbt(name string) nbt.Compound {
+    return nbt.Compound{
+        "display": nbt.Compound{
+            "Name": &nbt.String{name},
+        },
+    }
+}
+
+func TestSlot_Nbt_Stacking(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+    apple := ItemTypeId(1)
+    makeItemType(apple)
+
+    plain := Slot{apple, 1, 0, nil}
+    named := Slot{apple, 1, 0, namedItemNbt("Bob")}
+    otherNamed := Slot{apple, 1, 0, namedItemNbt("Alice")}
+    sameNamed := Slot{apple, 1, 0, namedItemNbt("Bob")}
+    emptyNbt := Slot{apple, 1, 0, nbt.Compound{}}
+
+    if plain.IsSameType(&named) {
+        t.Errorf("plain item stacks with named item")
+    }
+    if named.IsSameType(&otherNamed) {
+        t.Errorf("items with different names stack")
+    }
+    if !named.IsSameType(&sameNamed) {
+        t.Errorf("items with the same name do not stack")
+    }
+    if !plain.IsSameType(&emptyNbt) {
+        t.Errorf("empty NBT differs from no NBT")
+    }
+
+    // Named items do not stack onto plain items.
+    dst, src := plain, named
+    if dst.Add(&src) {
+        t.Errorf("named item added to plain stack")
+    }
+
+    // Items split into an empty slot keep their NBT.
+    dst, src = Slot{}, Slot{apple, 4, 0, namedItemNbt("Bob")}
+    src.Split(&dst)
+    if !dst.Equals(&Slot{apple, 2, 0, namedItemNbt("Bob")}) || !src.Equals(&Slot{apple, 2, 0, namedItemNbt("Bob")}) {
+        t.Errorf("split lost NBT: dst=%+v src=%+v", dst, src)
+    }
+
+    // Emptied slots lose their NBT.
+    dst = Slot{apple, 1, 0, namedItemNbt("Bob")}
+    dst.Decrement()
+    if dst.Nbt != nil {
+        t.Errorf("emptied slot kept NBT %v", dst.Nbt)
+    }
+}
+
+func TestSlot_Nbt_Marshal(t *testing.T) {
+    tests := []Slot{
+        {1, 3, 2, nil},
+        {1, 1, 0, namedItemNbt("Bob")},
+    }
+
+    for _, slot := range tests {
+        tag := nbt.NewCompound()
+        if err := slot.MarshalNbt(tag); err != nil {
+            t.Errorf("%+v: marshal failed: %v", slot, err)
+            continue
+        }
+        if _, hasTag := tag["tag"]; hasTag != (slot.Nbt != nil) {
+            t.Errorf("%+v: got tag present=%t", slot, hasTag)
+        }
+
+        var result Slot
+        if err := result.UnmarshalNbt(tag); err != nil {
+            t.Errorf("%+v: unmarshal failed: %v", slot, err)
+        } else if !result.Equals(&slot) {
+            t.Errorf("%+v: got %+v after round trip", slot, result)
+        }
+
+        itemSlot := slot.ItemSlot()
+        result = Slot{}
+        result.SetItemSlot(&itemSlot)
+        if !result.Equals(&slot) {
+            t.Errorf("%+v: got %+v after proto round trip", slot, result)
+        }
+    }
+}
+
+func TestSlot_ItemSlot_Serialization(t *testing.T) {
+    // Any item may have NBT data on the wire, not only tools and armour.
+    tests := []Slot{
+        {1, 3, 2, nil},
+        {1, 1, 0, namedItemNbt("Bob")},
+        {},
+    }
+
+    var ps proto.PacketSerializer
+    var buf bytes.Buffer
+    for i, slot := range tests {
+        ps.WritePacketsBuffer(&buf, slot.UpdatePacket(1, SlotId(i)))
+    }
+
+    for i, slot := range tests {
+        pkt, err := ps.ReadPacket(&buf, false)
+        if err != nil {
+            t.Fatalf("%+v: read failed: %v", slot, err)
+        }
+        setSlot, ok := pkt.(*proto.PacketWindowSetSlot)
+        if !ok || setSlot.SlotIndex != SlotId(i) {
+            t.Fatalf("%+v: got packet %#v", slot, pkt)
+        }
+        var result Slot
+        result.SetItemSlot(&setSlot.Item)
+        if !result.Equals(&slot) {
+            t.Errorf("%+v: got %+v after serialization", slot, result)
+        }
+    }
+    if buf.Len() != 0 {
+        t.Errorf("expected all data to be read, %d bytes left", buf.Len())
+    }
+}
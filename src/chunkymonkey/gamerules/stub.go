@@ -31,6 +31,10 @@ type IPlayerShardClient interface {
 
     ReqMulticastPlayers(chunkLoc ChunkXz, exclude EntityId, packet []byte)
 
+    // ReqMulticastTracking sends the packet to the other players that can see
+    // the player.
+    ReqMulticastTracking(packet []byte)
+
     ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, held ItemTypeId)
 
     ReqRemovePlayerData(chunkLoc ChunkXz, isDisconnect bool)
@@ -39,6 +43,10 @@ type IPlayerShardClient interface {
 
     ReqSetPlayerLook(chunkLoc ChunkXz, look LookBytes)
 
+    // ReqSetTrackingPosition tells the shard where the player is, so that it
+    // only sends the player packets about entities that are in range of them.
+    ReqSetTrackingPosition(position AbsXyz)
+
     // ReqHitBlock requests that the targetted block be hit.
     ReqHitBlock(held Slot, target BlockXyz, digStatus DigStatus, face Face)
 
@@ -48,8 +56,10 @@ type IPlayerShardClient interface {
     // ReqPlaceItem requests that the item passed be placed at the given target
     // location. The shard *may* choose not to do this, but if it cannot, then it
     // *must* account for the item in some way (maybe hand it back to the player
-    // or just drop it on the ground).
-    ReqPlaceItem(target BlockXyz, slot Slot)
+    // or just drop it on the ground). face is the face of the block that the
+    // item is placed against, and look is the direction that the player
+    // placing the item is facing.
+    ReqPlaceItem(target BlockXyz, face Face, slot Slot, look LookDegrees)
 
     // ReqTakeItem requests that the item with the specified entityId is given to
     // the player. The chunk doesn't have to respect this (particularly if the
@@ -59,6 +69,10 @@ type IPlayerShardClient interface {
     // ReqDropItem requests that an item be created.
     ReqDropItem(content Slot, position AbsXyz, velocity AbsVelocity, pickupImmunity Ticks)
 
+    // ReqDropExperience requests that experience orbs be dropped at the given
+    // position.
+    ReqDropExperience(amount Experience, position AbsXyz)
+
     // ReqInventoryClick requests that the given cursor be "clicked" onto the
     // inventory. The chunk should send a replying ReqInventoryCursorUpdate to
     // reflect the new state of the cursor afterwards - in addition to any
@@ -68,6 +82,71 @@ type IPlayerShardClient interface {
     // ReqInventoryUnsubscribed requests that the inventory for the block be
     // unsubscribed to.
     ReqInventoryUnsubscribed(block BlockXyz)
+
+    // ReqUseEntity requests that the player use (or attack, if leftClick is
+    // true) the entity with the given ID while holding the given item. Shards
+    // that do not contain the entity ignore the request.
+    ReqUseEntity(held Slot, target EntityId, leftClick bool)
+
+    // ReqFeedEntity requests that the animal with the given ID be fed the
+    // given food, which the player has already used up. The food is given
+    // back if the animal does not eat it. Shards that do not contain the
+    // animal ignore the request.
+    ReqFeedEntity(food Slot, target EntityId)
+
+    // ReqEntityInventoryClick is the equivalent of ReqInventoryClick for the
+    // inventory of an entity.
+    ReqEntityInventoryClick(target EntityId, click Click)
+
+    // ReqEntityInventoryUnsubscribed is the equivalent of
+    // ReqInventoryUnsubscribed for the inventory of an entity.
+    ReqEntityInventoryUnsubscribed(target EntityId)
+
+    // ReqTrade requests that the villager with the given ID agree to the
+    // player trading the offer at the given index, which the player was shown
+    // as the given offer. The player is sent the outcome and the villager's
+    // updated offers. Shards that do not contain the villager ignore the
+    // request.
+    ReqTrade(merchant EntityId, index int, offer MerchantOffer)
+
+    // ReqUseItem requests that the held item be used without a target block,
+    // e.g placing a boat on water. The item is aimed along the look direction
+    // from the given eye position.
+    ReqUseItem(held Slot, eyePosition AbsXyz, look LookDegrees)
+
+    // ReqSteerVehicle passes the movement input of a riding player to the
+    // vehicle with the given ID. Shards that do not contain the vehicle ignore
+    // the request.
+    ReqSteerVehicle(vehicle EntityId, input AbsVelocity)
+
+    // ReqPortalArrival requests that the player, having just travelled to the
+    // shard's dimension, be moved to a portal near the given position. A
+    // portal is built if there is none nearby.
+    ReqPortalArrival(position AbsXyz, look LookDegrees)
+
+    // ReqCheckBed requests that the bed whose head is at the given location,
+    // which the player has just respawned at, be checked. The player is sent
+    // BedMissing if it is no longer there.
+    ReqCheckBed(bed BlockXyz)
+
+    // ReqSetGameType tells the shard which game mode the player is in, which
+    // changes how the player's actions are carried out (e.g creative players
+    // break blocks instantly).
+    ReqSetGameType(gameType GameType)
+
+    // ReqCheckMove requests that the player's move between the given
+    // positions be checked against the blocks around it. The result is
+    // reported back with MoveChecked.
+    ReqCheckMove(from AbsXyz, to AbsXyz)
+
+    // ReqSetSignText requests that the text of the sign at the given location
+    // be set. The new text is sent to all players subscribed to the chunk.
+    ReqSetSignText(target BlockXyz, text [SignLines]string)
+
+    // ReqUseAnvil requests that the anvil at the given location be worn by the
+    // player taking an item from its window. Players using the anvil are sent
+    // InventoryUnsubscribed if it breaks.
+    ReqUseAnvil(target BlockXyz)
 }
 
 // IShardShardClient provides an interface for shards to make requests against
@@ -79,6 +158,11 @@ type IShardShardClient interface {
     ReqSetActiveBlocks(blocks []BlockXyz)
 
     ReqTransferEntity(loc ChunkXz, entity INonPlayerEntity)
+
+    // ReqPortalSearch passes on the search for the portal nearest to an
+    // arriving player. The shard searches its part of the area and passes the
+    // search on, or moves the player if the search is done.
+    ReqPortalSearch(search *PortalSearch)
 }
 
 // IGame provide an interface for interacting with and taking action on the
@@ -102,6 +186,19 @@ type IGame interface {
 
     // Get the maximum number of players
     GetMaxPlayers() int
+
+    // TimeOfDay returns the current time within the day/night cycle, in the
+    // range 0 <= t < TicksPerDay.
+    TimeOfDay() Ticks
+
+    // SetPlayerSleeping records whether or not the player is in a bed. Once all
+    // players are asleep, the time is moved on to the next morning and the
+    // players are woken.
+    SetPlayerSleeping(id EntityId, sleeping bool)
+
+    // ShardConnecter returns the IShardConnecter for the shards of the given
+    // dimension. ok=false if the dimension is not hosted.
+    ShardConnecter(dimension DimensionId) (connecter IShardConnecter, ok bool)
 }
 
 // IShardClient is the interface by which shards communicate to players on
@@ -119,6 +216,11 @@ type IPlayerClient interface {
     // opened.
     InventorySubscribed(block BlockXyz, invTypeId InvTypeId, slots proto.ItemSlotSlice)
 
+    // EntityInventorySubscribed informs the player that the inventory of an
+    // entity has been opened. block identifies the inventory in subsequent
+    // inventory updates.
+    EntityInventorySubscribed(entityId EntityId, block BlockXyz, invTypeId InvTypeId, slots proto.ItemSlotSlice)
+
     // InventorySlotUpdate informs the player of a change to a slot in the
     // open inventory.
     InventorySlotUpdate(block BlockXyz, slot Slot, slotId SlotId)
@@ -135,6 +237,32 @@ type IPlayerClient interface {
     // TxStateDeferred is returned from Click.
     InventoryTxState(block BlockXyz, txId TxId, accepted bool)
 
+    // InventoryTransferred gives the player the items in click.Cursor after a
+    // transfer click (see Click.IsTransfer) on a remote inventory. These are
+    // the items taken out of the inventory, or those that did not fit.
+    InventoryTransferred(block BlockXyz, click Click)
+
+    // OpenEnderChest requests that the player open the window onto their own
+    // ender chest inventory, through the ender chest at the given location.
+    OpenEnderChest(block BlockXyz)
+
+    // OpenEnchantingTable requests that the player open an enchanting window
+    // through the enchanting table at the given location, which has the given
+    // number of bookshelves around it.
+    OpenEnchantingTable(block BlockXyz, bookshelves int)
+
+    // OpenAnvil requests that the player open an anvil window through the
+    // anvil at the given location.
+    OpenAnvil(block BlockXyz)
+
+    // OpenMerchant requests that the player open a window to trade the given
+    // offers with the villager with the given ID, at the given position.
+    OpenMerchant(merchant EntityId, position AbsXyz, offers []MerchantOffer)
+
+    // TradeResult informs the player whether the villager with the given ID
+    // agreed to their trade, and of its updated offers.
+    TradeResult(merchant EntityId, accepted bool, offers []MerchantOffer)
+
     // InventorySubscribed informs the player that an inventory has been
     // closed.
     InventoryUnsubscribed(block BlockXyz)
@@ -142,8 +270,25 @@ type IPlayerClient interface {
     // PlaceHeldItem requests that the player frontend take one item from the
     // held item stack and send it in a ReqPlaceItem to the target block.  The
     // player code may *not* honour this request (e.g there might be no suitable
-    // held item).
-    PlaceHeldItem(target BlockXyz, wasHeld Slot)
+    // held item). face is the face of the block that the item is placed
+    // against, or FaceNull if there is none.
+    PlaceHeldItem(target BlockXyz, face Face, wasHeld Slot)
+
+    // WearHeldItem requests that the player add wear to their held tool,
+    // assuming that it has not changed since wasHeld.
+    WearHeldItem(wasHeld Slot, uses ItemData)
+
+    // ConsumeHeldItem requests that the player use up one of their held item,
+    // assuming that it has not changed since wasHeld, and be given the leaves
+    // item in its place if it is not empty (e.g a bucket filled with milk).
+    // Players in creative mode keep their held item, but are still given the
+    // leaves item.
+    ConsumeHeldItem(wasHeld Slot, leaves Slot)
+
+    // FeedEntity requests that the player use up one of their held item,
+    // assuming that it has not changed since wasHeld, and then
+    // ReqFeedEntity to feed it to the animal with the given ID.
+    FeedEntity(wasHeld Slot, target EntityId)
 
     // OfferItem requests that the player check if it can take the item.  If
     // it can then it should ReqTakeItem from the chunk.
@@ -158,6 +303,9 @@ type IPlayerClient interface {
     // current position as the 'atPosition'.
     GiveItem(item Slot)
 
+    // GiveExperience adds to the player's experience.
+    GiveExperience(amount Experience)
+
     // PositionLook returns the player's current position and look
     PositionLook() (AbsXyz, LookDegrees)
 
@@ -166,6 +314,44 @@ type IPlayerClient interface {
 
     // EchoMessage displays a message to the player
     EchoMessage(msg string)
+
+    // SleepInBed requests that the player sleep in the bed whose head is at
+    // the given location. The player may refuse if it is not night.
+    SleepInBed(bed BlockXyz)
+
+    // BedMissing informs the player that the bed that they respawned at is no
+    // longer there, so they respawn at the world spawn instead.
+    BedMissing()
+
+    // EnterPortal informs the player that they are standing in a portal to
+    // the given dimension. They travel once they have stood in it for long
+    // enough.
+    EnterPortal(dimension DimensionId)
+
+    // SetVehicle informs the player that they are now riding the given
+    // vehicle, or EntityIdNull if they have dismounted.
+    SetVehicle(vehicle EntityId)
+
+    // SetGameType changes the player's game mode.
+    SetGameType(gameType GameType)
+
+    // Hurt requests that the player take damage, e.g from being hit by another
+    // player. Players in creative mode are invulnerable, and ignore it.
+    Hurt(amount Health)
+
+    // AddEffect applies a status effect to the player, e.g from a splash
+    // potion that landed near them.
+    AddEffect(effect StatusEffect)
+
+    // MoveChecked reports on a move that the player made to the given
+    // position. supported is true if the player is standing on or holding on
+    // to something there, and blocked is true if the move passed through
+    // solid blocks.
+    MoveChecked(position AbsXyz, supported bool, blocked bool)
+
+    // MoveWithVehicle informs the player that the vehicle they are riding has
+    // moved to the given position.
+    MoveWithVehicle(position AbsXyz)
 }
 
 type ICommandFramework interface {
@@ -0,0 +1,182 @@
+package gamerules
+
+import (
+    "math"
+    "math/rand"
+
+    . "chunkymonkey/types"
+)
+
+// Types of tool that are used for digging.
+const (
+    ToolTypeNone    = ToolTypeId(0)
+    ToolTypeShovel  = ToolTypeId(1)
+    ToolTypePickaxe = ToolTypeId(2)
+    ToolTypeAxe     = ToolTypeId(3)
+    ToolTypeSword   = ToolTypeId(4)
+    ToolTypeHoe     = ToolTypeId(5)
+)
+
+type ToolMaterialId byte
+
+const (
+    ToolMaterialNone    = ToolMaterialId(0)
+    ToolMaterialWood    = ToolMaterialId(1)
+    ToolMaterialStone   = ToolMaterialId(2)
+    ToolMaterialIron    = ToolMaterialId(3)
+    ToolMaterialDiamond = ToolMaterialId(4)
+    ToolMaterialGold    = ToolMaterialId(5)
+)
+
+const (
+    // Speed of a sword against the blocks that it is effective against.
+    swordDigSpeed = 15
+
+    // Divisors of the dig speed against a block's hardness, depending on
+    // whether the block can be harvested with the tool used.
+    digHarvestDivisor   = 30
+    digNoHarvestDivisor = 100
+)
+
+type toolMaterial struct {
+    // Multiplier of digging speed against blocks that the tool is effective
+    // against.
+    speed float32
+    // Blocks that require a tool with at least this harvest level to drop
+    // items.
+    harvestLevel int8
+    // Extra damage done by the tool when attacking.
+    damage Health
+}
+
+var toolMaterials = map[ToolMaterialId]toolMaterial{
+    ToolMaterialWood:    {2, 0, 0},
+    ToolMaterialStone:   {4, 1, 1},
+    ToolMaterialIron:    {6, 2, 2},
+    ToolMaterialDiamond: {8, 3, 3},
+    ToolMaterialGold:    {12, 0, 0},
+}
+
+// Damage done by attacking with each type of tool, before the material's
+// extra damage.
+var toolAttackDamage = map[ToolTypeId]Health{
+    ToolTypeShovel:  1,
+    ToolTypePickaxe: 2,
+    ToolTypeAxe:     3,
+    ToolTypeSword:   4,
+}
+
+// heldTool returns the tool type and material of the item in the slot.
+func (s *Slot) heldTool() (toolType ToolTypeId, material toolMaterial) {
+    itemType := s.ItemType()
+    if s.IsEmpty() || itemType == nil {
+        return ToolTypeNone, material
+    }
+    return itemType.ToolType, toolMaterials[itemType.ToolMaterial]
+}
+
+// AttackDamage returns the damage done by attacking with the item in the slot.
+func (s *Slot) AttackDamage() Health {
+    toolType, material := s.heldTool()
+    if damage, ok := toolAttackDamage[toolType]; ok {
+        return damage + material.damage + s.sharpnessDamage()
+    }
+    return 1
+}
+
+// sharpnessDamage returns the extra damage done by a sharpness enchantment on
+// the item in the slot. Each level adds 1.5 on average.
+func (s *Slot) sharpnessDamage() Health {
+    return Health(s.EnchantmentLevel(EnchantmentSharpness)) * 3 / 2
+}
+
+// DigWear returns the number of uses taken from the item in the slot by
+// digging a block with the given hardness.
+func (s *Slot) DigWear(hardness float32) ItemData {
+    if hardness == 0 {
+        return 0
+    }
+    toolType, _ := s.heldTool()
+    switch toolType {
+    case ToolTypeShovel, ToolTypePickaxe, ToolTypeAxe:
+        return 1
+    case ToolTypeSword:
+        return 2
+    }
+    return 0
+}
+
+// Wear adds the given number of uses to the damage of the tool in the slot.
+// The tool is removed once it is worn out. changed=false if the item does not
+// wear. An unbreaking enchantment gives each use a chance of not wearing the
+// tool.
+func (s *Slot) Wear(uses ItemData) (changed bool) {
+    itemType := s.ItemType()
+    if uses <= 0 || s.IsEmpty() || itemType == nil || itemType.ToolUses == 0 {
+        return false
+    }
+
+    if unbreaking := int(s.EnchantmentLevel(EnchantmentUnbreaking)); unbreaking > 0 {
+        worn := ItemData(0)
+        for i := ItemData(0); i < uses; i++ {
+            if rand.Intn(unbreaking+1) == 0 {
+                worn++
+            }
+        }
+        if uses = worn; uses == 0 {
+            return false
+        }
+    }
+
+    s.Data += uses
+    if s.Data >= itemType.ToolUses {
+        s.Clear()
+    }
+    return true
+}
+
+// isEffectiveTool returns true if the held item is the type of tool that digs
+// the block faster.
+func (aspect *StandardAspect) isEffectiveTool(held *Slot) bool {
+    toolType, _ := held.heldTool()
+    return aspect.ToolType != ToolTypeNone && toolType == aspect.ToolType
+}
+
+func (aspect *StandardAspect) CanHarvest(held *Slot) bool {
+    if !aspect.ToolRequired {
+        return true
+    }
+    _, material := held.heldTool()
+    return aspect.isEffectiveTool(held) && material.harvestLevel >= aspect.HarvestLevel
+}
+
+func (aspect *StandardAspect) DigTicks(held *Slot) (ticks Ticks, ok bool) {
+    hardness := aspect.blockAttrs.Hardness
+    if hardness < 0 {
+        return 0, false
+    } else if hardness == 0 {
+        return 0, true
+    }
+
+    speed := float32(1)
+    if aspect.isEffectiveTool(held) {
+        toolType, material := held.heldTool()
+        if toolType == ToolTypeSword {
+            speed = swordDigSpeed
+        } else if material.speed > 0 {
+            speed = material.speed
+        }
+        // Efficiency adds level²+1 to the speed.
+        if efficiency := float32(held.EnchantmentLevel(EnchantmentEfficiency)); efficiency > 0 {
+            speed += efficiency*efficiency + 1
+        }
+    }
+
+    divisor := float32(digNoHarvestDivisor)
+    if aspect.CanHarvest(held) {
+        divisor = digHarvestDivisor
+    }
+
+    damagePerTick := speed / hardness / divisor
+    return Ticks(math.Ceil(float64(1 / damagePerTick))), true
+}
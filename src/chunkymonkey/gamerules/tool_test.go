@@ -0,0 +1,82 @@
+package gamerules
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func TestStandardAspect_DigTicks(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+    woodPickaxe := ItemTypeId(270)
+    ironPickaxe := ItemTypeId(257)
+    ironShovel := ItemTypeId(256)
+    Items[woodPickaxe] = &ItemType{Id: woodPickaxe, MaxStack: 1, ToolType: ToolTypePickaxe, ToolUses: 60, ToolMaterial: ToolMaterialWood}
+    Items[ironPickaxe] = &ItemType{Id: ironPickaxe, MaxStack: 1, ToolType: ToolTypePickaxe, ToolUses: 251, ToolMaterial: ToolMaterialIron}
+    Items[ironShovel] = &ItemType{Id: ironShovel, MaxStack: 1, ToolType: ToolTypeShovel, ToolUses: 251, ToolMaterial: ToolMaterialIron}
+
+    stone := &StandardAspect{ToolType: ToolTypePickaxe, ToolRequired: true}
+    stone.setAttrs(&BlockAttrs{Hardness: 1.5})
+    ironOre := &StandardAspect{ToolType: ToolTypePickaxe, ToolRequired: true, HarvestLevel: 1}
+    ironOre.setAttrs(&BlockAttrs{Hardness: 3})
+    dirt := &StandardAspect{ToolType: ToolTypeShovel}
+    dirt.setAttrs(&BlockAttrs{Hardness: 0.5})
+    flower := &StandardAspect{}
+    flower.setAttrs(&BlockAttrs{Hardness: 0})
+    bedrock := &StandardAspect{}
+    bedrock.setAttrs(&BlockAttrs{Hardness: -1})
+
+    type Test struct {
+        desc          string
+        aspect        *StandardAspect
+        held          Slot
+        expectTicks   Ticks
+        expectOk      bool
+        expectHarvest bool
+    }
+
+    tests := []Test{
+        {"stone by hand", stone, Slot{}, 150, true, false},
+        {"stone with wooden pickaxe", stone, Slot{woodPickaxe, 1, 0, nil}, 23, true, true},
+        {"stone with shovel", stone, Slot{ironShovel, 1, 0, nil}, 150, true, false},
+        {"iron ore with wooden pickaxe", ironOre, Slot{woodPickaxe, 1, 0, nil}, 150, true, false},
+        {"iron ore with iron pickaxe", ironOre, Slot{ironPickaxe, 1, 0, nil}, 15, true, true},
+        {"dirt by hand", dirt, Slot{}, 15, true, true},
+        {"dirt with shovel", dirt, Slot{ironShovel, 1, 0, nil}, 3, true, true},
+        {"flower", flower, Slot{}, 0, true, true},
+        {"bedrock", bedrock, Slot{ironPickaxe, 1, 0, nil}, 0, false, true},
+    }
+
+    for _, test := range tests {
+        ticks, ok := test.aspect.DigTicks(&test.held)
+        if ticks != test.expectTicks || ok != test.expectOk {
+            t.Errorf("%s: expected DigTicks (%d, %t), got (%d, %t)", test.desc, test.expectTicks, test.expectOk, ticks, ok)
+        }
+        if harvest := test.aspect.CanHarvest(&test.held); harvest != test.expectHarvest {
+            t.Errorf("%s: expected CanHarvest %t, got %t", test.desc, test.expectHarvest, harvest)
+        }
+    }
+}
+
+func TestSlot_Wear(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+    pickaxe := ItemTypeId(270)
+    apple := ItemTypeId(260)
+    Items[pickaxe] = &ItemType{Id: pickaxe, MaxStack: 1, ToolType: ToolTypePickaxe, ToolUses: 60, ToolMaterial: ToolMaterialWood}
+    Items[apple] = &ItemType{Id: apple, MaxStack: 64}
+
+    slot := Slot{pickaxe, 1, 58, nil}
+    if !slot.Wear(1) || slot.Data != 59 {
+        t.Errorf("expected tool to wear to 59, got %+v", slot)
+    }
+    if !slot.Wear(1) || !slot.IsEmpty() {
+        t.Errorf("expected worn out tool to be removed, got %+v", slot)
+    }
+
+    slot = Slot{apple, 5, 0, nil}
+    if slot.Wear(1) || slot.Data != 0 {
+        t.Errorf("expected non-tool not to wear, got %+v", slot)
+    }
+}
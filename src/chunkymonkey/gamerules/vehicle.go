@@ -0,0 +1,65 @@
+package gamerules
+
+import (
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+// vehicle is embedded in entities that a player can ride, and keeps track of
+// the rider.
+type vehicle struct {
+    rider EntityId
+}
+
+func (v *vehicle) initVehicle() {
+    v.rider = EntityIdNull
+}
+
+// toggleRider mounts the player onto the vehicle if it is empty, or dismounts
+// them if they are already riding it.
+func (v *vehicle) toggleRider(player IPlayerClient, vehicleId EntityId) {
+    playerId := player.GetEntityId()
+    if v.rider == playerId {
+        v.rider = EntityIdNull
+        player.SetVehicle(EntityIdNull)
+    } else if v.rider == EntityIdNull {
+        v.rider = playerId
+        player.SetVehicle(vehicleId)
+    }
+}
+
+// ejectRider dismounts any rider from the vehicle.
+func (v *vehicle) ejectRider(chunk IChunkBlock) {
+    if v.rider == EntityIdNull {
+        return
+    }
+    if player, ok := chunk.SubscribedPlayer(v.rider); ok {
+        player.SetVehicle(EntityIdNull)
+    }
+    v.rider = EntityIdNull
+}
+
+// moveRider tells the rider that the vehicle has moved. If the rider has gone
+// away, the vehicle becomes empty.
+func (v *vehicle) moveRider(chunk IChunkBlock, position AbsXyz) {
+    if v.rider == EntityIdNull {
+        return
+    }
+    if player, ok := chunk.SubscribedPlayer(v.rider); ok {
+        player.MoveWithVehicle(position)
+    } else {
+        v.rider = EntityIdNull
+    }
+}
+
+// riderPackets appends the packets to show the rider on the vehicle to a
+// newly subscribed player.
+func (v *vehicle) riderPackets(pkts []proto.IPacket, vehicleId EntityId) []proto.IPacket {
+    if v.rider != EntityIdNull {
+        pkts = append(pkts, &proto.PacketEntityAttach{
+            EntityId:  v.rider,
+            VehicleId: vehicleId,
+        })
+    }
+    return pkts
+}
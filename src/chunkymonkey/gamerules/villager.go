@@ -0,0 +1,426 @@
+package gamerules
+
+import (
+    "errors"
+    "math/rand"
+
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+type VillagerProfession int32
+
+const (
+    VillagerProfessionFarmer     = VillagerProfession(0)
+    VillagerProfessionLibrarian  = VillagerProfession(1)
+    VillagerProfessionPriest     = VillagerProfession(2)
+    VillagerProfessionBlacksmith = VillagerProfession(3)
+    VillagerProfessionButcher    = VillagerProfession(4)
+)
+
+const (
+    itemTypeIdEmerald = ItemTypeId(388)
+
+    // Metadata index of a villager's profession, which sets how clients
+    // show it.
+    villagerMetadataProfession = 16
+
+    // Number of times that a new offer can be traded before it is locked.
+    merchantOfferDefaultMaxUses = 7
+
+    // Ticks after the newest offer is traded before locked offers are
+    // unlocked and a new offer is added.
+    villagerRestockTicks = 40
+)
+
+// villagerTrade is a trade that villagers of a profession may offer. Either
+// the villager buys some of an item for an emerald, or sells an item for
+// emeralds.
+type villagerTrade struct {
+    // The item that the villager buys or sells.
+    item ItemTypeId
+    sell bool
+    // The range of the number of items bought for an emerald, or of emeralds
+    // that the item is sold for.
+    min, max ItemCount
+    // Percentage chance of the trade being offered when the villager's offers
+    // are added to.
+    chance int
+}
+
+var villagerTrades = map[VillagerProfession][]villagerTrade{
+    VillagerProfessionFarmer: {
+        {296, false, 18, 22, 90}, // Wheat.
+        {35, false, 14, 22, 50},  // Wool.
+        {365, false, 14, 18, 50}, // Raw chicken.
+        {350, false, 9, 13, 40},  // Cooked fish.
+        {297, true, 1, 2, 90},    // Bread.
+        {360, true, 1, 2, 30},    // Melon.
+        {260, true, 1, 2, 30},    // Apple.
+        {357, true, 1, 2, 30},    // Cookie.
+        {359, true, 3, 4, 30},    // Shears.
+        {259, true, 3, 4, 30},    // Flint and steel.
+        {366, true, 2, 3, 30},    // Cooked chicken.
+        {262, true, 1, 2, 50},    // Arrow.
+    },
+    VillagerProfessionLibrarian: {
+        {339, false, 24, 36, 80}, // Paper.
+        {340, false, 11, 13, 80}, // Book.
+        {47, true, 3, 4, 80},     // Bookshelf.
+        {20, true, 1, 2, 20},     // Glass.
+        {345, true, 10, 12, 20},  // Compass.
+        {347, true, 10, 12, 20},  // Clock.
+    },
+    VillagerProfessionPriest: {
+        {367, false, 36, 64, 30}, // Rotten flesh.
+        {266, false, 8, 10, 30},  // Gold ingot.
+        {331, true, 1, 2, 40},    // Redstone.
+        {348, true, 1, 3, 30},    // Glowstone dust.
+    },
+    VillagerProfessionBlacksmith: {
+        {263, false, 16, 24, 70}, // Coal.
+        {265, false, 8, 10, 50},  // Iron ingot.
+        {266, false, 8, 10, 50},  // Gold ingot.
+        {264, false, 4, 6, 50},   // Diamond.
+        {267, true, 7, 11, 50},   // Iron sword.
+        {276, true, 12, 14, 50},  // Diamond sword.
+        {258, true, 6, 8, 30},    // Iron axe.
+        {257, true, 7, 9, 50},    // Iron pickaxe.
+        {307, true, 10, 14, 20},  // Iron chestplate.
+        {311, true, 16, 19, 20},  // Diamond chestplate.
+    },
+    VillagerProfessionButcher: {
+        {263, false, 16, 24, 70}, // Coal.
+        {319, false, 14, 18, 50}, // Raw porkchop.
+        {363, false, 14, 18, 50}, // Raw beef.
+        {329, true, 6, 8, 10},    // Saddle.
+        {320, true, 1, 2, 30},    // Cooked porkchop.
+        {364, true, 1, 2, 30},    // Steak.
+    },
+}
+
+// offer makes a merchant offer for the trade, with a random price.
+func (trade *villagerTrade) offer(rand *rand.Rand) MerchantOffer {
+    count := trade.min + ItemCount(rand.Intn(int(trade.max-trade.min)+1))
+    offer := MerchantOffer{MaxUses: merchantOfferDefaultMaxUses}
+    if trade.sell {
+        offer.Buy = Slot{ItemTypeId: itemTypeIdEmerald, Count: count}
+        offer.Sell = Slot{ItemTypeId: trade.item, Count: 1}
+    } else {
+        offer.Buy = Slot{ItemTypeId: trade.item, Count: count}
+        offer.Sell = Slot{ItemTypeId: itemTypeIdEmerald, Count: 1}
+    }
+    return offer
+}
+
+// MerchantOffer is a trade offered by a villager. The player gives the Buy
+// item, and the BuySecond item if it is not empty, in return for the Sell
+// item.
+type MerchantOffer struct {
+    Buy       Slot
+    BuySecond Slot
+    Sell      Slot
+    // Uses is the number of times that the offer has been traded, up to
+    // MaxUses, after which it is locked until the villager unlocks it.
+    Uses    int32
+    MaxUses int32
+}
+
+// IsLocked returns true if the offer cannot currently be traded.
+func (offer *MerchantOffer) IsLocked() bool {
+    return offer.Uses >= offer.MaxUses
+}
+
+// IsSameTrade returns true if the offer wants and gives the same items as the
+// other offer, regardless of how many.
+func (offer *MerchantOffer) IsSameTrade(other *MerchantOffer) bool {
+    return (offer.Buy.IsSameType(&other.Buy) &&
+        offer.BuySecond.IsSameType(&other.BuySecond) &&
+        offer.Sell.IsSameType(&other.Sell))
+}
+
+// isShownAs returns true if the offer still wants and gives what the player
+// was shown.
+func (offer *MerchantOffer) isShownAs(shown *MerchantOffer) bool {
+    return (offer.Buy.Equals(&shown.Buy) &&
+        offer.BuySecond.Equals(&shown.BuySecond) &&
+        offer.Sell.Equals(&shown.Sell))
+}
+
+// payment works out if the items in the two slots pay for the offer. The
+// first and second items pay for Buy and BuySecond respectively.
+func (offer *MerchantOffer) payment(first, second *Slot) bool {
+    if !payFor(&offer.Buy, first) {
+        return false
+    }
+    if offer.BuySecond.IsEmpty() {
+        return true
+    }
+    return payFor(&offer.BuySecond, second)
+}
+
+// payFor returns true if the item in the slot is enough to pay for want.
+func payFor(want, have *Slot) bool {
+    return have.ItemTypeId == want.ItemTypeId && have.Data == want.Data && have.Count >= want.Count
+}
+
+func (offer *MerchantOffer) ProtoOffer() proto.MerchantOffer {
+    return proto.MerchantOffer{
+        Buy:       offer.Buy.ItemSlot(),
+        BuySecond: offer.BuySecond.ItemSlot(),
+        Sell:      offer.Sell.ItemSlot(),
+        Disabled:  offer.IsLocked(),
+    }
+}
+
+func (offer *MerchantOffer) UnmarshalNbt(tag nbt.Compound) (err error) {
+    var ok bool
+    var buyTag, sellTag nbt.Compound
+    var usesTag, maxUsesTag *nbt.Int
+
+    if buyTag, ok = tag.Lookup("buy").(nbt.Compound); !ok {
+        return errors.New("buy tag not Compound")
+    }
+    if sellTag, ok = tag.Lookup("sell").(nbt.Compound); !ok {
+        return errors.New("sell tag not Compound")
+    }
+
+    if err = offer.Buy.UnmarshalNbt(buyTag); err != nil {
+        return
+    }
+    if err = offer.Sell.UnmarshalNbt(sellTag); err != nil {
+        return
+    }
+
+    offer.BuySecond.Clear()
+    if buyBTag := tag.Lookup("buyB"); buyBTag != nil {
+        if buyBTag, ok := buyBTag.(nbt.Compound); !ok {
+            return errors.New("buyB tag not Compound")
+        } else if err = offer.BuySecond.UnmarshalNbt(buyBTag); err != nil {
+            return
+        }
+    }
+
+    // Offers saved before uses were recorded are unused.
+    offer.Uses = 0
+    if usesTag, ok = tag.Lookup("uses").(*nbt.Int); ok {
+        offer.Uses = usesTag.Value
+    }
+    offer.MaxUses = merchantOfferDefaultMaxUses
+    if maxUsesTag, ok = tag.Lookup("maxUses").(*nbt.Int); ok {
+        offer.MaxUses = maxUsesTag.Value
+    }
+
+    return
+}
+
+func (offer *MerchantOffer) MarshalNbt(tag nbt.Compound) (err error) {
+    buyTag := nbt.NewCompound()
+    if err = offer.Buy.MarshalNbt(buyTag); err != nil {
+        return
+    }
+    tag.Set("buy", buyTag)
+
+    if !offer.BuySecond.IsEmpty() {
+        buyBTag := nbt.NewCompound()
+        if err = offer.BuySecond.MarshalNbt(buyBTag); err != nil {
+            return
+        }
+        tag.Set("buyB", buyBTag)
+    }
+
+    sellTag := nbt.NewCompound()
+    if err = offer.Sell.MarshalNbt(sellTag); err != nil {
+        return
+    }
+    tag.Set("sell", sellTag)
+
+    tag.Set("uses", &nbt.Int{offer.Uses})
+    tag.Set("maxUses", &nbt.Int{offer.MaxUses})
+
+    return
+}
+
+// Villager is a mob that trades with players. Which items it trades depends
+// on its profession. Offers are locked after being traded a number of times,
+// and are unlocked again some time after the villager's newest offer is
+// traded, when the villager also adds a new offer.
+type Villager struct {
+    Mob
+    profession VillagerProfession
+    offers     []MerchantOffer
+    // Ticks until locked offers are unlocked, or zero if not restocking.
+    restockTicks int
+}
+
+func NewVillager() INonPlayerEntity {
+    v := new(Villager)
+    v.Mob.Init(VillagerType.Id)
+    v.metadata[villagerMetadataProfession] = int32(v.profession)
+    return v
+}
+
+func (v *Villager) Profession() VillagerProfession {
+    return v.profession
+}
+
+func (v *Villager) SetProfession(profession VillagerProfession) {
+    v.profession = profession
+    v.setMetadata(villagerMetadataProfession, int32(profession))
+}
+
+func (v *Villager) UnmarshalNbt(tag nbt.Compound) (err error) {
+    if err = v.Mob.UnmarshalNbt(tag); err != nil {
+        return
+    }
+
+    if professionTag, ok := tag.Lookup("Profession").(*nbt.Int); ok {
+        v.profession = VillagerProfession(professionTag.Value)
+    }
+    v.metadata[villagerMetadataProfession] = int32(v.profession)
+
+    // A villager that has never been traded with has no offers yet.
+    v.offers = nil
+    recipesTag, ok := tag.Lookup("Offers/Recipes").(*nbt.List)
+    if !ok {
+        return
+    }
+    v.offers = make([]MerchantOffer, len(recipesTag.Value))
+    for i, recipeTag := range recipesTag.Value {
+        recipeCompound, ok := recipeTag.(nbt.Compound)
+        if !ok {
+            return errors.New("offer not Compound")
+        }
+        if err = v.offers[i].UnmarshalNbt(recipeCompound); err != nil {
+            return
+        }
+    }
+
+    return
+}
+
+func (v *Villager) MarshalNbt(tag nbt.Compound) (err error) {
+    if err = v.Mob.MarshalNbt(tag); err != nil {
+        return
+    }
+
+    tag.Set("Profession", &nbt.Int{int32(v.profession)})
+
+    if v.offers == nil {
+        return
+    }
+    recipes := make([]nbt.ITag, len(v.offers))
+    for i := range v.offers {
+        recipeTag := nbt.NewCompound()
+        if err = v.offers[i].MarshalNbt(recipeTag); err != nil {
+            return
+        }
+        recipes[i] = recipeTag
+    }
+    offersTag := nbt.NewCompound()
+    offersTag.Set("Recipes", &nbt.List{nbt.TagCompound, recipes})
+    tag.Set("Offers", offersTag)
+
+    return
+}
+
+func (v *Villager) Tick(chunk IChunkBlock) (leftBlock bool) {
+    if v.restockTicks > 0 {
+        v.restockTicks--
+        if v.restockTicks == 0 {
+            v.restock(chunk.Rand())
+        }
+    }
+
+    return v.Mob.Tick(chunk)
+}
+
+// Use opens a window for the player to trade with the villager when they
+// right-click on it.
+func (v *Villager) Use(chunk IChunkBlock, player IPlayerClient, held *Slot, leftClick bool) (destroyed bool) {
+    if leftClick {
+        return v.Mob.Use(chunk, player, held, leftClick)
+    }
+
+    if v.offers == nil {
+        v.addOffer(chunk.Rand())
+    }
+
+    player.OpenMerchant(v.EntityId, *v.Position(), v.Offers())
+    return false
+}
+
+// Offers returns a copy of the villager's offers.
+func (v *Villager) Offers() []MerchantOffer {
+    offers := make([]MerchantOffer, len(v.offers))
+    copy(offers, v.offers)
+    return offers
+}
+
+// Trade records that the player traded the offer at the given index, if it
+// is still the offer that the player was shown and it is not locked. The
+// player is told whether the trade was agreed to, along with the villager's
+// updated offers.
+func (v *Villager) Trade(player IPlayerClient, index int, shown *MerchantOffer) {
+    if index < 0 || index >= len(v.offers) || !v.offers[index].isShownAs(shown) || v.offers[index].IsLocked() {
+        v.RefuseTrade(player)
+        return
+    }
+
+    v.offers[index].Uses++
+
+    if index == len(v.offers)-1 {
+        // The newest offer was traded, so the villager will soon have
+        // something new to offer.
+        v.restockTicks = villagerRestockTicks
+    }
+
+    player.TradeResult(v.EntityId, true, v.Offers())
+}
+
+// RefuseTrade tells the player that a trade was not agreed to.
+func (v *Villager) RefuseTrade(player IPlayerClient) {
+    player.TradeResult(v.EntityId, false, v.Offers())
+}
+
+// restock unlocks locked offers, allowing them to be traded a few more times,
+// and adds a new offer.
+func (v *Villager) restock(rand *rand.Rand) {
+    for i := range v.offers {
+        if offer := &v.offers[i]; offer.IsLocked() {
+            offer.MaxUses += int32(rand.Intn(6) + rand.Intn(6) + 2)
+        }
+    }
+    v.addOffer(rand)
+}
+
+// addOffer adds an offer for a trade chosen at random from the villager's
+// profession's trades. It replaces any existing offer for the same trade.
+func (v *Villager) addOffer(rand *rand.Rand) {
+    trades := villagerTrades[v.profession]
+    if len(trades) == 0 {
+        return
+    }
+
+    var candidates []*villagerTrade
+    for i := range trades {
+        if rand.Intn(100) < trades[i].chance {
+            candidates = append(candidates, &trades[i])
+        }
+    }
+    var offer MerchantOffer
+    if len(candidates) == 0 {
+        offer = trades[rand.Intn(len(trades))].offer(rand)
+    } else {
+        offer = candidates[rand.Intn(len(candidates))].offer(rand)
+    }
+
+    for i := range v.offers {
+        if v.offers[i].IsSameTrade(&offer) {
+            v.offers[i] = offer
+            return
+        }
+    }
+    v.offers = append(v.offers, offer)
+}
@@ -0,0 +1,304 @@
+package gamerules
+
+import (
+    "math/rand"
+    "testing"
+
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+const (
+    wheatId = ItemTypeId(296)
+    breadId = ItemTypeId(297)
+)
+
+func makeMerchantOffers() []MerchantOffer {
+    return []MerchantOffer{
+        {Buy: Slot{wheatId, 20, 0, nil}, Sell: Slot{itemTypeIdEmerald, 1, 0, nil}, MaxUses: 2},
+        {Buy: Slot{itemTypeIdEmerald, 2, 0, nil}, Sell: Slot{breadId, 1, 0, nil}, MaxUses: 7},
+    }
+}
+
+func TestMerchantInventory(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+    makeItemType(wheatId)
+    makeItemType(breadId)
+    makeItemType(itemTypeIdEmerald)
+
+    inv := NewMerchantInventory(makeMerchantOffers())
+
+    // Too few items pay for nothing.
+    click := Click{SlotId: merchantSlotPayment, Cursor: Slot{wheatId, 19, 0, nil}}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, emptySlot, inv.slots[merchantSlotOutput])
+
+    // The payment can go in either slot.
+    click = Click{SlotId: merchantSlotPaymentSecond, Cursor: Slot{wheatId, 30, 0, nil}}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    emerald := Slot{itemTypeIdEmerald, 1, 0, nil}
+    checkSlot(t, emerald, inv.slots[merchantSlotOutput])
+
+    // Taking the output takes the payment, but the item waits for the
+    // villager to agree to the trade.
+    click = Click{SlotId: merchantSlotOutput, ExpectedSlot: emerald}
+    checkTx(t, TxStateDeferred, inv.Click(&click))
+    checkSlot(t, emptySlot, click.Cursor)
+    checkSlot(t, Slot{wheatId, 19, 0, nil}, inv.slots[merchantSlotPayment])
+    checkSlot(t, Slot{wheatId, 10, 0, nil}, inv.slots[merchantSlotPaymentSecond])
+    trade, ok := inv.TakeTrade()
+    if !ok || trade.Index != 0 || len(trade.Payment) != 1 {
+        t.Fatalf("expected offer 0 to be traded, got %+v", trade)
+    }
+    checkSlot(t, Slot{wheatId, 20, 0, nil}, trade.Payment[0])
+    if _, ok := inv.TakeTrade(); ok {
+        t.Errorf("expected the trade to be taken only once")
+    }
+
+    // Nothing more can be traded until the villager answers.
+    checkSlot(t, emptySlot, inv.slots[merchantSlotOutput])
+    inv.EndTrade(true, trade.Payment)
+    checkSlot(t, Slot{wheatId, 19, 0, nil}, inv.slots[merchantSlotPayment])
+
+    // The payment for a refused trade is put back.
+    click = Click{SlotId: merchantSlotPayment, Cursor: Slot{wheatId, 1, 0, nil}, ExpectedSlot: inv.slots[merchantSlotPayment]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    click = Click{SlotId: merchantSlotOutput, ExpectedSlot: emerald}
+    checkTx(t, TxStateDeferred, inv.Click(&click))
+    trade, _ = inv.TakeTrade()
+    checkSlot(t, emptySlot, inv.slots[merchantSlotPayment])
+    inv.EndTrade(false, trade.Payment)
+    checkSlot(t, Slot{wheatId, 20, 0, nil}, inv.slots[merchantSlotPayment])
+    checkSlot(t, emptySlot, trade.Payment[0])
+    inv.SetOffers(makeMerchantOffers())
+
+    // The output only goes onto a cursor that can take it, and is not swapped
+    // with other items.
+    click = Click{SlotId: merchantSlotOutput, Cursor: Slot{wheatId, 1, 0, nil}, ExpectedSlot: emerald}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    click = Click{SlotId: merchantSlotOutput, Mode: ClickModeNumberKey, Cursor: emerald, ExpectedSlot: emerald}
+    checkTx(t, TxStateRejected, inv.Click(&click))
+    click = Click{SlotId: merchantSlotOutput, Cursor: emerald, ExpectedSlot: emerald}
+    checkTx(t, TxStateDeferred, inv.Click(&click))
+    trade, _ = inv.TakeTrade()
+    inv.EndTrade(true, trade.Payment)
+
+    // Taking the payment out leaves nothing to trade.
+    click = Click{SlotId: merchantSlotPaymentSecond, ExpectedSlot: inv.slots[merchantSlotPaymentSecond]}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, emptySlot, inv.slots[merchantSlotOutput])
+
+    // The offer is locked after its last use.
+    click = Click{SlotId: merchantSlotPaymentSecond, Cursor: Slot{wheatId, 20, 0, nil}}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    click = Click{SlotId: merchantSlotOutput, ExpectedSlot: emerald}
+    checkTx(t, TxStateDeferred, inv.Click(&click))
+    trade, _ = inv.TakeTrade()
+    inv.EndTrade(true, trade.Payment)
+    if !inv.Offers()[0].IsLocked() {
+        t.Errorf("expected offer 0 to be locked")
+    }
+    checkSlot(t, emptySlot, inv.slots[merchantSlotOutput])
+
+    // Offers from the villager replace those in the window.
+    inv.slots[merchantSlotPaymentSecond] = Slot{wheatId, 20, 0, nil}
+    inv.SetOffers(makeMerchantOffers())
+    checkSlot(t, emerald, inv.slots[merchantSlotOutput])
+
+    // The selected offer is preferred over others that are paid for.
+    inv.slots[merchantSlotPayment] = Slot{itemTypeIdEmerald, 2, 0, nil}
+    inv.slots[merchantSlotPaymentSecond] = Slot{wheatId, 20, 0, nil}
+    if !inv.SelectOffer(1) {
+        t.Fatalf("expected offer 1 to be selected")
+    }
+    checkSlot(t, Slot{breadId, 1, 0, nil}, inv.slots[merchantSlotOutput])
+    if inv.SelectOffer(2) {
+        t.Errorf("expected selecting a missing offer to fail")
+    }
+
+    // The output is not given back when the window closes.
+    items := inv.TakeAllItems()
+    if len(items) != 2 {
+        t.Errorf("expected the two payment items back, got %v", items)
+    }
+}
+
+// merchantPlayer records the offers that a villager shows to a player, and
+// whether it agreed to the player's last trade.
+type merchantPlayer struct {
+    IPlayerClient
+    merchant EntityId
+    offers   []MerchantOffer
+    accepted bool
+}
+
+func (player *merchantPlayer) OpenMerchant(merchant EntityId, position AbsXyz, offers []MerchantOffer) {
+    player.merchant, player.offers = merchant, offers
+}
+
+func (player *merchantPlayer) TradeResult(merchant EntityId, accepted bool, offers []MerchantOffer) {
+    player.merchant, player.accepted, player.offers = merchant, accepted, offers
+}
+
+type randChunk struct {
+    IChunkBlock
+    rand *rand.Rand
+}
+
+func (chunk *randChunk) Rand() *rand.Rand {
+    return chunk.rand
+}
+
+func TestVillager_trade(t *testing.T) {
+    defer func(items ItemTypeMap) { Items = items }(Items)
+    Items = make(ItemTypeMap)
+
+    chunk := &randChunk{rand: rand.New(rand.NewSource(1))}
+    player := &merchantPlayer{}
+    v := NewVillager().(*Villager)
+    v.EntityId = 10
+
+    // Right-clicking on a new villager gives it its first offer, and opens
+    // its trading window.
+    if v.Use(chunk, player, &Slot{}, false) {
+        t.Fatalf("expected villager not to be destroyed")
+    }
+    if player.merchant != v.EntityId || len(player.offers) != 1 {
+        t.Fatalf("expected the window to open with 1 offer from villager %d, got %d offers from %d", v.EntityId, len(player.offers), player.merchant)
+    }
+    offer := player.offers[0]
+    for _, item := range []Slot{offer.Buy, offer.BuySecond, offer.Sell} {
+        if !item.IsEmpty() {
+            makeItemType(item.ItemTypeId)
+        }
+    }
+
+    // Each trade in the window is put to the villager, which agrees to it and
+    // sends back its updated offers, until the offer is locked.
+    inv := NewMerchantInventory(player.offers)
+    for i := int32(0); i < offer.MaxUses; i++ {
+        click := Click{SlotId: merchantSlotPayment, Cursor: offer.Buy}
+        checkTx(t, TxStateAccepted, inv.Click(&click))
+        if !offer.BuySecond.IsEmpty() {
+            click = Click{SlotId: merchantSlotPaymentSecond, Cursor: offer.BuySecond}
+            checkTx(t, TxStateAccepted, inv.Click(&click))
+        }
+        click = Click{SlotId: merchantSlotOutput, ExpectedSlot: offer.Sell}
+        checkTx(t, TxStateDeferred, inv.Click(&click))
+
+        trade, ok := inv.TakeTrade()
+        if !ok {
+            t.Fatalf("expected trade %d to be made", i)
+        }
+        v.Trade(player, trade.Index, &trade.Offer)
+        if !player.accepted {
+            t.Errorf("expected trade %d to be agreed to", i)
+        }
+        inv.EndTrade(player.accepted, trade.Payment)
+        inv.SetOffers(player.offers)
+    }
+
+    if !v.offers[0].IsLocked() || !inv.Offers()[0].IsLocked() {
+        t.Errorf("expected the offer to be locked after %d trades, has %d uses", offer.MaxUses, v.offers[0].Uses)
+    }
+    if v.restockTicks != villagerRestockTicks {
+        t.Errorf("expected the villager to restock after its newest offer was traded")
+    }
+
+    // Nothing more can be bought.
+    click := Click{SlotId: merchantSlotPayment, Cursor: offer.Buy}
+    checkTx(t, TxStateAccepted, inv.Click(&click))
+    checkSlot(t, emptySlot, inv.slots[merchantSlotOutput])
+
+    // Not even by a player whose copy of the offers is out of date.
+    v.Trade(player, 0, &offer)
+    if player.accepted {
+        t.Errorf("expected the locked offer not to be traded")
+    }
+
+    // Once unlocked, offers can only be traded as they are.
+    v.restock(chunk.rand)
+    changed := offer
+    changed.Sell.Count++
+    v.Trade(player, 0, &changed)
+    if player.accepted {
+        t.Errorf("expected an offer that has changed not to be traded")
+    }
+    v.Trade(player, len(v.offers), &offer)
+    if player.accepted {
+        t.Errorf("expected a missing offer not to be traded")
+    }
+    v.Trade(player, 0, &offer)
+    if !player.accepted {
+        t.Errorf("expected the unlocked offer to be traded")
+    }
+}
+
+func TestVillager_professionMetadata(t *testing.T) {
+    v := NewVillager().(*Villager)
+    v.SetProfession(VillagerProfessionBlacksmith)
+
+    for _, entry := range v.FormatMetadata() {
+        if entry.Field2 == villagerMetadataProfession {
+            if entry != (proto.EntityMetadata{2, villagerMetadataProfession, int32(VillagerProfessionBlacksmith)}) {
+                t.Errorf("expected blacksmith profession int, got %v", entry)
+            }
+            return
+        }
+    }
+    t.Errorf("expected profession metadata")
+}
+
+func TestVillager_restock(t *testing.T) {
+    v := NewVillager().(*Villager)
+    v.offers = makeMerchantOffers()
+    v.offers[0].Uses = 2
+
+    v.restock(rand.New(rand.NewSource(1)))
+
+    if v.offers[0].IsLocked() {
+        t.Errorf("expected the locked offer to be unlocked")
+    }
+    if v.offers[0].MaxUses < 4 || v.offers[0].MaxUses > 14 {
+        t.Errorf("expected the unlocked offer to have 2-12 more uses, has max uses %d", v.offers[0].MaxUses)
+    }
+    if len(v.offers) < 2 || len(v.offers) > 3 {
+        t.Errorf("expected a new offer for the farmer to be added or replace another, have %d offers", len(v.offers))
+    }
+}
+
+func TestVillager_Nbt(t *testing.T) {
+    v := NewVillager().(*Villager)
+    v.SetProfession(VillagerProfessionLibrarian)
+    v.offers = makeMerchantOffers()
+    v.offers[0].Uses = 2
+    v.offers[1].BuySecond = Slot{wheatId, 3, 0, nil}
+
+    tag := nbt.NewCompound()
+    if err := v.MarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error marshalling villager: %v", err)
+    }
+
+    loaded := NewVillager().(*Villager)
+    if err := loaded.UnmarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error unmarshalling villager: %v", err)
+    }
+
+    if loaded.Profession() != VillagerProfessionLibrarian {
+        t.Errorf("expected librarian, got profession %d", loaded.Profession())
+    }
+    if len(loaded.offers) != len(v.offers) {
+        t.Fatalf("expected %d offers, got %d", len(v.offers), len(loaded.offers))
+    }
+    for i := range v.offers {
+        want, got := &v.offers[i], &loaded.offers[i]
+        if !got.IsSameTrade(want) || !got.Buy.Equals(&want.Buy) || !got.BuySecond.Equals(&want.BuySecond) || got.Uses != want.Uses || got.MaxUses != want.MaxUses {
+            t.Errorf("offer %d: expected %+v, got %+v", i, *want, *got)
+        }
+    }
+    if !loaded.offers[0].IsLocked() {
+        t.Errorf("expected offer 0 to still be locked")
+    }
+}
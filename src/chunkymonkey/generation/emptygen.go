@@ -0,0 +1,39 @@
+package generation
+
+import (
+    "errors"
+
+    "chunkymonkey/chunkstore"
+    . "chunkymonkey/types"
+)
+
+// EmptyGenerator implements chunkstore.IChunkStore. It generates chunks that
+// contain nothing but air, for dimensions that do not yet have a generator of
+// their own.
+type EmptyGenerator struct{}
+
+func NewEmptyGenerator() *EmptyGenerator {
+    return &EmptyGenerator{}
+}
+
+func (gen *EmptyGenerator) SupportsWrite() bool {
+    return false
+}
+
+func (gen *EmptyGenerator) Writer() chunkstore.IChunkWriter {
+    return nil
+}
+
+func (gen *EmptyGenerator) WriteChunk(writer chunkstore.IChunkWriter) error {
+    return errors.New("writes not supported by EmptyGenerator")
+}
+
+func (gen *EmptyGenerator) ReadChunk(chunkLoc ChunkXz) (reader chunkstore.IChunkReader, err error) {
+    data := newChunkData(chunkLoc)
+
+    for i := range data.skyLight {
+        data.skyLight[i] = 0xff
+    }
+
+    return data, nil
+}
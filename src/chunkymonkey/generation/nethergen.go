@@ -0,0 +1,252 @@
+package generation
+
+import (
+    "errors"
+    "math/rand"
+
+    "chunkymonkey/chunkstore"
+    . "chunkymonkey/types"
+    "perlin"
+)
+
+const (
+    // Lava fills the nether's caverns up to this height.
+    NetherLavaLevel = 31
+
+    // Average heights of the floor and ceiling of the nether's caverns.
+    netherFloorBase   = 24
+    netherCeilingBase = 96
+
+    // Height of the ragged bedrock layers at the bottom and top of the nether.
+    netherBedrockDepth = 4
+
+    // Number of glowstone clusters attempted per chunk, and the number of
+    // blocks placed in each.
+    netherGlowstoneClusters = 3
+    netherGlowstoneSize     = 24
+
+    blockIdAir        = 0
+    blockIdBedrock    = 7
+    blockIdLava       = 11 // stationary lava
+    blockIdGravel     = 13
+    blockIdNetherrack = 87
+    blockIdSoulSand   = 88
+    blockIdGlowstone  = 89
+)
+
+// NetherGenerator implements chunkstore.IChunkStore. It generates nether
+// terrain: netherrack caverns over a lava ocean, between floors and ceilings of
+// bedrock. The chunks generated depend only on the seed and the chunk
+// location.
+type NetherGenerator struct {
+    seed          int64
+    floorSource   ISource
+    ceilingSource ISource
+    pillarSource  ISource
+    surfaceSource ISource
+}
+
+func NewNetherGenerator(seed int64) *NetherGenerator {
+    perlin := perlin.NewPerlinNoise(seed)
+
+    return &NetherGenerator{
+        seed: seed,
+        floorSource: &Sum{
+            Inputs: []ISource{
+                &Turbulence{
+                    Dx:     &Scale{30, 1, &Offset{20.1, 0, perlin}},
+                    Dy:     &Scale{30, 1, &Offset{10.1, 0, perlin}},
+                    Factor: 20,
+                    Source: &Scale{
+                        Wavelength: 60,
+                        Amplitude:  24,
+                        Source:     perlin,
+                    },
+                },
+                &Scale{
+                    Wavelength: 8,
+                    Amplitude:  3,
+                    Source:     &Offset{0, 40.3, perlin},
+                },
+            },
+        },
+        ceilingSource: &Sum{
+            Inputs: []ISource{
+                &Scale{
+                    Wavelength: 50,
+                    Amplitude:  20,
+                    Source:     &Offset{60.7, 0, perlin},
+                },
+                &Scale{
+                    Wavelength: 6,
+                    Amplitude:  4,
+                    Source:     &Offset{0, 70.9, perlin},
+                },
+            },
+        },
+        // Joins the floor and ceiling in places, making columns and walls that
+        // divide the caverns.
+        pillarSource: &Scale{
+            Wavelength: 20,
+            Amplitude:  1,
+            Source:     &Offset{90.3, 90.7, perlin},
+        },
+        // Chooses where the floor is covered in soul sand or gravel.
+        surfaceSource: &Scale{
+            Wavelength: 16,
+            Amplitude:  1,
+            Source:     &Offset{120.5, 30.1, perlin},
+        },
+    }
+}
+
+func (gen *NetherGenerator) SupportsWrite() bool {
+    return false
+}
+
+func (gen *NetherGenerator) Writer() chunkstore.IChunkWriter {
+    return nil
+}
+
+func (gen *NetherGenerator) WriteChunk(writer chunkstore.IChunkWriter) error {
+    return errors.New("writes not supported by NetherGenerator")
+}
+
+// chunkRand returns a random number generator for details within a chunk,
+// seeded from the world seed and the chunk location so that regenerating a
+// chunk gives the same result.
+func (gen *NetherGenerator) chunkRand(chunkLoc ChunkXz) *rand.Rand {
+    chunkSeed := gen.seed ^ int64(chunkLoc.X)*341873128712 ^ int64(chunkLoc.Z)*132897987541
+    return rand.New(rand.NewSource(chunkSeed))
+}
+
+func (gen *NetherGenerator) ReadChunk(chunkLoc ChunkXz) (reader chunkstore.IChunkReader, err error) {
+    baseBlockXyz := chunkLoc.ChunkCornerBlockXY()
+    baseX, baseZ := baseBlockXyz.X, baseBlockXyz.Z
+
+    data := newChunkData(chunkLoc)
+    rnd := gen.chunkRand(chunkLoc)
+
+    baseIndex := BlockIndex(0)
+    heightMapIndex := 0
+    for x := 0; x < ChunkSizeH; x++ {
+        for z := 0; z < ChunkSizeH; z++ {
+            xf, zf := float64(x)+float64(baseX), float64(z)+float64(baseZ)
+
+            floor := netherFloorBase + int(gen.floorSource.At2d(xf, zf))
+            ceiling := netherCeilingBase + int(gen.ceilingSource.At2d(xf, zf))
+            if gen.pillarSource.At2d(xf, zf) > 0.45 {
+                ceiling = floor
+            }
+
+            gen.setBlockStack(
+                floor, ceiling,
+                gen.surfaceSource.At2d(xf, zf),
+                rnd,
+                data.blocks[baseIndex:baseIndex+ChunkSizeY])
+
+            // No sky light reaches into the nether.
+            data.heightMap[heightMapIndex] = ChunkSizeY
+
+            heightMapIndex++
+            baseIndex += ChunkSizeY
+        }
+    }
+
+    gen.addGlowstone(data, rnd)
+
+    return data, nil
+}
+
+// setBlockStack fills a column of blocks with the open cavern between floor
+// and ceiling.
+func (gen *NetherGenerator) setBlockStack(floor, ceiling int, surface float64, rnd *rand.Rand, blocks []byte) {
+    if floor < netherBedrockDepth {
+        floor = netherBedrockDepth
+    }
+    if ceiling > ChunkSizeY-netherBedrockDepth-1 {
+        ceiling = ChunkSizeY - netherBedrockDepth - 1
+    }
+
+    for y := 0; y < ChunkSizeY; y++ {
+        switch {
+        case y <= floor || y >= ceiling:
+            blocks[y] = blockIdNetherrack
+        case y <= NetherLavaLevel:
+            blocks[y] = blockIdLava
+        default:
+            blocks[y] = blockIdAir
+        }
+    }
+
+    // Soul sand and gravel collect on the floor near the shore of the lava
+    // ocean.
+    if floor <= NetherLavaLevel+4 && floor < ceiling {
+        var surfaceBlock byte
+        switch {
+        case surface > 0.3:
+            surfaceBlock = blockIdSoulSand
+        case surface < -0.4:
+            surfaceBlock = blockIdGravel
+        }
+        if surfaceBlock != 0 {
+            for y := floor; y > floor-3 && y > 0; y-- {
+                blocks[y] = surfaceBlock
+            }
+        }
+    }
+
+    // Bedrock is solid at the very bottom and top, becoming more ragged
+    // further in.
+    for depth := 0; depth < netherBedrockDepth; depth++ {
+        if depth == 0 || rnd.Intn(netherBedrockDepth) >= depth {
+            blocks[depth] = blockIdBedrock
+        }
+        if depth == 0 || rnd.Intn(netherBedrockDepth) >= depth {
+            blocks[ChunkSizeY-1-depth] = blockIdBedrock
+        }
+    }
+}
+
+// addGlowstone grows clusters of glowstone down from the cavern ceilings. The
+// clusters are kept within the chunk.
+func (gen *NetherGenerator) addGlowstone(data *ChunkData, rnd *rand.Rand) {
+    var subLoc SubChunkXyz
+
+    for cluster := 0; cluster < netherGlowstoneClusters; cluster++ {
+        x := 1 + rnd.Intn(ChunkSizeH-2)
+        z := 1 + rnd.Intn(ChunkSizeH-2)
+
+        // Find the underside of the ceiling above the lava ocean.
+        columnIndex := (x*ChunkSizeH + z) * ChunkSizeY
+        y := ChunkSizeY - netherBedrockDepth - 1
+        for y > NetherLavaLevel && data.blocks[columnIndex+y] != blockIdAir {
+            y--
+        }
+        if y <= NetherLavaLevel {
+            continue
+        }
+        top := y + 1
+
+        subLoc = SubChunkXyz{SubChunkCoord(x), SubChunkCoord(y), SubChunkCoord(z)}
+        index, _ := subLoc.BlockIndex()
+        data.blocks[index] = blockIdGlowstone
+
+        // Randomly grow the cluster from blocks touching what is already
+        // there.
+        for i := 0; i < netherGlowstoneSize; i++ {
+            subLoc = SubChunkXyz{
+                SubChunkCoord(x + rnd.Intn(5) - 2),
+                SubChunkCoord(top - 1 - rnd.Intn(6)),
+                SubChunkCoord(z + rnd.Intn(5) - 2),
+            }
+            index, ok := subLoc.BlockIndex()
+            if !ok || data.blocks[index] != blockIdAir {
+                continue
+            }
+            if adjacentBlockIs(data, int(subLoc.X), int(subLoc.Y), int(subLoc.Z), 1, 1, 1, blockIdGlowstone) {
+                data.blocks[index] = blockIdGlowstone
+            }
+        }
+    }
+}
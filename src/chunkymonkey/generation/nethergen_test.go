@@ -0,0 +1,69 @@
+package generation
+
+import (
+    "bytes"
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func TestNetherGenerator(t *testing.T) {
+    allowed := map[byte]bool{
+        blockIdAir:        true,
+        blockIdBedrock:    true,
+        blockIdLava:       true,
+        blockIdGravel:     true,
+        blockIdNetherrack: true,
+        blockIdSoulSand:   true,
+        blockIdGlowstone:  true,
+    }
+
+    gen := NewNetherGenerator(1234)
+    sawLava, sawAir := false, false
+
+    for _, loc := range []ChunkXz{{0, 0}, {-3, 7}, {20, -11}} {
+        reader, err := gen.ReadChunk(loc)
+        if err != nil {
+            t.Fatalf("chunk %v: %v", loc, err)
+        }
+        blocks := reader.Blocks()
+
+        for column := 0; column < ChunkSizeH*ChunkSizeH; column++ {
+            stack := blocks[column*ChunkSizeY : (column+1)*ChunkSizeY]
+            if stack[0] != blockIdBedrock || stack[ChunkSizeY-1] != blockIdBedrock {
+                t.Errorf("chunk %v column %d: expected bedrock floor and ceiling", loc, column)
+            }
+            for _, block := range stack {
+                if !allowed[block] {
+                    t.Errorf("chunk %v column %d: unexpected block %d", loc, column, block)
+                }
+                sawLava = sawLava || block == blockIdLava
+                sawAir = sawAir || block == blockIdAir
+            }
+        }
+
+        // The same chunk must come out the same from a new generator with the
+        // same seed.
+        again, _ := NewNetherGenerator(1234).ReadChunk(loc)
+        if !bytes.Equal(blocks, again.Blocks()) {
+            t.Errorf("chunk %v: not generated deterministically", loc)
+        }
+    }
+
+    if !sawLava || !sawAir {
+        t.Errorf("expected caverns with lava, got lava=%t air=%t", sawLava, sawAir)
+    }
+}
+
+func Benchmark_NetherGenerator_generate(b *testing.B) {
+    gen := NewNetherGenerator(0)
+    var loc ChunkXz
+
+    b.ResetTimer()
+    b.StartTimer()
+
+    for i := 0; i < b.N; i++ {
+        loc.X = ChunkCoord(i & 0xffff)
+        gen.ReadChunk(loc)
+    }
+}
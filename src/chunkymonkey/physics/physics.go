@@ -52,6 +52,10 @@ func (obj *PointObject) Position() *AbsXyz {
     return &obj.position
 }
 
+func (obj *PointObject) Velocity() *AbsVelocity {
+    return &obj.velocity
+}
+
 func (obj *PointObject) Init(position AbsXyz, velocity AbsVelocity) {
     obj.LastSentPosition = *position.ToAbsIntXyz()
     obj.LastSentVelocity = *velocity.ToVelocity()
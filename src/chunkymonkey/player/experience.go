@@ -0,0 +1,82 @@
+package player
+
+import (
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+const (
+    // Experience dropped on death for each level that the player had, and the
+    // most that is dropped.
+    deathExperiencePerLevel = 7
+    deathExperienceMax      = 100
+)
+
+// experience tracks a player's experience level and progress to the next.
+type experience struct {
+    level int32
+    // Fraction of the way to the next level.
+    progress float32
+    // Total experience collected.
+    total Experience
+}
+
+// levelExperience returns the experience needed to go from the given level to
+// the next.
+func levelExperience(level int32) Experience {
+    switch {
+    case level >= 30:
+        return 62 + Experience(level-30)*7
+    case level >= 15:
+        return 17 + Experience(level-15)*3
+    }
+    return 17
+}
+
+// add gives experience, moving up levels as needed.
+func (xp *experience) add(amount Experience) {
+    if amount <= 0 {
+        return
+    }
+    xp.total += amount
+
+    points := float32(amount)
+    for points > 0 {
+        needed := float32(levelExperience(xp.level))
+        remaining := (1 - xp.progress) * needed
+        if points < remaining {
+            xp.progress += points / needed
+            break
+        }
+        points -= remaining
+        xp.level++
+        xp.progress = 0
+    }
+}
+
+// takeLevels removes experience levels, e.g to pay for enchanting an item.
+func (xp *experience) takeLevels(levels int32) {
+    xp.level -= levels
+    if xp.level < 0 {
+        xp.level = 0
+        xp.progress = 0
+    }
+}
+
+// deathDrop returns the experience dropped by a player that dies with this
+// experience.
+func (xp *experience) deathDrop() Experience {
+    amount := Experience(xp.level) * deathExperiencePerLevel
+    if amount > deathExperienceMax {
+        amount = deathExperienceMax
+    }
+    return amount
+}
+
+func (xp *experience) packet() *proto.PacketPlayerExperience {
+    return &proto.PacketPlayerExperience{
+        Experience:      xp.progress,
+        Level:           int16(xp.level),
+        TotalExperience: int16(xp.total),
+    }
+}
@@ -0,0 +1,62 @@
+package player
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func Test_experience_add(t *testing.T) {
+    type Test struct {
+        desc          string
+        amounts       []Experience
+        expectLevel   int32
+        expectPercent int
+        expectTotal   Experience
+    }
+
+    tests := []Test{
+        {"nothing", nil, 0, 0, 0},
+        {"part level", []Experience{5}, 0, 29, 5},
+        {"exact level", []Experience{17}, 1, 0, 17},
+        {"several orbs", []Experience{3, 7, 7, 3}, 1, 18, 20},
+        {"several levels", []Experience{17 * 15}, 15, 0, 255},
+        {"past 15", []Experience{17*15 + 10}, 15, 59, 265},
+    }
+
+    for _, test := range tests {
+        var xp experience
+        for _, amount := range test.amounts {
+            xp.add(amount)
+        }
+        percent := int(xp.progress*100 + 0.5)
+        if xp.level != test.expectLevel || percent != test.expectPercent || xp.total != test.expectTotal {
+            t.Errorf("%s: expected level %d %d%% total %d, got level %d %d%% total %d",
+                test.desc, test.expectLevel, test.expectPercent, test.expectTotal,
+                xp.level, percent, xp.total)
+        }
+    }
+}
+
+func Test_experience_deathDrop(t *testing.T) {
+    xp := experience{level: 3}
+    if drop := xp.deathDrop(); drop != 21 {
+        t.Errorf("expected 21 dropped at level 3, got %d", drop)
+    }
+    xp = experience{level: 30}
+    if drop := xp.deathDrop(); drop != deathExperienceMax {
+        t.Errorf("expected %d dropped at level 30, got %d", deathExperienceMax, drop)
+    }
+}
+
+func Test_experience_takeLevels(t *testing.T) {
+    xp := experience{level: 12, progress: 0.5, total: 300}
+    xp.takeLevels(5)
+    if xp.level != 7 || xp.progress != 0.5 || xp.total != 300 {
+        t.Errorf("expected level 7 50%% total 300, got %+v", xp)
+    }
+    xp.takeLevels(30)
+    if xp.level != 0 || xp.progress != 0 {
+        t.Errorf("expected level 0 0%%, got %+v", xp)
+    }
+}
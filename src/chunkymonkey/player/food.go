@@ -0,0 +1,131 @@
+package player
+
+import (
+    "flag"
+
+    . "chunkymonkey/types"
+)
+
+var playerDifficulty = flag.Int(
+    "difficulty", int(GameDifficultyPeaceful),
+    "Game difficulty (0-3), which sets how much players are hurt by "+
+        "starvation.")
+
+const (
+    // Saturation that players start with.
+    initialFoodSaturation = 5
+
+    // Exhaustion at which a point of saturation or food is used up, and the
+    // most exhaustion that can build up.
+    exhaustionPerFood = 4
+    maxExhaustion     = 40
+
+    // Exhaustion from the things that players do.
+    exhaustionBreakBlock   = 0.025
+    exhaustionAttack       = 0.3
+    exhaustionHurt         = 0.3
+    exhaustionJump         = 0.2
+    exhaustionSprintJump   = 0.8
+    exhaustionWalkPerBlock = 0.01
+    exhaustionSprintBlock  = 0.1
+    exhaustionRegenerate   = 3
+
+    // Food level at or above which players regenerate health.
+    foodRegenerateLevel = 18
+
+    // Ticks between health being regenerated or lost to starvation.
+    foodHealthTicks = 80
+    // Ticks between health being regenerated on peaceful difficulty.
+    peacefulRegenerateTicks = 20
+
+    // Rise in a single move that is taken to be the start of a jump.
+    jumpRise = AbsCoord(0.4)
+
+    // Ticks that it takes to eat food.
+    eatingTicks = 32
+)
+
+// foodStats tracks a player's hunger.
+type foodStats struct {
+    level      FoodUnits
+    saturation float32
+    exhaustion float32
+    // Ticks counted towards the next change in health.
+    timer Ticks
+}
+
+func newFoodStats() foodStats {
+    return foodStats{
+        level:      MaxFoodUnits,
+        saturation: initialFoodSaturation,
+    }
+}
+
+// addExhaustion records the effort of something that the player has done.
+func (food *foodStats) addExhaustion(amount float32) {
+    food.exhaustion += amount
+    if food.exhaustion > maxExhaustion {
+        food.exhaustion = maxExhaustion
+    }
+}
+
+// eat adds the food points and saturation of eaten food. Saturation cannot
+// exceed the food level.
+func (food *foodStats) eat(points FoodUnits, saturationModifier float32) {
+    food.level += points
+    if food.level > MaxFoodUnits {
+        food.level = MaxFoodUnits
+    }
+    food.saturation += float32(points) * saturationModifier * 2
+    if food.saturation > float32(food.level) {
+        food.saturation = float32(food.level)
+    }
+}
+
+// tick runs the hunger model for a tick, and returns the change in health
+// that the player should have as a result: +1 when regenerating health, and
+// -1 when starving.
+func (food *foodStats) tick(difficulty GameDifficulty, health Health) (change Health) {
+    if food.exhaustion >= exhaustionPerFood {
+        food.exhaustion -= exhaustionPerFood
+        if food.saturation > 0 {
+            food.saturation--
+            if food.saturation < 0 {
+                food.saturation = 0
+            }
+        } else if difficulty > GameDifficultyPeaceful && food.level > 0 {
+            food.level--
+        }
+    }
+
+    switch {
+    case difficulty == GameDifficultyPeaceful && health < MaxHealth:
+        food.timer++
+        if food.timer >= peacefulRegenerateTicks {
+            food.timer = 0
+            change = 1
+        }
+    case food.level >= foodRegenerateLevel && health < MaxHealth:
+        food.timer++
+        if food.timer >= foodHealthTicks {
+            food.timer = 0
+            // Health regenerated from food is paid for with exhaustion.
+            food.addExhaustion(exhaustionRegenerate)
+            change = 1
+        }
+    case food.level <= 0:
+        food.timer++
+        if food.timer >= foodHealthTicks {
+            food.timer = 0
+            // Starvation only kills players on hard difficulty, and leaves
+            // them with half of their health on easy.
+            if health > MaxHealth/2 || difficulty >= GameDifficultyHard || (health > 1 && difficulty >= GameDifficultyNormal) {
+                change = -1
+            }
+        }
+    default:
+        food.timer = 0
+    }
+
+    return
+}
@@ -0,0 +1,96 @@
+package player
+
+import (
+    "testing"
+
+    . "chunkymonkey/types"
+)
+
+func Test_foodStats_exhaustion(t *testing.T) {
+    food := newFoodStats()
+
+    // Exhaustion uses up saturation before food.
+    for i := 0; i < initialFoodSaturation; i++ {
+        food.addExhaustion(exhaustionPerFood)
+        food.tick(GameDifficultyNormal, MaxHealth)
+    }
+    if food.saturation != 0 || food.level != MaxFoodUnits {
+        t.Fatalf("expected saturation to be used up first, got %+v", food)
+    }
+
+    food.addExhaustion(exhaustionPerFood)
+    food.tick(GameDifficultyNormal, MaxHealth)
+    if food.level != MaxFoodUnits-1 {
+        t.Errorf("expected food level %d, got %d", MaxFoodUnits-1, food.level)
+    }
+
+    // Peaceful players do not get hungry.
+    food.addExhaustion(exhaustionPerFood)
+    food.tick(GameDifficultyPeaceful, MaxHealth)
+    if food.level != MaxFoodUnits-1 {
+        t.Errorf("expected peaceful food level %d, got %d", MaxFoodUnits-1, food.level)
+    }
+}
+
+func Test_foodStats_tick(t *testing.T) {
+    type Test struct {
+        desc       string
+        level      FoodUnits
+        difficulty GameDifficulty
+        health     Health
+        expect     Health
+    }
+
+    tests := []Test{
+        {"fed and hurt", 18, GameDifficultyNormal, 10, 1},
+        {"fed and healthy", 20, GameDifficultyNormal, MaxHealth, 0},
+        {"hungry", 10, GameDifficultyNormal, 10, 0},
+        {"starving on normal", 0, GameDifficultyNormal, 10, -1},
+        {"starving on normal at 1", 0, GameDifficultyNormal, 1, 0},
+        {"starving on easy at 10", 0, GameDifficultyEasy, 10, 0},
+        {"starving on hard at 1", 0, GameDifficultyHard, 1, -1},
+    }
+
+    for _, test := range tests {
+        food := foodStats{level: test.level}
+        var change Health
+        for i := 0; i < foodHealthTicks; i++ {
+            change += food.tick(test.difficulty, test.health)
+        }
+        if change != test.expect {
+            t.Errorf("%s: expected health change %d, got %d", test.desc, test.expect, change)
+        }
+    }
+}
+
+func Test_foodStats_regenerateExhaustion(t *testing.T) {
+    food := foodStats{level: MaxFoodUnits}
+
+    for i := 0; i < foodHealthTicks; i++ {
+        food.tick(GameDifficultyNormal, 10)
+    }
+    if food.exhaustion != exhaustionRegenerate {
+        t.Errorf("expected exhaustion %v after regenerating, got %+v", float32(exhaustionRegenerate), food)
+    }
+
+    // The next point of health regenerated uses up food, on the tick after.
+    for i := 0; i <= foodHealthTicks; i++ {
+        food.tick(GameDifficultyNormal, 11)
+    }
+    if food.level != MaxFoodUnits-1 || food.exhaustion != 2*exhaustionRegenerate-exhaustionPerFood {
+        t.Errorf("expected food level %d after regenerating twice, got %+v", MaxFoodUnits-1, food)
+    }
+}
+
+func Test_foodStats_eat(t *testing.T) {
+    food := foodStats{level: 10}
+    food.eat(8, 0.8)
+    if food.level != 18 || food.saturation != 12.8 {
+        t.Errorf("expected level 18 saturation 12.8, got %+v", food)
+    }
+
+    food.eat(8, 0.8)
+    if food.level != MaxFoodUnits || food.saturation != float32(MaxFoodUnits) {
+        t.Errorf("expected food and saturation capped at %d, got %+v", MaxFoodUnits, food)
+    }
+}
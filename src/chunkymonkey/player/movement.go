@@ -0,0 +1,197 @@
+package player
+
+import (
+    "flag"
+    "math"
+    "time"
+
+    . "chunkymonkey/types"
+)
+
+var (
+    playerMoveMaxSpeed = flag.Float64(
+        "player_move_max_speed", 10,
+        "Maximum horizontal speed (in blocks per second) that players may move "+
+            "at, averaged over player_move_window.")
+
+    playerMoveWindow = flag.Duration(
+        "player_move_window", time.Second,
+        "Period over which player movement speed is measured.")
+
+    playerMoveMaxHover = flag.Duration(
+        "player_move_max_hover", 2*time.Second,
+        "Maximum time that players may stay in the air without falling.")
+
+    playerMoveKickViolations = flag.Int(
+        "player_move_kick_violations", 20,
+        "Number of movement violations within player_move_violation_period "+
+            "after which a player is kicked. Zero disables kicking.")
+
+    playerMoveViolationPeriod = flag.Duration(
+        "player_move_violation_period", time.Minute,
+        "Period over which player movement violations are counted.")
+)
+
+const (
+    // Greatest height that players can jump above the ground they last stood
+    // on, with some slack.
+    maxJumpHeight = AbsCoord(1.5)
+
+    // Extra horizontal distance allowed within the window on top of the
+    // maximum speed, for the jitter of packets arriving in bursts.
+    moveDistanceSlack = AbsCoord(2)
+
+    // Players that are allowed to fly may move this much faster.
+    flyingSpeedFactor = 2
+
+    // Extra speed allowed for each level of the speed effect, as a fraction
+    // of normal speed, and extra jump height for each level of jump boost.
+    speedEffectFactor = 0.2
+    jumpEffectHeight  = AbsCoord(1)
+)
+
+// moveSample is a position that a player moved to, and when.
+type moveSample struct {
+    at       time.Time
+    position AbsXyz
+}
+
+// moveValidator checks the movement that a player's client reports for
+// speeds, flight and passing through blocks that the player should not be
+// capable of.
+type moveValidator struct {
+    // Moves made within the current window, oldest first.
+    samples []moveSample
+
+    // lastGood is the last position known to be valid, which the player is
+    // sent back to when they move invalidly.
+    lastGood AbsXyz
+
+    // Height of the ground that the player last stood on.
+    groundY AbsCoord
+    // Whether the player is in the air, and the last time that they were seen
+    // to be falling or on the ground.
+    inAir      bool
+    lastDropAt time.Time
+    lastY      AbsCoord
+
+    // Moves sent to a shard for checking that have not been reported on, and
+    // how many of those were made before the last reset and so are to be
+    // ignored.
+    checksPending int
+    checksStale   int
+
+    // Times of recent violations.
+    violations []time.Time
+
+    // allowFlight is set for players that are allowed to fly, such as those
+    // in creative mode. They may also move faster.
+    allowFlight bool
+
+    // Extra speed (as a fraction of normal) and jump height that status
+    // effects give the player.
+    speedBoost float64
+    jumpBoost  AbsCoord
+}
+
+// reset starts validating afresh from the given position, which the server
+// has put the player at.
+func (v *moveValidator) reset(position AbsXyz, now time.Time) {
+    v.samples = v.samples[:0]
+    v.lastGood = position
+    v.groundY = position.Y
+    v.inAir = false
+    v.lastDropAt = now
+    v.lastY = position.Y
+    v.checksStale = v.checksPending
+}
+
+// checkSpeed records a move to the given position, and returns false if the
+// player has moved further than they could within the window.
+func (v *moveValidator) checkSpeed(position AbsXyz, now time.Time) bool {
+    windowStart := now.Add(-*playerMoveWindow)
+    expired := 0
+    for expired < len(v.samples) && v.samples[expired].at.Before(windowStart) {
+        expired++
+    }
+    if expired > 0 {
+        // Keep the newest of the expired samples as the start of the window.
+        v.samples = v.samples[expired-1:]
+    }
+    v.samples = append(v.samples, moveSample{now, position})
+
+    distance := AbsCoord(0)
+    for i := 1; i < len(v.samples); i++ {
+        from, to := &v.samples[i-1].position, &v.samples[i].position
+        dx, dz := float64(to.X-from.X), float64(to.Z-from.Z)
+        distance += AbsCoord(math.Sqrt(dx*dx + dz*dz))
+    }
+
+    maxDistance := AbsCoord(*playerMoveMaxSpeed*playerMoveWindow.Seconds()) + moveDistanceSlack
+    if v.allowFlight {
+        maxDistance *= flyingSpeedFactor
+    }
+    maxDistance *= AbsCoord(1 + v.speedBoost)
+    return distance <= maxDistance
+}
+
+// checkAir updates whether the player is standing on something at the given
+// position, and returns false if they appear to be flying.
+func (v *moveValidator) checkAir(position AbsXyz, supported bool, now time.Time) bool {
+    defer func() {
+        v.lastY = position.Y
+    }()
+
+    if supported || v.allowFlight {
+        v.inAir = false
+        v.groundY = position.Y
+        v.lastDropAt = now
+        return true
+    }
+
+    if !v.inAir {
+        v.inAir = true
+        v.lastDropAt = now
+    } else if position.Y < v.lastY {
+        v.lastDropAt = now
+    }
+
+    if position.Y > v.groundY+maxJumpHeight+v.jumpBoost {
+        return false
+    }
+
+    return now.Sub(v.lastDropAt) <= *playerMoveMaxHover
+}
+
+// checkSent records that a move has been sent to a shard to be checked.
+func (v *moveValidator) checkSent() {
+    v.checksPending++
+}
+
+// checkReceived records that a shard has reported on a move. It returns false
+// if the move was made before the last reset, and should be ignored.
+func (v *moveValidator) checkReceived() bool {
+    if v.checksPending > 0 {
+        v.checksPending--
+    }
+    if v.checksStale > 0 {
+        v.checksStale--
+        return false
+    }
+    return true
+}
+
+// addViolation records an invalid move, and returns true if the player has
+// made enough of them recently to be kicked.
+func (v *moveValidator) addViolation(now time.Time) bool {
+    periodStart := now.Add(-*playerMoveViolationPeriod)
+    recent := v.violations[:0]
+    for _, at := range v.violations {
+        if !at.Before(periodStart) {
+            recent = append(recent, at)
+        }
+    }
+    v.violations = append(recent, now)
+
+    return *playerMoveKickViolations > 0 && len(v.violations) >= *playerMoveKickViolations
+}
@@ -0,0 +1,111 @@
+package player
+
+import (
+    "testing"
+    "time"
+
+    . "chunkymonkey/types"
+)
+
+func TestMoveValidator_checkSpeed(t *testing.T) {
+    start := time.Unix(1000, 0)
+    tick := time.Second / TicksPerSecond
+
+    type Test struct {
+        desc        string
+        stepPerTick AbsCoord
+        expectOk    bool
+    }
+
+    tests := []Test{
+        {"walking", 0.22, true},
+        {"sprinting", 0.28, true},
+        {"speed hack", 1.0, false},
+    }
+
+    for _, test := range tests {
+        var v moveValidator
+        v.reset(AbsXyz{0, 64, 0}, start)
+
+        ok := true
+        for i := 1; i <= 2*TicksPerSecond && ok; i++ {
+            position := AbsXyz{AbsCoord(i) * test.stepPerTick, 64, 0}
+            ok = v.checkSpeed(position, start.Add(time.Duration(i)*tick))
+        }
+        if ok != test.expectOk {
+            t.Errorf("%s: expected %t, got %t", test.desc, test.expectOk, ok)
+        }
+    }
+}
+
+func TestMoveValidator_checkAir(t *testing.T) {
+    start := time.Unix(1000, 0)
+    tick := time.Second / TicksPerSecond
+
+    type move struct {
+        y         AbsCoord
+        supported bool
+    }
+
+    type Test struct {
+        desc     string
+        moves    []move
+        expectOk bool
+    }
+
+    jump := []move{{64.4, false}, {64.8, false}, {65.1, false}, {65.2, false}, {64.9, false}, {64.3, false}, {64, true}}
+
+    var hover []move
+    for i := 0; i < 3*TicksPerSecond; i++ {
+        hover = append(hover, move{64.5, false})
+    }
+
+    var fall []move
+    for y := AbsCoord(63); y > 20; y-- {
+        fall = append(fall, move{y, false})
+    }
+
+    tests := []Test{
+        {"jump", jump, true},
+        {"fall", fall, true},
+        {"rising", []move{{64.5, false}, {65.2, false}, {66, false}}, false},
+        {"hover", hover, false},
+    }
+
+    for _, test := range tests {
+        var v moveValidator
+        v.reset(AbsXyz{0, 64, 0}, start)
+
+        ok := true
+        for i, m := range test.moves {
+            ok = v.checkAir(AbsXyz{0, m.y, 0}, m.supported, start.Add(time.Duration(i+1)*tick))
+            if !ok {
+                break
+            }
+        }
+        if ok != test.expectOk {
+            t.Errorf("%s: expected %t, got %t", test.desc, test.expectOk, ok)
+        }
+    }
+}
+
+func TestMoveValidator_addViolation(t *testing.T) {
+    start := time.Unix(1000, 0)
+
+    var v moveValidator
+    for i := 1; i < *playerMoveKickViolations; i++ {
+        // Violations spread out over time are forgotten.
+        if v.addViolation(start.Add(time.Duration(i) * *playerMoveViolationPeriod)) {
+            t.Fatalf("kicked after %d spread out violations", i)
+        }
+    }
+
+    v = moveValidator{}
+    kicked := false
+    for i := 0; i < *playerMoveKickViolations && !kicked; i++ {
+        kicked = v.addViolation(start.Add(time.Duration(i) * time.Second))
+    }
+    if !kicked {
+        t.Errorf("expected kick after %d violations", *playerMoveKickViolations)
+    }
+}
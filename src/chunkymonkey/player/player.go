@@ -1,11 +1,14 @@
 package player
 
 import (
+    "bytes"
+    "encoding/binary"
     "errors"
     "expvar"
     "flag"
     "fmt"
     "log"
+    "math"
     "math/rand"
     "net"
     "strings"
@@ -38,6 +41,35 @@ const (
 
     PingTimeout  = 60 * time.Second // Player connection times out after 60 seconds.
     PingInterval = 20 * time.Second // Time between receiving keep alive response from client and sending new request.
+
+    // The range of the time of day within which players can sleep in a bed.
+    SleepTimeStart = Ticks(12541)
+    SleepTimeEnd   = Ticks(23458)
+
+    // The Y coordinate sent by clients in position packets while riding a
+    // vehicle. The X and Z coordinates then hold the player's movement input.
+    ridingPositionY = AbsCoord(-999)
+
+    // Slot ID in PacketCreativeInventoryAction for items that are dropped
+    // rather than put into the inventory.
+    creativeDropSlotId = SlotId(-1)
+
+    // Thrown items leave from just below the player's eyes, moving in the
+    // direction that the player is looking, and cannot be picked up again
+    // straight away.
+    throwHeight         = AbsCoord(1.3)
+    throwSpeed          = AbsVelocityCoord(0.3)
+    throwLift           = AbsVelocityCoord(0.1)
+    throwPickupImmunity = 2 * TicksPerSecond
+
+    // Time that a player must stand in a portal before travelling through it.
+    portalDelay = 4 * time.Second
+    // Time without hearing that the player is in a portal after which they are
+    // considered to have left it.
+    portalContactTimeout = 1500 * time.Millisecond
+
+    // Minimum time between a player's attacks.
+    attackCooldown = 500 * time.Millisecond
 )
 
 func init() {
@@ -81,16 +113,23 @@ type Player struct {
     // The following attributes are game-logic related.
 
     // Data entries that may change
+    worldSpawn BlockXyz
     spawnBlock BlockXyz
+    // Set when spawnBlock is the player's bed, rather than the world spawn.
+    bedSpawn   bool
     position   AbsXyz
     height     AbsCoord
     look       LookDegrees
     chunkSubs  chunkSubscriptions
     health     Health
-    food       FoodUnits
+    food       foodStats
+    gameType   GameType
+    xp         experience
+    effects    gamerules.StatusEffects
+
+    dimension int32
 
     // The following data fields are loaded, but not used yet
-    dimension    int32
     onGround     int8
     sleeping     int8
     fallDistance float32
@@ -107,6 +146,33 @@ type Player struct {
     curWindow    window.IWindow
     nextWindowId WindowId
     remoteInv    *RemoteInventory
+    enderItems   gamerules.Inventory // Seen through any ender chest.
+    enchantInv   *gamerules.EnchantInventory // Of the open enchanting window.
+    anvilInv     *gamerules.AnvilInventory // Of the open anvil window.
+    anvilBlock   BlockXyz // Of the anvil being used.
+    merchantInv  *gamerules.MerchantInventory // Of the open trading window.
+    merchant     EntityId // Villager being traded with.
+    trade        *gamerules.MerchantTrade // Waiting for a villager to agree to it.
+    tradeWith    EntityId // Villager that the trade is waiting for.
+
+    vehicle EntityId // Entity being ridden, or EntityIdNull.
+
+    move moveValidator // Checks that the player's movement is possible.
+
+    // Time that the player stepped into the portal that they are in, and the
+    // last time that they were seen inside it.
+    portalEnteredAt time.Time
+    portalLastSeen  time.Time
+    // Set once the player has travelled through the portal that they are in,
+    // so that they do not go back until they have stepped out of it.
+    portalTravelled bool
+
+    sprinting bool
+    // Time of the player's last attack, to limit how often they can attack.
+    lastAttackAt time.Time
+    // Ticks left until the player finishes eating the held item, or zero if
+    // they are not eating.
+    eatingTicksLeft Ticks
 }
 
 func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn net.Conn, name string, spawnBlock BlockXyz, onDisconnect chan<- EntityId, game gamerules.IGame) *Player {
@@ -115,6 +181,7 @@ func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn
         shardConnecter: shardConnecter,
         conn:           conn,
         name:           name,
+        worldSpawn:     spawnBlock,
         spawnBlock:     spawnBlock,
         position: AbsXyz{
             X:  AbsCoord(spawnBlock.X),
@@ -125,11 +192,13 @@ func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn
         look:   LookDegrees{0, 0},
 
         health: MaxHealth,
-        food:   MaxFoodUnits, // TODO: Check what initial level should be.
+        food:   newFoodStats(),
 
         curWindow:    nil,
         nextWindowId: WindowIdFreeMin,
 
+        vehicle: EntityIdNull,
+
         mainQueue:  make(chan func(*Player), 128),
         txQueue:    make(chan []byte, 128),
         txErrChan:  make(chan error, 1),
@@ -143,6 +212,7 @@ func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn
     player.rx.init(conn)
     player.playerClient.Init(player)
     player.inventory.Init(player.EntityId, player)
+    player.enderItems.Init(gamerules.ChestNumSlots)
 
     return player
 }
@@ -192,6 +262,12 @@ func (player *Player) UnmarshalNbt(tag nbt.Compound) (err error) {
         return
     }
 
+    if enderItems, ok := tag.Lookup("EnderItems").(*nbt.List); ok {
+        if err = player.enderItems.UnmarshalNbtList(enderItems); err != nil {
+            return
+        }
+    }
+
     if player.onGround, err = nbtutil.ReadByte(tag, "OnGround"); err != nil {
         return
     }
@@ -203,6 +279,9 @@ func (player *Player) UnmarshalNbt(tag nbt.Compound) (err error) {
     if player.sleeping, err = nbtutil.ReadByte(tag, "Sleeping"); err != nil {
         return
     }
+    // Players that left while in bed wake up when they come back, as the game
+    // no longer counts them as sleeping.
+    player.sleeping = 0
 
     if player.fallDistance, err = nbtutil.ReadFloat(tag, "FallDistance"); err != nil {
         return
@@ -236,6 +315,66 @@ func (player *Player) UnmarshalNbt(tag nbt.Compound) (err error) {
         return
     }
 
+    // Hunger is absent for players saved before it was stored.
+    if _, ok := tag.Lookup("foodLevel").(*nbt.Int); ok {
+        var level, timer int32
+        if level, err = nbtutil.ReadInt(tag, "foodLevel"); err != nil {
+            return
+        }
+        player.food.level = FoodUnits(level)
+        if player.food.saturation, err = nbtutil.ReadFloat(tag, "foodSaturationLevel"); err != nil {
+            return
+        }
+        if player.food.exhaustion, err = nbtutil.ReadFloat(tag, "foodExhaustionLevel"); err != nil {
+            return
+        }
+        if timer, err = nbtutil.ReadInt(tag, "foodTickTimer"); err != nil {
+            return
+        }
+        player.food.timer = Ticks(timer)
+    }
+
+    // Experience is absent for players saved before it was stored.
+    if _, ok := tag.Lookup("XpLevel").(*nbt.Int); ok {
+        if player.xp.level, err = nbtutil.ReadInt(tag, "XpLevel"); err != nil {
+            return
+        }
+        if player.xp.progress, err = nbtutil.ReadFloat(tag, "XpP"); err != nil {
+            return
+        }
+        var total int32
+        if total, err = nbtutil.ReadInt(tag, "XpTotal"); err != nil {
+            return
+        }
+        player.xp.total = Experience(total)
+    }
+
+    if err = player.effects.UnmarshalNbt(tag); err != nil {
+        return
+    }
+    player.effectsChanged()
+
+    // Game mode is absent for players saved before it was stored.
+    if gameTypeTag, ok := tag.Lookup("playerGameType").(*nbt.Int); ok {
+        player.gameType = GameType(gameTypeTag.Value)
+    }
+
+    // Spawn point is only present if one has been set for the player.
+    if _, ok := tag.Lookup("SpawnX").(*nbt.Int); ok {
+        var x, y, z int32
+        if x, err = nbtutil.ReadInt(tag, "SpawnX"); err != nil {
+            return
+        }
+        if y, err = nbtutil.ReadInt(tag, "SpawnY"); err != nil {
+            return
+        }
+        if z, err = nbtutil.ReadInt(tag, "SpawnZ"); err != nil {
+            return
+        }
+        player.spawnBlock = BlockXyz{BlockCoord(x), BlockYCoord(y), BlockCoord(z)}
+        player.bedSpawn = true
+    }
+
     return nil
 }
 
@@ -246,6 +385,12 @@ func (player *Player) MarshalNbt(tag nbt.Compound) (err error) {
         return
     }
 
+    enderItems, err := player.enderItems.MarshalNbtList()
+    if err != nil {
+        return
+    }
+    tag.Set("EnderItems", enderItems)
+
     tag.Set("OnGround", &nbt.Byte{player.onGround})
     tag.Set("Dimension", &nbt.Int{player.dimension})
     tag.Set("Sleeping", &nbt.Byte{player.sleeping})
@@ -271,8 +416,21 @@ func (player *Player) MarshalNbt(tag nbt.Compound) (err error) {
     }})
     tag.Set("Fire", &nbt.Short{player.fire})
     tag.Set("Health", &nbt.Short{int16(player.health)})
+    tag.Set("playerGameType", &nbt.Int{int32(player.gameType)})
+    tag.Set("foodLevel", &nbt.Int{int32(player.food.level)})
+    tag.Set("foodSaturationLevel", &nbt.Float{player.food.saturation})
+    tag.Set("foodExhaustionLevel", &nbt.Float{player.food.exhaustion})
+    tag.Set("foodTickTimer", &nbt.Int{int32(player.food.timer)})
+    tag.Set("XpLevel", &nbt.Int{player.xp.level})
+    tag.Set("XpP", &nbt.Float{player.xp.progress})
+    tag.Set("XpTotal", &nbt.Int{int32(player.xp.total)})
+    if player.bedSpawn {
+        tag.Set("SpawnX", &nbt.Int{int32(player.spawnBlock.X)})
+        tag.Set("SpawnY", &nbt.Int{int32(player.spawnBlock.Y)})
+        tag.Set("SpawnZ", &nbt.Int{int32(player.spawnBlock.Z)})
+    }
 
-    return nil
+    return player.effects.MarshalNbt(tag)
 }
 
 func (player *Player) getHeldItemTypeId() ItemTypeId {
@@ -285,13 +443,19 @@ func (player *Player) getHeldItemTypeId() ItemTypeId {
 }
 
 func (player *Player) Run() {
+    if shardConnecter, ok := player.game.ShardConnecter(DimensionId(player.dimension)); ok {
+        player.shardConnecter = shardConnecter
+    } else {
+        player.dimension = int32(DimensionNormal)
+    }
+
     data := player.txPktSerial.SerializePackets(
         &proto.PacketLogin{ //@TODO This isnt very dynamic
             EntityId:   int32(player.EntityId),
             LevelType:  string(LevelTypeDefault),
-            GameMode:   int32(GameTypeSurvival),
-            Dimension:  DimensionNormal,
-            Difficulty: GameDifficultyPeaceful,
+            GameMode:   int32(player.gameType),
+            Dimension:  DimensionId(player.dimension),
+            Difficulty: GameDifficulty(*playerDifficulty),
             MaxPlayers: int32(player.game.GetMaxPlayers()),
         },
         &proto.PacketSpawnPosition{player.spawnBlock.X, BlockYCoord(int32(player.spawnBlock.Y)), player.spawnBlock.Z},
@@ -299,6 +463,8 @@ func (player *Player) Run() {
 
     player.TransmitPacket(data)
 
+    player.move.allowFlight = player.gameType == GameTypeCreative
+
     go player.rx.loop()
     go player.transmitLoop()
     go player.mainLoop()
@@ -338,6 +504,10 @@ func (player *Player) handlePacket(pkt interface{}) {
         player.handlePacketPlayerLook(pkt)
     case *proto.PacketPlayerPositionLook:
         player.handlePacketPlayerPositionLook(pkt)
+    case *proto.PacketPlayerDigging:
+        player.handlePacketPlayerDigging(pkt)
+    case *proto.PacketPlayerBlockPlacement:
+        player.handlePacketPlayerBlockPlacement(pkt)
     case *proto.PacketPlayerHoldingChange:
         player.handlePacketPlayerHoldingChange(pkt)
     case *proto.PacketEntityAnimation:
@@ -354,8 +524,12 @@ func (player *Player) handlePacket(pkt interface{}) {
         player.handlePacketWindowTransaction(pkt)
     case *proto.PacketCreativeInventoryAction:
         player.handlePacketCreativeInventoryAction(pkt)
+    case *proto.PacketEnchantItem:
+        player.handlePacketEnchantItem(pkt)
     case *proto.PacketSignUpdate:
         player.handlePacketSignUpdate(pkt)
+    case *proto.PacketPluginMessage:
+        player.handlePacketPluginMessage(pkt)
     case *proto.PacketServerListPing:
         player.handlePacketServerListPing(pkt)
     case *proto.PacketDisconnect:
@@ -392,12 +566,92 @@ func (player *Player) handlePacketChatMessage(pkt *proto.PacketChatMessage) {
 }
 
 func (player *Player) handlePacketEntityAction(pkt *proto.PacketEntityAction) {
+    switch pkt.Action {
+    case EntityActionLeaveBed:
+        if player.sleeping != 0 {
+            player.wakeUp()
+            player.game.SetPlayerSleeping(player.EntityId, false)
+        }
+    case EntityActionCrouch:
+        if player.vehicle != EntityIdNull {
+            // Sneaking dismounts the vehicle.
+            player.useEntity(player.vehicle, false)
+        }
+    case EntityActionStartSprint:
+        player.sprinting = true
+    case EntityActionStartUnsprint:
+        player.sprinting = false
+    }
 }
 
 func (player *Player) handlePacketUseEntity(pkt *proto.PacketUseEntity) {
+    if pkt.User != player.EntityId {
+        return
+    }
+
+    player.useEntity(pkt.Target, pkt.LeftClick)
+}
+
+// useHeldItem asks the shard to use the held item in the direction that the
+// player is looking.
+func (player *Player) useHeldItem() {
+    held, _ := player.inventory.HeldItem()
+    if held.Count < 1 {
+        return
+    }
+
+    if player.startEating(&held) {
+        return
+    }
+
+    if held.IsSplashPotion() && player.gameType != GameTypeCreative {
+        var thrown gamerules.Slot
+        player.inventory.TakeOneHeldItem(&thrown)
+        if thrown.Count < 1 {
+            return
+        }
+    }
+
+    eyePosition := player.position
+    eyePosition.Y += player.height
+
+    if shardClient, ok := player.chunkSubs.CurrentShardClient(); ok {
+        shardClient.ReqUseItem(held, eyePosition, player.look)
+    }
+}
+
+// steerVehicle passes the player's movement input to the vehicle they are
+// riding.
+func (player *Player) steerVehicle(input AbsVelocity) {
+    for _, shardClient := range player.chunkSubs.ShardClients() {
+        shardClient.ReqSteerVehicle(player.vehicle, input)
+    }
+}
+
+// useEntity asks the shards to have the player use (right-click) or hit
+// (left-click) an entity.
+func (player *Player) useEntity(target EntityId, leftClick bool) {
+    if leftClick {
+        now := time.Now()
+        if now.Sub(player.lastAttackAt) < attackCooldown {
+            return
+        }
+        player.lastAttackAt = now
+        player.food.addExhaustion(exhaustionAttack)
+    }
+
+    held, _ := player.inventory.HeldItem()
+    for _, shardClient := range player.chunkSubs.ShardClients() {
+        shardClient.ReqUseEntity(held, target, leftClick)
+    }
 }
 
 func (player *Player) handlePacketRespawn(pkt *proto.PacketRespawn) {
+    // Only dead players can respawn.
+    if player.health > 0 {
+        return
+    }
+    player.respawn()
 }
 
 func (player *Player) handlePacketPlayer(pkt *proto.PacketPlayer) {
@@ -412,7 +666,12 @@ func (player *Player) handlePacketPlayerLook(pkt *proto.PacketPlayerLook) {
 }
 
 func (player *Player) handlePacketPlayerPositionLook(pkt *proto.PacketPlayerPositionLook) {
-    player.handleMove(pkt.Position(true), pkt.Stance(true))
+    if player.vehicle != EntityIdNull && pkt.Y1 == ridingPositionY {
+        // Riding clients send their movement input in place of a position.
+        player.steerVehicle(AbsVelocity{X: AbsVelocityCoord(pkt.X), Z: AbsVelocityCoord(pkt.Z)})
+    } else {
+        player.handleMove(pkt.Position(true), pkt.Stance(true))
+    }
     player.handleLook(pkt.Look)
 }
 
@@ -423,20 +682,158 @@ func (player *Player) handleMove(position AbsXyz, stance AbsCoord) {
         return
     }
 
+    if player.vehicle != EntityIdNull {
+        // The vehicle decides where a riding player is.
+        return
+    }
+
     if !player.position.IsWithinDistanceOf(position, 10) {
         log.Printf("Discarding player position that is too far removed (%.2f, %.2f, %.2f)",
             position.X, position.Y, position.Z)
         return
     }
+
+    if !player.move.checkSpeed(position, time.Now()) {
+        player.moveViolation("moving too fast", position)
+        return
+    }
+
+    from := player.position
     player.position = position
     player.height = stance - position.Y
+    player.moveExhaustion(&from, &position)
     player.chunkSubs.Move(&position)
 
-    // TODO: Should keep track of when players enter/leave their mutual radius
-    // of "awareness". I.e a client should receive a RemoveEntity packet when
-    // the player walks out of range, and no longer receive WriteEntityTeleport
-    // packets for them. The converse should happen when players come in range
-    // of each other.
+    // Have the shard check the move against the blocks around it.
+    if shardClient, ok := player.chunkSubs.CurrentShardClient(); ok {
+        player.move.checkSent()
+        shardClient.ReqCheckMove(from, position)
+    }
+}
+
+// moveExhaustion adds the exhaustion from the player walking, sprinting and
+// jumping between the positions.
+func (player *Player) moveExhaustion(from, to *AbsXyz) {
+    dx, dz := float64(to.X-from.X), float64(to.Z-from.Z)
+    distance := float32(math.Sqrt(dx*dx + dz*dz))
+
+    if player.sprinting {
+        player.food.addExhaustion(distance * exhaustionSprintBlock)
+    } else {
+        player.food.addExhaustion(distance * exhaustionWalkPerBlock)
+    }
+
+    if !player.move.inAir && to.Y-from.Y >= jumpRise {
+        if player.sprinting {
+            player.food.addExhaustion(exhaustionSprintJump)
+        } else {
+            player.food.addExhaustion(exhaustionJump)
+        }
+    }
+}
+
+// moveChecked is called when a shard has checked a move that the player made
+// to the given position.
+func (player *Player) moveChecked(position *AbsXyz, supported bool, blocked bool) {
+    if !player.move.checkReceived() || player.vehicle != EntityIdNull {
+        return
+    }
+
+    now := time.Now()
+    switch {
+    case blocked:
+        player.moveViolation("moving through blocks", *position)
+    case !player.move.checkAir(*position, supported, now):
+        player.moveViolation("flying", *position)
+    case supported:
+        player.move.lastGood = *position
+    }
+}
+
+// moveViolation sends the player back to the last position that they were
+// known to be validly at, and kicks them if they move invalidly too often.
+func (player *Player) moveViolation(reason string, position AbsXyz) {
+    log.Printf("%v: rejected move to (%.2f, %.2f, %.2f): %s",
+        player, position.X, position.Y, position.Z, reason)
+
+    if player.move.addViolation(time.Now()) {
+        log.Printf("%v: kicked for repeated invalid movement", player)
+        player.SendPacket(&proto.PacketDisconnect{Reason: "Invalid movement"})
+        player.Stop()
+        return
+    }
+
+    player.position = player.move.lastGood
+    player.chunkSubs.Move(&player.position)
+    player.move.reset(player.position, time.Now())
+
+    posLookPkt := &proto.PacketPlayerPositionLook{
+        Look:     player.look,
+        OnGround: false,
+    }
+    posLookPkt.SetStance(player.position.Y+player.height, false)
+    posLookPkt.SetPosition(player.position, false)
+    player.SendPacket(posLookPkt)
+}
+
+func (player *Player) handlePacketPlayerDigging(pkt *proto.PacketPlayerDigging) {
+    switch pkt.Status {
+    case DigReleaseUseItem:
+        player.stopEating()
+        return
+    case DigDropItem, DigDropItemStack:
+        var item gamerules.Slot
+        if pkt.Status == DigDropItemStack {
+            player.inventory.TakeHeldStack(&item)
+        } else {
+            player.inventory.TakeOneHeldItem(&item)
+        }
+        player.stopEating()
+        player.DropItem(item)
+        return
+    }
+
+    // Validate that the player is actually somewhere near the block.
+    targetAbsPos := pkt.Block.MidPointToAbsXyz()
+    if !targetAbsPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        log.Printf("%v: ignoring player dig at %v (too far away)", player, pkt.Block)
+        return
+    }
+
+    shardConn, _, ok := player.chunkSubs.ShardClientForBlockXyz(&pkt.Block)
+    if ok {
+        held, _ := player.inventory.HeldItem()
+        shardConn.ReqHitBlock(held, pkt.Block, pkt.Status, pkt.Face)
+
+        if pkt.Status == DigBlockBroke {
+            player.food.addExhaustion(exhaustionBreakBlock)
+        }
+    }
+}
+
+func (player *Player) handlePacketPlayerBlockPlacement(pkt *proto.PacketPlayerBlockPlacement) {
+    if pkt.Face == FaceNull {
+        // The player is using the held item without targetting a block.
+        player.useHeldItem()
+        return
+    }
+
+    if pkt.Face < FaceMinValid || pkt.Face > FaceMaxValid {
+        return
+    }
+
+    // Validate that the player is actually somewhere near the block.
+    targetAbsPos := pkt.Block.MidPointToAbsXyz()
+    if !targetAbsPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        log.Printf("%v: ignoring player interact at %v (too far away)", player, pkt.Block)
+        return
+    }
+
+    shardConn, _, ok := player.chunkSubs.ShardClientForBlockXyz(&pkt.Block)
+    if ok {
+        held, _ := player.inventory.HeldItem()
+        shardConn.ReqInteractBlock(held, pkt.Block, pkt.Face)
+    }
 }
 
 func (player *Player) handleLook(look LookDegrees) {
@@ -450,6 +847,7 @@ func (player *Player) handleLook(look LookDegrees) {
 
 func (player *Player) handlePacketPlayerHoldingChange(pkt *proto.PacketPlayerHoldingChange) {
     player.inventory.SetHolding(pkt.SlotId)
+    player.stopEating()
 }
 
 func (player *Player) handlePacketEntityAnimation(pkt *proto.PacketEntityAnimation) {
@@ -459,16 +857,15 @@ func (player *Player) handlePacketWindowOpen(pkt *proto.PacketWindowOpen) {
 }
 
 func (player *Player) handlePacketWindowClose(pkt *proto.PacketWindowClose) {
+    if pkt.WindowId == WindowIdInventory {
+        for _, item := range player.inventory.TakeCraftingItems() {
+            player.returnItem(&item)
+        }
+    }
     player.closeCurrentWindow(false)
 }
 
 func (player *Player) handlePacketWindowClick(pkt *proto.PacketWindowClick) {
-
-    // Note that the expectedSlot parameter is currently ignored. The item(s)
-    // involved are worked out from the server-side data.
-    // TODO use the expectedSlot as a conditions for the click, and base the
-    // transaction result on that.
-
     // Determine which inventory window is involved.
     // TODO support for more windows
 
@@ -489,13 +886,31 @@ func (player *Player) handlePacketWindowClick(pkt *proto.PacketWindowClick) {
         SlotId:     pkt.Slot,
         Cursor:     player.cursor,
         RightClick: pkt.RightClick == 1,
-        ShiftClick: pkt.Mode == 1,
+        Mode:       ClickMode(pkt.Mode),
+        Button:     pkt.RightClick,
         TxId:       pkt.TxId,
     }
-    //click.ExpectedSlot.SetItemSlot(&pkt.ExpectedSlot) @TODO 1.5.1
+    click.ExpectedSlot.SetItemSlot(&pkt.ClickedItem)
+
+    // Only players in creative mode may conjure up stacks of items.
+    if click.Mode == ClickModeMiddle && player.gameType != GameTypeCreative {
+        clickedWindow = nil
+    }
 
     if clickedWindow != nil {
+        if player.anvilInv != nil {
+            player.anvilInv.SetLevels(player.spendableLevels())
+        }
+
         txState = clickedWindow.Click(&click)
+        player.cursor = click.Cursor
+
+        if player.anvilInv != nil {
+            player.payForAnvil()
+        }
+        if player.merchantInv != nil {
+            player.requestTrade()
+        }
     }
 
     switch txState {
@@ -506,6 +921,13 @@ func (player *Player) handlePacketWindowClick(pkt *proto.PacketWindowClick) {
     case TxStateDeferred:
         // The remote inventory should send the transaction outcome.
     }
+
+    if txState == TxStateRejected && clickedWindow != nil {
+        // The client will have guessed at the outcome of the click, so correct
+        // it.
+        player.SendPacket(clickedWindow.PacketWindowItems())
+        player.SendPacket(player.cursor.UpdatePacket(WindowIdCursor, SlotIdCursor))
+    }
 }
 
 func (player *Player) handlePacketWindowTransaction(pkt *proto.PacketWindowTransaction) {
@@ -515,9 +937,181 @@ func (player *Player) handlePacketWindowTransaction(pkt *proto.PacketWindowTrans
 }
 
 func (player *Player) handlePacketCreativeInventoryAction(pkt *proto.PacketCreativeInventoryAction) {
+    if player.gameType != GameTypeCreative {
+        log.Printf("%v: ignoring creative inventory action while not in creative mode", player)
+        return
+    }
+
+    var item gamerules.Slot
+    item.SetItemSlot(&pkt.Slot)
+    if !item.IsEmpty() && (!item.IsValidType() || item.Count > item.MaxStack()) {
+        log.Printf("%v: ignoring creative inventory action with bad item %+v", player, item)
+        return
+    }
+
+    if pkt.SlotId == creativeDropSlotId {
+        // The item is thrown out of the inventory into the world.
+        player.DropItem(item)
+        return
+    }
+
+    if !player.inventory.SetSlot(pkt.SlotId, item) {
+        log.Printf("%v: ignoring creative inventory action on slot %d", player, pkt.SlotId)
+    }
+}
+
+func (player *Player) handlePacketEnchantItem(pkt *proto.PacketEnchantItem) {
+    if player.enchantInv == nil || player.curWindow == nil || player.curWindow.WindowId() != pkt.WindowId {
+        log.Printf("%v: ignoring enchant item for window %d that is not open", player, pkt.WindowId)
+        return
+    }
+
+    // Players in creative mode enchant for free.
+    level, creative := player.spendableLevels()
+    if creative {
+        level = math.MaxInt32
+    }
+
+    cost, ok := player.enchantInv.Enchant(int(pkt.Enchantment), level)
+    if !ok || creative {
+        return
+    }
+
+    player.xp.takeLevels(cost)
+    player.SendPacket(player.xp.packet())
+}
+
+func (player *Player) handlePacketPluginMessage(pkt *proto.PacketPluginMessage) {
+    switch pkt.Channel {
+    case "MC|ItemName":
+        // The name typed into the anvil window.
+        if player.anvilInv == nil {
+            return
+        }
+        if !player.anvilInv.Rename(string(pkt.Data)) {
+            log.Printf("%v: rejected invalid item name %q", player, pkt.Data)
+        }
+    case "MC|TrSel":
+        // The offer selected in the trading window.
+        if player.merchantInv == nil || len(pkt.Data) != 4 {
+            return
+        }
+        index := int(int32(binary.BigEndian.Uint32(pkt.Data)))
+        if !player.merchantInv.SelectOffer(index) {
+            log.Printf("%v: selected unknown offer %d", player, index)
+        }
+    }
+}
+
+// spendableLevels returns the experience levels that the player can spend,
+// and whether they are in creative mode, where they spend none.
+func (player *Player) spendableLevels() (levels int32, creative bool) {
+    return player.xp.level, player.gameType == GameTypeCreative
+}
+
+// payForAnvil takes the levels owed for items taken from the anvil window, and
+// wears the anvil.
+func (player *Player) payForAnvil() {
+    cost, used := player.anvilInv.TakeCost()
+    if !used {
+        return
+    }
+
+    if player.gameType != GameTypeCreative {
+        player.xp.takeLevels(cost)
+        player.SendPacket(player.xp.packet())
+    }
+
+    shardConn, _, ok := player.chunkSubs.ShardClientForBlockXyz(&player.anvilBlock)
+    if ok {
+        shardConn.ReqUseAnvil(player.anvilBlock)
+    }
+}
+
+// requestTrade asks the villager to agree to the trade paid for in the trading
+// window, if any.
+func (player *Player) requestTrade() {
+    trade, ok := player.merchantInv.TakeTrade()
+    if !ok {
+        return
+    }
+
+    player.trade = trade
+    player.tradeWith = player.merchant
+    for _, shardClient := range player.chunkSubs.ShardClients() {
+        shardClient.ReqTrade(player.merchant, trade.Index, trade.Offer)
+    }
+}
+
+// tradeResult hands out the item bought by the trade waiting for the
+// villager if it agreed to it, or gives back the payment if it did not. This
+// happens even if the trading window has since closed, so that nothing is
+// lost.
+func (player *Player) tradeResult(merchant EntityId, accepted bool, offers []gamerules.MerchantOffer) {
+    trade := player.trade
+    if trade == nil || player.tradeWith != merchant {
+        return
+    }
+    player.trade = nil
+    player.tradeWith = EntityIdNull
+
+    windowOpen := player.merchantInv != nil && player.merchant == merchant
+    if player.merchantInv != nil {
+        if windowOpen {
+            player.merchantInv.EndTrade(accepted, trade.Payment)
+        } else {
+            player.merchantInv.SetTrading(false)
+        }
+    }
+
+    if accepted {
+        click := trade.Click
+        click.Cursor = trade.Offer.Sell
+        if click.Mode == ClickModeNormal && windowOpen && player.cursor.AddWhole(&click.Cursor) {
+            player.SendPacket(player.cursor.UpdatePacket(WindowIdCursor, SlotIdCursor))
+        }
+        if !click.Cursor.IsEmpty() {
+            player.takeTransferred(&click)
+        }
+    } else {
+        for i := range trade.Payment {
+            if !trade.Payment[i].IsEmpty() {
+                player.returnItem(&trade.Payment[i])
+            }
+        }
+    }
+
+    if windowOpen {
+        player.SendPacket(&proto.PacketWindowTransaction{
+            WindowId: player.curWindow.WindowId(),
+            TxId:     trade.Click.TxId,
+            Accepted: accepted,
+        })
+        if !accepted {
+            // The client will have guessed that the output was taken.
+            player.SendPacket(player.curWindow.PacketWindowItems())
+            player.SendPacket(player.cursor.UpdatePacket(WindowIdCursor, SlotIdCursor))
+        }
+    }
+
+    player.updateMerchantOffers(merchant, offers)
 }
 
 func (player *Player) handlePacketSignUpdate(pkt *proto.PacketSignUpdate) {
+    target := BlockXyz{BlockCoord(pkt.X), BlockYCoord(pkt.Y), BlockCoord(pkt.Z)}
+
+    // Validate that the player is actually somewhere near the sign.
+    targetAbsPos := target.MidPointToAbsXyz()
+    if !targetAbsPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        log.Printf("%v: ignoring sign update at %v (too far away)", player, target)
+        return
+    }
+
+    shardConn, _, ok := player.chunkSubs.ShardClientForBlockXyz(&target)
+    if ok {
+        text := [gamerules.SignLines]string{pkt.Text1, pkt.Text2, pkt.Text3, pkt.Text4}
+        shardConn.ReqSetSignText(target, text)
+    }
 }
 
 func (player *Player) handlePacketServerListPing(pkt *proto.PacketServerListPing) {
@@ -566,6 +1160,48 @@ func (player *Player) SendPacket(packet proto.IPacket) {
     player.TransmitPacket(player.txPktSerial.SerializePackets(packet))
 }
 
+// DropItem throws the item into the world in the direction that the player is
+// looking. This must be called from the player main loop.
+func (player *Player) DropItem(item gamerules.Slot) {
+    if item.IsEmpty() {
+        return
+    }
+
+    shardClient, ok := player.chunkSubs.CurrentShardClient()
+    if !ok {
+        log.Printf("%v: lost dropped item %v", player, item)
+        return
+    }
+
+    position := player.position
+    position.Y += throwHeight
+    shardClient.ReqDropItem(item, position, throwVelocity(&player.look), throwPickupImmunity)
+}
+
+// throwVelocity returns the velocity of an item thrown by a player looking in
+// the given direction.
+func throwVelocity(look *LookDegrees) AbsVelocity {
+    dx, dy, dz := look.Direction()
+    return AbsVelocity{
+        X: throwSpeed * AbsVelocityCoord(dx),
+        Y: throwSpeed*AbsVelocityCoord(dy) + throwLift,
+        Z: throwSpeed * AbsVelocityCoord(dz),
+    }
+}
+
+// returnItem puts the item back into the player's inventory, throwing out
+// whatever does not fit.
+func (player *Player) returnItem(item *gamerules.Slot) {
+    player.inventory.PutItem(item)
+    player.DropItem(*item)
+    item.Clear()
+}
+
+// ReturnItem implements window.IWindowViewer.ReturnItem.
+func (player *Player) ReturnItem(item gamerules.Slot) {
+    player.returnItem(&item)
+}
+
 // pingNew starts a new "keep-alive" ping.
 func (player *Player) pingNew() {
     if player.ping.running {
@@ -675,6 +1311,9 @@ func (player *Player) mainLoop() {
 
     //player.sendChatMessage(fmt.Sprintf("%s has joined", player.name), false)
 
+    ticker := time.NewTicker(time.Second / TicksPerSecond)
+    defer ticker.Stop()
+
 MAINLOOP:
     for {
         select {
@@ -690,6 +1329,9 @@ MAINLOOP:
         case _ = <-player.ping.timer.C:
             player.pingTimeout()
 
+        case <-ticker.C:
+            player.tick()
+
         case pkt := <-player.rx.RecvPkt:
             player.handlePacket(pkt)
         case err := <-player.rx.RecvErr:
@@ -709,6 +1351,7 @@ func (player *Player) notifyChunkLoad() {
 
         // Player seems to fall through block unless elevated very slightly.
         player.position.Y += 0.01
+        player.move.reset(player.position, time.Now())
 
         posLookPkt := &proto.PacketPlayerPositionLook{
             Look:     player.look,
@@ -717,19 +1360,22 @@ func (player *Player) notifyChunkLoad() {
         posLookPkt.SetStance(player.position.Y+player.height, false)
         posLookPkt.SetPosition(player.position, false)
 
-        // Send player start position, inventory, health.
-        data := player.txPktSerial.SerializePackets(
+        // Send player start position, inventory, health, effects.
+        pkts := []proto.IPacket{
             posLookPkt,
             player.inventory.PacketWindowItems(),
-            &proto.PacketUpdateHealth{player.health, player.food, 0},
-        )
+            player.healthPacket(),
+            player.xp.packet(),
+        }
+        pkts = player.effects.Packets(player.EntityId, pkts)
+        data := player.txPktSerial.SerializePackets(pkts...)
 
         player.TransmitPacket(data)
     }
 }
 
 func (player *Player) inventorySubscribed(block *BlockXyz, invTypeId InvTypeId, slots proto.ItemSlotSlice) {
-    if player.remoteInv != nil {
+    if player.curWindow != nil {
         player.closeCurrentWindow(true)
     }
 
@@ -741,22 +1387,134 @@ func (player *Player) inventorySubscribed(block *BlockXyz, invTypeId InvTypeId,
     }
 
     player.remoteInv = remoteInv
-    player.curWindow = window
+    player.openWindow(window)
+}
 
-    if player.nextWindowId >= WindowIdFreeMax {
-        player.nextWindowId = WindowIdFreeMin
-    } else {
-        player.nextWindowId++
+// openEnderChest opens the window onto the player's ender chest inventory.
+func (player *Player) openEnderChest(block *BlockXyz) {
+    blockPos := block.MidPointToAbsXyz()
+    if !blockPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        return
     }
 
-    data := player.txPktSerial.SerializePackets(
-        window.PacketWindowOpen(),
-        window.PacketWindowItems(),
-    )
-
+    if player.curWindow != nil {
+        player.closeCurrentWindow(true)
+    }
+
+    player.openWindow(player.inventory.NewEnderChestWindow(player.nextWindowId, &player.enderItems))
+}
+
+// openEnchantingTable opens an enchanting window through the enchanting table
+// at the given location.
+func (player *Player) openEnchantingTable(block *BlockXyz, bookshelves int) {
+    blockPos := block.MidPointToAbsXyz()
+    if !blockPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        return
+    }
+
+    if player.curWindow != nil {
+        player.closeCurrentWindow(true)
+    }
+
+    player.enchantInv = gamerules.NewEnchantInventory(bookshelves)
+    player.openWindow(player.inventory.NewEnchantWindow(player.nextWindowId, player.enchantInv))
+}
+
+// openAnvil opens an anvil window through the anvil at the given location.
+func (player *Player) openAnvil(block *BlockXyz) {
+    blockPos := block.MidPointToAbsXyz()
+    if !blockPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        return
+    }
+
+    if player.curWindow != nil {
+        player.closeCurrentWindow(true)
+    }
+
+    player.anvilInv = gamerules.NewAnvilInventory()
+    player.anvilBlock = *block
+    player.openWindow(player.inventory.NewAnvilWindow(player.nextWindowId, player.anvilInv))
+}
+
+// openMerchant opens a window for trading with a villager at the given
+// position.
+func (player *Player) openMerchant(merchant EntityId, position *AbsXyz, offers []gamerules.MerchantOffer) {
+    if !position.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        return
+    }
+
+    if player.curWindow != nil {
+        player.closeCurrentWindow(true)
+    }
+
+    player.merchantInv = gamerules.NewMerchantInventory(offers)
+    // Only one trade can wait for a villager at a time.
+    player.merchantInv.SetTrading(player.trade != nil)
+    player.merchant = merchant
+    player.openWindow(player.inventory.NewMerchantWindow(player.nextWindowId, player.merchantInv))
+    player.sendMerchantOffers()
+}
+
+// updateMerchantOffers replaces the offers in the trading window, if it is
+// for the villager.
+func (player *Player) updateMerchantOffers(merchant EntityId, offers []gamerules.MerchantOffer) {
+    if player.merchantInv == nil || player.merchant != merchant {
+        return
+    }
+
+    player.merchantInv.SetOffers(offers)
+    player.sendMerchantOffers()
+}
+
+// sendMerchantOffers tells the client the offers shown in the trading window.
+func (player *Player) sendMerchantOffers() {
+    offers := player.merchantInv.Offers()
+    list := proto.MerchantOfferList{
+        WindowId: player.curWindow.WindowId(),
+        Offers:   make([]proto.MerchantOffer, len(offers)),
+    }
+    for i := range offers {
+        list.Offers[i] = offers[i].ProtoOffer()
+    }
+
+    var buf bytes.Buffer
+    if err := list.MinecraftMarshal(&buf, &player.txPktSerial); err != nil {
+        log.Printf("%v: error writing merchant offers: %v", player, err)
+        return
+    }
+
+    player.SendPacket(&proto.PacketPluginMessage{
+        Channel: "MC|TrList",
+        Data:    buf.Bytes(),
+    })
+}
+
+// openWindow makes the window the player's current window, and sends it to
+// the client.
+func (player *Player) openWindow(win window.IWindow) {
+    player.curWindow = win
+
+    if player.nextWindowId >= WindowIdFreeMax {
+        player.nextWindowId = WindowIdFreeMin
+    } else {
+        player.nextWindowId++
+    }
+
+    data := player.txPktSerial.SerializePackets(
+        win.PacketWindowOpen(),
+        win.PacketWindowItems(),
+    )
+
     player.TransmitPacket(data)
 }
 
+func (player *Player) entityInventorySubscribed(entityId EntityId, block *BlockXyz, invTypeId InvTypeId, slots proto.ItemSlotSlice) {
+    player.inventorySubscribed(block, invTypeId, slots)
+    if player.remoteInv != nil && player.remoteInv.IsForBlock(block) {
+        player.remoteInv.entityId = entityId
+    }
+}
+
 func (player *Player) inventorySlotUpdate(block *BlockXyz, slot *gamerules.Slot, slotId SlotId) {
     if player.remoteInv == nil || !player.remoteInv.IsForBlock(block) {
         return
@@ -794,15 +1552,40 @@ func (player *Player) inventoryTxState(block *BlockXyz, txId TxId, accepted bool
     })
 }
 
+// inventoryTransferred puts items moved out of a remote inventory into the
+// player's inventory. They are given to the player even if the inventory's
+// window has since closed, so that they are not lost. Items taken to be thrown
+// are dropped instead.
+func (player *Player) inventoryTransferred(block *BlockXyz, click *gamerules.Click) {
+    player.takeTransferred(click)
+}
+
+// takeTransferred puts the items in the click's Cursor into the player's
+// inventory, as the click moved them there, or drops them if the click threw
+// them.
+func (player *Player) takeTransferred(click *gamerules.Click) {
+    if click.Mode == ClickModeDrop {
+        player.DropItem(click.Cursor)
+        return
+    }
+    player.inventory.PutTransferred(click)
+    if !click.Cursor.IsEmpty() {
+        player.giveItem(&player.position, &click.Cursor)
+    }
+}
+
 func (player *Player) inventoryUnsubscribed(block *BlockXyz) {
-    if player.remoteInv == nil || !player.remoteInv.IsForBlock(block) {
+    // The anvil being used may have broken.
+    usingAnvil := player.anvilInv != nil && player.anvilBlock == *block
+
+    if !usingAnvil && (player.remoteInv == nil || !player.remoteInv.IsForBlock(block)) {
         return
     }
 
     player.closeCurrentWindow(true)
 }
 
-func (player *Player) placeHeldItem(target *BlockXyz, wasHeld *gamerules.Slot) {
+func (player *Player) placeHeldItem(target *BlockXyz, face Face, wasHeld *gamerules.Slot) {
     curHeld, _ := player.inventory.HeldItem()
 
     // Currently held item has changed since chunk saw it.
@@ -816,12 +1599,301 @@ func (player *Player) placeHeldItem(target *BlockXyz, wasHeld *gamerules.Slot) {
     if ok {
         var into gamerules.Slot
 
-        player.inventory.TakeOneHeldItem(&into)
+        if player.gameType == GameTypeCreative {
+            // Creative players have an endless supply of items.
+            into = curHeld
+        } else if curHeld.IsTool() {
+            // Tools are not used up by placing blocks, but they do wear.
+            into = curHeld
+            player.inventory.WearHeldItem(1)
+        } else {
+            player.inventory.TakeOneHeldItem(&into)
+        }
+
+        shardClient.ReqPlaceItem(*target, face, into, player.look)
+    }
+}
+
+// setGameType changes the player's game mode, and tells their client and the
+// shards that they are connected to.
+func (player *Player) setGameType(gameType GameType) {
+    player.gameType = gameType
+    player.move.allowFlight = gameType == GameTypeCreative
+
+    player.SendPacket(&proto.PacketState{
+        Reason:   StateReasonChangeGameType,
+        GameType: gameType,
+    })
+
+    for _, shardClient := range player.chunkSubs.ShardClients() {
+        shardClient.ReqSetGameType(gameType)
+    }
+}
+
+// hurt damages the player, unless they are invulnerable. The damage is
+// reduced by any protection from the armor that they are wearing.
+func (player *Player) hurt(amount Health) {
+    if player.gameType == GameTypeCreative {
+        return
+    }
+
+    amount = player.protectedDamage(amount)
+    if amount <= 0 {
+        return
+    }
+
+    wasAlive := player.health > 0
+    player.food.addExhaustion(exhaustionHurt)
+    player.health -= amount
+    if player.health < 0 {
+        player.health = 0
+    }
+    player.SendPacket(player.healthPacket())
+
+    if wasAlive && player.health == 0 {
+        player.die()
+    }
+}
+
+// protectedDamage returns the damage done to the player by an attack,
+// injury, starvation or poison, after any protection from the armor that they
+// are wearing.
+func (player *Player) protectedDamage(amount Health) Health {
+    reduction := player.inventory.ArmorProtection()
+    return Health(math.Floor(float64(amount)*(1-reduction) + 0.5))
+}
+
+// heal restores some of the player's health.
+func (player *Player) heal(amount Health) {
+    if player.health <= 0 || player.health >= MaxHealth {
+        return
+    }
+
+    player.health += amount
+    if player.health > MaxHealth {
+        player.health = MaxHealth
+    }
+    player.SendPacket(player.healthPacket())
+}
+
+func (player *Player) healthPacket() *proto.PacketUpdateHealth {
+    return &proto.PacketUpdateHealth{
+        Health:         player.health,
+        Food:           player.food.level,
+        FoodSaturation: player.food.saturation,
+    }
+}
+
+// tick runs the player's hunger, eating and status effects for a tick.
+func (player *Player) tick() {
+    if !player.spawnComplete || player.health <= 0 {
+        return
+    }
+
+    healthChange, expired := player.effects.Tick()
+    for _, id := range expired {
+        player.SendPacket(&proto.PacketEntityRemoveEffect{player.EntityId, id})
+    }
+    if len(expired) > 0 {
+        player.effectsChanged()
+    }
+    if healthChange > 0 {
+        player.heal(healthChange)
+    } else if healthChange < 0 && player.health > 1 {
+        // Poison does not kill.
+        if -healthChange >= player.health {
+            healthChange = 1 - player.health
+        }
+        player.hurt(-healthChange)
+    }
+
+    if player.eatingTicksLeft > 0 {
+        player.eatingTicksLeft--
+        if player.eatingTicksLeft == 0 {
+            player.finishEating()
+        }
+    }
+
+    if player.gameType == GameTypeCreative {
+        return
+    }
+
+    level, saturation := player.food.level, player.food.saturation
+    switch player.food.tick(GameDifficulty(*playerDifficulty), player.health) {
+    case 1:
+        player.heal(1)
+    case -1:
+        player.hurt(1)
+    default:
+        if player.food.level != level || player.food.saturation != saturation {
+            player.SendPacket(player.healthPacket())
+        }
+    }
+}
+
+// startEating begins eating the held item, if it is food that the player can
+// eat. ok=false if the item is not eaten.
+func (player *Player) startEating(held *gamerules.Slot) (ok bool) {
+    if held.IsDrinkablePotion() {
+        player.eatingTicksLeft = eatingTicks
+        return true
+    }
+
+    itemType := held.ItemType()
+    if itemType == nil || itemType.FoodPoints == 0 {
+        return false
+    }
+    if player.food.level >= MaxFoodUnits && player.gameType != GameTypeCreative {
+        return false
+    }
+
+    player.eatingTicksLeft = eatingTicks
+    return true
+}
+
+func (player *Player) stopEating() {
+    player.eatingTicksLeft = 0
+}
+
+// finishEating eats the held item once the player has been eating it for long
+// enough.
+func (player *Player) finishEating() {
+    held, _ := player.inventory.HeldItem()
+    itemType := held.ItemType()
+    isPotion := held.IsDrinkablePotion()
+    if itemType == nil || (itemType.FoodPoints == 0 && !isPotion) {
+        return
+    }
+
+    if player.gameType != GameTypeCreative {
+        var eaten gamerules.Slot
+        player.inventory.TakeOneHeldItem(&eaten)
+        if eaten.Count < 1 {
+            return
+        }
+    }
+
+    player.SendPacket(&proto.PacketEntityStatus{player.EntityId, EntityStatusEatingAccepted})
+    if isPotion {
+        if effect, ok := gamerules.PotionEffect(held.Data); ok {
+            player.addEffect(effect)
+        }
+    } else {
+        player.food.eat(itemType.FoodPoints, itemType.FoodSaturation)
+        player.SendPacket(player.healthPacket())
+
+        if foodEffect := itemType.FoodEffect; foodEffect != nil {
+            if rand.Intn(100) < int(foodEffect.Probability) {
+                player.addEffect(foodEffect.StatusEffect)
+            }
+        }
+    }
+
+    if itemType.FoodLeaves != 0 && player.gameType != GameTypeCreative {
+        player.giveItem(&player.position, &gamerules.Slot{ItemTypeId: itemType.FoodLeaves, Count: 1})
+    }
+}
+
+// die drops some of the player's experience where they died, and takes the
+// rest away.
+func (player *Player) die() {
+    for _, id := range player.effects.Clear() {
+        player.SendPacket(&proto.PacketEntityRemoveEffect{player.EntityId, id})
+    }
+    player.effectsChanged()
+
+    if amount := player.xp.deathDrop(); amount > 0 {
+        if shardClient, ok := player.chunkSubs.CurrentShardClient(); ok {
+            shardClient.ReqDropExperience(amount, player.position)
+        }
+    }
+
+    player.xp = experience{}
+    player.SendPacket(player.xp.packet())
+}
+
+// addEffect applies a status effect to the player.
+func (player *Player) addEffect(effect gamerules.StatusEffect) {
+    healthChange, changed := player.effects.Add(effect)
+    if changed {
+        player.SendPacket(player.effects.AddPacket(player.EntityId, effect.Id))
+        player.effectsChanged()
+    }
+    if healthChange > 0 {
+        player.heal(healthChange)
+    } else if healthChange < 0 {
+        player.hurt(-healthChange)
+    }
+}
+
+// effectsChanged updates the movement that the player is allowed for the
+// status effects that they have.
+func (player *Player) effectsChanged() {
+    player.move.speedBoost = 0
+    if effect, ok := player.effects.Get(EntityEffectMoveFaster); ok {
+        player.move.speedBoost = speedEffectFactor * float64(effect.Amplifier+1)
+    }
+    player.move.jumpBoost = 0
+    if effect, ok := player.effects.Get(EntityEffectJump); ok {
+        player.move.jumpBoost = jumpEffectHeight * AbsCoord(effect.Amplifier+1)
+    }
+}
+
+func (player *Player) giveExperience(amount Experience) {
+    player.xp.add(amount)
+    player.SendPacket(player.xp.packet())
+}
+
+func (player *Player) wearHeldItem(wasHeld *gamerules.Slot, uses ItemData) {
+    if player.gameType == GameTypeCreative {
+        return
+    }
+
+    curHeld, _ := player.inventory.HeldItem()
+
+    // Currently held item has changed since chunk saw it.
+    if !curHeld.IsSameType(wasHeld) {
+        return
+    }
 
-        shardClient.ReqPlaceItem(*target, into)
+    player.inventory.WearHeldItem(uses)
+}
+
+func (player *Player) consumeHeldItem(wasHeld *gamerules.Slot, leaves *gamerules.Slot) {
+    if player.takeHeldItem(wasHeld) && !leaves.IsEmpty() {
+        player.giveItem(&player.position, leaves)
     }
 }
 
+func (player *Player) feedEntity(wasHeld *gamerules.Slot, target EntityId) {
+    if !player.takeHeldItem(wasHeld) {
+        return
+    }
+
+    food := *wasHeld
+    food.Count = 1
+    for _, shardClient := range player.chunkSubs.ShardClients() {
+        shardClient.ReqFeedEntity(food, target)
+    }
+}
+
+// takeHeldItem takes one of the held item, returning false if it has changed
+// since the chunk saw it. Players in creative mode keep their held item.
+func (player *Player) takeHeldItem(wasHeld *gamerules.Slot) bool {
+    curHeld, _ := player.inventory.HeldItem()
+
+    // Currently held item has changed since chunk saw it.
+    if !curHeld.IsSameType(wasHeld) {
+        return false
+    }
+
+    if player.gameType != GameTypeCreative {
+        var used gamerules.Slot
+        player.inventory.TakeOneHeldItem(&used)
+    }
+    return true
+}
+
 // Used to receive items picked up from chunks. It is synchronous so that the
 // passed item can be looked at by the caller afterwards to see if it has been
 // consumed.
@@ -852,6 +1924,215 @@ func (player *Player) giveItem(atPosition *AbsXyz, item *gamerules.Slot) {
     player.inventory.PutItem(item)
 }
 
+// sleepInBed puts the player to sleep in the bed with its head at the given
+// location, provided that it is night. The bed also becomes the player's spawn
+// point.
+func (player *Player) sleepInBed(bed *BlockXyz) {
+    if player.sleeping != 0 {
+        return
+    }
+
+    bedPos := bed.MidPointToAbsXyz()
+    if !bedPos.IsWithinDistanceOf(player.position, MaxInteractDistance) {
+        return
+    }
+
+    timeOfDay := player.game.TimeOfDay()
+    if timeOfDay < SleepTimeStart || timeOfDay > SleepTimeEnd {
+        player.SendPacket(&proto.PacketChatMessage{"You can only sleep at night"})
+        return
+    }
+
+    player.sleeping = 1
+    player.sleepTimer = 0
+    player.spawnBlock = *bed
+    player.bedSpawn = true
+
+    data := player.txPktSerial.SerializePackets(&proto.PacketPlayerUseBed{
+        EntityId: player.EntityId,
+        Block:    *bed,
+    })
+    player.TransmitPacket(data)
+    player.chunkSubs.curShard.ReqMulticastTracking(data)
+    player.SendPacket(&proto.PacketSpawnPosition{bed.X, bed.Y, bed.Z})
+
+    player.game.SetPlayerSleeping(player.EntityId, true)
+}
+
+// wakeUp gets the player out of bed, if they are in one.
+func (player *Player) wakeUp() {
+    if player.sleeping == 0 {
+        return
+    }
+
+    player.sleeping = 0
+    player.sleepTimer = 0
+
+    data := player.txPktSerial.SerializePackets(&proto.PacketEntityAnimation{
+        EntityId:  player.EntityId,
+        Animation: EntityAnimationLeaveBed,
+    })
+    player.TransmitPacket(data)
+    player.chunkSubs.curShard.ReqMulticastTracking(data)
+}
+
+func (player *Player) setVehicle(vehicle EntityId) {
+    player.vehicle = vehicle
+
+    data := player.txPktSerial.SerializePackets(&proto.PacketEntityAttach{
+        EntityId:  player.EntityId,
+        VehicleId: vehicle,
+    })
+    player.TransmitPacket(data)
+    player.chunkSubs.curShard.ReqMulticastTracking(data)
+}
+
+func (player *Player) moveWithVehicle(position AbsXyz) {
+    if player.vehicle == EntityIdNull {
+        return
+    }
+
+    player.position = position
+    player.chunkSubs.Move(&position)
+    player.move.reset(position, time.Now())
+}
+
+// enterPortal is called while the player is standing in a portal to the given
+// dimension. The player travels through it once they have been inside it for
+// long enough.
+func (player *Player) enterPortal(dimension DimensionId) {
+    now := time.Now()
+    if now.Sub(player.portalLastSeen) > portalContactTimeout {
+        // The player has stepped into a portal afresh.
+        player.portalEnteredAt = now
+        player.portalTravelled = false
+    }
+    player.portalLastSeen = now
+
+    if player.portalTravelled || now.Sub(player.portalEnteredAt) < portalDelay {
+        return
+    }
+
+    if player.travelToDimension(dimension) {
+        player.portalTravelled = true
+    }
+}
+
+// travelToDimension moves the player into another dimension, arriving at the
+// position corresponding to their current one. Returns false if the player
+// could not travel.
+func (player *Player) travelToDimension(dimension DimensionId) bool {
+    from := DimensionId(player.dimension)
+    if dimension == from || player.vehicle != EntityIdNull {
+        return false
+    }
+
+    shardConnecter, ok := player.game.ShardConnecter(dimension)
+    if !ok {
+        log.Printf("%v: cannot travel to unknown dimension %d", player, dimension)
+        return false
+    }
+
+    if from == DimensionEnd {
+        // Players leaving the end return to their spawn point.
+        player.reconnect(dimension, shardConnecter, player.spawnPosition())
+        return true
+    }
+
+    player.reconnect(dimension, shardConnecter, gamerules.PortalDestination(from, dimension, player.position))
+    if shardClient, ok := player.chunkSubs.CurrentShardClient(); ok {
+        shardClient.ReqPortalArrival(player.position, player.look)
+    }
+
+    return true
+}
+
+// reconnect moves the player to the given position in the given dimension,
+// connecting them to its shards afresh. The client is sent a respawn packet,
+// after which it is sent the chunks around the player again.
+func (player *Player) reconnect(dimension DimensionId, shardConnecter gamerules.IShardConnecter, position AbsXyz) {
+    player.closeCurrentWindow(true)
+    player.chunkSubs.Close()
+
+    player.dimension = int32(dimension)
+    player.shardConnecter = shardConnecter
+    player.position = position
+    player.spawnComplete = false
+
+    player.SendPacket(&proto.PacketRespawn{
+        Dimension:   dimension,
+        Difficulty:  GameDifficulty(*playerDifficulty),
+        GameType:    player.gameType,
+        WorldHeight: int16(ChunkSizeY),
+        LevelType:   string(LevelTypeDefault),
+    })
+
+    player.chunkSubs.Init(player)
+}
+
+// spawnPosition returns the position that the player spawns at.
+func (player *Player) spawnPosition() AbsXyz {
+    position := player.spawnBlock.MidPointToAbsXyz()
+    position.Y = AbsCoord(player.spawnBlock.Y)
+    return position
+}
+
+// respawn brings a dead player back to life at their spawn point, in the
+// overworld. Players whose spawn point is a bed go back to the world spawn if
+// the bed has gone.
+func (player *Player) respawn() {
+    shardConnecter, ok := player.game.ShardConnecter(DimensionNormal)
+    if !ok {
+        log.Printf("%v: cannot respawn without the overworld", player)
+        return
+    }
+
+    if player.sleeping != 0 {
+        player.wakeUp()
+        player.game.SetPlayerSleeping(player.EntityId, false)
+    }
+
+    player.health = MaxHealth
+    player.food = newFoodStats()
+    player.effects.Clear()
+    player.effectsChanged()
+    player.xp = experience{}
+    player.eatingTicksLeft = 0
+    player.fallDistance = 0
+    player.fire = 0
+    player.air = 0
+
+    player.reconnect(DimensionNormal, shardConnecter, player.spawnPosition())
+    player.SendPacket(player.healthPacket())
+
+    if player.bedSpawn {
+        if shardClient, ok := player.chunkSubs.CurrentShardClient(); ok {
+            shardClient.ReqCheckBed(player.spawnBlock)
+        }
+    }
+}
+
+// bedMissing moves a player that has respawned at their bed to the world
+// spawn, as the bed is no longer there.
+func (player *Player) bedMissing() {
+    if !player.bedSpawn {
+        return
+    }
+
+    player.bedSpawn = false
+    player.spawnBlock = player.worldSpawn
+    player.SendPacket(&proto.PacketChatMessage{"Your home bed was missing or obstructed"})
+    player.SendPacket(&proto.PacketSpawnPosition{player.spawnBlock.X, player.spawnBlock.Y, player.spawnBlock.Z})
+    player.setPositionLook(player.spawnPosition(), player.look)
+}
+
+// WakeUp gets the player out of bed. It may be called from any goroutine.
+func (player *Player) WakeUp() {
+    player.Enqueue(func(player *Player) {
+        player.wakeUp()
+    })
+}
+
 // Enqueue queues a function to run with the within the player's mainloop.
 func (player *Player) Enqueue(f func(*Player)) {
     if f == nil {
@@ -876,8 +2157,16 @@ func (player *Player) sendChatMessage(message string, sendToSelf bool) {
     )
 }
 
-// closeCurrentWindow closes any open window.
+// closeCurrentWindow closes any open window. Items left on the cursor are
+// returned to the inventory.
 func (player *Player) closeCurrentWindow(sendClosePacket bool) {
+    if !player.cursor.IsEmpty() {
+        player.returnItem(&player.cursor)
+        if sendClosePacket {
+            player.SendPacket(player.cursor.UpdatePacket(WindowIdCursor, SlotIdCursor))
+        }
+    }
+
     if player.curWindow != nil {
         player.curWindow.Finalize(sendClosePacket)
         player.curWindow = nil
@@ -888,6 +2177,31 @@ func (player *Player) closeCurrentWindow(sendClosePacket bool) {
         player.remoteInv = nil
     }
 
+    // The item being enchanted is given back.
+    if player.enchantInv != nil {
+        for _, item := range player.enchantInv.TakeAllItems() {
+            player.returnItem(&item)
+        }
+        player.enchantInv = nil
+    }
+
+    // As are the items put into the anvil.
+    if player.anvilInv != nil {
+        for _, item := range player.anvilInv.TakeAllItems() {
+            player.returnItem(&item)
+        }
+        player.anvilInv = nil
+    }
+
+    // And those put into the trading window.
+    if player.merchantInv != nil {
+        for _, item := range player.merchantInv.TakeAllItems() {
+            player.returnItem(&item)
+        }
+        player.merchantInv = nil
+        player.merchant = EntityIdNull
+    }
+
     player.inventory.Resubscribe()
 }
 
@@ -897,6 +2211,7 @@ func (player *Player) setPositionLook(pos AbsXyz, look LookDegrees) {
     player.position = pos
     player.look = look
     player.height = StanceNormal - pos.Y
+    player.move.reset(pos, time.Now())
 
     if player.chunkSubs.Move(&player.position) {
         // The destination chunk isn't loaded. Wait for it.
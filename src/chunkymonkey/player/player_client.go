@@ -36,6 +36,12 @@ func (p *playerClient) InventorySubscribed(block BlockXyz, invTypeId InvTypeId,
     })
 }
 
+func (p *playerClient) EntityInventorySubscribed(entityId EntityId, block BlockXyz, invTypeId InvTypeId, slots proto.ItemSlotSlice) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.entityInventorySubscribed(entityId, &block, invTypeId, slots)
+    })
+}
+
 func (p *playerClient) InventorySlotUpdate(block BlockXyz, slot gamerules.Slot, slotId SlotId) {
     p.player.Enqueue(func(_ *Player) {
         p.player.inventorySlotUpdate(&block, &slot, slotId)
@@ -60,15 +66,63 @@ func (p *playerClient) InventoryTxState(block BlockXyz, txId TxId, accepted bool
     })
 }
 
+func (p *playerClient) InventoryTransferred(block BlockXyz, click gamerules.Click) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.inventoryTransferred(&block, &click)
+    })
+}
+
 func (p *playerClient) InventoryUnsubscribed(block BlockXyz) {
     p.player.Enqueue(func(_ *Player) {
         p.player.inventoryUnsubscribed(&block)
     })
 }
 
-func (p *playerClient) PlaceHeldItem(target BlockXyz, wasHeld gamerules.Slot) {
+func (p *playerClient) PlaceHeldItem(target BlockXyz, face Face, wasHeld gamerules.Slot) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.placeHeldItem(&target, face, &wasHeld)
+    })
+}
+
+func (p *playerClient) WearHeldItem(wasHeld gamerules.Slot, uses ItemData) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.wearHeldItem(&wasHeld, uses)
+    })
+}
+
+func (p *playerClient) ConsumeHeldItem(wasHeld gamerules.Slot, leaves gamerules.Slot) {
+    p.player.Enqueue(func(player *Player) {
+        player.consumeHeldItem(&wasHeld, &leaves)
+    })
+}
+
+func (p *playerClient) FeedEntity(wasHeld gamerules.Slot, target EntityId) {
+    p.player.Enqueue(func(player *Player) {
+        player.feedEntity(&wasHeld, target)
+    })
+}
+
+func (p *playerClient) SetGameType(gameType GameType) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.setGameType(gameType)
+    })
+}
+
+func (p *playerClient) Hurt(amount Health) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.hurt(amount)
+    })
+}
+
+func (p *playerClient) AddEffect(effect gamerules.StatusEffect) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.addEffect(effect)
+    })
+}
+
+func (p *playerClient) MoveChecked(position AbsXyz, supported bool, blocked bool) {
     p.player.Enqueue(func(_ *Player) {
-        p.player.placeHeldItem(&target, &wasHeld)
+        p.player.moveChecked(&position, supported, blocked)
     })
 }
 
@@ -84,6 +138,12 @@ func (p *playerClient) GiveItemAtPosition(atPosition AbsXyz, item gamerules.Slot
     })
 }
 
+func (p *playerClient) GiveExperience(amount Experience) {
+    p.player.Enqueue(func(_ *Player) {
+        p.player.giveExperience(amount)
+    })
+}
+
 func (p *playerClient) GiveItem(item gamerules.Slot) {
     p.player.Enqueue(func(_ *Player) {
         p.player.giveItem(&p.player.position, &item)
@@ -120,3 +180,63 @@ func (p *playerClient) SetPositionLook(pos AbsXyz, look LookDegrees) {
         player.setPositionLook(pos, look)
     })
 }
+
+func (p *playerClient) OpenEnderChest(block BlockXyz) {
+    p.player.Enqueue(func(player *Player) {
+        player.openEnderChest(&block)
+    })
+}
+
+func (p *playerClient) OpenEnchantingTable(block BlockXyz, bookshelves int) {
+    p.player.Enqueue(func(player *Player) {
+        player.openEnchantingTable(&block, bookshelves)
+    })
+}
+
+func (p *playerClient) OpenAnvil(block BlockXyz) {
+    p.player.Enqueue(func(player *Player) {
+        player.openAnvil(&block)
+    })
+}
+
+func (p *playerClient) OpenMerchant(merchant EntityId, position AbsXyz, offers []gamerules.MerchantOffer) {
+    p.player.Enqueue(func(player *Player) {
+        player.openMerchant(merchant, &position, offers)
+    })
+}
+
+func (p *playerClient) TradeResult(merchant EntityId, accepted bool, offers []gamerules.MerchantOffer) {
+    p.player.Enqueue(func(player *Player) {
+        player.tradeResult(merchant, accepted, offers)
+    })
+}
+
+func (p *playerClient) SleepInBed(bed BlockXyz) {
+    p.player.Enqueue(func(player *Player) {
+        player.sleepInBed(&bed)
+    })
+}
+
+func (p *playerClient) BedMissing() {
+    p.player.Enqueue(func(player *Player) {
+        player.bedMissing()
+    })
+}
+
+func (p *playerClient) SetVehicle(vehicle EntityId) {
+    p.player.Enqueue(func(player *Player) {
+        player.setVehicle(vehicle)
+    })
+}
+
+func (p *playerClient) MoveWithVehicle(position AbsXyz) {
+    p.player.Enqueue(func(player *Player) {
+        player.moveWithVehicle(position)
+    })
+}
+
+func (p *playerClient) EnterPortal(dimension DimensionId) {
+    p.player.Enqueue(func(player *Player) {
+        player.enterPortal(dimension)
+    })
+}
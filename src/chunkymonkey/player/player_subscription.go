@@ -57,6 +57,10 @@ func (sub *chunkSubscriptions) Init(player *Player) {
 // subscribed to by a chunk, indicating that the player will receive a
 // notifyChunkLoad when that chunk has been sent to the client.
 func (sub *chunkSubscriptions) Move(newLoc *AbsXyz) (notify bool) {
+    for _, ref := range sub.shardClients {
+        ref.shard.ReqSetTrackingPosition(*newLoc)
+    }
+
     newChunkLoc := newLoc.ToChunkXz()
     if newChunkLoc.X != sub.curChunkLoc.X || newChunkLoc.Z != sub.curChunkLoc.Z {
         notify = sub.moveToChunk(newChunkLoc, newLoc)
@@ -115,6 +119,17 @@ func (sub *chunkSubscriptions) ShardClientForBlockXyz(blockLoc *BlockXyz) (conn
     return
 }
 
+// ShardClients returns the connections to all shards that the player is
+// subscribed to. This is useful for requests about entities, whose chunk is not
+// known to the player.
+func (sub *chunkSubscriptions) ShardClients() (conns []gamerules.IPlayerShardClient) {
+    conns = make([]gamerules.IPlayerShardClient, 0, len(sub.shardClients))
+    for _, ref := range sub.shardClients {
+        conns = append(conns, ref.shard)
+    }
+    return
+}
+
 // ShardClientForChunkXz is a convenience function to get the correct shard
 // connection for a given ChunkXz position. Returns ok = false if there is no
 // open connection for that shard. Note that this doesn't check if the chunk
@@ -146,6 +161,8 @@ func (sub *chunkSubscriptions) subscribeToChunks(destLoc ChunkXz, chunkLocs []Ch
                 count: 0,
             }
             sub.shardClients[shardKey] = ref
+            ref.shard.ReqSetTrackingPosition(sub.player.position)
+            ref.shard.ReqSetGameType(sub.player.gameType)
         }
 
         isDestChunk := chunkLoc.X == destLoc.X && chunkLoc.Z == destLoc.Z
@@ -0,0 +1,415 @@
+package player
+
+import (
+    "testing"
+
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+    "nbt"
+)
+
+// protectionArmor returns a piece of armor with the protection enchantment.
+func protectionArmor(itemTypeId ItemTypeId, level int16) gamerules.Slot {
+    enchTag := nbt.NewCompound()
+    enchTag.Set("id", &nbt.Short{int16(gamerules.EnchantmentProtection)})
+    enchTag.Set("lvl", &nbt.Short{level})
+    tag := nbt.NewCompound()
+    tag.Set("ench", &nbt.List{nbt.TagCompound, []nbt.ITag{enchTag}})
+    return gamerules.Slot{itemTypeId, 1, 0, tag}
+}
+
+func TestPlayer_starvationProtection(t *testing.T) {
+    defer func(difficulty int) { *playerDifficulty = difficulty }(*playerDifficulty)
+    *playerDifficulty = int(GameDifficultyHard)
+
+    starve := func(player *Player) {
+        player.spawnComplete = true
+        player.food.level = 0
+        player.food.saturation = 0
+        for i := 0; i < foodHealthTicks; i++ {
+            player.tick()
+            // Throw away the packets sent.
+            for len(player.txQueue) > 0 {
+                <-player.txQueue
+            }
+        }
+    }
+
+    player := NewPlayer(1, nil, nil, "unprotected", BlockXyz{0, 64, 0}, nil, nil)
+    starve(player)
+    if player.health != MaxHealth-1 {
+        t.Errorf("expected starving player to have health %d, got %d", MaxHealth-1, player.health)
+    }
+
+    // Protection IV on every piece of armor reduces damage by 80%.
+    player = NewPlayer(2, nil, nil, "protected", BlockXyz{0, 64, 0}, nil, nil)
+    for slotId, itemTypeId := range []ItemTypeId{306, 307, 308, 309} {
+        if !player.inventory.SetSlot(SlotId(5+slotId), protectionArmor(itemTypeId, 4)) {
+            t.Fatalf("failed to put armor in slot %d", 5+slotId)
+        }
+    }
+    starve(player)
+    if player.health != MaxHealth {
+        t.Errorf("expected armor to protect starving player, got health %d", player.health)
+    }
+}
+
+func TestPlayer_Nbt(t *testing.T) {
+    player := NewPlayer(1, nil, nil, "sleeper", BlockXyz{0, 64, 0}, nil, nil)
+    player.sleeping = 1
+
+    tag := nbt.NewCompound()
+    if err := player.MarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error marshalling player: %v", err)
+    }
+    if tag.Lookup("SpawnX") != nil {
+        t.Errorf("expected no spawn point to be saved without a bed")
+    }
+
+    loaded := NewPlayer(2, nil, nil, "sleeper", BlockXyz{0, 64, 0}, nil, nil)
+    if err := loaded.UnmarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error unmarshalling player: %v", err)
+    }
+    if loaded.sleeping != 0 {
+        t.Errorf("expected player to wake up when loaded")
+    }
+    if loaded.bedSpawn {
+        t.Errorf("expected player to have no bed spawn point")
+    }
+
+    // A bed spawn point is kept.
+    player.spawnBlock, player.bedSpawn = BlockXyz{10, 70, -5}, true
+    tag = nbt.NewCompound()
+    if err := player.MarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error marshalling player: %v", err)
+    }
+    if err := loaded.UnmarshalNbt(tag); err != nil {
+        t.Fatalf("unexpected error unmarshalling player: %v", err)
+    }
+    if !loaded.bedSpawn || loaded.spawnBlock != player.spawnBlock {
+        t.Errorf("expected bed spawn point %v, got %v (bed=%t)", player.spawnBlock, loaded.spawnBlock, loaded.bedSpawn)
+    }
+}
+
+// testGame provides the overworld to players.
+type testGame struct {
+    gamerules.IGame
+    connecter gamerules.IShardConnecter
+}
+
+func (game *testGame) ShardConnecter(dimension DimensionId) (gamerules.IShardConnecter, bool) {
+    return game.connecter, dimension == DimensionNormal
+}
+
+func (game *testGame) SetPlayerSleeping(id EntityId, sleeping bool) {
+}
+
+// testConnecter connects players to a single testShard.
+type testConnecter struct {
+    gamerules.IShardConnecter
+    shard *testShard
+}
+
+func (connecter *testConnecter) PlayerShardConnect(entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) gamerules.IPlayerShardClient {
+    return connecter.shard
+}
+
+// testShard records the requests made of it that tests are interested in.
+type testShard struct {
+    gamerules.IPlayerShardClient
+    checkedBeds  []BlockXyz
+    usedEntities []EntityId
+    trades       []int
+    feeds        []gamerules.Slot
+}
+
+func (shard *testShard) Disconnect()                                             {}
+func (shard *testShard) ReqSetTrackingPosition(pos AbsXyz)                       {}
+func (shard *testShard) ReqSetGameType(gameType GameType)                        {}
+func (shard *testShard) ReqSubscribeChunk(chunkLoc ChunkXz, notify bool)         {}
+func (shard *testShard) ReqUnsubscribeChunk(chunkLoc ChunkXz)                    {}
+func (shard *testShard) ReqSetPlayerPosition(chunkLoc ChunkXz, pos AbsXyz)       {}
+func (shard *testShard) ReqRemovePlayerData(chunkLoc ChunkXz, isDisconnect bool) {}
+func (shard *testShard) ReqAddPlayerData(chunkLoc ChunkXz, name string, pos AbsXyz, look LookBytes, held ItemTypeId) {
+}
+func (shard *testShard) ReqCheckBed(bed BlockXyz) {
+    shard.checkedBeds = append(shard.checkedBeds, bed)
+}
+func (shard *testShard) ReqUseEntity(held gamerules.Slot, target EntityId, leftClick bool) {
+    shard.usedEntities = append(shard.usedEntities, target)
+}
+func (shard *testShard) ReqFeedEntity(food gamerules.Slot, target EntityId) {
+    shard.feeds = append(shard.feeds, food)
+}
+func (shard *testShard) ReqTrade(merchant EntityId, index int, offer gamerules.MerchantOffer) {
+    shard.trades = append(shard.trades, index)
+}
+
+func TestPlayer_respawn(t *testing.T) {
+    shard := &testShard{}
+    game := &testGame{connecter: &testConnecter{shard: shard}}
+    worldSpawn := BlockXyz{0, 64, 0}
+
+    newDeadPlayer := func() *Player {
+        player := NewPlayer(1, nil, nil, "dead", worldSpawn, nil, game)
+        player.health = 0
+        player.food.level = 3
+        player.position = AbsXyz{100, 20, 100}
+        return player
+    }
+
+    player := newDeadPlayer()
+    player.handlePacketRespawn(&proto.PacketRespawn{})
+    if player.health != MaxHealth || player.food.level != MaxFoodUnits {
+        t.Errorf("expected respawned player to be healthy and fed, got health %d, food %d", player.health, player.food.level)
+    }
+    if expected := (AbsXyz{0.5, 64, 0.5}); player.position != expected {
+        t.Errorf("expected player to respawn at %v, got %v", expected, player.position)
+    }
+    if len(shard.checkedBeds) != 0 {
+        t.Errorf("expected no bed to be checked, got %v", shard.checkedBeds)
+    }
+
+    // Players with a bed respawn at it, unless it has gone.
+    player = newDeadPlayer()
+    player.spawnBlock, player.bedSpawn = BlockXyz{10, 70, -5}, true
+    player.handlePacketRespawn(&proto.PacketRespawn{})
+    if expected := (AbsXyz{10.5, 70, -4.5}); player.position != expected {
+        t.Errorf("expected player to respawn at their bed %v, got %v", expected, player.position)
+    }
+    if len(shard.checkedBeds) != 1 || shard.checkedBeds[0] != player.spawnBlock {
+        t.Errorf("expected bed at %v to be checked, got %v", player.spawnBlock, shard.checkedBeds)
+    }
+    player.bedMissing()
+    if player.bedSpawn || player.spawnBlock != worldSpawn {
+        t.Errorf("expected missing bed to reset spawn point to %v, got %v", worldSpawn, player.spawnBlock)
+    }
+
+    // Living players cannot respawn.
+    player.position = AbsXyz{100, 20, 100}
+    player.handlePacketRespawn(&proto.PacketRespawn{})
+    if expected := (AbsXyz{100, 20, 100}); player.position != expected {
+        t.Errorf("expected living player to stay at %v, got %v", expected, player.position)
+    }
+}
+
+func TestPlayer_attackCooldown(t *testing.T) {
+    shard := &testShard{}
+    player := NewPlayer(1, &testConnecter{shard: shard}, nil, "attacker", BlockXyz{0, 64, 0}, nil, nil)
+    player.chunkSubs.Init(player)
+    // Each request goes to every shard that the player is connected to.
+    shards := len(player.chunkSubs.ShardClients())
+
+    player.useEntity(2, true)
+    player.useEntity(2, true)
+    if len(shard.usedEntities) != shards {
+        t.Errorf("expected one attack within the cooldown, got %d", len(shard.usedEntities)/shards)
+    }
+
+    // Using entities is not limited.
+    player.useEntity(2, false)
+    if len(shard.usedEntities) != 2*shards {
+        t.Errorf("expected entity to be used, got %d requests", len(shard.usedEntities)/shards)
+    }
+
+    player.lastAttackAt = player.lastAttackAt.Add(-attackCooldown)
+    player.useEntity(2, true)
+    if len(shard.usedEntities) != 3*shards {
+        t.Errorf("expected attack after the cooldown, got %d requests", len(shard.usedEntities)/shards)
+    }
+}
+
+func TestPlayer_creativeInventoryAction(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    gamerules.Items = gamerules.ItemTypeMap{
+        1: &gamerules.ItemType{Id: 1, MaxStack: 64},
+    }
+
+    // The first slot of the hotbar, which the player is holding.
+    const heldSlotId = SlotId(36)
+
+    tests := []struct {
+        desc     string
+        gameType GameType
+        slotId   SlotId
+        item     proto.ItemSlot
+        expected gamerules.Slot
+    }{
+        {"creative", GameTypeCreative, heldSlotId, proto.ItemSlot{1, 64, 0, nil}, gamerules.Slot{1, 64, 0, nil}},
+        {"survival", GameTypeSurvival, heldSlotId, proto.ItemSlot{1, 64, 0, nil}, gamerules.Slot{}},
+        {"unknown item", GameTypeCreative, heldSlotId, proto.ItemSlot{9999, 1, 0, nil}, gamerules.Slot{}},
+        {"too many items", GameTypeCreative, heldSlotId, proto.ItemSlot{1, 65, 0, nil}, gamerules.Slot{}},
+        {"crafting output", GameTypeCreative, 0, proto.ItemSlot{1, 64, 0, nil}, gamerules.Slot{}},
+    }
+
+    for _, test := range tests {
+        player := NewPlayer(1, nil, nil, "creator", BlockXyz{0, 64, 0}, nil, nil)
+        player.gameType = test.gameType
+
+        player.handlePacketCreativeInventoryAction(&proto.PacketCreativeInventoryAction{test.slotId, test.item})
+        if held, _ := player.inventory.HeldItem(); !held.Equals(&test.expected) {
+            t.Errorf("%s: expected held item %v, got %v", test.desc, test.expected, held)
+        }
+    }
+}
+
+func TestPlayer_hurt(t *testing.T) {
+    player := NewPlayer(1, nil, nil, "victim", BlockXyz{0, 64, 0}, nil, nil)
+
+    player.hurt(5)
+    if player.health != MaxHealth-5 {
+        t.Errorf("expected player to have health %d, got %d", MaxHealth-5, player.health)
+    }
+    player.hurt(MaxHealth)
+    if player.health != 0 {
+        t.Errorf("expected player to have no health, got %d", player.health)
+    }
+
+    // Creative players are invulnerable.
+    player = NewPlayer(2, nil, nil, "creator", BlockXyz{0, 64, 0}, nil, nil)
+    player.gameType = GameTypeCreative
+    player.hurt(5)
+    if player.health != MaxHealth {
+        t.Errorf("expected creative player to be unhurt, got health %d", player.health)
+    }
+}
+
+func TestPlayer_trade(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    const wheat, emerald = ItemTypeId(296), ItemTypeId(388)
+    gamerules.Items = gamerules.ItemTypeMap{
+        wheat:   &gamerules.ItemType{Id: wheat, MaxStack: 64},
+        emerald: &gamerules.ItemType{Id: emerald, MaxStack: 64},
+    }
+    offers := []gamerules.MerchantOffer{
+        {Buy: gamerules.Slot{wheat, 20, 0, nil}, Sell: gamerules.Slot{emerald, 1, 0, nil}, MaxUses: 7},
+    }
+
+    shard := &testShard{}
+    player := NewPlayer(1, &testConnecter{shard: shard}, nil, "trader", BlockXyz{0, 64, 0}, nil, nil)
+    player.chunkSubs.Init(player)
+    // Each request goes to every shard that the player is connected to.
+    shards := len(player.chunkSubs.ShardClients())
+
+    // Villagers out of reach cannot be traded with.
+    player.openMerchant(10, &AbsXyz{20, 64, 0}, offers)
+    if player.merchantInv != nil {
+        t.Fatalf("expected no trading window for a villager out of reach")
+    }
+    player.openMerchant(10, &AbsXyz{2, 64, 0}, offers)
+    if player.merchantInv == nil {
+        t.Fatalf("expected a trading window for a villager in reach")
+    }
+
+    click := func(slotId SlotId, expected gamerules.Slot) {
+        player.handlePacketWindowClick(&proto.PacketWindowClick{
+            WindowId:    player.curWindow.WindowId(),
+            Slot:        slotId,
+            ClickedItem: expected.ItemSlot(),
+        })
+    }
+    paid := gamerules.Slot{emerald, 1, 0, nil}
+
+    // The bought item is only handed out once the villager agrees.
+    player.cursor = gamerules.Slot{wheat, 40, 0, nil}
+    click(0, gamerules.Slot{})
+    click(2, paid)
+    if !player.cursor.IsEmpty() || len(shard.trades) != shards {
+        t.Fatalf("expected trade to wait for the villager, got cursor %v and %d requests", player.cursor, len(shard.trades)/shards)
+    }
+    player.tradeResult(10, true, offers)
+    if !player.cursor.Equals(&paid) {
+        t.Errorf("expected agreed trade to put %v on the cursor, got %v", paid, player.cursor)
+    }
+
+    // Nothing more can be traded while a trade waits.
+    click(2, paid)
+    if len(shard.trades) != 2*shards {
+        t.Fatalf("expected a second trade, got %d requests", len(shard.trades)/shards)
+    }
+    click(2, paid)
+    if len(shard.trades) != 2*shards {
+        t.Errorf("expected no trade while one is waiting, got %d requests", len(shard.trades)/shards)
+    }
+
+    // The payment for a refused trade is given back, even once the window has
+    // closed.
+    player.closeCurrentWindow(true)
+    player.tradeResult(10, false, offers)
+    if player.trade != nil {
+        t.Errorf("expected no trade to be waiting")
+    }
+    player.inventory.SetHolding(1)
+    if held, _ := player.inventory.HeldItem(); !held.Equals(&gamerules.Slot{wheat, 20, 0, nil}) {
+        t.Errorf("expected the payment to be given back, got %v", held)
+    }
+}
+
+func TestPlayer_feedEntity(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    const carrot, wheat = ItemTypeId(391), ItemTypeId(296)
+    gamerules.Items = gamerules.ItemTypeMap{
+        carrot: &gamerules.ItemType{Id: carrot, MaxStack: 64},
+        wheat:  &gamerules.ItemType{Id: wheat, MaxStack: 64},
+    }
+    food := gamerules.Slot{carrot, 1, 0, nil}
+
+    tests := []struct {
+        desc     string
+        gameType GameType
+        wasHeld  gamerules.Slot
+        fed      bool
+        held     gamerules.Slot
+    }{
+        {"survival", GameTypeSurvival, food, true, gamerules.Slot{carrot, 4, 0, nil}},
+        {"creative", GameTypeCreative, food, true, gamerules.Slot{carrot, 5, 0, nil}},
+        {"held item changed", GameTypeSurvival, gamerules.Slot{wheat, 1, 0, nil}, false, gamerules.Slot{carrot, 5, 0, nil}},
+    }
+
+    for _, test := range tests {
+        shard := &testShard{}
+        player := NewPlayer(1, &testConnecter{shard: shard}, nil, "farmer", BlockXyz{0, 64, 0}, nil, nil)
+        player.chunkSubs.Init(player)
+        // Each request goes to every shard that the player is connected to.
+        shards := len(player.chunkSubs.ShardClients())
+        player.gameType = test.gameType
+        player.inventory.SetSlot(36, gamerules.Slot{carrot, 5, 0, nil})
+
+        player.feedEntity(&test.wasHeld, 2)
+        if test.fed {
+            if len(shard.feeds) != shards || !shard.feeds[0].Equals(&food) {
+                t.Errorf("%s: expected the animal to be fed %v, got %v", test.desc, food, shard.feeds)
+            }
+        } else if len(shard.feeds) != 0 {
+            t.Errorf("%s: expected the animal not to be fed, got %v", test.desc, shard.feeds)
+        }
+        if held, _ := player.inventory.HeldItem(); !held.Equals(&test.held) {
+            t.Errorf("%s: expected held item %v, got %v", test.desc, test.held, held)
+        }
+    }
+}
+
+func TestPlayer_consumeHeldItem(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    const bucket, milk = ItemTypeId(325), ItemTypeId(335)
+    gamerules.Items = gamerules.ItemTypeMap{
+        bucket: &gamerules.ItemType{Id: bucket, MaxStack: 16},
+        milk:   &gamerules.ItemType{Id: milk, MaxStack: 1},
+    }
+    milkBucket := gamerules.Slot{milk, 1, 0, nil}
+
+    // Creative players keep their bucket, and are still given the milk.
+    player := NewPlayer(1, nil, nil, "milker", BlockXyz{0, 64, 0}, nil, nil)
+    player.gameType = GameTypeCreative
+    player.inventory.SetSlot(36, gamerules.Slot{bucket, 1, 0, nil})
+    leaves := milkBucket
+    player.consumeHeldItem(&gamerules.Slot{bucket, 1, 0, nil}, &leaves)
+    if held, _ := player.inventory.HeldItem(); !held.Equals(&gamerules.Slot{bucket, 1, 0, nil}) {
+        t.Errorf("expected creative player to keep their bucket, got %v", held)
+    }
+    player.inventory.SetHolding(1)
+    if held, _ := player.inventory.HeldItem(); !held.Equals(&milkBucket) {
+        t.Errorf("expected creative player to be given %v, got %v", milkBucket, held)
+    }
+}
@@ -8,6 +8,7 @@ import (
 
 type RemoteInventory struct {
     blockLoc   BlockXyz
+    entityId   EntityId // The entity holding the inventory, or EntityIdNull for blocks.
     chunkSubs  *chunkSubscriptions
     slots      proto.ItemSlotSlice
     subscriber gamerules.IInventorySubscriber
@@ -16,6 +17,7 @@ type RemoteInventory struct {
 func NewRemoteInventory(block *BlockXyz, chunkSubs *chunkSubscriptions, slots proto.ItemSlotSlice) *RemoteInventory {
     return &RemoteInventory{
         blockLoc:   *block,
+        entityId:   EntityIdNull,
         chunkSubs:  chunkSubs,
         slots:      slots,
         subscriber: nil,
@@ -27,6 +29,10 @@ func (inv *RemoteInventory) IsForBlock(block *BlockXyz) bool {
 }
 
 func (inv *RemoteInventory) slotUpdate(slot *gamerules.Slot, slotId SlotId) {
+    if slotId >= 0 && int(slotId) < len(inv.slots) {
+        inv.slots[slotId] = slot.ItemSlot()
+    }
+
     if inv.subscriber != nil {
         inv.subscriber.SlotUpdate(slot, slotId)
     }
@@ -41,7 +47,13 @@ func (inv *RemoteInventory) progressUpdate(prgBarId PrgBarId, value PrgBarValue)
 func (inv *RemoteInventory) Close() {
     shard, _, ok := inv.chunkSubs.ShardClientForBlockXyz(&inv.blockLoc)
 
-    if ok {
+    if !ok {
+        return
+    }
+
+    if inv.entityId != EntityIdNull {
+        shard.ReqEntityInventoryUnsubscribed(inv.entityId)
+    } else {
         shard.ReqInventoryUnsubscribed(inv.blockLoc)
     }
 }
@@ -56,7 +68,11 @@ func (inv *RemoteInventory) Click(click *gamerules.Click) (txState TxState) {
     shard, _, ok := inv.chunkSubs.ShardClientForBlockXyz(&inv.blockLoc)
 
     if ok {
-        shard.ReqInventoryClick(inv.blockLoc, *click)
+        if inv.entityId != EntityIdNull {
+            shard.ReqEntityInventoryClick(inv.entityId, *click)
+        } else {
+            shard.ReqInventoryClick(inv.blockLoc, *click)
+        }
     }
 
     return TxStateDeferred
@@ -67,8 +83,7 @@ func (inv *RemoteInventory) SetSubscriber(subscriber gamerules.IInventorySubscri
 }
 
 func (inv *RemoteInventory) GetProtoSlots(slots proto.ItemSlotSlice) {
-    // Note that this only produces accurate results before any slot updates come
-    // through. inv.slots contains only a snapshot of the state when the window
-    // opened.
+    // inv.slots is kept up to date with slot updates, but may lag behind clicks
+    // that are still being carried out remotely.
     copy(slots, inv.slots)
 }
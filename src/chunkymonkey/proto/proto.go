@@ -6,6 +6,7 @@ import (
     "compress/zlib"
     "encoding/binary"
     "io"
+    "io/ioutil"
     "log"
     "math"
     "reflect"
@@ -22,30 +23,6 @@ const (
     ucs2ReplChar = 0xfffd
 )
 
-// nbtItemIds is the set of item type IDs that have an NBT structure associated
-// with them in packets. Taken from https://gist.github.com/1268479
-var nbtItemIds = map[ItemTypeId]bool{
-    0x105: true, // Bow
-    0x15A: true, // Fishing rod
-    0x167: true, // Shears
-
-    // TOOLS
-    // sword, shovel, pickaxe, axe, hoe
-    0x10C: true, 0x10D: true, 0x10E: true, 0x10F: true, 0x122: true, // WOOD
-    0x110: true, 0x111: true, 0x112: true, 0x113: true, 0x123: true, // STONE
-    0x10B: true, 0x100: true, 0x101: true, 0x102: true, 0x124: true, // IRON
-    0x114: true, 0x115: true, 0x116: true, 0x117: true, 0x125: true, // DIAMOND
-    0x11B: true, 0x11C: true, 0x11D: true, 0x11E: true, 0x126: true, // GOLD
-
-    // ARMOUR
-    // helmet, chestplate, leggings, boots
-    0x12A: true, 0x12B: true, 0x12C: true, 0x12D: true, // LEATHER
-    0x12E: true, 0x12F: true, 0x130: true, 0x131: true, // CHAIN
-    0x132: true, 0x133: true, 0x134: true, 0x135: true, // IRON
-    0x136: true, 0x137: true, 0x138: true, 0x139: true, // DIAMOND
-    0x13A: true, 0x13B: true, 0x13C: true, 0x14D: true, // GOLD
-}
-
 type IPacket interface {
     // IsPacket doesn't do anything, it's present purely for type-checking
     // packets.
@@ -425,8 +402,8 @@ func (*PacketMultiBlockChange) IsPacket() {}
 
 type PacketBlockChange struct {
     Block     BlockXyz
-    TypeId    BlockId
-    BlockData EntityMetadataTable
+    TypeId    int16
+    BlockData byte
 }
 
 func (*PacketBlockChange) IsPacket() {}
@@ -499,7 +476,7 @@ type PacketParticle struct {
 }
 
 type PacketState struct {
-    Reason   byte
+    Reason   StateReason
     GameType GameType
 }
 
@@ -547,10 +524,9 @@ type PacketWindowSetSlot struct {
 
 func (*PacketWindowSetSlot) IsPacket() {}
 
-type PacketWindowItems struct { //@CHECK 1.5.1 @TODO
+type PacketWindowItems struct {
     WindowId WindowId
-    Count    int16
-    Slots    []ItemSlot
+    Slots    ItemSlotSlice
 }
 
 func (*PacketWindowItems) IsPacket() {}
@@ -711,6 +687,7 @@ func (is *ItemSlot) MinecraftUnmarshal(reader io.Reader, ps *PacketSerializer) e
         return err
     }
     is.ItemTypeId = ItemTypeId(typeIdUint16)
+    is.Nbt = nil
 
     if is.ItemTypeId == -1 {
         is.Count = 0
@@ -728,92 +705,89 @@ func (is *ItemSlot) MinecraftUnmarshal(reader io.Reader, ps *PacketSerializer) e
         is.Count = ItemCount(countUint8)
         is.Data = ItemData(dataUint16)
 
-        if _, requiresNbt := nbtItemIds[is.ItemTypeId]; requiresNbt {
-            // Read NBT data.
-            lUint16, err := ps.readUint16(reader)
-            if err != nil {
-                return err
-            }
-            lInt16 := int16(lUint16)
-            if lInt16 < 0 {
-                return nil
-            }
+        // Read NBT data, which every item may have.
+        lUint16, err := ps.readUint16(reader)
+        if err != nil {
+            return err
+        }
+        lInt16 := int16(lUint16)
+        if lInt16 < 0 {
+            return nil
+        }
 
-            zReader, err := gzip.NewReader(&io.LimitedReader{reader, int64(lInt16)})
-            if err != nil {
-                return err
-            }
+        nbtReader := &io.LimitedReader{reader, int64(lInt16)}
+        zReader, err := gzip.NewReader(nbtReader)
+        if err != nil {
+            return err
+        }
 
-            err = zReader.Close()
-            if err != nil {
-                return err
-            }
+        is.Nbt, err = nbt.Read(zReader)
+        if err != nil {
+            return err
+        }
 
-            is.Nbt, err = nbt.Read(zReader)
-            if err != nil {
-                return err
-            }
+        err = zReader.Close()
+        if err != nil {
+            return err
+        }
+
+        // Skip anything left over so that the rest of the packet is read
+        // from the right place.
+        if _, err = io.Copy(ioutil.Discard, nbtReader); err != nil {
+            return err
         }
     }
     return nil
 }
 
 func (is *ItemSlot) MinecraftMarshal(writer io.Writer, ps *PacketSerializer) error {
+    if is.ItemTypeId <= 0 {
+        // Empty slot.
+        return ps.writeUint16(writer, 0xffff)
+    }
+
     err := ps.writeUint16(writer, uint16(is.ItemTypeId))
     if err != nil {
         return err
     }
+    err = ps.writeUint8(writer, uint8(is.Count))
+    if err != nil {
+        return err
+    }
+    err = ps.writeUint16(writer, uint16(is.Data))
+    if err != nil {
+        return err
+    }
 
-    if is.ItemTypeId != -1 {
-        err = ps.writeUint8(writer, uint8(is.Count))
-        if err != nil {
-            return err
-        }
-        err = ps.writeUint16(writer, uint16(is.Data))
-        if err != nil {
-            return err
-        }
+    // Write NBT data, which every item may have.
+    if len(is.Nbt) == 0 {
+        // No tags.
+        return ps.writeUint16(writer, uint16(0xffff))
+    }
 
-        if _, requiresNbt := nbtItemIds[is.ItemTypeId]; requiresNbt {
-            // Write NBT data.
-            if is.Nbt == nil || len(is.Nbt) == 0 {
-                // No tags.
-                err = ps.writeUint16(writer, uint16(0xffff))
-                if err != nil {
-                    return err
-                }
-            } else {
-                // Have tags.
-                var buf bytes.Buffer
-
-                zWriter := gzip.NewWriter(&buf)
-
-                err = nbt.Write(zWriter, is.Nbt)
-                if err != nil {
-                    return err
-                }
-
-                err = zWriter.Close()
-                if err != nil {
-                    return err
-                }
-
-                data := buf.Bytes()
-
-                err = ps.writeUint16(writer, uint16(len(data)))
-                if err != nil {
-                    return err
-                }
-
-                _, err = writer.Write(data)
-                if err != nil {
-                    return err
-                }
-            }
-        }
+    var buf bytes.Buffer
+
+    zWriter := gzip.NewWriter(&buf)
+
+    err = nbt.Write(zWriter, is.Nbt)
+    if err != nil {
+        return err
     }
 
-    return nil
+    err = zWriter.Close()
+    if err != nil {
+        return err
+    }
+
+    data := buf.Bytes()
+
+    err = ps.writeUint16(writer, uint16(len(data)))
+    if err != nil {
+        return err
+    }
+
+    _, err = writer.Write(data)
+    return err
 }
 
 // ItemSlotSlice implements IMarshaler.
@@ -853,6 +827,95 @@ func (slots *ItemSlotSlice) MinecraftMarshal(writer io.Writer, ps *PacketSeriali
     return
 }
 
+// MerchantOffer is a trade offered by a villager.
+type MerchantOffer struct {
+    Buy       ItemSlot
+    BuySecond ItemSlot // Can be empty, for trades that only want one item.
+    Sell      ItemSlot
+    Disabled  bool
+}
+
+// MerchantOfferList implements IMarshaler. It is the data of the "MC|TrList"
+// plugin message, which tells the client the offers shown in a merchant
+// window.
+var assertMerchantOfferList = IMarshaler(&MerchantOfferList{})
+
+type MerchantOfferList struct {
+    WindowId WindowId
+    Offers   []MerchantOffer
+}
+
+func (ol *MerchantOfferList) MinecraftUnmarshal(reader io.Reader, ps *PacketSerializer) (err error) {
+    windowId, err := ps.readUint32(reader)
+    if err != nil {
+        return
+    }
+    ol.WindowId = WindowId(windowId)
+
+    numOffers, err := ps.readUint8(reader)
+    if err != nil {
+        return
+    }
+
+    ol.Offers = make([]MerchantOffer, numOffers)
+    for i := range ol.Offers {
+        offer := &ol.Offers[i]
+        if err = offer.Buy.MinecraftUnmarshal(reader, ps); err != nil {
+            return
+        }
+        if err = offer.Sell.MinecraftUnmarshal(reader, ps); err != nil {
+            return
+        }
+        var hasSecond bool
+        if hasSecond, err = ps.readBool(reader); err != nil {
+            return
+        }
+        if hasSecond {
+            if err = offer.BuySecond.MinecraftUnmarshal(reader, ps); err != nil {
+                return
+            }
+        }
+        if offer.Disabled, err = ps.readBool(reader); err != nil {
+            return
+        }
+    }
+
+    return
+}
+
+func (ol *MerchantOfferList) MinecraftMarshal(writer io.Writer, ps *PacketSerializer) (err error) {
+    if err = ps.writeUint32(writer, uint32(ol.WindowId)); err != nil {
+        return
+    }
+    if err = ps.writeUint8(writer, uint8(len(ol.Offers))); err != nil {
+        return
+    }
+
+    for i := range ol.Offers {
+        offer := &ol.Offers[i]
+        if err = offer.Buy.MinecraftMarshal(writer, ps); err != nil {
+            return
+        }
+        if err = offer.Sell.MinecraftMarshal(writer, ps); err != nil {
+            return
+        }
+        hasSecond := offer.BuySecond.ItemTypeId > 0 && offer.BuySecond.Count > 0
+        if err = ps.writeBool(writer, hasSecond); err != nil {
+            return
+        }
+        if hasSecond {
+            if err = offer.BuySecond.MinecraftMarshal(writer, ps); err != nil {
+                return
+            }
+        }
+        if err = ps.writeBool(writer, offer.Disabled); err != nil {
+            return
+        }
+    }
+
+    return
+}
+
 // ThrowerData implements IMarshaler.
 var assertThrowerData = IMarshaler(&ThrowerData{})
 
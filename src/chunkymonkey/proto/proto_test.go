@@ -179,7 +179,8 @@ func Test_PacketPlayerBlockInteract(t *testing.T) {
             "\x02"+
             "\x00\x01"+
             "\x02"+
-            "\x00\x03"),
+            "\x00\x03"+
+            "\xff\xff"),
     )
 
     // Test with last two fields missing (no tool used).
@@ -201,7 +202,7 @@ func Test_PacketPlayerBlockInteract(t *testing.T) {
             "\xff\xff"),
     )
 
-    // Test with an item that can have NBT data, but which is empty.
+    // Test with an item that has no NBT data.
     testPacketSerial(
         t,
         true,
@@ -368,7 +369,7 @@ func Test_PacketWindowItems(t *testing.T) {
             "\x05"+
             "\x00\x02"+
             "\xff\xff"+
-            "\x00\x03\x07\x00\x01"),
+            "\x00\x03\x07\x00\x01\xff\xff"),
     )
 }
 
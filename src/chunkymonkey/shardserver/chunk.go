@@ -29,6 +29,7 @@ type Chunk struct {
     subscribers  map[EntityId]gamerules.IPlayerClient   // Players getting updates from the chunk.
     playersData  map[EntityId]*playerData               // Some player data for player(s) in the chunk.
     onUnsub      map[EntityId][]gamerules.IUnsubscribed // Functions to be called when unsubscribed.
+    digs         map[EntityId]*blockDig                 // Blocks being dug by players.
     storeDirty   bool                                   // Is the chunk store copy of this chunk dirty?
 
     activeBlocks    map[BlockIndex]bool // Blocks that need to "tick".
@@ -51,6 +52,7 @@ func newChunkFromReader(reader chunkstore.IChunkReader, shard *ChunkShard) (chun
         subscribers:  make(map[EntityId]gamerules.IPlayerClient),
         playersData:  make(map[EntityId]*playerData),
         onUnsub:      make(map[EntityId][]gamerules.IUnsubscribed),
+        digs:         make(map[EntityId]*blockDig),
         storeDirty:   false,
 
         activeBlocks:    make(map[BlockIndex]bool),
@@ -119,11 +121,11 @@ func (chunk *Chunk) setBlock(blockLoc *BlockXyz, subLoc *SubChunkXyz, index Bloc
 
     // Tell players that the block changed.
     buf := new(bytes.Buffer)
-    // chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketBlockChange{ @TODO 1.5.1
-    //     Block:     *blockLoc,
-    //     TypeId:    blockType,
-    //     BlockData: blockData,
-    // })
+    chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketBlockChange{
+        Block:     *blockLoc,
+        TypeId:    int16(blockType),
+        BlockData: blockData,
+    })
     chunk.reqMulticastPlayers(-1, buf.Bytes())
 
     return
@@ -145,6 +147,63 @@ func (chunk *Chunk) SetBlockByIndex(blockIndex BlockIndex, blockId BlockId, bloc
         blockData)
 }
 
+// chunkForBlock returns the chunk containing blockLoc, which may be this
+// chunk or a loaded neighbour within the same shard. Returns nil if there is
+// no such chunk.
+func (chunk *Chunk) chunkForBlock(blockLoc *BlockXyz) *Chunk {
+    chunkLoc := blockLoc.ToChunkXz()
+    if chunk.isSameChunk(chunkLoc) {
+        return chunk
+    }
+    return chunk.shard.loadedChunk(*chunkLoc)
+}
+
+func (chunk *Chunk) BlockAt(blockLoc *BlockXyz) (blockType *gamerules.BlockType, blockData byte, ok bool) {
+    owner := chunk.chunkForBlock(blockLoc)
+    if owner == nil {
+        return nil, 0, false
+    }
+
+    _, subLoc := blockLoc.ToChunkLocal()
+    index, ok := subLoc.BlockIndex()
+    if !ok {
+        return nil, 0, false
+    }
+
+    return owner.blockTypeAndData(index)
+}
+
+func (chunk *Chunk) SetBlockAt(blockLoc *BlockXyz, blockId BlockId, blockData byte) (ok bool) {
+    owner := chunk.chunkForBlock(blockLoc)
+    if owner == nil {
+        return false
+    }
+
+    _, subLoc := blockLoc.ToChunkLocal()
+    index, ok := subLoc.BlockIndex()
+    if !ok {
+        return false
+    }
+
+    owner.setBlock(blockLoc, subLoc, index, blockId, blockData)
+
+    return true
+}
+
+func (chunk *Chunk) BlockInstanceAt(blockLoc *BlockXyz) (instance *gamerules.BlockInstance, ok bool) {
+    owner := chunk.chunkForBlock(blockLoc)
+    if owner == nil {
+        return nil, false
+    }
+
+    instance, _, ok = owner.blockInstanceAndType(blockLoc)
+    return
+}
+
+func (chunk *Chunk) Dimension() DimensionId {
+    return chunk.shard.dimension
+}
+
 func (chunk *Chunk) Rand() *rand.Rand {
     return chunk.rand
 }
@@ -160,28 +219,28 @@ func (chunk *Chunk) transferEntity(s gamerules.INonPlayerEntity) {
     chunk.storeDirty = true
 }
 
-// AddEntity creates a mob or item in this chunk and notifies all chunk
-// subscribers of the new entity
+// AddEntity creates a mob or item in this chunk and notifies chunk
+// subscribers in range of the new entity
 func (chunk *Chunk) AddEntity(s gamerules.INonPlayerEntity) {
     newEntityId := chunk.shard.entityMgr.NewEntity()
     s.SetEntityId(newEntityId)
     chunk.entities[newEntityId] = s
 
     // Spawn new item/mob for players.
-    data := chunk.shard.pktSerial.SerializePackets(s.SpawnPackets(nil)...)
-    chunk.reqMulticastPlayers(-1, data)
+    chunk.trackEntity(s, nil)
 
     chunk.storeDirty = true
 }
 
-func (chunk *Chunk) removeEntity(s gamerules.INonPlayerEntity) {
+// RemoveEntity removes a mob or item from this chunk and notifies the
+// players that could see it that it has gone.
+func (chunk *Chunk) RemoveEntity(s gamerules.INonPlayerEntity) {
     entityId := s.GetEntityId()
     chunk.shard.entityMgr.RemoveEntityById(entityId)
     delete(chunk.entities, entityId)
 
-    // Tell all subscribers that the spawn's entity is destroyed.
-    data := chunk.shard.pktSerial.SerializePackets(&proto.PacketEntityDestroy{EntityCount: 1, EntityId: entityId})
-    chunk.reqMulticastPlayers(-1, data)
+    // Tell players that the spawn's entity is destroyed.
+    chunk.untrackEntity(entityId)
 
     chunk.storeDirty = true
 }
@@ -261,16 +320,68 @@ func (chunk *Chunk) blockInstanceAndType(blockLoc *BlockXyz) (blockInstance *gam
     return
 }
 
-func (chunk *Chunk) reqHitBlock(player gamerules.IPlayerClient, held gamerules.Slot, digStatus DigStatus, target *BlockXyz, face Face) {
+func (chunk *Chunk) reqHitBlock(player gamerules.IPlayerClient, gameType GameType, held gamerules.Slot, digStatus DigStatus, target *BlockXyz, face Face) {
 
     blockInstance, blockType, ok := chunk.blockInstanceAndType(target)
     if !ok {
         return
     }
 
-    if blockType.Destructable && blockType.Aspect.Hit(blockInstance, player, digStatus) {
+    if !blockType.Destructable {
+        return
+    }
+
+    if gameType == GameTypeCreative {
+        // Creative players break blocks as soon as they hit them, and get
+        // nothing from them.
+        if digStatus == DigStarted {
+            blockInstance.NoDrops = true
+            blockType.Aspect.Destroy(blockInstance)
+            chunk.setBlock(target, &blockInstance.SubLoc, blockInstance.Index, BlockIdAir, 0)
+        }
+        return
+    }
+
+    entityId := player.GetEntityId()
+    destroyed := false
+
+    switch digStatus {
+    case DigStarted:
+        instant := chunk.startDig(player, &held, target, blockInstance.Index, blockType)
+        destroyed = blockType.Aspect.Hit(blockInstance, player, digStatus)
+        if !destroyed && instant {
+            // The client does not report finishing digs that are instant.
+            destroyed = blockType.Aspect.Hit(blockInstance, player, DigBlockBroke)
+        }
+    case DigCancelled:
+        chunk.stopDig(entityId)
+        return
+    case DigBlockBroke:
+        destroyed = blockType.Aspect.Hit(blockInstance, player, digStatus)
+    default:
+        // Dropping items and releasing the used item are not hits on the
+        // block.
+        return
+    }
+
+    if destroyed && !chunk.isDigComplete(entityId, target) {
+        log.Printf("%v: player %d broke block at %v too quickly", chunk, entityId, target)
+        chunk.stopDig(entityId)
+        chunk.resendBlock(player, target, blockInstance.Index)
+        return
+    }
+
+    if destroyed {
+        chunk.stopDig(entityId)
+
+        // Blocks only drop items when dug with an adequate tool.
+        blockInstance.NoDrops = !blockType.Aspect.CanHarvest(&held)
         blockType.Aspect.Destroy(blockInstance)
         chunk.setBlock(target, &blockInstance.SubLoc, blockInstance.Index, BlockIdAir, 0)
+
+        if uses := held.DigWear(blockType.Hardness); uses > 0 {
+            player.WearHeldItem(held, uses)
+        }
     }
 
     return
@@ -287,7 +398,11 @@ func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gameru
         return
     }
 
-    if _, isBlockHeld := held.ItemTypeId.ToBlockId(); isBlockHeld && blockType.Attachable {
+    if receiver, ok := blockType.Aspect.(gamerules.IBlockItemReceiver); ok && receiver.AcceptsItem(blockInstance, &held) {
+        // The player is putting an item onto the block (e.g a minecart onto
+        // rails).
+        player.PlaceHeldItem(*target, againstFace, held)
+    } else if _, isBlockHeld := held.PlacedBlockId(); isBlockHeld && blockType.Attachable {
         // The player is interacting with a block that can be attached to.
 
         // Work out the position to put the block at.
@@ -298,7 +413,7 @@ func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gameru
             return
         }
 
-        player.PlaceHeldItem(*destLoc, held)
+        player.PlaceHeldItem(*destLoc, againstFace, held)
     } else {
         // Player is otherwise interacting with the block.
         blockType.Aspect.Interact(blockInstance, player)
@@ -310,7 +425,7 @@ func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gameru
 // placeBlock attempts to place a block. This is called by PlayerBlockInteract
 // in the situation where the player interacts with an attachable block
 // (potentially in a different chunk to the one where the block gets placed).
-func (chunk *Chunk) reqPlaceItem(player gamerules.IPlayerClient, target *BlockXyz, slot *gamerules.Slot) {
+func (chunk *Chunk) reqPlaceItem(player gamerules.IPlayerClient, target *BlockXyz, face Face, slot *gamerules.Slot, look LookDegrees) {
     // TODO defer a check for remaining items in slot, and do something with them
     // (send to player or drop on the ground).
 
@@ -318,9 +433,20 @@ func (chunk *Chunk) reqPlaceItem(player gamerules.IPlayerClient, target *BlockXy
     // items on farmland doesn't fit this current simplistic model). The block
     // type for the block being placed against should probably contain this logic
     // (i.e farmland block should know about the seed item).
-    heldBlockType, ok := slot.ItemTypeId.ToBlockId()
-    if !ok || slot.Count < 1 {
-        // Not a placeable item.
+    if slot.Count < 1 {
+        return
+    }
+
+    heldBlockType, ok := slot.PlacedBlockId()
+    if !ok {
+        // Not a placeable block, but the target block might accept the item.
+        blockInstance, blockType, ok := chunk.blockInstanceAndType(target)
+        if !ok {
+            return
+        }
+        if receiver, ok := blockType.Aspect.(gamerules.IBlockItemReceiver); ok && receiver.AcceptsItem(blockInstance, slot) {
+            receiver.PlaceItem(blockInstance, slot, look)
+        }
         return
     }
 
@@ -336,37 +462,99 @@ func (chunk *Chunk) reqPlaceItem(player gamerules.IPlayerClient, target *BlockXy
         return
     }
 
+    newBlockType, ok := gamerules.Blocks.Get(heldBlockType)
+    if !ok {
+        return
+    }
+
     // Safe to replace block.
-    chunk.setBlock(target, subLoc, index, heldBlockType, byte(slot.Data))
+    if placer, ok := newBlockType.Aspect.(gamerules.IBlockPlacer); ok {
+        instance := &gamerules.BlockInstance{
+            Chunk:     chunk,
+            BlockLoc:  *target,
+            SubLoc:    *subLoc,
+            Index:     index,
+            BlockType: newBlockType,
+            Data:      byte(slot.Data),
+            Placer:    player.GetEntityId(),
+            Face:      face,
+        }
+        if !placer.Place(instance, look) {
+            return
+        }
+    } else {
+        chunk.setBlock(target, subLoc, index, heldBlockType, byte(slot.Data))
+    }
     // Allow this block to tick once
     chunk.AddActiveBlockIndex(index)
 
     slot.Decrement()
 }
 
+func (chunk *Chunk) reqSetSignText(player gamerules.IPlayerClient, target *BlockXyz, text [gamerules.SignLines]string) {
+    blockInstance, blockType, ok := chunk.blockInstanceAndType(target)
+    if !ok {
+        return
+    }
+
+    signAspect, ok := blockType.Aspect.(*gamerules.SignAspect)
+    if !ok {
+        log.Printf("%v: ignoring sign text for non-sign block at %v", chunk, target)
+        return
+    }
+
+    sign, ok := signAspect.SetText(blockInstance, player.GetEntityId(), text)
+    if !ok {
+        log.Printf("%v: rejected sign text from %v at %v", chunk, player.GetEntityId(), target)
+        return
+    }
+    chunk.storeDirty = true
+
+    // Tell all players about the new text, including the player that set it.
+    if spawner, ok := sign.(gamerules.ITileEntitySpawner); ok {
+        chunk.reqMulticastPlayers(-1, chunk.shard.pktSerial.SerializePackets(spawner.SpawnPackets(nil)...))
+    }
+}
+
+func (chunk *Chunk) reqUseAnvil(player gamerules.IPlayerClient, target *BlockXyz) {
+    blockInstance, blockType, ok := chunk.blockInstanceAndType(target)
+    if !ok {
+        return
+    }
+
+    anvilAspect, ok := blockType.Aspect.(*gamerules.AnvilAspect)
+    if !ok {
+        // The anvil has gone since the player opened its window.
+        player.InventoryUnsubscribed(*target)
+        return
+    }
+
+    if anvilAspect.Use(blockInstance) {
+        player.InventoryUnsubscribed(*target)
+    }
+}
+
 func (chunk *Chunk) reqTakeItem(player gamerules.IPlayerClient, entityId EntityId) {
     if entity, ok := chunk.entities[entityId]; ok {
         if item, ok := entity.(*gamerules.Item); ok {
             player.GiveItemAtPosition(*item.Position(), *item.GetSlot())
 
-            // Tell all subscribers to animate the item flying at the
-            // player.
+            // Tell the players that can see the item to animate it flying at
+            // the player.
             buf := new(bytes.Buffer)
             chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketItemCollect{
                 CollectedItem: entityId,
                 Collector:     player.GetEntityId(),
             })
-            chunk.reqMulticastPlayers(-1, buf.Bytes())
-            chunk.removeEntity(item)
+            chunk.shard.multicastTracking(entityId, buf.Bytes())
+            chunk.RemoveEntity(item)
         }
     }
 }
 
 func (chunk *Chunk) reqDropItem(player gamerules.IPlayerClient, content *gamerules.Slot, position AbsXyz, velocity AbsVelocity, pickupImmunity Ticks) {
-    spawnedItem := gamerules.NewItem(
-        content.ItemTypeId,
-        content.Count,
-        content.Data,
+    spawnedItem := gamerules.NewItemFromSlot(
+        *content,
         position,
         velocity,
         pickupImmunity,
@@ -393,6 +581,179 @@ func (chunk *Chunk) reqInventoryUnsubscribed(player gamerules.IPlayerClient, blo
     blockType.Aspect.InventoryUnsubscribed(blockInstance, player)
 }
 
+func (chunk *Chunk) reqUseEntity(player gamerules.IPlayerClient, held *gamerules.Slot, target EntityId, leftClick bool) {
+    entity, ok := chunk.entities[target]
+    if !ok {
+        if leftClick {
+            chunk.attackPlayer(player, held, target)
+        }
+        return
+    }
+    if !chunk.shard.isInReach(player.GetEntityId(), entity.Position()) {
+        return
+    }
+
+    if usable, ok := entity.(gamerules.IUsableEntity); ok {
+        if usable.Use(chunk, player, held, leftClick) {
+            chunk.RemoveEntity(entity)
+        }
+        chunk.storeDirty = true
+    }
+}
+
+// reqFeedEntity feeds the animal if it is in the chunk. Food that the animal
+// no longer eats (e.g it has fallen in love since it was used) is given back
+// to players that are not in creative mode.
+func (chunk *Chunk) reqFeedEntity(player gamerules.IPlayerClient, gameType GameType, food *gamerules.Slot, target EntityId) {
+    entity, ok := chunk.entities[target]
+    if !ok {
+        return
+    }
+    animal, ok := entity.(gamerules.IBreedable)
+    if !ok {
+        return
+    }
+
+    if animal.Feed(food) {
+        chunk.storeDirty = true
+    } else if gameType != GameTypeCreative {
+        player.GiveItem(*food)
+    }
+}
+
+// attackPlayer hurts the target player if they are in the chunk.
+func (chunk *Chunk) attackPlayer(player gamerules.IPlayerClient, held *gamerules.Slot, target EntityId) {
+    if target == player.GetEntityId() {
+        return
+    }
+    victimData, ok := chunk.playersData[target]
+    if !ok || !chunk.shard.isInReach(player.GetEntityId(), &victimData.position) {
+        return
+    }
+    if victim, ok := chunk.subscribers[target]; ok {
+        victim.Hurt(held.AttackDamage())
+    }
+}
+
+func (chunk *Chunk) reqEntityInventoryClick(player gamerules.IPlayerClient, target EntityId, click *gamerules.Click) {
+    if invEntity, ok := chunk.entities[target].(gamerules.IInventoryEntity); ok {
+        invEntity.InventoryClick(player, click)
+        chunk.storeDirty = true
+    }
+}
+
+func (chunk *Chunk) reqEntityInventoryUnsubscribed(player gamerules.IPlayerClient, target EntityId) {
+    if invEntity, ok := chunk.entities[target].(gamerules.IInventoryEntity); ok {
+        invEntity.InventoryUnsubscribed(player)
+    }
+}
+
+func (chunk *Chunk) reqTrade(player gamerules.IPlayerClient, merchant EntityId, index int, offer *gamerules.MerchantOffer) {
+    villager, ok := chunk.entities[merchant].(*gamerules.Villager)
+    if !ok {
+        return
+    }
+
+    // Players that have walked away from the villager cannot trade with it.
+    if !chunk.shard.isInReach(player.GetEntityId(), villager.Position()) {
+        villager.RefuseTrade(player)
+        return
+    }
+
+    villager.Trade(player, index, offer)
+    chunk.storeDirty = true
+}
+
+// reqUseItem traces a line from the player's eye in the direction that they
+// are looking, to find a block that accepts the held item (e.g water for a
+// boat). The search stops at the first solid block.
+func (chunk *Chunk) reqUseItem(player gamerules.IPlayerClient, held *gamerules.Slot, eyePosition *AbsXyz, look *LookDegrees) {
+    const step = 0.1
+
+    if held.IsSplashPotion() {
+        chunk.throwPotion(player, held, eyePosition, look)
+        return
+    }
+
+    dx, dy, dz := look.Direction()
+    position := *eyePosition
+    var lastLoc BlockXyz
+
+    for dist := AbsCoord(0); dist < MaxInteractDistance; dist += step {
+        position.X += dx * step
+        position.Y += dy * step
+        position.Z += dz * step
+
+        blockLoc := position.ToBlockXyz()
+        if blockLoc == nil {
+            return
+        }
+        if *blockLoc == lastLoc {
+            continue
+        }
+        lastLoc = *blockLoc
+
+        blockType, _, ok := chunk.BlockAt(blockLoc)
+        if !ok {
+            return
+        }
+
+        if receiver, ok := blockType.Aspect.(gamerules.IBlockItemReceiver); ok {
+            blockInstance, _, ok := chunk.chunkForBlock(blockLoc).blockInstanceAndType(blockLoc)
+            if ok && receiver.AcceptsItem(blockInstance, held) {
+                player.PlaceHeldItem(*blockLoc, FaceNull, *held)
+                return
+            }
+        }
+
+        if blockType.Solid {
+            return
+        }
+    }
+}
+
+func (chunk *Chunk) reqSteerVehicle(player gamerules.IPlayerClient, vehicle EntityId, input *AbsVelocity) {
+    if steerable, ok := chunk.entities[vehicle].(gamerules.ISteerableEntity); ok {
+        steerable.Steer(player.GetEntityId(), *input)
+    }
+}
+
+// reqPortalArrival finds (or builds) the place for a player arriving in this
+// dimension to appear at, and moves them there. In the end, this is on an
+// obsidian platform. In other dimensions it is in the nearest portal, which
+// may be in another shard.
+func (chunk *Chunk) reqPortalArrival(player gamerules.IPlayerClient, position *AbsXyz, look *LookDegrees) {
+    if chunk.Dimension() == DimensionEnd {
+        player.SetPositionLook(gamerules.BuildEndPlatform(chunk), *look)
+        return
+    }
+
+    search, ok := gamerules.NewPortalSearch(player, position, *look)
+    if !ok {
+        player.SetPositionLook(*position, *look)
+        return
+    }
+    chunk.shard.reqPortalSearch(search)
+}
+
+// reqCheckBed tells the player if the bed that they respawned at has gone.
+func (chunk *Chunk) reqCheckBed(player gamerules.IPlayerClient, bed *BlockXyz) {
+    blockType, _, ok := chunk.BlockAt(bed)
+    if !ok {
+        return
+    }
+    if _, isBed := blockType.Aspect.(*gamerules.BedAspect); !isBed {
+        player.BedMissing()
+    }
+}
+
+// SubscribedPlayer returns the player with the given entity ID if they are
+// subscribed to the chunk.
+func (chunk *Chunk) SubscribedPlayer(entityId EntityId) (player gamerules.IPlayerClient, ok bool) {
+    player, ok = chunk.subscribers[entityId]
+    return
+}
+
 // Used to read the BlockId of a block that's either in the chunk, or
 // immediately adjoining it in a neighbouring chunk. In cases where the block
 // type can't be determined we assume that the block asked about is solid
@@ -442,6 +803,9 @@ func (chunk *Chunk) BlockQuery(blockLoc BlockXyz) (isSolid bool, isWithinChunk b
 
 func (chunk *Chunk) tick() {
     chunk.spawnTick()
+    chunk.experienceOrbTick()
+    chunk.breedTick()
+    chunk.digTick()
     if chunk.tickAll {
         chunk.tickAll = false
         chunk.blockTickAll()
@@ -463,7 +827,7 @@ func (chunk *Chunk) spawnTick() {
         if e.Tick(chunk) {
             if e.Position().Y <= 0 {
                 // Item or mob fell out of the world.
-                chunk.removeEntity(e)
+                chunk.RemoveEntity(e)
             } else {
                 outgoingEntities = append(outgoingEntities, e)
             }
@@ -479,6 +843,9 @@ func (chunk *Chunk) spawnTick() {
             // Transfer to other chunk.
             chunkLoc := e.Position().ToChunkXz()
             shardLoc := chunkLoc.ToShardXz()
+            if !shardLoc.Equals(&chunk.shard.loc) {
+                chunk.shard.forgetEntity(e)
+            }
 
             // TODO Batch spawns up into a request per shard if there are efficiency
             // concerns in sending them individually.
@@ -591,6 +958,78 @@ func (chunk *Chunk) items() (s []*gamerules.Item) {
     return
 }
 
+func (chunk *Chunk) ItemsInBlock(blockLoc *BlockXyz) (s []*gamerules.Item) {
+    for _, item := range chunk.items() {
+        if itemLoc := item.Position().ToBlockXyz(); itemLoc != nil && *itemLoc == *blockLoc {
+            s = append(s, item)
+        }
+    }
+    return
+}
+
+func (chunk *Chunk) experienceOrbs() (s []*gamerules.ExperienceOrb) {
+    for _, e := range chunk.entities {
+        if orb, ok := e.(*gamerules.ExperienceOrb); ok {
+            s = append(s, orb)
+        }
+    }
+    return
+}
+
+// experienceOrbTick removes experience orbs that have expired, and merges
+// those that are close together.
+func (chunk *Chunk) experienceOrbTick() {
+    orbs := chunk.experienceOrbs()
+    for i, orb := range orbs {
+        if orb == nil {
+            continue
+        }
+        if orb.Expired() {
+            chunk.RemoveEntity(orb)
+            continue
+        }
+        for j := i + 1; j < len(orbs); j++ {
+            other := orbs[j]
+            if other != nil && !other.Expired() && orb.Position().IsWithinDistanceOf(*other.Position(), gamerules.ExperienceOrbMergeDistance) {
+                orb.Merge(other)
+                chunk.RemoveEntity(other)
+                orbs[j] = nil
+            }
+        }
+    }
+}
+
+func (chunk *Chunk) animalsInLove() (s []gamerules.IBreedable) {
+    for _, e := range chunk.entities {
+        if animal, ok := e.(gamerules.IBreedable); ok && animal.IsInLove() {
+            s = append(s, animal)
+        }
+    }
+    return
+}
+
+// breedTick mates animals in love that are close together, adding their
+// babies to the chunk.
+func (chunk *Chunk) breedTick() {
+    animals := chunk.animalsInLove()
+    for i, animal := range animals {
+        if animal == nil {
+            continue
+        }
+        for j := i + 1; j < len(animals); j++ {
+            other := animals[j]
+            if other == nil || !animal.Position().IsWithinDistanceOf(*other.Position(), gamerules.AnimalMateDistance) {
+                continue
+            }
+            if baby, ok := animal.Mate(chunk, other); ok {
+                chunk.AddEntity(baby)
+                animals[j] = nil
+                break
+            }
+        }
+    }
+}
+
 func (chunk *Chunk) reqSubscribeChunk(entityId EntityId, player gamerules.IPlayerClient, notify bool) {
     if _, ok := chunk.subscribers[entityId]; ok {
         // Already subscribed.
@@ -607,36 +1046,27 @@ func (chunk *Chunk) reqSubscribeChunk(entityId EntityId, player gamerules.IPlaye
     // })
     player.TransmitPacket(buf.Bytes())
     player.TransmitPacket(chunk.chunkPacket())
+    player.TransmitPacket(chunk.tileEntityPacket())
     if notify {
         player.NotifyChunkLoad()
     }
 
-    // Send spawn packets for all entities in the chunk to the player.
-    if len(chunk.entities) > 0 {
-        buf := new(bytes.Buffer)
-        for _, entity := range chunk.entities {
-            pkts := entity.SpawnPackets(nil)
-            chunk.shard.pktSerial.WritePacketsBuffer(buf, pkts...)
-        }
-        player.TransmitPacket(buf.Bytes())
+    // Spawn the entities and existing players in the chunk that are in range
+    // of the new player.
+    tracker := chunk.shard.tracker(entityId, player)
+    for _, entity := range chunk.entities {
+        tracker.update(chunk.shard, entity, nil)
     }
-
-    // Spawn existing players for new player.
-    if len(chunk.playersData) > 0 {
-        buf := new(bytes.Buffer)
-        for _, existing := range chunk.playersData {
-            if existing.entityId != entityId {
-                pkts := existing.SpawnPackets(nil)
-                chunk.shard.pktSerial.WritePacketsBuffer(buf, pkts...)
-            }
-        }
-        player.TransmitPacket(buf.Bytes())
+    for _, existing := range chunk.playersData {
+        tracker.update(chunk.shard, existing, nil)
     }
 }
 
 func (chunk *Chunk) reqUnsubscribeChunk(entityId EntityId, sendPacket bool) {
     if player, ok := chunk.subscribers[entityId]; ok {
         delete(chunk.subscribers, entityId)
+        chunk.untrackChunk(entityId, sendPacket)
+        chunk.stopDig(entityId)
 
         // Call any observers registered with AddOnUnsubscribe.
         if observers, ok := chunk.onUnsub[entityId]; ok {
@@ -710,20 +1140,20 @@ func (chunk *Chunk) reqAddPlayerData(entityId EntityId, name string, pos AbsXyz,
     }
     chunk.playersData[entityId] = newPlayerData
 
-    // Spawn new player for existing players.
-    buf := new(bytes.Buffer)
-    pkts := newPlayerData.SpawnPackets(nil)
-    chunk.shard.pktSerial.WritePacketsBuffer(buf, pkts...)
-    chunk.reqMulticastPlayers(entityId, buf.Bytes())
+    // Spawn new player for existing players in range.
+    chunk.trackEntity(newPlayerData, nil)
 }
 
 func (chunk *Chunk) reqRemovePlayerData(entityId EntityId, isDisconnect bool) {
+    data, ok := chunk.playersData[entityId]
     delete(chunk.playersData, entityId)
 
     if isDisconnect {
-        buf := new(bytes.Buffer)
-        chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketEntityDestroy{EntityCount: 1, EntityId: entityId})
-        chunk.reqMulticastPlayers(entityId, buf.Bytes())
+        chunk.untrackEntity(entityId)
+    } else if ok && !chunk.shard.isPlayerInShard(entityId) {
+        // The player has moved to another shard, which takes over telling
+        // other players about them.
+        chunk.shard.forgetEntity(data)
     }
 }
 
@@ -741,9 +1171,7 @@ func (chunk *Chunk) reqSetPlayerPosition(entityId EntityId, pos AbsXyz) {
     data.position = pos
 
     // Update subscribers.
-    buf := new(bytes.Buffer)
-    chunk.shard.pktSerial.WritePacketsBuffer(buf, data.UpdatePackets(nil)...)
-    chunk.reqMulticastPlayers(entityId, buf.Bytes())
+    chunk.trackEntity(data, data.UpdatePackets(nil))
 
     player, ok := chunk.subscribers[entityId]
 
@@ -760,6 +1188,31 @@ func (chunk *Chunk) reqSetPlayerPosition(entityId EntityId, pos AbsXyz) {
                 player.OfferItem(chunk.loc, item.EntityId, *slot)
             }
         }
+
+        // Is the player close enough to any experience orbs to collect them?
+        for _, orb := range chunk.experienceOrbs() {
+            if data.NearExperienceOrb(orb) {
+                player.GiveExperience(orb.Value)
+
+                buf := new(bytes.Buffer)
+                chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketItemCollect{
+                    CollectedItem: orb.EntityId,
+                    Collector:     entityId,
+                })
+                chunk.shard.multicastTracking(orb.EntityId, buf.Bytes())
+                chunk.RemoveEntity(orb)
+            }
+        }
+
+        // Is the player inside a block that reacts to them (e.g a portal)?
+        if blockLoc := pos.ToBlockXyz(); blockLoc != nil && chunk.isSameChunk(blockLoc.ToChunkXz()) {
+            blockInstance, blockType, ok := chunk.blockInstanceAndType(blockLoc)
+            if ok {
+                if enterable, ok := blockType.Aspect.(gamerules.IBlockEnterable); ok {
+                    enterable.PlayerInside(blockInstance, player)
+                }
+            }
+        }
     }
 }
 
@@ -777,10 +1230,7 @@ func (chunk *Chunk) reqSetPlayerLook(entityId EntityId, look LookBytes) {
     data.look = look
 
     // Update subscribers.
-    buf := new(bytes.Buffer)
-    pkts := data.UpdatePackets(nil)
-    chunk.shard.pktSerial.WritePacketsBuffer(buf, pkts...)
-    chunk.reqMulticastPlayers(entityId, buf.Bytes())
+    chunk.trackEntity(data, data.UpdatePackets(nil))
 }
 
 func (chunk *Chunk) chunkPacket() []byte {
@@ -802,13 +1252,22 @@ func (chunk *Chunk) chunkPacket() []byte {
     return chunk.cachedPacket
 }
 
+// tileEntityPacket returns the packets that tell a client about the tile
+// entities in the chunk that it needs to know about (e.g sign text).
+func (chunk *Chunk) tileEntityPacket() []byte {
+    var pkts []proto.IPacket
+    for _, tileEntity := range chunk.tileEntities {
+        if spawner, ok := tileEntity.(gamerules.ITileEntitySpawner); ok {
+            pkts = spawner.SpawnPackets(pkts)
+        }
+    }
+    return chunk.shard.pktSerial.SerializePackets(pkts...)
+}
+
 func (chunk *Chunk) sendUpdate() {
-    buf := new(bytes.Buffer)
     for _, entity := range chunk.entities {
-        pkts := entity.UpdatePackets(nil)
-        chunk.shard.pktSerial.WritePacketsBuffer(buf, pkts...)
+        chunk.trackEntity(entity, entity.UpdatePackets(nil))
     }
-    chunk.reqMulticastPlayers(-1, buf.Bytes())
 }
 
 func (chunk *Chunk) isSameChunk(otherChunkLoc *ChunkXz) bool {
@@ -0,0 +1,124 @@
+package shardserver
+
+import (
+    "math/rand"
+    "testing"
+
+    "chunkymonkey/entity"
+    "chunkymonkey/gamerules"
+    . "chunkymonkey/types"
+)
+
+// newTestChunk returns a chunk of air with the given block at blockLoc.
+func newTestChunk(t *testing.T, blockLoc *BlockXyz, blockId BlockId) (*Chunk, BlockIndex) {
+    shard := newTestShard()
+    shard.entityMgr = new(entity.EntityManager)
+    shard.entityMgr.Init()
+
+    chunk := &Chunk{
+        shard:        shard,
+        blocks:       make([]byte, ChunkSizeH*ChunkSizeH*ChunkSizeY),
+        blockData:    make([]byte, ChunkSizeH*ChunkSizeH*ChunkSizeY/2),
+        entities:     make(map[EntityId]gamerules.INonPlayerEntity),
+        tileEntities: make(map[BlockIndex]gamerules.ITileEntity),
+        rand:         rand.New(rand.NewSource(0)),
+        subscribers:  make(map[EntityId]gamerules.IPlayerClient),
+        playersData:  make(map[EntityId]*playerData),
+        digs:         make(map[EntityId]*blockDig),
+    }
+    index, _, ok := chunk.getBlockIndexByBlockXyz(blockLoc)
+    if !ok {
+        t.Fatalf("block %v is not in the test chunk", blockLoc)
+    }
+    index.SetBlockId(chunk.blocks, blockId)
+    return chunk, index
+}
+
+func TestChunk_attackPlayer(t *testing.T) {
+    chunk, _ := newTestChunk(t, &BlockXyz{1, 64, 1}, BlockIdAir)
+    _, attacker := newTestTracker(t, chunk.shard, 1, AbsXyz{0, 64, 0})
+
+    tests := []struct {
+        desc     string
+        target   EntityId
+        position AbsXyz
+        hurt     bool
+    }{
+        {"in reach", 2, AbsXyz{3, 64, 0}, true},
+        {"out of reach", 2, AbsXyz{0, 64, 7}, false},
+        {"self", 1, AbsXyz{0, 64, 0}, false},
+    }
+
+    for _, test := range tests {
+        victim := &testPlayer{t: t, entityId: test.target}
+        chunk.subscribers[test.target] = victim
+        chunk.playersData[test.target] = &playerData{entityId: test.target, position: test.position}
+
+        chunk.attackPlayer(attacker, &gamerules.Slot{}, test.target)
+        if hurt := victim.hurt > 0; hurt != test.hurt {
+            t.Errorf("%s: expected player hurt %t, got damage %d", test.desc, test.hurt, victim.hurt)
+        }
+    }
+}
+
+func TestChunk_reqTrade(t *testing.T) {
+    chunk, _ := newTestChunk(t, &BlockXyz{1, 64, 1}, BlockIdAir)
+    villager := gamerules.NewVillager().(*gamerules.Villager)
+    *villager.Position() = AbsXyz{2, 64, 0}
+    chunk.AddEntity(villager)
+    merchant := villager.GetEntityId()
+
+    tests := []struct {
+        desc     string
+        position AbsXyz
+        accepted bool
+    }{
+        {"in reach", AbsXyz{0, 64, 0}, true},
+        {"out of reach", AbsXyz{10, 64, 0}, false},
+    }
+
+    for _, test := range tests {
+        tracker, player := newTestTracker(t, chunk.shard, 1, test.position)
+        villager.Use(chunk, player, &gamerules.Slot{}, false)
+        if len(player.offers) == 0 {
+            t.Fatalf("%s: expected the villager to offer trades", test.desc)
+        }
+
+        player.accepted = !test.accepted
+        chunk.reqTrade(player, merchant, 0, &player.offers[0])
+        if player.accepted != test.accepted {
+            t.Errorf("%s: expected trade accepted %t", test.desc, test.accepted)
+        }
+        delete(chunk.shard.trackers, tracker.entityId)
+    }
+}
+
+func TestChunk_reqFeedEntity(t *testing.T) {
+    chunk, _ := newTestChunk(t, &BlockXyz{1, 64, 1}, BlockIdAir)
+    pig := gamerules.NewPig().(*gamerules.Pig)
+    chunk.AddEntity(pig)
+    carrot := gamerules.Slot{ItemTypeId: gamerules.PigType.BreedingFood, Count: 1}
+
+    tests := []struct {
+        desc     string
+        gameType GameType
+        inLove   bool
+        given    int
+    }{
+        {"fed", GameTypeSurvival, true, 0},
+        // The pig is already in love, so does not eat the carrot.
+        {"not eaten", GameTypeSurvival, true, 1},
+        {"not eaten by creative player", GameTypeCreative, true, 0},
+    }
+
+    for _, test := range tests {
+        _, player := newTestTracker(t, chunk.shard, 1, AbsXyz{0, 64, 0})
+        chunk.reqFeedEntity(player, test.gameType, &carrot, pig.GetEntityId())
+        if pig.IsInLove() != test.inLove {
+            t.Errorf("%s: expected pig in love %t", test.desc, test.inLove)
+        }
+        if len(player.given) != test.given {
+            t.Errorf("%s: expected %d items given back, got %v", test.desc, test.given, player.given)
+        }
+    }
+}
@@ -0,0 +1,131 @@
+package shardserver
+
+import (
+    "bytes"
+
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+const (
+    // Fraction of a block's dig time that a player must have spent digging it
+    // before the break is accepted. This allows for latency between the
+    // client and server.
+    digTimeAllowance = 0.7
+
+    // Digs that have not finished this many ticks after their dig time are
+    // abandoned.
+    digExpiryTicks = 5 * TicksPerSecond
+
+    // Number of break animation stages, and the stage that removes the
+    // animation.
+    digStages    = 10
+    digStageNone = digStages
+)
+
+// blockDig tracks a player digging a block in the chunk.
+type blockDig struct {
+    blockLoc BlockXyz
+    index    BlockIndex
+    blockId  BlockId
+    digTicks Ticks
+    elapsed  Ticks
+    stage    byte
+}
+
+func (dig *blockDig) isComplete() bool {
+    return float64(dig.elapsed+1) >= digTimeAllowance*float64(dig.digTicks)
+}
+
+// startDig records that the player has started digging a block. instant=true
+// if the block is dug as soon as the player starts.
+func (chunk *Chunk) startDig(player gamerules.IPlayerClient, held *gamerules.Slot, blockLoc *BlockXyz, index BlockIndex, blockType *gamerules.BlockType) (instant bool) {
+    entityId := player.GetEntityId()
+    chunk.stopDig(entityId)
+
+    digTicks, ok := blockType.Aspect.DigTicks(held)
+    if !ok {
+        // The block cannot be dug at all.
+        return false
+    }
+
+    chunk.digs[entityId] = &blockDig{
+        blockLoc: *blockLoc,
+        index:    index,
+        blockId:  index.BlockId(chunk.blocks),
+        digTicks: digTicks,
+        stage:    digStageNone,
+    }
+
+    return digTicks <= 1
+}
+
+// stopDig forgets any block that the player is digging in the chunk.
+func (chunk *Chunk) stopDig(entityId EntityId) {
+    if dig, ok := chunk.digs[entityId]; ok {
+        delete(chunk.digs, entityId)
+        if dig.stage != digStageNone {
+            chunk.sendDigStage(entityId, dig, digStageNone)
+        }
+    }
+}
+
+// isDigComplete returns true if the player has been digging the block for
+// long enough to break it.
+func (chunk *Chunk) isDigComplete(entityId EntityId, blockLoc *BlockXyz) bool {
+    dig, ok := chunk.digs[entityId]
+    return ok && dig.blockLoc == *blockLoc && dig.isComplete()
+}
+
+// digTick advances the digs in progress in the chunk, and shows their
+// progress to other players.
+func (chunk *Chunk) digTick() {
+    for entityId, dig := range chunk.digs {
+        dig.elapsed++
+
+        if dig.index.BlockId(chunk.blocks) != dig.blockId || dig.elapsed > dig.digTicks+digExpiryTicks {
+            chunk.stopDig(entityId)
+            continue
+        }
+
+        if dig.digTicks == 0 {
+            continue
+        }
+        stage := byte(dig.elapsed * digStages / dig.digTicks)
+        if stage >= digStages {
+            stage = digStages - 1
+        }
+        if stage != dig.stage {
+            chunk.sendDigStage(entityId, dig, stage)
+        }
+    }
+}
+
+// sendDigStage shows the progress of a dig to the players that can see the
+// player digging.
+func (chunk *Chunk) sendDigStage(entityId EntityId, dig *blockDig, stage byte) {
+    dig.stage = stage
+
+    buf := new(bytes.Buffer)
+    chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketBlockBreakAnimation{
+        EntityId: entityId,
+        X:        int32(dig.blockLoc.X),
+        Y:        int32(dig.blockLoc.Y),
+        Z:        int32(dig.blockLoc.Z),
+        Stage:    stage,
+    })
+    chunk.shard.multicastTracking(entityId, buf.Bytes())
+}
+
+// resendBlock tells the player what the block at the given location really is,
+// undoing a change that the player's client made on its own.
+func (chunk *Chunk) resendBlock(player gamerules.IPlayerClient, blockLoc *BlockXyz, index BlockIndex) {
+    buf := new(bytes.Buffer)
+    chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketBlockChange{
+        Block:     *blockLoc,
+        TypeId:    int16(index.BlockId(chunk.blocks)),
+        BlockData: index.BlockData(chunk.blockData),
+    })
+    player.TransmitPacket(buf.Bytes())
+}
@@ -0,0 +1,123 @@
+package shardserver
+
+import (
+    "strings"
+    "testing"
+
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+// digBlocks defines stone that takes 30 ticks to dig by hand, and always drops
+// cobblestone. Block 2 is like stone, but would break when items are dropped.
+const digBlocks = `{
+  "0": {"Aspect": "Void", "AspectArgs": {}, "Name": "air", "Destructable": true, "Replaceable": true},
+  "1": {"Aspect": "Standard", "AspectArgs": {"BreakOn": 2, "DroppedItems": [{"DroppedItem": 4, "Probability": 100, "Count": 1}]}, "Name": "stone", "Hardness": 1, "Destructable": true, "Solid": true},
+  "2": {"Aspect": "Standard", "AspectArgs": {"BreakOn": 4}, "Name": "dropstone", "Hardness": 1, "Destructable": true, "Solid": true}
+}`
+
+func TestChunk_reqHitBlock(t *testing.T) {
+    defer func(blocks gamerules.BlockTypeList) { gamerules.Blocks = blocks }(gamerules.Blocks)
+    var err error
+    if gamerules.Blocks, err = gamerules.LoadBlockDefs(strings.NewReader(digBlocks)); err != nil {
+        t.Fatalf("failed to load block types: %v", err)
+    }
+
+    type hit struct {
+        // ticks is how many ticks pass before the hit.
+        ticks  int
+        status DigStatus
+    }
+
+    tests := []struct {
+        desc     string
+        gameType GameType
+        blockId  BlockId
+        hits     []hit
+        expected BlockId
+        resent   bool
+        drops    int
+    }{
+        {
+            "dug for long enough", GameTypeSurvival, 1,
+            []hit{{0, DigStarted}, {20, DigBlockBroke}},
+            BlockIdAir, false, 1,
+        },
+        {
+            "dug too quickly", GameTypeSurvival, 1,
+            []hit{{0, DigStarted}, {5, DigBlockBroke}},
+            1, true, 0,
+        },
+        {
+            "broken without digging", GameTypeSurvival, 1,
+            []hit{{20, DigBlockBroke}},
+            1, true, 0,
+        },
+        {
+            "dig cancelled", GameTypeSurvival, 1,
+            []hit{{0, DigStarted}, {10, DigCancelled}, {10, DigBlockBroke}},
+            1, true, 0,
+        },
+        {
+            "dig expired", GameTypeSurvival, 1,
+            []hit{{0, DigStarted}, {30 + int(digExpiryTicks) + 1, DigBlockBroke}},
+            1, true, 0,
+        },
+        {
+            "items dropped and released", GameTypeSurvival, 2,
+            []hit{{0, DigStarted}, {20, DigDropItem}, {0, DigDropItemStack}, {0, DigReleaseUseItem}},
+            2, false, 0,
+        },
+        // Creative players break blocks instantly, without drops.
+        {
+            "creative dig", GameTypeCreative, 1,
+            []hit{{0, DigStarted}},
+            BlockIdAir, false, 0,
+        },
+        {
+            "creative break without starting", GameTypeCreative, 1,
+            []hit{{0, DigBlockBroke}},
+            1, false, 0,
+        },
+    }
+
+    blockLoc := BlockXyz{1, 64, 1}
+    for _, test := range tests {
+        chunk, index := newTestChunk(t, &blockLoc, test.blockId)
+        player := &testPlayer{t: t, entityId: 1}
+
+        for _, hit := range test.hits {
+            for i := 0; i < hit.ticks; i++ {
+                chunk.digTick()
+            }
+            chunk.reqHitBlock(player, test.gameType, gamerules.Slot{}, hit.status, &blockLoc, FaceTop)
+        }
+
+        if blockId := index.BlockId(chunk.blocks); blockId != test.expected {
+            t.Errorf("%s: expected block %d, got %d", test.desc, test.expected, blockId)
+        }
+        if len(chunk.entities) != test.drops {
+            t.Errorf("%s: expected %d items dropped, got %d", test.desc, test.drops, len(chunk.entities))
+        }
+
+        pkts := player.takePackets()
+        if !test.resent {
+            if len(pkts) != 0 {
+                t.Errorf("%s: expected no packets, got %v", test.desc, pkts)
+            }
+            continue
+        }
+        if len(pkts) != 1 {
+            t.Errorf("%s: expected block to be resent, got %v", test.desc, pkts)
+            continue
+        }
+        change, ok := pkts[0].(*proto.PacketBlockChange)
+        if !ok || change.Block != blockLoc || BlockId(change.TypeId) != test.blockId {
+            t.Errorf("%s: expected block to be resent at %v, got %v", test.desc, blockLoc, pkts[0])
+        }
+        if _, digging := chunk.digs[player.entityId]; digging {
+            t.Errorf("%s: expected dig to be forgotten", test.desc)
+        }
+    }
+}
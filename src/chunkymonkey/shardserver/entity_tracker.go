@@ -0,0 +1,189 @@
+package shardserver
+
+import (
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+// entityTracker keeps track of which of a shard's entities a player has been
+// told about. A player is only sent packets about entities that are within the
+// entity's radius of awareness of them. A shard has an entityTracker for each
+// player connected to it, which is only accessed from the shard's goroutine.
+type entityTracker struct {
+    entityId      EntityId
+    player        gamerules.IPlayerClient
+    position      AbsXyz
+    positionKnown bool
+    moved         bool // Position has changed since visibility was updated.
+    visible       map[EntityId]bool
+}
+
+func newEntityTracker(entityId EntityId, player gamerules.IPlayerClient) *entityTracker {
+    return &entityTracker{
+        entityId: entityId,
+        player:   player,
+        visible:  make(map[EntityId]bool),
+    }
+}
+
+func (tracker *entityTracker) setPosition(position AbsXyz) {
+    tracker.position = position
+    tracker.positionKnown = true
+    tracker.moved = true
+}
+
+// inRange returns true if the entity is close enough to the player for them to
+// be told about it.
+func (tracker *entityTracker) inRange(entity gamerules.IEntity) bool {
+    if !tracker.positionKnown || entity.GetEntityId() == tracker.entityId {
+        return false
+    }
+    return tracker.position.IsWithinDistanceOf(*entity.Position(), entity.AwarenessRadius())
+}
+
+// update spawns or destroys the entity for the player if it has come into or
+// gone out of range. If the entity remains visible, updateData (if any) is
+// sent to the player.
+func (tracker *entityTracker) update(shard *ChunkShard, entity gamerules.IEntity, updateData []byte) {
+    entityId := entity.GetEntityId()
+    visible, inRange := tracker.visible[entityId], tracker.inRange(entity)
+
+    switch {
+    case inRange && !visible:
+        tracker.visible[entityId] = true
+        tracker.player.TransmitPacket(shard.pktSerial.SerializePackets(entity.SpawnPackets(nil)...))
+    case !inRange && visible:
+        tracker.hide(shard, entityId, true)
+    case inRange && len(updateData) > 0:
+        tracker.player.TransmitPacket(updateData)
+    }
+}
+
+// hide forgets that the player can see the entity, optionally telling them
+// that it has gone.
+func (tracker *entityTracker) hide(shard *ChunkShard, entityId EntityId, sendPacket bool) {
+    if !tracker.visible[entityId] {
+        return
+    }
+    delete(tracker.visible, entityId)
+    if sendPacket {
+        tracker.player.TransmitPacket(shard.pktSerial.SerializePackets(
+            &proto.PacketEntityDestroy{EntityCount: 1, EntityId: entityId}))
+    }
+}
+
+// tracker returns the entityTracker for the given player, creating it if
+// necessary.
+func (shard *ChunkShard) tracker(entityId EntityId, player gamerules.IPlayerClient) *entityTracker {
+    tracker, ok := shard.trackers[entityId]
+    if !ok {
+        tracker = newEntityTracker(entityId, player)
+        shard.trackers[entityId] = tracker
+    }
+    return tracker
+}
+
+// updateMovedTrackers updates which entities are visible to players that have
+// moved since the last tick.
+func (shard *ChunkShard) updateMovedTrackers() {
+    for _, tracker := range shard.trackers {
+        if !tracker.moved {
+            continue
+        }
+        tracker.moved = false
+
+        for _, chunk := range shard.chunks {
+            if chunk == nil {
+                continue
+            }
+            if _, subscribed := chunk.subscribers[tracker.entityId]; !subscribed {
+                continue
+            }
+            for _, entity := range chunk.entities {
+                tracker.update(shard, entity, nil)
+            }
+            for _, data := range chunk.playersData {
+                tracker.update(shard, data, nil)
+            }
+        }
+    }
+}
+
+// forgetEntity removes an entity that has left the shard from all trackers.
+// Players that it has gone out of range of are told that it has gone. Those
+// still in range are told about it by the shard that it has moved to.
+func (shard *ChunkShard) forgetEntity(entity gamerules.IEntity) {
+    entityId := entity.GetEntityId()
+    for _, tracker := range shard.trackers {
+        tracker.hide(shard, entityId, !tracker.inRange(entity))
+    }
+}
+
+// multicastTracking sends the packet to the players that can see the entity.
+func (shard *ChunkShard) multicastTracking(entityId EntityId, packet []byte) {
+    for _, tracker := range shard.trackers {
+        if tracker.visible[entityId] {
+            tracker.player.TransmitPacket(packet)
+        }
+    }
+}
+
+// isInReach returns true if the player is close enough to the position to
+// interact with things there.
+func (shard *ChunkShard) isInReach(entityId EntityId, position *AbsXyz) bool {
+    tracker, ok := shard.trackers[entityId]
+    if !ok || !tracker.positionKnown {
+        return false
+    }
+    return tracker.position.IsWithinDistanceOf(*position, MaxInteractDistance)
+}
+
+// isPlayerInShard returns true if the player is in one of the shard's chunks.
+func (shard *ChunkShard) isPlayerInShard(entityId EntityId) bool {
+    for _, chunk := range shard.chunks {
+        if chunk != nil {
+            if _, ok := chunk.playersData[entityId]; ok {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// trackEntity tells the chunk's subscribers about an entity that has come into
+// or gone out of their range. Subscribers that can still see it are sent the
+// updatePackets.
+func (chunk *Chunk) trackEntity(entity gamerules.IEntity, updatePkts []proto.IPacket) {
+    var updateData []byte
+    if len(updatePkts) > 0 {
+        updateData = chunk.shard.pktSerial.SerializePackets(updatePkts...)
+    }
+
+    for subscriberId, player := range chunk.subscribers {
+        chunk.shard.tracker(subscriberId, player).update(chunk.shard, entity, updateData)
+    }
+}
+
+// untrackEntity tells players that could see an entity that it has been
+// destroyed.
+func (chunk *Chunk) untrackEntity(entityId EntityId) {
+    for _, tracker := range chunk.shard.trackers {
+        tracker.hide(chunk.shard, entityId, true)
+    }
+}
+
+// untrackChunk forgets that a player can see the entities in the chunk, when
+// they unsubscribe from it.
+func (chunk *Chunk) untrackChunk(entityId EntityId, sendPacket bool) {
+    tracker, ok := chunk.shard.trackers[entityId]
+    if !ok {
+        return
+    }
+    for id := range chunk.entities {
+        tracker.hide(chunk.shard, id, sendPacket)
+    }
+    for id := range chunk.playersData {
+        tracker.hide(chunk.shard, id, sendPacket)
+    }
+}
@@ -0,0 +1,214 @@
+package shardserver
+
+import (
+    "bytes"
+    "io"
+    "testing"
+
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+// testPlayer records the packets that it is sent, the damage done to it, the
+// trades offered to it, and the items given to it.
+type testPlayer struct {
+    gamerules.IPlayerClient
+    t        *testing.T
+    entityId EntityId
+    pkts     []proto.IPacket
+    hurt     Health
+    offers   []gamerules.MerchantOffer
+    accepted bool
+    given    []gamerules.Slot
+}
+
+func (player *testPlayer) GetEntityId() EntityId {
+    return player.entityId
+}
+
+func (player *testPlayer) Hurt(amount Health) {
+    player.hurt += amount
+}
+
+func (player *testPlayer) GiveItem(item gamerules.Slot) {
+    player.given = append(player.given, item)
+}
+
+func (player *testPlayer) OpenMerchant(merchant EntityId, position AbsXyz, offers []gamerules.MerchantOffer) {
+    player.offers = offers
+}
+
+func (player *testPlayer) TradeResult(merchant EntityId, accepted bool, offers []gamerules.MerchantOffer) {
+    player.accepted, player.offers = accepted, offers
+}
+
+func (player *testPlayer) TransmitPacket(packet []byte) {
+    var ps proto.PacketSerializer
+    reader := bytes.NewReader(packet)
+    for {
+        pkt, err := ps.ReadPacket(reader, false)
+        if err == io.EOF {
+            return
+        } else if err != nil {
+            player.t.Fatalf("error reading packet sent to player: %v", err)
+        }
+        player.pkts = append(player.pkts, pkt)
+    }
+}
+
+// takePackets returns the packets sent to the player since the last call.
+func (player *testPlayer) takePackets() (pkts []proto.IPacket) {
+    pkts, player.pkts = player.pkts, nil
+    return
+}
+
+// testEntity is an entity that players are told about within 10 blocks.
+type testEntity struct {
+    entityId EntityId
+    position AbsXyz
+}
+
+func (entity *testEntity) GetEntityId() EntityId {
+    return entity.entityId
+}
+
+func (entity *testEntity) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
+    return append(pkts, &proto.PacketEntity{entity.entityId})
+}
+
+func (entity *testEntity) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
+    return pkts
+}
+
+func (entity *testEntity) Position() *AbsXyz {
+    return &entity.position
+}
+
+func (entity *testEntity) AwarenessRadius() AbsCoord {
+    return 10
+}
+
+func newTestShard() *ChunkShard {
+    return &ChunkShard{trackers: make(map[EntityId]*entityTracker)}
+}
+
+// newTestTracker adds a tracker at the given position for a new testPlayer.
+func newTestTracker(t *testing.T, shard *ChunkShard, entityId EntityId, position AbsXyz) (*entityTracker, *testPlayer) {
+    player := &testPlayer{t: t, entityId: entityId}
+    tracker := shard.tracker(entityId, player)
+    tracker.setPosition(position)
+    return tracker, player
+}
+
+func checkPackets(t *testing.T, desc string, expected []proto.IPacket, player *testPlayer) {
+    pkts := player.takePackets()
+    if len(pkts) != len(expected) {
+        t.Errorf("%s: expected packets %v, got %v", desc, expected, pkts)
+        return
+    }
+    for i := range expected {
+        if *(pkts[i].(*proto.PacketEntity)) != *(expected[i].(*proto.PacketEntity)) {
+            t.Errorf("%s: expected packet %d to be %v, got %v", desc, i, expected[i], pkts[i])
+        }
+    }
+}
+
+func TestEntityTracker_update(t *testing.T) {
+    shard := newTestShard()
+    tracker, player := newTestTracker(t, shard, 1, AbsXyz{0, 64, 0})
+    entity := &testEntity{2, AbsXyz{100, 64, 0}}
+    update := shard.pktSerial.SerializePackets(&proto.PacketEntity{3})
+
+    tracker.update(shard, entity, update)
+    if len(player.takePackets()) != 0 || tracker.visible[2] {
+        t.Errorf("expected entity out of range to be unseen")
+    }
+
+    // Entering range.
+    entity.position.X = 5
+    tracker.update(shard, entity, update)
+    checkPackets(t, "entering range", []proto.IPacket{&proto.PacketEntity{2}}, player)
+    if !tracker.visible[2] {
+        t.Errorf("expected entity in range to be seen")
+    }
+
+    tracker.update(shard, entity, update)
+    checkPackets(t, "in range", []proto.IPacket{&proto.PacketEntity{3}}, player)
+
+    // Leaving range.
+    entity.position.X = 11
+    tracker.update(shard, entity, update)
+    pkts := player.takePackets()
+    if len(pkts) != 1 {
+        t.Fatalf("leaving range: expected one packet, got %v", pkts)
+    }
+    if destroy, ok := pkts[0].(*proto.PacketEntityDestroy); !ok || destroy.EntityId != 2 {
+        t.Errorf("leaving range: expected entity to be destroyed, got %v", pkts[0])
+    }
+    if tracker.visible[2] {
+        t.Errorf("expected entity out of range to be unseen")
+    }
+
+    // Players are not told about themselves.
+    tracker.update(shard, &testEntity{1, AbsXyz{0, 64, 0}}, nil)
+    if len(player.takePackets()) != 0 || tracker.visible[1] {
+        t.Errorf("expected player not to see themselves")
+    }
+}
+
+func TestChunkShard_forgetEntity(t *testing.T) {
+    shard := newTestShard()
+    entity := &testEntity{3, AbsXyz{0, 64, 0}}
+    near, nearPlayer := newTestTracker(t, shard, 1, AbsXyz{5, 64, 0})
+    far, farPlayer := newTestTracker(t, shard, 2, AbsXyz{20, 64, 0})
+    near.visible[3], far.visible[3] = true, true
+
+    shard.forgetEntity(entity)
+    if near.visible[3] || far.visible[3] {
+        t.Errorf("expected entity to be forgotten")
+    }
+    if pkts := nearPlayer.takePackets(); len(pkts) != 0 {
+        t.Errorf("expected player in range to be left for the new shard, got %v", pkts)
+    }
+    pkts := farPlayer.takePackets()
+    if len(pkts) != 1 {
+        t.Fatalf("expected player out of range to be sent one packet, got %v", pkts)
+    }
+    if destroy, ok := pkts[0].(*proto.PacketEntityDestroy); !ok || destroy.EntityId != 3 {
+        t.Errorf("expected entity to be destroyed for player out of range, got %v", pkts[0])
+    }
+}
+
+func TestChunkShard_multicastTracking(t *testing.T) {
+    shard := newTestShard()
+    seeing, seeingPlayer := newTestTracker(t, shard, 1, AbsXyz{0, 64, 0})
+    _, unseeingPlayer := newTestTracker(t, shard, 2, AbsXyz{0, 64, 0})
+    seeing.visible[3] = true
+
+    shard.multicastTracking(3, shard.pktSerial.SerializePackets(&proto.PacketEntity{3}))
+    checkPackets(t, "seeing", []proto.IPacket{&proto.PacketEntity{3}}, seeingPlayer)
+    checkPackets(t, "not seeing", nil, unseeingPlayer)
+}
+
+func TestChunkShard_isInReach(t *testing.T) {
+    shard := newTestShard()
+    newTestTracker(t, shard, 1, AbsXyz{0, 64, 0})
+
+    tests := []struct {
+        entityId EntityId
+        position AbsXyz
+        expected bool
+    }{
+        {1, AbsXyz{3, 65, 0}, true},
+        {1, AbsXyz{0, 64, 7}, false},
+        // Players that the shard does not know the position of.
+        {2, AbsXyz{0, 64, 0}, false},
+    }
+
+    for _, test := range tests {
+        if inReach := shard.isInReach(test.entityId, &test.position); inReach != test.expected {
+            t.Errorf("player %d at %v: expected isInReach %t, got %t", test.entityId, test.position, test.expected, inReach)
+        }
+    }
+}
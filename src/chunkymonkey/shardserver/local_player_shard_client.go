@@ -10,6 +10,9 @@ type localPlayerShardClient struct {
     entityId EntityId
     player   gamerules.IPlayerClient
     shard    *ChunkShard
+
+    // gameType must only be accessed from within the shard's goroutine.
+    gameType GameType
 }
 
 func newLocalPlayerShardClient(entityId EntityId, player gamerules.IPlayerClient, shard *ChunkShard) *localPlayerShardClient {
@@ -26,6 +29,9 @@ func (conn *localPlayerShardClient) Disconnect() {
     conn.shard.enqueueAllChunks(func(chunk *Chunk) {
         chunk.reqUnsubscribeChunk(conn.entityId, false)
     })
+    conn.shard.enqueue(func() {
+        delete(conn.shard.trackers, conn.entityId)
+    })
 }
 
 func (conn *localPlayerShardClient) ReqSubscribeChunk(chunkLoc ChunkXz, notify bool) {
@@ -46,6 +52,12 @@ func (conn *localPlayerShardClient) ReqMulticastPlayers(chunkLoc ChunkXz, exclud
     })
 }
 
+func (conn *localPlayerShardClient) ReqMulticastTracking(packet []byte) {
+    conn.shard.enqueue(func() {
+        conn.shard.multicastTracking(conn.entityId, packet)
+    })
+}
+
 func (conn *localPlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, held ItemTypeId) {
     conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
         chunk.reqAddPlayerData(conn.entityId, name, position, look, held)
@@ -70,11 +82,17 @@ func (conn *localPlayerShardClient) ReqSetPlayerLook(chunkLoc ChunkXz, look Look
     })
 }
 
+func (conn *localPlayerShardClient) ReqSetTrackingPosition(position AbsXyz) {
+    conn.shard.enqueue(func() {
+        conn.shard.tracker(conn.entityId, conn.player).setPosition(position)
+    })
+}
+
 func (conn *localPlayerShardClient) ReqHitBlock(held gamerules.Slot, target BlockXyz, digStatus DigStatus, face Face) {
     chunkLoc := target.ToChunkXz()
 
     conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
-        chunk.reqHitBlock(conn.player, held, digStatus, &target, face)
+        chunk.reqHitBlock(conn.player, conn.gameType, held, digStatus, &target, face)
     })
 }
 
@@ -86,11 +104,11 @@ func (conn *localPlayerShardClient) ReqInteractBlock(held gamerules.Slot, target
     })
 }
 
-func (conn *localPlayerShardClient) ReqPlaceItem(target BlockXyz, slot gamerules.Slot) {
+func (conn *localPlayerShardClient) ReqPlaceItem(target BlockXyz, face Face, slot gamerules.Slot, look LookDegrees) {
     chunkLoc, _ := target.ToChunkLocal()
 
     conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
-        chunk.reqPlaceItem(conn.player, &target, &slot)
+        chunk.reqPlaceItem(conn.player, &target, face, &slot, look)
     })
 }
 
@@ -107,6 +125,13 @@ func (conn *localPlayerShardClient) ReqDropItem(content gamerules.Slot, position
     })
 }
 
+func (conn *localPlayerShardClient) ReqDropExperience(amount Experience, position AbsXyz) {
+    chunkLoc := position.ToChunkXz()
+    conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+        gamerules.SpawnExperience(chunk, amount, position)
+    })
+}
+
 func (conn *localPlayerShardClient) ReqInventoryClick(block BlockXyz, click gamerules.Click) {
     chunkLoc := block.ToChunkXz()
     conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
@@ -120,3 +145,87 @@ func (conn *localPlayerShardClient) ReqInventoryUnsubscribed(block BlockXyz) {
         chunk.reqInventoryUnsubscribed(conn.player, &block)
     })
 }
+
+func (conn *localPlayerShardClient) ReqUseEntity(held gamerules.Slot, target EntityId, leftClick bool) {
+    conn.shard.enqueueAllChunks(func(chunk *Chunk) {
+        chunk.reqUseEntity(conn.player, &held, target, leftClick)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqFeedEntity(food gamerules.Slot, target EntityId) {
+    conn.shard.enqueueAllChunks(func(chunk *Chunk) {
+        chunk.reqFeedEntity(conn.player, conn.gameType, &food, target)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqEntityInventoryClick(target EntityId, click gamerules.Click) {
+    conn.shard.enqueueAllChunks(func(chunk *Chunk) {
+        chunk.reqEntityInventoryClick(conn.player, target, &click)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqEntityInventoryUnsubscribed(target EntityId) {
+    conn.shard.enqueueAllChunks(func(chunk *Chunk) {
+        chunk.reqEntityInventoryUnsubscribed(conn.player, target)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqTrade(merchant EntityId, index int, offer gamerules.MerchantOffer) {
+    conn.shard.enqueueAllChunks(func(chunk *Chunk) {
+        chunk.reqTrade(conn.player, merchant, index, &offer)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqUseItem(held gamerules.Slot, eyePosition AbsXyz, look LookDegrees) {
+    chunkLoc := eyePosition.ToChunkXz()
+    conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+        chunk.reqUseItem(conn.player, &held, &eyePosition, &look)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqSteerVehicle(vehicle EntityId, input AbsVelocity) {
+    conn.shard.enqueueAllChunks(func(chunk *Chunk) {
+        chunk.reqSteerVehicle(conn.player, vehicle, &input)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqSetGameType(gameType GameType) {
+    conn.shard.enqueue(func() {
+        conn.gameType = gameType
+    })
+}
+
+func (conn *localPlayerShardClient) ReqCheckMove(from AbsXyz, to AbsXyz) {
+    chunkLoc := to.ToChunkXz()
+    conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+        chunk.reqCheckMove(conn.player, &from, &to)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqSetSignText(target BlockXyz, text [gamerules.SignLines]string) {
+    chunkLoc := target.ToChunkXz()
+    conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
+        chunk.reqSetSignText(conn.player, &target, text)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqUseAnvil(target BlockXyz) {
+    chunkLoc := target.ToChunkXz()
+    conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
+        chunk.reqUseAnvil(conn.player, &target)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqPortalArrival(position AbsXyz, look LookDegrees) {
+    chunkLoc := position.ToChunkXz()
+    conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+        chunk.reqPortalArrival(conn.player, &position, &look)
+    })
+}
+
+func (conn *localPlayerShardClient) ReqCheckBed(bed BlockXyz) {
+    chunkLoc := bed.ToChunkXz()
+    conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
+        chunk.reqCheckBed(conn.player, &bed)
+    })
+}
@@ -33,3 +33,9 @@ func (client *localShardShardClient) ReqTransferEntity(loc ChunkXz, entity gamer
         }
     })
 }
+
+func (client *localShardShardClient) ReqPortalSearch(search *gamerules.PortalSearch) {
+    client.serverShard.enqueue(func() {
+        client.serverShard.reqPortalSearch(search)
+    })
+}
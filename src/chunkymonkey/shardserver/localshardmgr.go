@@ -13,14 +13,18 @@ import (
 // implements IShardConnecter and is for use in hosting all shards in the local
 // process.
 type LocalShardManager struct {
+    dimension  DimensionId
     entityMgr  *entity.EntityManager
     chunkStore chunkstore.IChunkStore
     shards     map[uint64]*ChunkShard
     lock       sync.Mutex
 }
 
-func NewLocalShardManager(chunkStore chunkstore.IChunkStore, entityMgr *entity.EntityManager) *LocalShardManager {
+// NewLocalShardManager creates a LocalShardManager for the shards of a single
+// dimension, whose chunks are in chunkStore.
+func NewLocalShardManager(dimension DimensionId, chunkStore chunkstore.IChunkStore, entityMgr *entity.EntityManager) *LocalShardManager {
     return &LocalShardManager{
+        dimension:  dimension,
         entityMgr:  entityMgr,
         chunkStore: chunkStore,
         shards:     make(map[uint64]*ChunkShard),
@@ -39,7 +43,7 @@ func (mgr *LocalShardManager) getShard(loc ShardXz, create bool) *ChunkShard {
     }
 
     // Create shard.
-    shard := NewChunkShard(mgr, mgr.chunkStore, mgr.entityMgr, loc)
+    shard := NewChunkShard(mgr, mgr.dimension, mgr.chunkStore, mgr.entityMgr, loc)
     mgr.shards[shardKey] = shard
     go shard.serve()
 
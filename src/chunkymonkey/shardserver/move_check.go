@@ -0,0 +1,111 @@
+package shardserver
+
+import (
+    "math"
+
+    "chunkymonkey/gamerules"
+    . "chunkymonkey/types"
+)
+
+const (
+    // Half the width of a player's body.
+    playerHalfWidth = AbsCoord(0.3)
+
+    // Heights above a player's feet between which their body must not be
+    // inside solid blocks. The lower limit allows for players stepping up onto
+    // slabs and stairs.
+    playerBodyLow  = AbsCoord(0.6)
+    playerBodyHigh = AbsCoord(1.7)
+
+    // Distance below a player's feet within which a block supports them.
+    playerSupportDepth = AbsCoord(0.6)
+
+    // Distance between the points checked along a player's path.
+    moveCheckStep = AbsCoord(0.25)
+)
+
+// reqCheckMove checks a player's move against the blocks around it, and
+// reports the result back to the player.
+func (chunk *Chunk) reqCheckMove(player gamerules.IPlayerClient, from, to *AbsXyz) {
+    blocked := chunk.isMoveBlocked(from, to)
+    supported := chunk.isPlayerSupported(to)
+    player.MoveChecked(*to, supported, blocked)
+}
+
+// isMoveBlocked returns true if a player moving in a straight line between the
+// positions would pass through solid blocks. Players already inside solid
+// blocks are allowed to move out of them.
+func (chunk *Chunk) isMoveBlocked(from, to *AbsXyz) bool {
+    if chunk.isBodyBlocked(from) {
+        return false
+    }
+
+    dx, dy, dz := to.X-from.X, to.Y-from.Y, to.Z-from.Z
+    distance := AbsCoord(math.Sqrt(float64(dx*dx + dy*dy + dz*dz)))
+    steps := int(math.Ceil(float64(distance / moveCheckStep)))
+
+    for i := 1; i <= steps; i++ {
+        f := AbsCoord(i) / AbsCoord(steps)
+        point := AbsXyz{from.X + dx*f, from.Y + dy*f, from.Z + dz*f}
+        if chunk.isBodyBlocked(&point) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// isBodyBlocked returns true if the body of a player standing at the position
+// is inside a solid block. Only the centre of the body is checked, as blocks
+// such as fences and stairs are not full blocks.
+func (chunk *Chunk) isBodyBlocked(position *AbsXyz) bool {
+    low := math.Floor(float64(position.Y + playerBodyLow))
+    high := math.Floor(float64(position.Y + playerBodyHigh))
+
+    for y := low; y <= high; y++ {
+        blockType, ok := chunk.blockTypeAtAbs(&AbsXyz{position.X, AbsCoord(y), position.Z})
+        if ok && blockType.Solid && !blockType.Passable {
+            return true
+        }
+    }
+    return false
+}
+
+// isPlayerSupported returns true if a player at the position is standing on a
+// solid block, or is inside a block that holds them up (e.g a ladder or
+// water). Players near blocks that are not loaded are given the benefit of
+// the doubt.
+func (chunk *Chunk) isPlayerSupported(position *AbsXyz) bool {
+    for _, ox := range [...]AbsCoord{-playerHalfWidth, playerHalfWidth} {
+        for _, oz := range [...]AbsCoord{-playerHalfWidth, playerHalfWidth} {
+            for _, oy := range [...]AbsCoord{-playerSupportDepth, -0.01} {
+                blockType, ok := chunk.blockTypeAtAbs(&AbsXyz{position.X + ox, position.Y + oy, position.Z + oz})
+                if !ok || blockType.Solid || blockType.Climbable {
+                    return true
+                }
+            }
+        }
+    }
+
+    for _, oy := range [...]AbsCoord{0.1, 1} {
+        blockType, ok := chunk.blockTypeAtAbs(&AbsXyz{position.X, position.Y + oy, position.Z})
+        if !ok || blockType.Climbable {
+            return true
+        }
+    }
+
+    return false
+}
+
+// blockTypeAtAbs returns the type of the block containing the given position.
+// ok=false if the block is not known.
+func (chunk *Chunk) blockTypeAtAbs(position *AbsXyz) (blockType *gamerules.BlockType, ok bool) {
+    if position.Y >= ChunkSizeY {
+        // There are no blocks above the top of the world.
+        return gamerules.Blocks.Get(BlockIdAir)
+    } else if position.Y < 0 {
+        return nil, false
+    }
+    blockType, _, ok = chunk.BlockAt(position.ToBlockXyz())
+    return
+}
@@ -10,6 +10,10 @@ const (
     // Assumed values for size of player axis-aligned bounding box (AAB).
     playerAabH = AbsCoord(0.75) // Each side of player.
     playerAabY = AbsCoord(2.00) // From player's feet position upwards.
+
+    // Distance from the middle of a player within which they collect
+    // experience orbs.
+    experienceOrbCollectDistance = AbsCoord(2)
 )
 
 // playerData represents a Chunk's knowledge about a player. Only one Chunk has
@@ -24,6 +28,14 @@ type playerData struct {
     // TODO Armor data.
 }
 
+func (player *playerData) GetEntityId() EntityId {
+    return player.entityId
+}
+
+func (player *playerData) Position() *AbsXyz {
+    return &player.position
+}
+
 func (player *playerData) SpawnPackets(pkts []proto.IPacket) []proto.IPacket {
     return append(pkts, &proto.PacketNamedEntitySpawn{
         EntityId:    player.entityId,
@@ -43,6 +55,19 @@ func (player *playerData) UpdatePackets(pkts []proto.IPacket) []proto.IPacket {
     })
 }
 
+func (player *playerData) AwarenessRadius() AbsCoord {
+    return gamerules.PlayerAwarenessRadius
+}
+
+// NearExperienceOrb returns true if the orb is close enough to the player to
+// be collected. Orbs are collected from further away than items, as they are
+// drawn towards nearby players.
+func (player *playerData) NearExperienceOrb(orb *gamerules.ExperienceOrb) bool {
+    centre := player.position
+    centre.Y += playerAabY / 2
+    return centre.IsWithinDistanceOf(*orb.Position(), experienceOrbCollectDistance)
+}
+
 func (player *playerData) OverlapsItem(item *gamerules.Item) bool {
     // TODO note that calling this function repeatedly is not as efficient as it
     // could be.
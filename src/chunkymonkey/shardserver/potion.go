@@ -0,0 +1,117 @@
+package shardserver
+
+import (
+    "bytes"
+
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+const (
+    // Splash potions fly in a straight line from the player that throws them,
+    // and land at the first solid block, player or mob in their path, or
+    // after this distance.
+    potionThrowDistance = AbsCoord(10)
+
+    // Players and mobs this close to a thrown potion's path are hit by it.
+    potionHitDistance = AbsCoord(1)
+)
+
+// throwPotion finds where a splash potion thrown by the player lands, and
+// splashes it there.
+func (chunk *Chunk) throwPotion(player gamerules.IPlayerClient, potion *gamerules.Slot, eyePosition *AbsXyz, look *LookDegrees) {
+    const step = 0.1
+
+    dx, dy, dz := look.Direction()
+    position := *eyePosition
+
+    for dist := AbsCoord(0); dist < potionThrowDistance; dist += step {
+        next := AbsXyz{position.X + dx*step, position.Y + dy*step, position.Z + dz*step}
+        blockLoc := next.ToBlockXyz()
+        if blockLoc == nil {
+            break
+        }
+        if blockType, _, ok := chunk.BlockAt(blockLoc); !ok || blockType.Solid {
+            break
+        }
+
+        position = next
+        if chunk.potionHitsAt(player.GetEntityId(), &position) {
+            break
+        }
+    }
+
+    chunk.splashPotion(potion.Data, &position)
+}
+
+// potionHitsAt returns true if a potion at the given position hits a mob or a
+// player other than the one that threw it.
+func (chunk *Chunk) potionHitsAt(thrower EntityId, position *AbsXyz) bool {
+    for _, neighbour := range chunk.neighbourhood() {
+        for _, entity := range neighbour.entities {
+            if _, ok := entity.(gamerules.IEffectEntity); ok && position.IsWithinDistanceOf(*entity.Position(), potionHitDistance) {
+                return true
+            }
+        }
+        for entityId, data := range neighbour.playersData {
+            if entityId != thrower && position.IsWithinDistanceOf(data.position, potionHitDistance) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// splashPotion applies the effect of a splash potion that has landed at the
+// given position to the players and mobs near it.
+func (chunk *Chunk) splashPotion(data ItemData, position *AbsXyz) {
+    if blockLoc := position.ToBlockXyz(); blockLoc != nil {
+        buf := new(bytes.Buffer)
+        chunk.shard.pktSerial.WritePacketsBuffer(buf, &proto.PacketSoundEffect{
+            Effect: ParticleEffectSplashPotion,
+            Block:  *blockLoc,
+            Data:   int32(data),
+        })
+        chunk.reqMulticastPlayers(-1, buf.Bytes())
+    }
+
+    for _, neighbour := range chunk.neighbourhood() {
+        for _, entity := range neighbour.entities {
+            target, ok := entity.(gamerules.IEffectEntity)
+            if !ok {
+                continue
+            }
+            effect, ok := gamerules.SplashPotionEffect(data, position.Distance(*entity.Position()))
+            if !ok {
+                continue
+            }
+            if target.AddEffect(neighbour, effect) {
+                neighbour.RemoveEntity(target)
+            }
+            neighbour.storeDirty = true
+        }
+
+        for entityId, playerData := range neighbour.playersData {
+            effect, ok := gamerules.SplashPotionEffect(data, position.Distance(playerData.position))
+            if !ok {
+                continue
+            }
+            if player, ok := neighbour.subscribers[entityId]; ok {
+                player.AddEffect(effect)
+            }
+        }
+    }
+}
+
+// neighbourhood returns the chunk and its loaded neighbours within the shard.
+func (chunk *Chunk) neighbourhood() (chunks []*Chunk) {
+    for x := chunk.loc.X - 1; x <= chunk.loc.X+1; x++ {
+        for z := chunk.loc.Z - 1; z <= chunk.loc.Z+1; z++ {
+            if neighbour := chunk.shard.loadedChunk(ChunkXz{x, z}); neighbour != nil {
+                chunks = append(chunks, neighbour)
+            }
+        }
+    }
+    return
+}
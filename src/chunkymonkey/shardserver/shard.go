@@ -1,6 +1,7 @@
 package shardserver
 
 import (
+    "bytes"
     "fmt"
     "log"
     "time"
@@ -30,6 +31,7 @@ type ChunkShard struct {
     pktSerial proto.PacketSerializer
 
     shardConnecter   gamerules.IShardConnecter
+    dimension        DimensionId
     chunkStore       chunkstore.IChunkStore
     entityMgr        *entity.EntityManager
     loc              ShardXz
@@ -45,11 +47,15 @@ type ChunkShard struct {
 
     shardClients map[uint64]gamerules.IShardShardClient
     selfClient   shardSelfClient
+
+    // Entity trackers for players connected to the shard.
+    trackers map[EntityId]*entityTracker
 }
 
-func NewChunkShard(shardConnecter gamerules.IShardConnecter, chunkStore chunkstore.IChunkStore, entityMgr *entity.EntityManager, loc ShardXz) (shard *ChunkShard) {
+func NewChunkShard(shardConnecter gamerules.IShardConnecter, dimension DimensionId, chunkStore chunkstore.IChunkStore, entityMgr *entity.EntityManager, loc ShardXz) (shard *ChunkShard) {
     shard = &ChunkShard{
         shardConnecter:   shardConnecter,
+        dimension:        dimension,
         chunkStore:       chunkStore,
         entityMgr:        entityMgr,
         loc:              loc,
@@ -64,6 +70,8 @@ func NewChunkShard(shardConnecter gamerules.IShardConnecter, chunkStore chunksto
         newActiveShards: make(map[uint64]*destActiveShard),
 
         shardClients: make(map[uint64]gamerules.IShardShardClient),
+
+        trackers: make(map[EntityId]*entityTracker),
     }
 
     shard.selfClient.shard = shard
@@ -96,6 +104,8 @@ func (shard *ChunkShard) tick() {
         }
     }
 
+    shard.updateMovedTrackers()
+
     if shard.ticksSinceUpdate >= TicksPerSecond {
         for _, chunk := range shard.chunks {
             if chunk != nil {
@@ -170,6 +180,57 @@ func (shard *ChunkShard) blockQuery(chunkLoc ChunkXz, subLoc *SubChunkXyz) (bloc
     return
 }
 
+// loadedChunk returns the chunk at the given location if it is within the
+// shard and already loaded, otherwise nil.
+func (shard *ChunkShard) loadedChunk(chunkLoc ChunkXz) *Chunk {
+    chunkIndex, _, _, ok := shard.chunkIndexAndRelLoc(chunkLoc)
+    if !ok {
+        return nil
+    }
+
+    return shard.chunks[chunkIndex]
+}
+
+// reqPortalSearch searches the shard's chunks for a portal near an arriving
+// player, loading them if need be, and passes the search on to the next
+// shard. Once every shard has been searched, the shard with the arrival point
+// moves the player, building a portal if none was found.
+func (shard *ChunkShard) reqPortalSearch(search *gamerules.PortalSearch) {
+    if _, done := search.Shard(); !done {
+        for _, chunkLoc := range search.ChunksInShard(shard.loc) {
+            chunk := shard.chunkAt(chunkLoc)
+            // Most chunks hold no portal, and are not worth searching block by
+            // block.
+            if chunk != nil && bytes.IndexByte(chunk.blocks, byte(gamerules.BlockIdPortal)) >= 0 {
+                search.Search(chunk, &chunkLoc)
+            }
+        }
+        search.ShardSearched()
+    }
+
+    for {
+        shardLoc, done := search.Shard()
+        if done && shardLoc.Equals(&shard.loc) {
+            centre := search.Centre()
+            if chunk := shard.chunkAt(*centre.ToChunkXz()); chunk != nil {
+                search.Arrive(chunk)
+            }
+            return
+        }
+
+        if client := shard.clientForShard(shardLoc); client != nil {
+            client.ReqPortalSearch(search)
+            return
+        } else if done {
+            log.Printf("%v: shard %v for portal arrival has gone", shard, shardLoc)
+            return
+        }
+        // Shards that are not running have no chunks loaded for players, so
+        // are skipped.
+        search.ShardSearched()
+    }
+}
+
 // transferActiveBlocks takes blocks marked as newly active by addActiveBlock,
 // and informs the chunk in the destination shards.
 func (shard *ChunkShard) transferActiveBlocks() {
@@ -336,3 +397,7 @@ func (client *shardSelfClient) ReqTransferEntity(loc ChunkXz, entity gamerules.I
         chunk.transferEntity(entity)
     }
 }
+
+func (client *shardSelfClient) ReqPortalSearch(search *gamerules.PortalSearch) {
+    client.shard.reqPortalSearch(search)
+}
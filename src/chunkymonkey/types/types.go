@@ -47,6 +47,17 @@ const (
     GameTypeAdventure = GameType(2)
 )
 
+// StateReason is the reason for a change in game state in PacketState.
+type StateReason byte
+
+const (
+    StateReasonInvalidBed     = StateReason(0)
+    StateReasonBeginRain      = StateReason(1)
+    StateReasonEndRain        = StateReason(2)
+    StateReasonChangeGameType = StateReason(3)
+    StateReasonEnterCredits   = StateReason(4)
+)
+
 // What type of level is it?
 type LevelType string
 
@@ -62,6 +73,9 @@ type Health int16
 // Player food level.
 type FoodUnits int16
 
+// Player experience points.
+type Experience int32
+
 // Item-related types
 
 // Item type ID
@@ -89,6 +103,12 @@ type ItemCount int8
 
 type EntityId int32
 
+const (
+    // EntityIdNull is used where no entity is referred to, e.g when a player
+    // is not riding a vehicle.
+    EntityIdNull = EntityId(-1)
+)
+
 func (e EntityId) GetEntityId() EntityId {
     return e
 }
@@ -170,6 +190,7 @@ const (
     EntityStatusWolfShake      = EntityStatus(8)
     EntityStatusEatingAccepted = EntityStatus(9)
     EntityStatusSheepEatGrass  = EntityStatus(10)
+    EntityStatusInLove         = EntityStatus(18)
 )
 
 type EntityAnimation byte
@@ -372,6 +393,14 @@ func (f Face) Dxyz() (dx BlockCoord, dy BlockYCoord, dz BlockCoord) {
     return
 }
 
+// Opposite returns the face on the other side of a block.
+func (f Face) Opposite() Face {
+    if f < FaceMinValid || f > FaceMaxValid {
+        return FaceNull
+    }
+    return f ^ 1
+}
+
 // SideFace - similar to Face, but doesn't include top and bottom
 // faces/directions, and has different IDs.
 type SideFace int32
@@ -403,8 +432,13 @@ type DigStatus byte
 
 const (
     DigStarted    = DigStatus(0)
+    DigCancelled  = DigStatus(1)
     DigBlockBroke = DigStatus(2)
-    DigDropItem   = DigStatus(4)
+    // The player dropped their held stack, or one item from it.
+    DigDropItemStack = DigStatus(3)
+    DigDropItem      = DigStatus(4)
+    // The player has stopped using their held item (e.g stopped eating).
+    DigReleaseUseItem = DigStatus(5)
 )
 
 const (
@@ -432,6 +466,12 @@ const (
     InvTypeIdWorkbench = InvTypeId(1)
     InvTypeIdFurnace   = InvTypeId(2)
     InvTypeIdDispenser = InvTypeId(3)
+    InvTypeIdEnchant   = InvTypeId(4)
+    InvTypeIdBrewing   = InvTypeId(5)
+    InvTypeIdMerchant  = InvTypeId(6)
+    InvTypeIdAnvil     = InvTypeId(8)
+    InvTypeIdHopper    = InvTypeId(9)
+    InvTypeIdDropper   = InvTypeId(10)
 )
 
 // ID of the slow in inventory or other item-slotted window element
@@ -439,7 +479,7 @@ type SlotId int16
 
 const (
     SlotIdCursor = SlotId(-1)
-    SlotIdNull   = SlotId(999) // Clicked outside window.
+    SlotIdNull   = SlotId(-999) // Clicked outside window.
 )
 
 type PrgBarId int16
@@ -447,6 +487,8 @@ type PrgBarId int16
 const (
     PrgBarIdFurnaceProgress = PrgBarId(0)
     PrgBarIdFurnaceFire     = PrgBarId(1)
+    PrgBarIdBrewingTime     = PrgBarId(0)
+    PrgBarIdAnvilCost       = PrgBarId(0)
 )
 
 type PrgBarValue int16
@@ -466,6 +508,30 @@ const (
     TxStateDeferred
 )
 
+// ClickMode is the kind of click that a player makes in an inventory window.
+type ClickMode byte
+
+const (
+    ClickModeNormal      = ClickMode(0) // Left or right click.
+    ClickModeShift       = ClickMode(1) // Shift-click, moving a stack elsewhere.
+    ClickModeNumberKey   = ClickMode(2) // Number key, swapping with the hotbar.
+    ClickModeMiddle      = ClickMode(3) // Middle click, cloning a stack.
+    ClickModeDrop        = ClickMode(4) // Drop key.
+    ClickModeDrag        = ClickMode(5) // Dragging items across slots.
+    ClickModeDoubleClick = ClickMode(6) // Double click, collecting a stack.
+)
+
+// Buttons sent with ClickModeDrag for each stage of dragging items across
+// slots with the left or right mouse button.
+const (
+    DragStartLeft  = 0
+    DragAddLeft    = 1
+    DragEndLeft    = 2
+    DragStartRight = 4
+    DragAddRight   = 5
+    DragEndRight   = 6
+)
+
 // Movement-related types and constants
 
 // PlayerMotion
@@ -523,7 +589,8 @@ type RelMove struct {
 // Angle-related types and constants
 
 const (
-    DegreesToBytes = 256.0 / 360.0
+    DegreesToBytes   = 256.0 / 360.0
+    DegreesToRadians = math.Pi / 180.0
 )
 
 // An angle, where there are 256 units in a circle.
@@ -557,12 +624,20 @@ func (l *LookDegrees) ToLookBytes() LookBytes {
     }
 }
 
+// Direction returns the unit vector pointing in the direction of the look.
+func (l *LookDegrees) Direction() (dx, dy, dz AbsCoord) {
+    yaw := float64(l.Yaw) * DegreesToRadians
+    pitch := float64(l.Pitch) * DegreesToRadians
+    cosPitch := math.Cos(pitch)
+    return AbsCoord(-math.Sin(yaw) * cosPitch), AbsCoord(-math.Sin(pitch)), AbsCoord(math.Cos(yaw) * cosPitch)
+}
+
 type LookBytes struct {
     Yaw, Pitch AngleBytes
 }
 
 type MobLookBytes struct {
-    Yaw, HeadPitch, Pitch AngleBytes
+    Yaw, Pitch, HeadYaw AngleBytes
 }
 
 type OrientationDegrees struct {
@@ -656,6 +731,14 @@ func (p *AbsXyz) IsWithinDistanceOf(other AbsXyz, maxDistance AbsCoord) bool {
     return (dx*dx + dy*dy + dz*dz) <= maxDistance*maxDistance
 }
 
+// Distance returns the straight line distance between the two positions.
+func (p *AbsXyz) Distance(other AbsXyz) AbsCoord {
+    dx := p.X - other.X
+    dy := p.Y - other.Y
+    dz := p.Z - other.Z
+    return AbsCoord(math.Sqrt(float64(dx*dx + dy*dy + dz*dz)))
+}
+
 // Specifies approximate world distance in pixels (absolute / PixelsPerBlock)
 type AbsIntCoord int32
 
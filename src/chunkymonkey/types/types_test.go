@@ -1,6 +1,7 @@
 package types
 
 import (
+    "math"
     "testing"
 )
 
@@ -50,6 +51,34 @@ func TestLookDegrees_ToLookBytes(t *testing.T) {
     }
 }
 
+func TestLookDegrees_Direction(t *testing.T) {
+    type Test struct {
+        input      LookDegrees
+        dx, dy, dz AbsCoord
+    }
+
+    var tests = []Test{
+        {LookDegrees{0, 0}, 0, 0, 1},
+        {LookDegrees{90, 0}, -1, 0, 0},
+        {LookDegrees{180, 0}, 0, 0, -1},
+        {LookDegrees{270, 0}, 1, 0, 0},
+        {LookDegrees{0, 90}, 0, -1, 0},
+        {LookDegrees{0, -90}, 0, 1, 0},
+    }
+
+    near := func(a, b AbsCoord) bool {
+        return math.Abs(float64(a-b)) < 1e-6
+    }
+
+    for _, r := range tests {
+        dx, dy, dz := r.input.Direction()
+        if !near(dx, r.dx) || !near(dy, r.dy) || !near(dz, r.dz) {
+            t.Errorf("LookDegrees%v expected direction (%v, %v, %v) got (%v, %v, %v)",
+                r.input, r.dx, r.dy, r.dz, dx, dy, dz)
+        }
+    }
+}
+
 func TestAbsXyz_ToChunkXz(t *testing.T) {
     type Test struct {
         input    AbsXyz
@@ -157,6 +186,13 @@ func Test_AbsXyz_IsWithinDistanceOf(t *testing.T) {
     }
 }
 
+func TestAbsXyz_Distance(t *testing.T) {
+    a, b := AbsXyz{1, 2, 3}, AbsXyz{4, 6, 3}
+    if dist := a.Distance(b); dist != 5 {
+        t.Errorf("%v.Distance(%v)=>%f expected 5", a, b, dist)
+    }
+}
+
 func TestAbsIntXyz_ToChunkXz(t *testing.T) {
     type Test struct {
         input    AbsIntXyz
@@ -15,6 +15,22 @@ const (
     playerInvHoldingNum = 9
 )
 
+// Indices of the views in the player inventory window.
+const (
+    playerInvViewCrafting = iota
+    playerInvViewArmor
+    playerInvViewMain
+    playerInvViewHolding
+)
+
+// Indices of the views in windows onto another inventory along with the
+// player's.
+const (
+    containerViewInventory = iota
+    containerViewMain
+    containerViewHolding
+)
+
 type PlayerInventory struct {
     Window
     entityId     EntityId
@@ -47,6 +63,13 @@ func (w *PlayerInventory) Init(entityId EntityId, viewer IWindowViewer) {
         &w.main,
         &w.holding,
     )
+    w.Window.setLayout(
+        playerInvViewHolding,
+        []int{playerInvViewMain, playerInvViewHolding}, // Crafting.
+        []int{playerInvViewMain, playerInvViewHolding}, // Armor.
+        []int{playerInvViewHolding},                    // Main.
+        []int{playerInvViewMain},                       // Holding.
+    )
     w.holdingIndex = 0
 }
 
@@ -62,23 +85,81 @@ func (w *PlayerInventory) Resubscribe() {
 // inventory sections with `w`. Returns nil for unrecognized inventory types.
 // TODO implement more inventory types.
 func (w *PlayerInventory) NewWindow(invTypeId InvTypeId, windowId WindowId, inv IInventory) IWindow {
+    var window *Window
     switch invTypeId {
     case InvTypeIdWorkbench:
-        return NewWindow(
+        window = NewWindow(
             windowId, invTypeId, w.viewer, "Crafting",
             inv, &w.main, &w.holding)
+        // Items are not shifted into the crafting grid, but between the
+        // player's main inventory and hotbar.
+        window.setLayout(
+            containerViewHolding,
+            []int{containerViewMain, containerViewHolding},
+            []int{containerViewHolding},
+            []int{containerViewMain},
+        )
+        return window
     case InvTypeIdChest:
-        return NewWindow(
-            windowId, invTypeId, w.viewer, "Chest",
-            inv, &w.main, &w.holding)
+        title := "Chest"
+        if inv.NumSlots() > gamerules.ChestNumSlots {
+            title = "Large chest"
+        }
+        return w.newContainerWindow(windowId, invTypeId, title, inv)
     case InvTypeIdFurnace:
-        return NewWindow(
-            windowId, invTypeId, w.viewer, "Furnace",
-            inv, &w.main, &w.holding)
+        return w.newContainerWindow(windowId, invTypeId, "Furnace", inv)
+    case InvTypeIdBrewing:
+        return w.newContainerWindow(windowId, invTypeId, "Brewing", inv)
+    case InvTypeIdDispenser:
+        return w.newContainerWindow(windowId, invTypeId, "Dispenser", inv)
+    case InvTypeIdDropper:
+        return w.newContainerWindow(windowId, invTypeId, "Dropper", inv)
+    case InvTypeIdHopper:
+        return w.newContainerWindow(windowId, invTypeId, "Item Hopper", inv)
     }
+
     return nil
 }
 
+// NewEnderChestWindow creates a new window onto the player's ender chest
+// inventory, with the player's inventory sections.
+func (w *PlayerInventory) NewEnderChestWindow(windowId WindowId, inv IInventory) IWindow {
+    return w.newContainerWindow(windowId, InvTypeIdChest, "Ender Chest", inv)
+}
+
+// NewEnchantWindow creates a new window onto an enchanting table's inventory,
+// with the player's inventory sections.
+func (w *PlayerInventory) NewEnchantWindow(windowId WindowId, inv IInventory) IWindow {
+    return w.newContainerWindow(windowId, InvTypeIdEnchant, "Enchant", inv)
+}
+
+// NewAnvilWindow creates a new window onto an anvil's inventory, with the
+// player's inventory sections.
+func (w *PlayerInventory) NewAnvilWindow(windowId WindowId, inv IInventory) IWindow {
+    return w.newContainerWindow(windowId, InvTypeIdAnvil, "Repairing", inv)
+}
+
+// NewMerchantWindow creates a new window for trading with a villager, with
+// the player's inventory sections.
+func (w *PlayerInventory) NewMerchantWindow(windowId WindowId, inv IInventory) IWindow {
+    return w.newContainerWindow(windowId, InvTypeIdMerchant, "Villager", inv)
+}
+
+// newContainerWindow creates a window with the player's inventory sections
+// below inv, shifting items between the two.
+func (w *PlayerInventory) newContainerWindow(windowId WindowId, invTypeId InvTypeId, title string, inv IInventory) *Window {
+    window := NewWindow(
+        windowId, invTypeId, w.viewer, title,
+        inv, &w.main, &w.holding)
+    window.setLayout(
+        containerViewHolding,
+        []int{containerViewMain, containerViewHolding},
+        []int{containerViewInventory},
+        []int{containerViewInventory},
+    )
+    return window
+}
+
 // SetHolding chooses the held item (0-8). Out of range values have no effect.
 func (w *PlayerInventory) SetHolding(holding SlotId) {
     if holding >= 0 && holding < SlotId(playerInvHoldingNum) {
@@ -100,6 +181,50 @@ func (w *PlayerInventory) TakeOneHeldItem(into *gamerules.Slot) {
     w.holding.TakeOneItem(w.holdingIndex, into)
 }
 
+// TakeHeldStack moves as much of the held stack as will fit into `into`.
+func (w *PlayerInventory) TakeHeldStack(into *gamerules.Slot) {
+    w.holding.TakeItems(w.holdingIndex, into)
+}
+
+// TakeCraftingItems empties the 2x2 crafting grid, returning the items that
+// were in it.
+func (w *PlayerInventory) TakeCraftingItems() []gamerules.Slot {
+    return w.crafting.TakeAllItems()
+}
+
+// ArmorProtection returns the fraction by which the enchantments on the armor
+// that the player is wearing reduce the damage done to them.
+func (w *PlayerInventory) ArmorProtection() float64 {
+    armor := make([]gamerules.Slot, w.armor.NumSlots())
+    for i := range armor {
+        armor[i] = w.armor.Slot(SlotId(i))
+    }
+    return gamerules.ProtectionReduction(armor)
+}
+
+// SetSlot replaces the contents of a slot in the armor, main or holding
+// sections of the inventory, given its slot ID within the window. This is used
+// by players in creative mode, who can conjure up any item. ok=false if the
+// slot is not one that can be set.
+func (w *PlayerInventory) SetSlot(slotId SlotId, item gamerules.Slot) (ok bool) {
+    for _, inv := range [...]*gamerules.Inventory{&w.armor, &w.main, &w.holding} {
+        for i := range w.Window.views {
+            view := &w.Window.views[i]
+            if view.inventory == inv && slotId >= view.startSlot && slotId < view.endSlot {
+                inv.SetSlot(slotId-view.startSlot, item)
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// WearHeldItem adds wear to the tool that the player is holding, removing it
+// if it wears out.
+func (w *PlayerInventory) WearHeldItem(uses ItemData) {
+    w.holding.WearItem(w.holdingIndex, uses)
+}
+
 // PutItem attempts to put the item stack into the player's inventory. The item
 // will be modified as a result.
 func (w *PlayerInventory) PutItem(item *gamerules.Slot) {
@@ -108,6 +233,17 @@ func (w *PlayerInventory) PutItem(item *gamerules.Slot) {
     return
 }
 
+// PutTransferred puts items given back by a remote inventory after a transfer
+// click into the player's inventory. Items from a number key click go into
+// the hotbar slot that the click was for if possible. Any items that do not
+// fit are left in the click's Cursor.
+func (w *PlayerInventory) PutTransferred(click *gamerules.Click) {
+    if click.Mode == ClickModeNumberKey && SlotId(click.Button) < playerInvHoldingNum {
+        w.holding.PutItemInSlot(SlotId(click.Button), &click.Cursor)
+    }
+    w.PutItem(&click.Cursor)
+}
+
 // CanTakeItem returns true if it can take at least one item from the passed
 // Slot.
 func (w *PlayerInventory) CanTakeItem(item *gamerules.Slot) bool {
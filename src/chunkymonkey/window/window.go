@@ -30,6 +30,11 @@ type IWindow interface {
 // *player.Player implements this.
 type IWindowViewer interface {
     SendPacket(packet proto.IPacket)
+    // DropItem throws the item out of the window into the world.
+    DropItem(item gamerules.Slot)
+    // ReturnItem puts the item into the player's inventory, throwing out
+    // whatever does not fit.
+    ReturnItem(item gamerules.Slot)
 }
 
 // inventoryView provides a single mapping between a window view onto an
@@ -63,6 +68,31 @@ func (iv *inventoryView) SlotUpdate(slot *gamerules.Slot, slotIndex SlotId) {
     iv.window.viewer.SendPacket(slot.UpdatePacket(iv.window.windowId, iv.startSlot+slotIndex))
 }
 
+// slots returns the contents of the view's inventory.
+func (iv *inventoryView) slots() []gamerules.Slot {
+    protoSlots := make(proto.ItemSlotSlice, iv.endSlot-iv.startSlot)
+    iv.inventory.GetProtoSlots(protoSlots)
+    slots := make([]gamerules.Slot, len(protoSlots))
+    for i := range protoSlots {
+        slots[i].SetItemSlot(&protoSlots[i])
+    }
+    return slots
+}
+
+// room returns how many of the given item would fit into the view's
+// inventory. This can be more than an ItemCount can hold.
+func (iv *inventoryView) room(item *gamerules.Slot) (room int) {
+    maxStack := item.MaxStack()
+    for _, slot := range iv.slots() {
+        if slot.IsEmpty() {
+            room += int(maxStack)
+        } else if slot.IsSameType(item) && slot.Count < maxStack {
+            room += int(maxStack - slot.Count)
+        }
+    }
+    return
+}
+
 func (iv *inventoryView) ProgressUpdate(prgBarId PrgBarId, value PrgBarValue) {
     iv.window.viewer.SendPacket(&proto.PacketWindowProgressBar{
         WindowId: iv.window.windowId,
@@ -80,6 +110,21 @@ type Window struct {
     views     []inventoryView
     title     string
     numSlots  SlotId
+
+    // shiftTargets lists, for each view, the views that shift-clicked items
+    // move into, in order of preference.
+    shiftTargets [][]int
+    // hotbar is the index of the view onto the player's hotbar, or -1.
+    hotbar int
+
+    drag dragState
+}
+
+// dragState tracks the slots that the player is dragging items across.
+type dragState struct {
+    active bool
+    right  bool
+    slots  []SlotId
 }
 
 // NewWindow creates a Window as a view onto the given inventories.
@@ -105,10 +150,18 @@ func (w *Window) Init(windowId WindowId, invTypeId InvTypeId, viewer IWindowView
         startSlot = endSlot
     }
     w.numSlots = startSlot
+    w.hotbar = -1
 
     return
 }
 
+// setLayout sets which view is the player's hotbar, and which views each view
+// shift-clicks items into.
+func (w *Window) setLayout(hotbar int, shiftTargets ...[]int) {
+    w.hotbar = hotbar
+    w.shiftTargets = shiftTargets
+}
+
 func (w *Window) WindowId() WindowId {
     return w.windowId
 }
@@ -153,22 +206,392 @@ func (w *Window) PacketWindowItems() *proto.PacketWindowItems {
     }
 }
 
+// Click applies a click to the window. The Cursor attribute of click is
+// updated to the player's new cursor contents.
 func (w *Window) Click(click *gamerules.Click) TxState {
-    if click.SlotId >= 0 {
-        for _, inventoryView := range w.views {
+    if click.Mode != ClickModeDrag {
+        w.drag.active = false
+    }
+
+    switch click.Mode {
+    case ClickModeNormal:
+        if click.SlotId == SlotIdNull {
+            return w.dropCursor(click)
+        }
+        return w.normalClick(click)
+    case ClickModeShift:
+        return w.shiftClick(click)
+    case ClickModeNumberKey:
+        return w.numberKeyClick(click)
+    case ClickModeMiddle:
+        return w.middleClick(click)
+    case ClickModeDrag:
+        return w.dragClick(click)
+    case ClickModeDoubleClick:
+        return w.doubleClick(click)
+    case ClickModeDrop:
+        return w.dropClick(click)
+    }
+
+    return TxStateRejected
+}
+
+// view returns the view containing the given window slot, and the slot's ID
+// within the view's inventory.
+func (w *Window) view(slotId SlotId) (index int, invSlotId SlotId, ok bool) {
+    for index := range w.views {
+        view := &w.views[index]
+        if slotId >= view.startSlot && slotId < view.endSlot {
+            return index, slotId - view.startSlot, true
+        }
+    }
+    return 0, 0, false
+}
+
+func (w *Window) normalClick(click *gamerules.Click) TxState {
+    index, invSlotId, ok := w.view(click.SlotId)
+    if !ok {
+        return TxStateRejected
+    }
 
-            if click.SlotId >= inventoryView.startSlot && click.SlotId < inventoryView.endSlot {
-                invClick := *click
-                invClick.SlotId = click.SlotId - inventoryView.startSlot
+    invClick := *click
+    invClick.SlotId = invSlotId
 
-                result := inventoryView.inventory.Click(&invClick)
+    result := w.views[index].inventory.Click(&invClick)
 
-                click.Cursor = invClick.Cursor
+    click.Cursor = invClick.Cursor
 
-                return result
+    return result
+}
+
+// dropCursor throws the cursor's items out of the window, or just one of them
+// when right clicking.
+func (w *Window) dropCursor(click *gamerules.Click) TxState {
+    var item gamerules.Slot
+    if click.RightClick {
+        item.AddOne(&click.Cursor)
+    } else {
+        item.Add(&click.Cursor)
+    }
+    if !item.IsEmpty() {
+        w.viewer.DropItem(item)
+    }
+
+    return TxStateAccepted
+}
+
+// dropClick throws one item, or the whole stack, out of the clicked slot.
+func (w *Window) dropClick(click *gamerules.Click) TxState {
+    if click.SlotId == SlotIdNull {
+        // Dropping with nothing under the cursor does nothing.
+        return TxStateAccepted
+    }
+    index, invSlotId, ok := w.view(click.SlotId)
+    if !ok {
+        return TxStateRejected
+    }
+
+    takeClick := gamerules.Click{
+        SlotId:       invSlotId,
+        Mode:         ClickModeDrop,
+        Button:       click.Button,
+        TxId:         click.TxId,
+        ExpectedSlot: click.ExpectedSlot,
+    }
+    txState := w.views[index].inventory.Click(&takeClick)
+    if txState == TxStateAccepted && !takeClick.Cursor.IsEmpty() {
+        // Remote inventories return the items to be thrown once taken.
+        w.viewer.DropItem(takeClick.Cursor)
+    }
+
+    return txState
+}
+
+// shiftClick moves the clicked stack into the other part of the window. The
+// whole stack must fit.
+func (w *Window) shiftClick(click *gamerules.Click) TxState {
+    index, invSlotId, ok := w.view(click.SlotId)
+    if !ok || index >= len(w.shiftTargets) {
+        return TxStateRejected
+    }
+    view := &w.views[index]
+    targets := w.shiftTargets[index]
+
+    item := view.slots()[invSlotId]
+    if item.IsEmpty() || w.room(&item, targets) < int(item.Count) {
+        return TxStateRejected
+    }
+
+    takeClick := gamerules.Click{
+        SlotId:       invSlotId,
+        Mode:         ClickModeShift,
+        TxId:         click.TxId,
+        ExpectedSlot: click.ExpectedSlot,
+    }
+    switch view.inventory.Click(&takeClick) {
+    case TxStateRejected:
+        return TxStateRejected
+    case TxStateDeferred:
+        // The items are given to the player when the remote inventory has
+        // taken them.
+        return TxStateDeferred
+    }
+
+    txState := TxStateAccepted
+    if w.moveInto(&takeClick.Cursor, targets, click.TxId) {
+        txState = TxStateDeferred
+    }
+    w.putBack(&takeClick.Cursor, view, invSlotId)
+
+    return txState
+}
+
+// numberKeyClick swaps the clicked slot with a hotbar slot.
+func (w *Window) numberKeyClick(click *gamerules.Click) TxState {
+    hotbarSlotId := SlotId(click.Button)
+    if w.hotbar < 0 || hotbarSlotId >= w.views[w.hotbar].endSlot-w.views[w.hotbar].startSlot {
+        return TxStateRejected
+    }
+    index, invSlotId, ok := w.view(click.SlotId)
+    if !ok {
+        return TxStateRejected
+    }
+    hotbar := &w.views[w.hotbar]
+    view := &w.views[index]
+
+    if index == w.hotbar && invSlotId == hotbarSlotId {
+        return TxStateAccepted
+    }
+
+    // Take the hotbar's items out, and swap them with the clicked slot.
+    takeClick := gamerules.Click{
+        SlotId:       hotbarSlotId,
+        Mode:         ClickModeShift,
+        TxId:         click.TxId,
+        ExpectedSlot: hotbar.slots()[hotbarSlotId],
+    }
+    if !takeClick.ExpectedSlot.IsEmpty() && hotbar.inventory.Click(&takeClick) != TxStateAccepted {
+        return TxStateRejected
+    }
+
+    swapClick := gamerules.Click{
+        SlotId:       invSlotId,
+        Cursor:       takeClick.Cursor,
+        Mode:         ClickModeNumberKey,
+        Button:       click.Button,
+        TxId:         click.TxId,
+        ExpectedSlot: click.ExpectedSlot,
+    }
+    txState := view.inventory.Click(&swapClick)
+    if txState != TxStateDeferred {
+        w.putBack(&swapClick.Cursor, hotbar, hotbarSlotId)
+    }
+
+    return txState
+}
+
+// middleClick puts a full stack of the clicked item on the cursor. Only
+// players in creative mode may do this.
+func (w *Window) middleClick(click *gamerules.Click) TxState {
+    index, invSlotId, ok := w.view(click.SlotId)
+    if !ok || !click.Cursor.IsEmpty() {
+        return TxStateRejected
+    }
+
+    item := w.views[index].slots()[invSlotId]
+    if item.IsEmpty() {
+        return TxStateRejected
+    }
+    item.Count = item.MaxStack()
+    click.Cursor = item
+
+    return TxStateAccepted
+}
+
+// dragClick handles a stage of dragging the cursor's items across slots. When
+// the drag ends, the items are spread evenly over the slots (or one is put in
+// each slot, when dragging with the right button).
+func (w *Window) dragClick(click *gamerules.Click) TxState {
+    switch click.Button {
+    case DragStartLeft, DragStartRight:
+        if click.Cursor.IsEmpty() {
+            return TxStateRejected
+        }
+        w.drag = dragState{
+            active: true,
+            right:  click.Button == DragStartRight,
+        }
+        return TxStateAccepted
+    case DragAddLeft, DragAddRight:
+        if !w.drag.active || w.drag.right != (click.Button == DragAddRight) {
+            return TxStateRejected
+        }
+        if _, _, ok := w.view(click.SlotId); !ok {
+            return TxStateRejected
+        }
+        for _, slotId := range w.drag.slots {
+            if slotId == click.SlotId {
+                return TxStateAccepted
             }
         }
+        w.drag.slots = append(w.drag.slots, click.SlotId)
+        return TxStateAccepted
+    case DragEndLeft, DragEndRight:
+        if !w.drag.active || w.drag.right != (click.Button == DragEndRight) {
+            return TxStateRejected
+        }
+        w.drag.active = false
+        return w.endDrag(click)
     }
 
     return TxStateRejected
 }
+
+func (w *Window) endDrag(click *gamerules.Click) TxState {
+    slots := w.drag.slots
+    w.drag.slots = nil
+    if len(slots) == 0 || click.Cursor.IsEmpty() {
+        return TxStateAccepted
+    }
+
+    perSlot := ItemCount(1)
+    if !w.drag.right {
+        perSlot = click.Cursor.Count / ItemCount(len(slots))
+        if perSlot < 1 {
+            perSlot = 1
+        }
+    }
+
+    txState := TxStateAccepted
+    for _, slotId := range slots {
+        if click.Cursor.Count <= 0 {
+            break
+        }
+        index, invSlotId, _ := w.view(slotId)
+        view := &w.views[index]
+
+        // Only add as many as the slot has room for.
+        current := view.slots()[invSlotId]
+        maxStack := click.Cursor.MaxStack()
+        if !current.IsEmpty() && !current.IsSameType(&click.Cursor) {
+            continue
+        }
+        amount := perSlot
+        if room := maxStack - current.Count; amount > room {
+            amount = room
+        }
+        if amount > click.Cursor.Count {
+            amount = click.Cursor.Count
+        }
+        if amount <= 0 {
+            continue
+        }
+
+        dragClick := gamerules.Click{
+            SlotId: invSlotId,
+            Cursor: click.Cursor,
+            Mode:   ClickModeDrag,
+            TxId:   click.TxId,
+        }
+        dragClick.Cursor.Count = amount
+        click.Cursor.Count -= amount
+
+        if view.inventory.Click(&dragClick) == TxStateDeferred {
+            txState = TxStateDeferred
+        } else {
+            click.Cursor.Count += dragClick.Cursor.Count
+        }
+    }
+    click.Cursor.Normalize()
+
+    return txState
+}
+
+// doubleClick collects items of the same type as the cursor from the whole
+// window onto the cursor.
+func (w *Window) doubleClick(click *gamerules.Click) TxState {
+    if click.Cursor.IsEmpty() {
+        return TxStateRejected
+    }
+
+    // The player's inventory views come after the inventory that they are
+    // viewing, and collect first, as a remote inventory stops any further
+    // collection.
+    txState := TxStateAccepted
+    for index := len(w.views) - 1; index >= 0; index-- {
+        view := &w.views[index]
+        collectClick := gamerules.Click{
+            Cursor: click.Cursor,
+            Mode:   ClickModeDoubleClick,
+            TxId:   click.TxId,
+        }
+        if view.inventory.Click(&collectClick) == TxStateDeferred {
+            // The remote inventory sends back the new cursor. Any other views
+            // must not collect into the cursor meanwhile.
+            txState = TxStateDeferred
+            break
+        }
+        click.Cursor = collectClick.Cursor
+    }
+
+    return txState
+}
+
+// room returns how many of the given item would fit into the given views.
+func (w *Window) room(item *gamerules.Slot, views []int) (room int) {
+    for _, index := range views {
+        room += w.views[index].room(item)
+    }
+    return
+}
+
+// moveInto moves items into the given views, in order. Items that are sent to
+// a remote inventory but do not fit are given back to the player later, and
+// deferred is true if that happened.
+func (w *Window) moveInto(items *gamerules.Slot, views []int, txId TxId) (deferred bool) {
+    for _, index := range views {
+        if items.Count <= 0 {
+            break
+        }
+        view := &w.views[index]
+        room := view.room(items)
+        if room <= 0 {
+            continue
+        }
+
+        putClick := gamerules.Click{
+            Cursor: *items,
+            Mode:   ClickModeShift,
+            TxId:   txId,
+        }
+        if int(putClick.Cursor.Count) > room {
+            putClick.Cursor.Count = ItemCount(room)
+        }
+        items.Count -= putClick.Cursor.Count
+
+        if view.inventory.Click(&putClick) == TxStateDeferred {
+            deferred = true
+        } else {
+            items.Count += putClick.Cursor.Count
+        }
+    }
+    items.Normalize()
+    return
+}
+
+// putBack returns items to the slot that they came from. Those that no longer
+// fit there are given to the player.
+func (w *Window) putBack(items *gamerules.Slot, view *inventoryView, invSlotId SlotId) {
+    if items.IsEmpty() {
+        return
+    }
+    putClick := gamerules.Click{
+        SlotId: invSlotId,
+        Cursor: *items,
+        Mode:   ClickModeDrag,
+    }
+    if view.inventory.Click(&putClick) != TxStateDeferred && !putClick.Cursor.IsEmpty() {
+        w.viewer.ReturnItem(putClick.Cursor)
+    }
+    items.Clear()
+}
@@ -0,0 +1,278 @@
+package window
+
+import (
+    "testing"
+
+    "chunkymonkey/gamerules"
+    "chunkymonkey/proto"
+    . "chunkymonkey/types"
+)
+
+const (
+    apple = ItemTypeId(260)
+    sword = ItemTypeId(267)
+)
+
+func makeWindowItemTypes() {
+    gamerules.Items = make(gamerules.ItemTypeMap)
+    gamerules.Items[apple] = &gamerules.ItemType{Id: apple, MaxStack: 64}
+    gamerules.Items[sword] = &gamerules.ItemType{Id: sword, MaxStack: 1}
+}
+
+// testViewer records the items that windows give back to the player.
+type testViewer struct {
+    dropped  []gamerules.Slot
+    returned []gamerules.Slot
+}
+
+func (viewer *testViewer) SendPacket(packet proto.IPacket) {
+}
+
+func (viewer *testViewer) DropItem(item gamerules.Slot) {
+    viewer.dropped = append(viewer.dropped, item)
+}
+
+func (viewer *testViewer) ReturnItem(item gamerules.Slot) {
+    viewer.returned = append(viewer.returned, item)
+}
+
+// remoteInventory stands in for an inventory held by a shard. It records the
+// clicks made on it, and defers them all.
+type remoteInventory struct {
+    gamerules.Inventory
+    clicks []gamerules.Click
+}
+
+func newRemoteInventory(numSlots int) *remoteInventory {
+    inv := &remoteInventory{}
+    inv.Init(numSlots)
+    return inv
+}
+
+func (inv *remoteInventory) Click(click *gamerules.Click) TxState {
+    inv.clicks = append(inv.clicks, *click)
+    return TxStateDeferred
+}
+
+// takeClick returns the only click made on the inventory since the last call.
+func (inv *remoteInventory) takeClick(t *testing.T) (click gamerules.Click) {
+    clicks := inv.clicks
+    inv.clicks = nil
+    if len(clicks) != 1 {
+        t.Fatalf("expected one click on remote inventory, got %+v", clicks)
+    }
+    return clicks[0]
+}
+
+// newTestWindows returns a player inventory, and a window onto it with a
+// remote inventory of 3 slots, which takes window slots 0-2. The player's main
+// inventory follows at 3-29, then the hotbar at 30-38.
+func newTestWindows() (viewer *testViewer, inv *PlayerInventory, remote *remoteInventory, window *Window) {
+    viewer = &testViewer{}
+    inv = &PlayerInventory{}
+    inv.Init(1, viewer)
+    remote = newRemoteInventory(3)
+    window = inv.newContainerWindow(2, InvTypeIdChest, "Chest", remote)
+    return
+}
+
+func checkSlot(t *testing.T, desc string, expected, result gamerules.Slot) {
+    if !expected.Equals(&result) {
+        t.Errorf("%s: expected %+v, got %+v", desc, expected, result)
+    }
+}
+
+func TestWindow_shiftClick(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    makeWindowItemTypes()
+    apples := gamerules.Slot{apple, 10, 0, nil}
+
+    // In the player's inventory, the hotbar shifts into the main inventory.
+    _, inv, remote, window := newTestWindows()
+    inv.holding.SetSlot(0, apples)
+    click := gamerules.Click{SlotId: 36, Mode: ClickModeShift, ExpectedSlot: apples}
+    if txState := inv.Click(&click); txState != TxStateAccepted {
+        t.Errorf("expected shift click in hotbar to be accepted, got %v", txState)
+    }
+    checkSlot(t, "main inventory", apples, inv.main.Slot(0))
+    checkSlot(t, "hotbar", gamerules.Slot{}, inv.holding.Slot(0))
+
+    // In a container window, the player's inventory shifts into the
+    // container.
+    click = gamerules.Click{SlotId: 3, Mode: ClickModeShift, ExpectedSlot: apples}
+    if txState := window.Click(&click); txState != TxStateDeferred {
+        t.Errorf("expected shift click into remote inventory to be deferred, got %v", txState)
+    }
+    putClick := remote.takeClick(t)
+    if putClick.Mode != ClickModeShift {
+        t.Errorf("expected shift click on remote inventory, got mode %v", putClick.Mode)
+    }
+    checkSlot(t, "items put into remote inventory", apples, putClick.Cursor)
+    checkSlot(t, "main inventory", gamerules.Slot{}, inv.main.Slot(0))
+
+    // Items in the container are taken by the remote inventory.
+    remote.SetSlot(1, apples)
+    click = gamerules.Click{SlotId: 1, Mode: ClickModeShift, ExpectedSlot: apples}
+    if txState := window.Click(&click); txState != TxStateDeferred {
+        t.Errorf("expected shift click out of remote inventory to be deferred, got %v", txState)
+    }
+    if takeClick := remote.takeClick(t); takeClick.SlotId != 1 || !takeClick.Cursor.IsEmpty() {
+        t.Errorf("expected items to be taken from remote slot 1, got %+v", takeClick)
+    }
+
+    // Stacks that do not fit are refused.
+    swords := gamerules.Slot{sword, 1, 0, nil}
+    for slotId := SlotId(0); slotId < 3; slotId++ {
+        remote.SetSlot(slotId, swords)
+    }
+    inv.main.SetSlot(0, swords)
+    click = gamerules.Click{SlotId: 3, Mode: ClickModeShift, ExpectedSlot: swords}
+    if txState := window.Click(&click); txState != TxStateRejected {
+        t.Errorf("expected shift click into full inventory to be rejected, got %v", txState)
+    }
+}
+
+func TestWindow_numberKeyClick(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    makeWindowItemTypes()
+    apples := gamerules.Slot{apple, 10, 0, nil}
+    swords := gamerules.Slot{sword, 1, 0, nil}
+
+    _, inv, remote, window := newTestWindows()
+    inv.main.SetSlot(0, apples)
+    inv.holding.SetSlot(2, swords)
+    click := gamerules.Click{SlotId: 9, Mode: ClickModeNumberKey, Button: 2, ExpectedSlot: apples}
+    if txState := inv.Click(&click); txState != TxStateAccepted {
+        t.Errorf("expected number key click to be accepted, got %v", txState)
+    }
+    checkSlot(t, "main inventory", swords, inv.main.Slot(0))
+    checkSlot(t, "hotbar", apples, inv.holding.Slot(2))
+
+    // Out of range hotbar slots are refused.
+    click = gamerules.Click{SlotId: 9, Mode: ClickModeNumberKey, Button: 9, ExpectedSlot: swords}
+    if txState := inv.Click(&click); txState != TxStateRejected {
+        t.Errorf("expected number key click for hotbar slot 9 to be rejected, got %v", txState)
+    }
+
+    // The hotbar's items are sent to the remote inventory to swap.
+    click = gamerules.Click{SlotId: 0, Mode: ClickModeNumberKey, Button: 2}
+    if txState := window.Click(&click); txState != TxStateDeferred {
+        t.Errorf("expected number key click on remote inventory to be deferred, got %v", txState)
+    }
+    swapClick := remote.takeClick(t)
+    if swapClick.Mode != ClickModeNumberKey || swapClick.SlotId != 0 || swapClick.Button != 2 {
+        t.Errorf("expected swap with remote slot 0, got %+v", swapClick)
+    }
+    checkSlot(t, "items sent to remote inventory", apples, swapClick.Cursor)
+    checkSlot(t, "hotbar", gamerules.Slot{}, inv.holding.Slot(2))
+}
+
+func TestWindow_dragClick(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    makeWindowItemTypes()
+
+    type Test struct {
+        desc         string
+        start, add   byte
+        end          byte
+        expectSlot   ItemCount
+        expectCursor ItemCount
+    }
+
+    tests := []Test{
+        {"left drag", DragStartLeft, DragAddLeft, DragEndLeft, 5, 0},
+        {"right drag", DragStartRight, DragAddRight, DragEndRight, 1, 8},
+    }
+
+    for _, test := range tests {
+        _, inv, _, _ := newTestWindows()
+        cursor := gamerules.Slot{apple, 10, 0, nil}
+
+        click := gamerules.Click{SlotId: SlotIdNull, Cursor: cursor, Mode: ClickModeDrag, Button: test.start}
+        if txState := inv.Click(&click); txState != TxStateAccepted {
+            t.Errorf("%s: expected drag start to be accepted, got %v", test.desc, txState)
+        }
+        for _, slotId := range []SlotId{9, 10, 9} {
+            click = gamerules.Click{SlotId: slotId, Cursor: cursor, Mode: ClickModeDrag, Button: test.add}
+            if txState := inv.Click(&click); txState != TxStateAccepted {
+                t.Errorf("%s: expected slot %d to be added to drag, got %v", test.desc, slotId, txState)
+            }
+        }
+        click = gamerules.Click{SlotId: SlotIdNull, Cursor: cursor, Mode: ClickModeDrag, Button: test.end}
+        if txState := inv.Click(&click); txState != TxStateAccepted {
+            t.Errorf("%s: expected drag end to be accepted, got %v", test.desc, txState)
+        }
+
+        expectSlot := gamerules.Slot{apple, test.expectSlot, 0, nil}
+        checkSlot(t, test.desc+" slot 9", expectSlot, inv.main.Slot(0))
+        checkSlot(t, test.desc+" slot 10", expectSlot, inv.main.Slot(1))
+        expectCursor := gamerules.Slot{apple, test.expectCursor, 0, nil}
+        expectCursor.Normalize()
+        checkSlot(t, test.desc+" cursor", expectCursor, click.Cursor)
+    }
+
+    // Slots cannot be added with the other button, or once the drag has
+    // ended.
+    _, inv, _, _ := newTestWindows()
+    cursor := gamerules.Slot{apple, 10, 0, nil}
+    inv.Click(&gamerules.Click{SlotId: SlotIdNull, Cursor: cursor, Mode: ClickModeDrag, Button: DragStartLeft})
+    if txState := inv.Click(&gamerules.Click{SlotId: 9, Cursor: cursor, Mode: ClickModeDrag, Button: DragAddRight}); txState != TxStateRejected {
+        t.Errorf("expected right drag add during left drag to be rejected, got %v", txState)
+    }
+    inv.Click(&gamerules.Click{SlotId: 9, Cursor: cursor, Mode: ClickModeNormal})
+    if txState := inv.Click(&gamerules.Click{SlotId: 9, Cursor: cursor, Mode: ClickModeDrag, Button: DragAddLeft}); txState != TxStateRejected {
+        t.Errorf("expected drag add after another click to be rejected, got %v", txState)
+    }
+}
+
+func TestWindow_doubleClick(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    makeWindowItemTypes()
+
+    // Items in the player's inventory are collected before the remote
+    // inventory is asked for more.
+    _, inv, remote, window := newTestWindows()
+    inv.main.SetSlot(0, gamerules.Slot{apple, 5, 0, nil})
+    inv.holding.SetSlot(0, gamerules.Slot{apple, 3, 0, nil})
+    inv.holding.SetSlot(1, gamerules.Slot{sword, 1, 0, nil})
+
+    click := gamerules.Click{SlotId: 3, Cursor: gamerules.Slot{apple, 1, 0, nil}, Mode: ClickModeDoubleClick}
+    if txState := window.Click(&click); txState != TxStateDeferred {
+        t.Errorf("expected double click with remote inventory to be deferred, got %v", txState)
+    }
+    collectClick := remote.takeClick(t)
+    checkSlot(t, "cursor sent to remote inventory", gamerules.Slot{apple, 9, 0, nil}, collectClick.Cursor)
+    checkSlot(t, "main inventory", gamerules.Slot{}, inv.main.Slot(0))
+    checkSlot(t, "hotbar", gamerules.Slot{}, inv.holding.Slot(0))
+    checkSlot(t, "other items", gamerules.Slot{sword, 1, 0, nil}, inv.holding.Slot(1))
+
+    // Double clicking needs items on the cursor.
+    click = gamerules.Click{SlotId: 3, Mode: ClickModeDoubleClick}
+    if txState := window.Click(&click); txState != TxStateRejected {
+        t.Errorf("expected double click with empty cursor to be rejected, got %v", txState)
+    }
+}
+
+func TestWindow_putBack(t *testing.T) {
+    defer func(items gamerules.ItemTypeMap) { gamerules.Items = items }(gamerules.Items)
+    makeWindowItemTypes()
+
+    viewer, inv, _, _ := newTestWindows()
+    view := &inv.views[playerInvViewHolding]
+
+    items := gamerules.Slot{apple, 5, 0, nil}
+    inv.putBack(&items, view, 0)
+    if !items.IsEmpty() || len(viewer.returned) != 0 {
+        t.Errorf("expected items to be put back, got %+v left and %+v returned", items, viewer.returned)
+    }
+    checkSlot(t, "hotbar", gamerules.Slot{apple, 5, 0, nil}, inv.holding.Slot(0))
+
+    // Items that no longer fit are given to the player.
+    inv.holding.SetSlot(0, gamerules.Slot{sword, 1, 0, nil})
+    items = gamerules.Slot{apple, 5, 0, nil}
+    inv.putBack(&items, view, 0)
+    if !items.IsEmpty() || len(viewer.returned) != 1 {
+        t.Fatalf("expected items to be returned, got %+v left and %+v returned", items, viewer.returned)
+    }
+    checkSlot(t, "returned items", gamerules.Slot{apple, 5, 0, nil}, viewer.returned[0])
+}
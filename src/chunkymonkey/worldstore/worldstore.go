@@ -56,15 +56,6 @@ func LoadWorldStore(worldPath string) (world *WorldStore, err error) {
         timeTicks = Ticks(timeTag.Value)
     }
 
-    var chunkStores []chunkstore.IChunkStore
-    persistantChunkStore, err := chunkstore.ChunkStoreForLevel(worldPath, levelData, DimensionNormal)
-    if err != nil {
-        return nil, err
-    }
-
-    persistantChunkService := chunkstore.NewChunkService(persistantChunkStore)
-    chunkStores = append(chunkStores, persistantChunkService)
-
     var seed int64
     if seedNbt, ok := levelData.Lookup("Data/RandomSeed").(*nbt.Long); ok {
         seed = seedNbt.Value
@@ -73,22 +64,17 @@ func LoadWorldStore(worldPath string) (world *WorldStore, err error) {
         seed = rand.New(rand.NewSource(t)).Int63()
     }
 
-    chunkStores = append(chunkStores, chunkstore.NewChunkService(generation.NewTestGenerator(seed)))
-
-    for _, store := range chunkStores {
-        go store.Serve()
-    }
-
     world = &WorldStore{
         WorldPath:     worldPath,
         Seed:          seed,
         Time:          timeTicks,
         LevelData:     levelData,
-        ChunkStore:    chunkstore.NewChunkService(chunkstore.NewMultiStore(chunkStores, persistantChunkService)),
         SpawnPosition: spawnPosition,
     }
 
-    go world.ChunkStore.Serve()
+    if world.ChunkStore, err = world.ChunkStoreForDimension(DimensionNormal); err != nil {
+        return nil, err
+    }
 
     return
 }
@@ -112,18 +98,43 @@ func loadLevelData(worldPath string) (levelData nbt.ITag, err error) {
     return
 }
 
-// NOTE: ChunkStoreForDimension shouldn't really be used in the server just
-// yet.
+// ChunkStoreForDimension creates the chunk store for a dimension of the world.
+// Chunks are read from the saved world if present, otherwise they are
+// generated. Chunks are always written back to the saved world.
 func (world *WorldStore) ChunkStoreForDimension(dimension DimensionId) (store chunkstore.IChunkStore, err error) {
-    fgStore, err := chunkstore.ChunkStoreForLevel(world.WorldPath, world.LevelData, dimension)
+    persistantChunkStore, err := chunkstore.ChunkStoreForLevel(world.WorldPath, world.LevelData, dimension)
     if err != nil {
         return
     }
-    store = chunkstore.NewChunkService(fgStore)
+    persistantChunkService := chunkstore.NewChunkService(persistantChunkStore)
+
+    chunkStores := []chunkstore.IChunkStore{
+        persistantChunkService,
+        chunkstore.NewChunkService(generatorForDimension(dimension, world.Seed)),
+    }
+
+    for _, store := range chunkStores {
+        go store.Serve()
+    }
+
+    store = chunkstore.NewChunkService(chunkstore.NewMultiStore(chunkStores, persistantChunkService))
     go store.Serve()
+
     return
 }
 
+// generatorForDimension returns the chunk generator used for new chunks in the
+// given dimension.
+func generatorForDimension(dimension DimensionId, seed int64) chunkstore.IChunkStoreForeground {
+    switch dimension {
+    case DimensionNormal:
+        return generation.NewTestGenerator(seed)
+    case DimensionNether:
+        return generation.NewNetherGenerator(seed)
+    }
+    return generation.NewEmptyGenerator()
+}
+
 func (world *WorldStore) PlayerData(user string) (playerData nbt.Compound, err error) {
     file, err := os.Open(path.Join(world.WorldPath, "players", user+".dat"))
     if err != nil {
@@ -38,6 +38,10 @@ var furnaceDefs = flag.String(
 	"furnace", "furnace.json",
 	"The JSON file containing furnace fuel and reaction definitions.")
 
+var brewingDefs = flag.String(
+	"brewing", "brewing.json",
+	"The JSON file containing brewing ingredient definitions.")
+
 var serverDesc = flag.String(
 	"server_desc", "Chunkymonkey Minecraft server",
 	"The server description.")
@@ -85,7 +89,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	err = gamerules.LoadGameRules(*blockDefs, *itemDefs, *recipeDefs, *furnaceDefs, *userDefs, *groupDefs)
+	err = gamerules.LoadGameRules(*blockDefs, *itemDefs, *recipeDefs, *furnaceDefs, *brewingDefs, *userDefs, *groupDefs)
 	if err != nil {
 		log.Print("Error loading game rules: ", err)
 		os.Exit(1)